@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	abortCmdLong = `
+Roll back an application's canary.
+
+All of the application's traffic is sent back to the version that was
+running before 'push --strategy canary', and the canary is removed.`
+
+	abortCmdExample = `
+  # Remove the canary of 'my-app', keeping its previous version
+  %[1]s abort my-app`
+)
+
+type AbortConfig struct {
+}
+
+func init() {
+	RootCmd.AddCommand(newAbortCmd("ocf"))
+}
+
+func newAbortCmd(commandName string) *cobra.Command {
+	config := &AbortConfig{}
+	cmd := &cobra.Command{
+		Use:     "abort APP_NAME",
+		Short:   "Roll back an application's canary.",
+		Long:    abortCmdLong,
+		Example: fmt.Sprintf(abortCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (config *AbortConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 1 {
+		return errors.New("Error: Application name is required")
+	}
+
+	app := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	return app.Abort()
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	appCmdLong = `
+Show the state of an application and its instances.
+
+This command emulates Cloud Foundry's 'cf app' command but targeting
+OpenShift instead. Each instance is one of the application's pods,
+shown with how often it has restarted. Why each instance last stopped,
+such as being killed for running out of memory, is listed under recent
+crashes.
+
+With --metrics, the CPU and memory each pod is using are read from the
+cluster's metrics API and shown next to the pod's memory limit.
+
+With --guid, only the GUID generated for the application on its first
+push is printed, for scripts written against 'cf app --guid'. The same
+GUID is the application_id in VCAP_APPLICATION.`
+
+	appCmdExample = `
+  # Show the state of my-app
+  %[1]s app my-app
+
+  # Include CPU and memory usage
+  %[1]s app my-app --metrics
+
+  # Print only the GUID of my-app
+  %[1]s app my-app --guid`
+)
+
+type AppConfig struct {
+	Metrics bool
+	GUID    bool
+}
+
+func init() {
+	RootCmd.AddCommand(newAppCmd("ocf"))
+}
+
+func newAppCmd(commandName string) *cobra.Command {
+	config := &AppConfig{}
+	cmd := &cobra.Command{
+		Use:     "app",
+		Short:   "Show the state of an application.",
+		Long:    appCmdLong,
+		Example: fmt.Sprintf(appCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&config.Metrics, "metrics", "", false, "Show the CPU and memory usage of each instance")
+	cmd.Flags().BoolVarP(&config.GUID, "guid", "", false, "Print only the application's GUID")
+
+	return cmd
+}
+
+func (config *AppConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 1 {
+		return errors.New("Error: Application name is required")
+	}
+
+	app := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	if config.GUID {
+		guid, err := app.GUID()
+		if err != nil {
+			return err
+		}
+		fmt.Println(guid)
+		return nil
+	}
+	info, err := app.Info(config.Metrics)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "name:\t%s\n", info.Name)
+	fmt.Fprintf(w, "kind:\t%s\n", info.Kind)
+	fmt.Fprintf(w, "instances:\t%s\n", info.Instances)
+	fmt.Fprintf(w, "memory:\t%s\n", info.Memory)
+	fmt.Fprintf(w, "route:\t%s\n", info.Host)
+	w.Flush()
+	fmt.Println()
+
+	w = tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	if config.Metrics {
+		fmt.Fprintln(w, "instance\tstate\tsince\trestarts\tcpu\tmemory")
+	} else {
+		fmt.Fprintln(w, "instance\tstate\tsince\trestarts")
+	}
+	for _, pod := range info.Pods {
+		if config.Metrics {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", pod.Name, pod.State, pod.Since, pod.Restarts, pod.CPU, pod.Memory)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", pod.Name, pod.State, pod.Since, pod.Restarts)
+		}
+	}
+	w.Flush()
+
+	printCrashes(info.Pods)
+	return nil
+}
+
+// printCrashes lists why each instance last stopped, like the crash
+// events 'cf app' shows.
+func printCrashes(pods []app.PodInfo) {
+	header := false
+	for _, pod := range pods {
+		if pod.LastCrash == "" {
+			continue
+		}
+		if !header {
+			fmt.Println()
+			fmt.Println("recent crashes:")
+			header = true
+		}
+		fmt.Printf("  %s: %s\n", pod.Name, pod.LastCrash)
+	}
+}
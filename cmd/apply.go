@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	applyCmdLong = `
+Push exactly what a plan file records.
+
+The applications are checked first to make sure pushing them would
+still change exactly what 'plan' recorded. If anything has changed in
+the cluster since, nothing is pushed and the plan has to be made again.`
+
+	applyCmdExample = `
+  # Push the applications recorded in plan.json
+  %[1]s apply plan.json`
+)
+
+type ApplyConfig struct {
+}
+
+func init() {
+	RootCmd.AddCommand(newApplyCmd("ocf"))
+}
+
+func newApplyCmd(commandName string) *cobra.Command {
+	config := &ApplyConfig{}
+	cmd := &cobra.Command{
+		Use:     "apply PLAN_FILE",
+		Short:   "Push exactly what a plan file records.",
+		Long:    applyCmdLong,
+		Example: fmt.Sprintf(applyCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+				exit(1)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (config *ApplyConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 1 {
+		return errors.New("Error: Plan file is required")
+	}
+
+	plan, err := app.ReadPlan(args[0])
+	if err != nil {
+		return err
+	}
+	return plan.Apply(commandContext)
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	appsCmdLong = `
+List all applications in the current project.
+
+This command emulates Cloud Foundry's 'cf apps' command, showing each
+application's running and desired instances along with its route.`
+
+	appsCmdExample = `
+  # List all applications
+  %[1]s apps`
+)
+
+type AppsConfig struct {
+}
+
+func init() {
+	RootCmd.AddCommand(newAppsCmd("ocf"))
+}
+
+func newAppsCmd(commandName string) *cobra.Command {
+	config := &AppsConfig{}
+	cmd := &cobra.Command{
+		Use:     "apps",
+		Short:   "List all applications.",
+		Long:    appsCmdLong,
+		Example: fmt.Sprintf(appsCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (config *AppsConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	summaries, err := app.ListApplications(commandContext)
+	// Show what we could look up even if some lookups failed
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "name\tkind\tinstances\troute")
+	for _, summary := range summaries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", summary.Name, summary.Kind, summary.Instances, summary.Host)
+	}
+	w.Flush()
+	return err
+}
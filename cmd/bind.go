@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 
 	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
 
 	"github.com/spf13/cobra"
 )
@@ -16,16 +19,42 @@ Bind a service to an application.
 This command emulates Cloud Foundry's 'cf bind-service' command but
 targeting OpenShift instead. Not all the Cloud Foundry options are
 supported; those that are supported are documented in the usage
-information below.`
+information below.
+
+The service can be a PostgreSQL, MySQL, or MongoDB deployment config
+or an existing secret or config map. Every key of a secret or config
+map is exposed to the application as an environment variable prefixed
+with the service name.
+
+Bound services are also described in VCAP_SERVICES, like on Cloud
+Foundry. Configuration parameters given with -c are stored with the
+binding and appear under "parameters" in the service's credentials.
+Services aren't provisioned by a broker, so the parameters are for the
+application to read rather than passed to a Service Catalog binding.`
 
 	bindCmdExample = `
   # Bind a 'rails-postgres' service to the application 'my-app'
-  %[1]s bind-service my-app rails-postgres`
+  %[1]s bind-service my-app rails-postgres
+
+  # Bind a 'rails-postgres' service with configuration parameters
+  %[1]s bind-service my-app rails-postgres -c '{"role":"readonly"}'
+
+  # Bind a 'rails-postgres' service with configuration parameters from a file
+  %[1]s bind-service my-app rails-postgres -c /path/to/config.json
+
+  # Bind 'rails-postgres' while staging too, for migrations run by the build
+  %[1]s bind-service my-app rails-postgres --staging
+
+  # Bind the existing secret 'api-credentials' to the application 'my-app'
+  %[1]s bind-service my-app api-credentials`
 )
 
 type BindConfig struct {
 	Application string
 	Service     string
+	Restart     bool
+	Parameters  string
+	Staging     bool
 }
 
 func init() {
@@ -42,26 +71,69 @@ func newBindCmd(commandName string) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			err := config.Run(args)
 			if err != nil {
-				fmt.Printf("err: %v\n", err)
+				log.Errorf("err: %v", err)
 			}
 		},
 	}
 
+	cmd.Flags().StringVarP(&config.Parameters, "configuration", "c", "", "Valid JSON object containing service-specific configuration parameters, provided inline or in a file. For a list of supported configuration parameters, see documentation for the particular service offering.")
+
+	cmd.Flags().BoolVarP(&config.Staging, "staging", "", false, "Bind the service to the application's builds too, for buildpacks that need it while staging")
+
+	cmd.Flags().BoolVarP(&config.Restart, "restart", "", false, "Restart the application afterwards so the change takes effect")
+	cmd.Flags().BoolVarP(&config.Restart, "restage", "", false, "Alias for --restart; bindings only change the runtime environment so no rebuild is needed")
+
 	return cmd
 }
 
 func (config *BindConfig) Run(args []string) error {
-	debugf("Config: %+v\n", config)
+	log.Debugf("Config: %+v", config)
 
 	if len(args) != 2 {
 		return errors.New("Error: Application name and service name are required")
 	}
 
-	app := &app.Application{Name: args[0]}
-	err := app.BindService(args[1])
+	parameters, err := parseBindingParameters(config.Parameters)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	app := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	err = app.BindService(args[1], parameters)
+	if err != nil {
+		return err
+	}
+	if config.Staging {
+		err = app.BindServiceToBuild(args[1], parameters)
+		if err != nil {
+			return err
+		}
+		log.Printf("TIP: Push %s again so its build uses %s", app.Name, args[1])
+	}
+
+	return restartAfterEnvChange(app, config.Restart)
+}
+
+// parseBindingParameters accepts binding parameters either as inline
+// JSON or as the path to a file containing JSON, like 'cf', and
+// returns them as compact JSON.
+func parseBindingParameters(parameters string) (string, error) {
+	if parameters == "" {
+		return "", nil
+	}
+	contents := []byte(parameters)
+	if fileContents, err := ioutil.ReadFile(parameters); err == nil {
+		contents = fileContents
+	}
+
+	var parsed map[string]interface{}
+	err := json.Unmarshal(contents, &parsed)
+	if err != nil {
+		return "", errors.New("Error: Invalid configuration provided for -c flag. Please provide a valid JSON object or path to a file containing a valid JSON object.")
+	}
+	compact, err := json.Marshal(parsed)
+	if err != nil {
+		return "", err
+	}
+	return string(compact), nil
 }
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBindingParameters(t *testing.T) {
+	parameters, err := parseBindingParameters("")
+	assert.Nil(t, err)
+	assert.Equal(t, "", parameters)
+
+	parameters, err = parseBindingParameters(`{ "role": "readonly" }`)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"role":"readonly"}`, parameters)
+
+	_, err = parseBindingParameters(`["not", "an", "object"]`)
+	assert.NotNil(t, err)
+
+	withManifestDir(t, func(dir string) {
+		path := writeManifest(t, dir, "config.json", `{"role": "admin"}`)
+		parameters, err := parseBindingParameters(path)
+		assert.Nil(t, err)
+		assert.Equal(t, `{"role":"admin"}`, parameters)
+	})
+}
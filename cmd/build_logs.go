@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	buildLogsCmdLong = `
+Show the logs of an application's build.
+
+'push' only streams build logs while it's attached, and not at all
+with --async-build. This command fetches the logs of the latest build,
+or of a given build, after the fact.`
+
+	buildLogsCmdExample = `
+  # Show the logs of the latest build of 'my-app'
+  %[1]s build-logs my-app
+
+  # Follow the logs of build 3 of 'my-app'
+  %[1]s build-logs my-app --build 3 --follow`
+)
+
+type BuildLogsConfig struct {
+	Build  string
+	Follow bool
+}
+
+func init() {
+	RootCmd.AddCommand(newBuildLogsCmd("ocf"))
+}
+
+func newBuildLogsCmd(commandName string) *cobra.Command {
+	config := &BuildLogsConfig{}
+	cmd := &cobra.Command{
+		Use:     "build-logs APP_NAME",
+		Short:   "Show the logs of an application's build.",
+		Long:    buildLogsCmdLong,
+		Example: fmt.Sprintf(buildLogsCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&config.Build, "build", "", "", "Build number or name, defaulting to the latest build")
+	cmd.Flags().BoolVarP(&config.Follow, "follow", "", false, "Keep streaming logs until the build finishes")
+
+	return cmd
+}
+
+func (config *BuildLogsConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 1 {
+		return errors.New("Error: Application name is required")
+	}
+
+	app := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	return app.BuildLogs(config.Build, config.Follow)
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// Exit codes of 'push --ci', so pipelines can tell why a push failed.
+const (
+	// exitFailed is any failure outside a step of the push, like an
+	// invalid flag or manifest
+	exitFailed = 1
+	// exitAuth is a failure to log in or use the project
+	exitAuth = 2
+	// exitBuild is a failed build or image pull
+	exitBuild = 3
+	// exitDeploy is a failure after the application was built, like
+	// a rollout or route that failed
+	exitDeploy = 4
+)
+
+// ciRetries is how many times 'push --ci' pushes again after a
+// transient failure.
+const ciRetries = 2
+
+// ciRetryDelay is how long 'push --ci' waits before the first retry,
+// doubling for each one after.
+var ciRetryDelay = 10 * time.Second
+
+var authSteps = map[string]bool{"login": true, "project": true, "permissions": true}
+var buildSteps = map[string]bool{"build": true, "pull-secret": true}
+
+// ciExitCode returns the exit code 'push --ci' fails with for err.
+func ciExitCode(err error) int {
+	stepErr, ok := err.(*app.StepError)
+	switch {
+	case !ok:
+		return exitFailed
+	case authSteps[stepErr.Step]:
+		return exitAuth
+	case buildSteps[stepErr.Step]:
+		return exitBuild
+	default:
+		return exitDeploy
+	}
+}
+
+// withRetries calls push, calling it again after failures that look
+// transient up to retries times.
+func withRetries(retries int, push func() error) error {
+	delay := ciRetryDelay
+	for attempt := 0; ; attempt++ {
+		err := push()
+		if err == nil || attempt == retries || !app.IsTransient(err) || commandContext.Err() != nil {
+			return err
+		}
+		log.Warnf("Push failed with what looks like a temporary problem, retrying in %s: %v", delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bbrowning/ocf/pkg/app"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCIExitCode(t *testing.T) {
+	assert.Equal(t, exitFailed, ciExitCode(errors.New("Error: Invalid route type")))
+	assert.Equal(t, exitAuth, ciExitCode(&app.StepError{Step: "login", Err: errors.New("Not logged in")}))
+	assert.Equal(t, exitBuild, ciExitCode(&app.StepError{Step: "build", Err: errors.New("Build failed")}))
+	assert.Equal(t, exitDeploy, ciExitCode(&app.StepError{Step: "smoke-test", Err: errors.New("Timed out")}))
+}
+
+func TestWithRetries(t *testing.T) {
+	original := ciRetryDelay
+	ciRetryDelay = 0
+	defer func() { ciRetryDelay = original }()
+
+	attempts := 0
+	err := withRetries(2, func() error {
+		attempts++
+		return errors.New("dial tcp: connection refused")
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, attempts)
+
+	attempts = 0
+	err = withRetries(2, func() error {
+		attempts++
+		return errors.New("Error: Application foo not found")
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, attempts)
+}
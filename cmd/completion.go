@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	completionCmdLong = `
+Output shell completion code for bash, zsh, or fish.
+
+Besides commands and flags, application and service names are
+completed by looking them up in the current project. Lookups time out
+quickly and are cached for a short while so completion stays
+responsive.`
+
+	completionCmdExample = `
+  # Load completion into the current bash shell
+  source <(%[1]s completion bash)
+
+  # Install completion for zsh
+  %[1]s completion zsh > "${fpath[1]}/_%[1]s"
+
+  # Install completion for fish
+  %[1]s completion fish > ~/.config/fish/completions/%[1]s.fish`
+)
+
+// argCompletions lists, for commands that take names as arguments,
+// what each positional argument names.
+var argCompletions = map[string][]string{
+	"abort":                    {app.CompleteApps},
+	"add-network-policy":       {app.CompleteApps},
+	"app":                      {app.CompleteApps},
+	"bind-service":             {app.CompleteApps, app.CompleteServices},
+	"build-logs":               {app.CompleteApps},
+	"create-app-manifest":      {app.CompleteApps},
+	"create-webhook":           {app.CompleteApps},
+	"create-service-key":       {app.CompleteServices},
+	"delete":                   {app.CompleteApps},
+	"exec":                     {app.CompleteApps},
+	"export-helm":              {app.CompleteApps},
+	"files":                    {app.CompleteApps},
+	"gc":                       {app.CompleteApps},
+	"logs":                     {app.CompleteApps},
+	"map-route":                {app.CompleteApps},
+	"migrate-service-bindings": {app.CompleteApps},
+	"port-forward":             {app.CompleteApps},
+	"promote":                  {app.CompleteApps},
+	"push":                     {app.CompleteApps},
+	"restart":                  {app.CompleteApps},
+	"restart-app-instance":     {app.CompleteApps},
+	"revisions":                {app.CompleteApps},
+	"rollback":                 {app.CompleteApps},
+	"service-keys":             {app.CompleteServices},
+	"set-env":                  {app.CompleteApps},
+	"sync":                     {app.CompleteApps},
+	"unbind-service":           {app.CompleteApps, app.CompleteServices},
+}
+
+type CompletionConfig struct {
+}
+
+func init() {
+	RootCmd.AddCommand(newCompletionCmd("ocf"))
+	RootCmd.AddCommand(newCompleteNamesCmd())
+}
+
+func newCompletionCmd(commandName string) *cobra.Command {
+	config := &CompletionConfig{}
+	cmd := &cobra.Command{
+		Use:       "completion SHELL",
+		Short:     "Output shell completion code.",
+		Long:      completionCmdLong,
+		Example:   fmt.Sprintf(completionCmdExample, commandName),
+		ValidArgs: []string{"bash", "zsh", "fish"},
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (config *CompletionConfig) Run(args []string) error {
+	if len(args) != 1 {
+		return errors.New("Error: completion requires a shell, one of bash, zsh, or fish")
+	}
+	switch args[0] {
+	case "bash":
+		return genBashCompletion(RootCmd, os.Stdout)
+	case "zsh":
+		// zsh can run bash completion functions itself
+		fmt.Printf("#compdef %s\n\nautoload -U +X bashcompinit && bashcompinit\n\n", RootCmd.Name())
+		return genBashCompletion(RootCmd, os.Stdout)
+	case "fish":
+		return genFishCompletion(RootCmd, os.Stdout)
+	}
+	return errors.New(fmt.Sprintf("Error: Unsupported shell %s, must be bash, zsh, or fish", args[0]))
+}
+
+// newCompleteNamesCmd returns the hidden command completion scripts
+// run to look up names.
+func newCompleteNamesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:    "__complete KIND",
+		Hidden: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				return
+			}
+			names, err := app.CompletionNames(commandContext, args[0])
+			if err != nil {
+				return
+			}
+			fmt.Println(strings.Join(names, "\n"))
+		},
+	}
+}
+
+func genBashCompletion(root *cobra.Command, w io.Writer) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `__%[1]s_complete_names()
+{
+    local names
+    if names=$(%[1]s __complete "$1" 2>/dev/null); then
+        COMPREPLY=( $(compgen -W "${names}" -- "$cur") )
+    fi
+}
+
+__custom_func() {
+    local kinds
+    case ${last_command} in
+`, root.Name())
+	for _, name := range sortedArgCompletions() {
+		fmt.Fprintf(&buf, "        %s_%s)\n            kinds=(%s)\n            ;;\n",
+			root.Name(), name, strings.Join(argCompletions[name], " "))
+	}
+	fmt.Fprintf(&buf, `        *)
+            return
+            ;;
+    esac
+    local kind=${kinds[${#nouns[@]}]}
+    if [[ -n ${kind} ]]; then
+        __%s_complete_names "${kind}"
+    fi
+}
+`, root.Name())
+	root.BashCompletionFunction = buf.String()
+	return root.GenBashCompletion(w)
+}
+
+func genFishCompletion(root *cobra.Command, w io.Writer) error {
+	name := root.Name()
+	fmt.Fprintf(w, "complete -c %s -f\n", name)
+	for _, cmd := range root.Commands() {
+		if cmd.Hidden {
+			continue
+		}
+		fmt.Fprintf(w, "complete -c %s -n '__fish_use_subcommand' -a %s -d %s\n", name, cmd.Name(), fishQuote(cmd.Short))
+		seen := fmt.Sprintf("__fish_seen_subcommand_from %s", cmd.Name())
+		cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+			fmt.Fprintf(w, "complete -c %s -n '%s' -l %s", name, seen, flag.Name)
+			if flag.Shorthand != "" {
+				fmt.Fprintf(w, " -s %s", flag.Shorthand)
+			}
+			fmt.Fprintf(w, " -d %s\n", fishQuote(flag.Usage))
+		})
+		for i, kind := range argCompletions[cmd.Name()] {
+			// The command line holds the binary and subcommand
+			// before the arguments
+			fmt.Fprintf(w, "complete -c %s -n '%s; and test (count (commandline -opc)) -eq %d' -a '(%s __complete %s 2>/dev/null)'\n",
+				name, seen, i+2, name, kind)
+		}
+	}
+	return nil
+}
+
+func fishQuote(s string) string {
+	return fmt.Sprint("'", strings.Replace(strings.Replace(s, `\`, `\\`, -1), "'", `\'`, -1), "'")
+}
+
+func sortedArgCompletions() []string {
+	var names []string
+	for name := range argCompletions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenBashCompletion(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, genBashCompletion(RootCmd, &buf))
+	assert.Contains(t, buf.String(), "ocf_bind-service)\n            kinds=(apps services)")
+	assert.Contains(t, buf.String(), "__ocf_complete_names")
+}
+
+func TestGenFishCompletion(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, genFishCompletion(RootCmd, &buf))
+	assert.Contains(t, buf.String(), "complete -c ocf -n '__fish_use_subcommand' -a restart")
+	assert.Contains(t, buf.String(), "complete -c ocf -n '__fish_seen_subcommand_from unbind-service; and test (count (commandline -opc)) -eq 3' -a '(ocf __complete services 2>/dev/null)'")
+	assert.NotContains(t, buf.String(), "__complete KIND")
+}
+
+func TestArgCompletionsNameCommands(t *testing.T) {
+	for name := range argCompletions {
+		cmd, _, err := RootCmd.Find([]string{name})
+		assert.Nil(t, err)
+		assert.Equal(t, name, cmd.Name())
+	}
+}
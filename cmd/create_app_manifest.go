@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	createAppManifestCmdLong = `
+Create a manifest for a running application.
+
+This command emulates Cloud Foundry's 'cf create-app-manifest' command
+but targeting OpenShift instead. The application's memory limit,
+instances, start command, environment variables, buildpack, build
+environment, bound services, and routes are read from the cluster and
+written as a manifest.yml that pushes the same application again.
+
+The manifest is written to APP_manifest.yml unless --path is given.`
+
+	createAppManifestCmdExample = `
+  # Write the manifest of my-app to my-app_manifest.yml
+  %[1]s create-app-manifest my-app
+
+  # Write the manifest of my-app to manifest.yml
+  %[1]s create-app-manifest my-app -p manifest.yml`
+)
+
+type CreateAppManifestConfig struct {
+	Path string
+}
+
+func init() {
+	RootCmd.AddCommand(newCreateAppManifestCmd("ocf"))
+}
+
+func newCreateAppManifestCmd(commandName string) *cobra.Command {
+	config := &CreateAppManifestConfig{}
+	cmd := &cobra.Command{
+		Use:     "create-app-manifest",
+		Short:   "Create a manifest for a running application.",
+		Long:    createAppManifestCmdLong,
+		Example: fmt.Sprintf(createAppManifestCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&config.Path, "path", "p", "", "File to write the manifest to")
+
+	return cmd
+}
+
+func (config *CreateAppManifestConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 1 {
+		return errors.New("Error: Application name is required")
+	}
+
+	app := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	manifest, err := app.CreateAppManifest()
+	if err != nil {
+		return err
+	}
+
+	path := config.Path
+	if path == "" {
+		path = fmt.Sprint(app.Name, "_manifest.yml")
+	}
+	err = ioutil.WriteFile(path, manifest, 0644)
+	if err != nil {
+		return err
+	}
+	log.Infof("Manifest for %s written to %s", app.Name, path)
+	return nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	createWebhookCmdLong = `
+Add a GitHub or GitLab webhook to an application built from Git.
+
+The application must have been pushed with --git, so the cluster
+builds it from its repository. The webhook trigger is added to the
+application's build configuration, if it doesn't already have one, and
+its URL and secret are printed. Add them as a webhook of the
+repository to build and deploy the application on every push to it.`
+
+	createWebhookCmdExample = `
+  # Rebuild 'my-app' on every push to its GitHub repository
+  %[1]s create-webhook my-app --github
+
+  # Rebuild 'my-app' on every push to its GitLab repository
+  %[1]s create-webhook my-app --gitlab`
+)
+
+type CreateWebhookConfig struct {
+	GitHub bool
+	GitLab bool
+}
+
+func init() {
+	RootCmd.AddCommand(newCreateWebhookCmd("ocf"))
+}
+
+func newCreateWebhookCmd(commandName string) *cobra.Command {
+	config := &CreateWebhookConfig{}
+	cmd := &cobra.Command{
+		Use:     "create-webhook APP_NAME",
+		Short:   "Add a GitHub or GitLab webhook to an application built from Git.",
+		Long:    createWebhookCmdLong,
+		Example: fmt.Sprintf(createWebhookCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&config.GitHub, "github", "", false, "Add a GitHub webhook")
+	cmd.Flags().BoolVarP(&config.GitLab, "gitlab", "", false, "Add a GitLab webhook")
+
+	return cmd
+}
+
+func (config *CreateWebhookConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 1 {
+		return errors.New("Error: Application name is required")
+	}
+	if config.GitHub == config.GitLab {
+		return errors.New("Error: Exactly one of --github or --gitlab is required")
+	}
+	provider := app.WebhookGitHub
+	if config.GitLab {
+		provider = app.WebhookGitLab
+	}
+
+	app := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	return app.CreateWebhook(provider)
+}
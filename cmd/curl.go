@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	curlCmdLong = `
+Make an authenticated request to the cluster's API.
+
+This command emulates Cloud Foundry's 'cf curl' command but targeting
+OpenShift instead. The request is made with the current oc or kubectl
+session and the response body is printed as is, so it can be piped to
+tools like jq.
+
+GET, POST, PUT, and DELETE requests are supported. The method defaults
+to GET, or to POST when a body is given with -d.`
+
+	curlCmdExample = `
+  # List the routes in every project
+  %[1]s curl /apis/route.openshift.io/v1/routes
+
+  # Create a config map from a file
+  %[1]s curl /api/v1/namespaces/my-project/configmaps -X POST -d @configmap.json
+
+  # Delete a route
+  %[1]s curl /apis/route.openshift.io/v1/namespaces/my-project/routes/my-app -X DELETE`
+)
+
+type CurlConfig struct {
+	Method string
+	Data   string
+}
+
+func init() {
+	RootCmd.AddCommand(newCurlCmd("ocf"))
+}
+
+func newCurlCmd(commandName string) *cobra.Command {
+	config := &CurlConfig{}
+	cmd := &cobra.Command{
+		Use:     "curl",
+		Short:   "Make an authenticated request to the cluster's API.",
+		Long:    curlCmdLong,
+		Example: fmt.Sprintf(curlCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&config.Method, "method", "X", "", "HTTP method (GET, POST, PUT, or DELETE)")
+	cmd.Flags().StringVarP(&config.Data, "data", "d", "", "Request body, or @FILE to read it from a file")
+
+	return cmd
+}
+
+func (config *CurlConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 1 {
+		return errors.New("Error: API path is required")
+	}
+
+	output, err := app.Curl(commandContext, config.Method, args[0], config.Data)
+	if err != nil {
+		return err
+	}
+	os.Stdout.Write(output)
+	return nil
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	deleteCmdLong = `
+Delete an application.
+
+Only the objects ocf recorded creating for the application are deleted,
+so objects with the same name that something else created are left
+alone.`
+
+	deleteCmdExample = `
+  # Delete 'my-app' without asking first
+  %[1]s delete my-app -f`
+)
+
+type DeleteConfig struct {
+	Force bool
+}
+
+func init() {
+	RootCmd.AddCommand(newDeleteCmd("ocf"))
+}
+
+func newDeleteCmd(commandName string) *cobra.Command {
+	config := &DeleteConfig{}
+	cmd := &cobra.Command{
+		Use:     "delete APP_NAME",
+		Short:   "Delete an application.",
+		Long:    deleteCmdLong,
+		Example: fmt.Sprintf(deleteCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&config.Force, "force", "f", false, "Force deletion without confirmation")
+
+	return cmd
+}
+
+func (config *DeleteConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 1 {
+		return errors.New("Error: Application name is required")
+	}
+	if !config.Force {
+		if !isInteractive() {
+			return errors.New("Error: Give -f to delete an application when running non-interactively")
+		}
+		if !confirm(fmt.Sprintf("Really delete the app %s? [y/N] ", args[0])) {
+			return nil
+		}
+	}
+
+	app := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	return app.Delete()
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	diffCmdLong = `
+Show what 'push' would change in the cluster.
+
+Environment variables, images, instances, memory limits, buildpacks,
+and routes from the manifest and flags are compared with the running
+application. This is the same as 'push --diff-only'.
+
+With --gitops-dir, the manifests written by 'push --gitops-dir' are
+compared with the cluster instead.
+
+Nothing is changed in the cluster.`
+
+	diffCmdExample = `
+  # Show what pushing with manifest.yml would change
+  %[1]s diff
+
+  # Show what changing my-app's memory would change
+  %[1]s diff my-app --no-manifest -m 1G
+
+  # Compare the manifests in ./deploy with the cluster
+  %[1]s diff --gitops-dir ./deploy`
+)
+
+type DiffConfig struct {
+	GitOpsDir string
+	Push      PushConfig
+}
+
+func init() {
+	RootCmd.AddCommand(newDiffCmd("ocf"))
+}
+
+func newDiffCmd(commandName string) *cobra.Command {
+	config := &DiffConfig{}
+	cmd := &cobra.Command{
+		Use:     "diff",
+		Short:   "Show differences between GitOps manifests and the cluster.",
+		Long:    diffCmdLong,
+		Example: fmt.Sprintf(diffCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&config.GitOpsDir, "gitops-dir", "", "", "Directory of manifests written by 'push --gitops-dir'")
+	cmd.Flags().StringVarP(&config.Push.Buildpack, "buildpack", "b", "", "Custom buildpack by Git URL")
+	cmd.Flags().StringVarP(&config.Push.Command, "command", "c", "", "Startup command")
+	cmd.Flags().StringVarP(&config.Push.ManifestPath, "manifest-path", "f", "", "Path to manifest")
+	cmd.Flags().BoolVarP(&config.Push.NoManifest, "no-manifest", "", false, "Ignore manifest file")
+	cmd.Flags().BoolVarP(&config.Push.All, "all", "", false, "Apply command line flags to every app in the manifest")
+	addVarsEnvFlag(cmd, &config.Push.VarsEnv)
+	cmd.Flags().StringVarP(&config.Push.Memory, "memory", "m", "", "Memory limit (e.g. 256M, 1024M, 1G)")
+	cmd.Flags().StringVarP(&config.Push.CPU, "cpu", "", "", "CPU limit in cores or millicores (e.g. 0.5, 2, 500m)")
+	cmd.Flags().StringVarP(&config.Push.Domain, "domain", "", "", "Domain for application ingresses when using the k8s platform")
+
+	return cmd
+}
+
+func (config *DiffConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if config.GitOpsDir == "" {
+		config.Push.DiffOnly = true
+		config.Push.CommandMode = app.CommandModeCF
+		config.Push.Workload = defaultWorkload()
+		config.Push.Compression = app.CompressionDefault
+		config.Push.RouteType = app.RouteTypeHTTP
+		config.Push.CleanupOnFail = app.CleanupPrompt
+		config.Push.Strategy = app.StrategyRolling
+		config.Push.Spread = app.SpreadZone
+		return config.Push.Run(args)
+	}
+	if len(args) > 0 {
+		return errors.New("Error: Application names can't be given with --gitops-dir")
+	}
+
+	diff, err := app.DiffGitOps(commandContext, config.GitOpsDir)
+	if err != nil {
+		return err
+	}
+	if diff == "" {
+		log.Infof("No differences found")
+	} else {
+		fmt.Print(diff)
+	}
+	return nil
+}
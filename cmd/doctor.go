@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	doctorCmdLong = `
+Diagnose problems with the local environment and cluster.
+
+This command checks that the client is installed and new enough, that
+you're logged in with a project selected, and that the registry,
+builder image, and router applications need are available. Failed
+checks are shown with how to fix them.`
+
+	doctorCmdExample = `
+  # Check the environment before pushing
+  %[1]s doctor
+
+  # Check a Kubernetes environment that pushes to quay.io
+  %[1]s doctor --platform k8s --registry quay.io/myorg`
+)
+
+type DoctorConfig struct {
+	Image    string
+	Registry string
+}
+
+func init() {
+	RootCmd.AddCommand(newDoctorCmd("ocf"))
+}
+
+func newDoctorCmd(commandName string) *cobra.Command {
+	config := &DoctorConfig{}
+	cmd := &cobra.Command{
+		Use:     "doctor",
+		Aliases: []string{"status"},
+		Short:   "Diagnose the local environment.",
+		Long:    doctorCmdLong,
+		Example: fmt.Sprintf(doctorCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+				exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&config.Image, "image", "", app.DefaultImage, "Builder image to check is pullable")
+	cmd.Flags().StringVarP(&config.Registry, "registry", "", "", "Registry built images are pushed to when using the k8s platform")
+
+	return cmd
+}
+
+func (config *DoctorConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	diagnoses := app.Doctor(commandContext, app.DoctorOptions{Image: config.Image, Registry: config.Registry})
+	return printDiagnoses(diagnoses)
+}
+
+// printDiagnoses shows each check's result followed by how to fix the
+// failed ones, returning an error if any failed.
+func printDiagnoses(diagnoses []app.Diagnosis) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "check\tstatus\tdetail")
+	failed := 0
+	for _, diagnosis := range diagnoses {
+		status := "OK"
+		if !diagnosis.OK {
+			status = "FAIL"
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", diagnosis.Check, status, diagnosis.Detail)
+	}
+	w.Flush()
+
+	if failed == 0 {
+		return nil
+	}
+	fmt.Println()
+	fmt.Println("To fix:")
+	for _, diagnosis := range diagnoses {
+		if !diagnosis.OK {
+			fmt.Printf("  %s: %s\n", diagnosis.Check, diagnosis.Remedy)
+		}
+	}
+	return errors.New(fmt.Sprintf("Error: %d of %d checks failed", failed, len(diagnoses)))
+}
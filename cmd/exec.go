@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	execCmdLong = `
+Run a command in a running application instance.
+
+This command is like Cloud Foundry's 'cf ssh' command with a command
+to run, but targeting OpenShift instead. The command runs in the
+application's container, and a shell is started when none is given.
+A terminal is attached when ocf runs interactively.
+
+The first running instance is used unless --instance picks one by its
+index, numbered from 0 in the order 'app' lists them.`
+
+	execCmdExample = `
+  # Start a shell in my-app
+  %[1]s exec my-app
+
+  # Show the environment of the second instance of my-app
+  %[1]s exec my-app --instance 1 -- env`
+)
+
+type ExecConfig struct {
+	Instance int
+}
+
+func init() {
+	RootCmd.AddCommand(newExecCmd("ocf"))
+}
+
+func newExecCmd(commandName string) *cobra.Command {
+	config := &ExecConfig{}
+	cmd := &cobra.Command{
+		Use:     "exec APP_NAME [-- COMMAND [ARGS...]]",
+		Short:   "Run a command in a running application instance.",
+		Long:    execCmdLong,
+		Example: fmt.Sprintf(execCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().IntVarP(&config.Instance, "instance", "i", -1, "Index of the instance to use, defaulting to the first running one")
+
+	return cmd
+}
+
+func (config *ExecConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) < 1 {
+		return errors.New("Error: Application name is required")
+	}
+
+	app := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	return app.Exec(args[1:], config.Instance, isInteractive())
+}
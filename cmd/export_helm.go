@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	exportHelmCmdLong = `
+Export a running application as a Helm chart.
+
+The chart recreates the application's deployment, service, and route
+(or ingress on Kubernetes) without needing ocf. The image, replicas,
+memory limit, environment variables, and route host are read from the
+cluster and exposed as chart values.`
+
+	exportHelmCmdExample = `
+  # Write a chart for my-app to ./chart
+  %[1]s export-helm my-app -o ./chart
+
+  # Install the exported chart with a different route host
+  helm install my-app ./chart --set host=my-app.example.com`
+)
+
+type ExportHelmConfig struct {
+	Output string
+}
+
+func init() {
+	RootCmd.AddCommand(newExportHelmCmd("ocf"))
+}
+
+func newExportHelmCmd(commandName string) *cobra.Command {
+	config := &ExportHelmConfig{}
+	cmd := &cobra.Command{
+		Use:     "export-helm APP_NAME",
+		Short:   "Export an application as a Helm chart.",
+		Long:    exportHelmCmdLong,
+		Example: fmt.Sprintf(exportHelmCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&config.Output, "output", "o", "", "Directory to write the chart to, defaulting to the application name")
+
+	return cmd
+}
+
+func (config *ExportHelmConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 1 {
+		return errors.New("Error: Exactly one application name must be given")
+	}
+	appName := args[0]
+	output := config.Output
+	if output == "" {
+		output = appName
+	}
+
+	err := app.ExportHelmChart(commandContext, appName, output)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Exported %s as a Helm chart to %s", appName, output)
+	return nil
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	filesCmdLong = `
+List the files of a running application instance.
+
+This command emulates Cloud Foundry's legacy 'cf files' command but
+targeting OpenShift instead. Given a directory, its contents are
+listed; given a file, it's printed. Paths are relative to the
+application's directory unless they're absolute.
+
+The first running instance is used unless --instance picks one by its
+index, numbered from 0 in the order 'app' lists them.`
+
+	filesCmdExample = `
+  # List the application directory of my-app
+  %[1]s files my-app
+
+  # Print a log file from the second instance of my-app
+  %[1]s files my-app logs/app.log --instance 1`
+)
+
+type FilesConfig struct {
+	Instance int
+}
+
+func init() {
+	RootCmd.AddCommand(newFilesCmd("ocf"))
+}
+
+func newFilesCmd(commandName string) *cobra.Command {
+	config := &FilesConfig{}
+	cmd := &cobra.Command{
+		Use:     "files APP_NAME [PATH]",
+		Short:   "List the files of a running application instance.",
+		Long:    filesCmdLong,
+		Example: fmt.Sprintf(filesCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().IntVarP(&config.Instance, "instance", "i", -1, "Index of the instance to use, defaulting to the first running one")
+
+	return cmd
+}
+
+func (config *FilesConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New("Error: Application name and an optional path are required")
+	}
+	path := ""
+	if len(args) == 2 {
+		path = args[1]
+	}
+
+	app := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	return app.Files(path, config.Instance)
+}
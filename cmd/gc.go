@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	gcCmdLong = `
+Delete an application's old builds, replication controllers, and
+image stream tags.
+
+Every push leaves a build, and every rollout a replication controller
+(or replica set for deployments), behind in the project. This command
+keeps the newest --keep of each and deletes the rest. Running builds,
+the running replication controller, and the build tag currently
+deployed are never deleted. Revisions whose replication controller is
+deleted can no longer be rolled back to.`
+
+	gcCmdExample = `
+  # Keep the newest 5 builds, replication controllers, and build tags
+  %[1]s gc my-app --keep 5
+
+  # List what would be deleted without deleting it
+  %[1]s gc my-app --dry-run`
+)
+
+type GCConfig struct {
+	Keep   int
+	DryRun bool
+}
+
+func init() {
+	RootCmd.AddCommand(newGCCmd("ocf"))
+}
+
+func newGCCmd(commandName string) *cobra.Command {
+	config := &GCConfig{}
+	cmd := &cobra.Command{
+		Use:     "gc APP_NAME",
+		Short:   "Delete an application's old builds and images.",
+		Long:    gcCmdLong,
+		Example: fmt.Sprintf(gcCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().IntVarP(&config.Keep, "keep", "", app.DefaultGCKeep, "How many of the newest builds, replication controllers, and build tags to keep")
+	cmd.Flags().BoolVarP(&config.DryRun, "dry-run", "", false, "List what would be deleted without deleting it")
+
+	return cmd
+}
+
+func (config *GCConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 1 {
+		return errors.New("Error: Application name is required")
+	}
+
+	application := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	result, err := application.GC(config.Keep, config.DryRun)
+	if err != nil {
+		return err
+	}
+
+	if config.DryRun {
+		for _, build := range result.Builds {
+			log.Infof("Would delete build %s", build)
+		}
+		for _, controller := range result.ReplicationControllers {
+			log.Infof("Would delete replication controller %s", controller)
+		}
+		for _, tag := range result.Tags {
+			log.Infof("Would delete image stream tag %s:%s", application.Name, tag)
+		}
+		return nil
+	}
+	log.Infof("Deleted %d build(s), %d replication controller(s), and %d image stream tag(s) of %s",
+		len(result.Builds), len(result.ReplicationControllers), len(result.Tags), application.Name)
+	return nil
+}
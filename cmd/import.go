@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	importCmdLong = `
+Import the applications in a Cloud Foundry space.
+
+Every application in the space is read from the Cloud Foundry API and
+written to a manifest.yml in the output directory, along with the
+source last pushed for each application and an import plan of the
+steps left to push them to OpenShift, such as services to create.
+
+The API, token, org, and space default to the ones targeted by the cf
+CLI, so 'cf login' and 'cf target' are usually all that's needed
+first. Nothing is changed in Cloud Foundry or the cluster.`
+
+	importCmdExample = `
+  # Import the space targeted by the cf CLI into ./imported
+  %[1]s import -d imported
+
+  # Import a space from another Cloud Foundry, downloading droplets too
+  %[1]s import --cf-api https://api.example.com --cf-token "$(cf oauth-token)" -o my-org -s dev --droplets`
+)
+
+type ImportConfig struct {
+	Options app.ImportOptions
+}
+
+func init() {
+	RootCmd.AddCommand(newImportCmd("ocf"))
+}
+
+func newImportCmd(commandName string) *cobra.Command {
+	config := &ImportConfig{}
+	cmd := &cobra.Command{
+		Use:     "import",
+		Short:   "Import the applications in a Cloud Foundry space.",
+		Long:    importCmdLong,
+		Example: fmt.Sprintf(importCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&config.Options.API, "cf-api", "", "", "Cloud Foundry API URL")
+	cmd.Flags().StringVarP(&config.Options.Token, "cf-token", "", "", "Cloud Foundry OAuth token, as printed by 'cf oauth-token'")
+	cmd.Flags().StringVarP(&config.Options.Org, "org", "o", "", "Cloud Foundry organization")
+	cmd.Flags().StringVarP(&config.Options.Space, "space", "s", "", "Cloud Foundry space")
+	cmd.Flags().StringVarP(&config.Options.Dir, "dir", "d", "", "Directory to write the manifest, sources, and import plan to")
+	cmd.Flags().BoolVarP(&config.Options.Droplets, "droplets", "", false, "Also download each application's current droplet")
+
+	return cmd
+}
+
+func (config *ImportConfig) Run(args []string) error {
+	// Keep the token out of debug output
+	options := config.Options
+	if options.Token != "" {
+		options.Token = "REDACTED"
+	}
+	log.Debugf("Config: %+v", options)
+
+	if len(args) > 0 {
+		return errors.New("Error: Application names can't be given, every application in the space is imported")
+	}
+	return app.Import(config.Options)
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	installOcCmdLong = `
+Download the oc client.
+
+The client matching the version of the cluster you're logged in to, or
+the one given by --server, is downloaded into ~/.ocf/bin. ocf uses it
+in preference to any oc on your PATH. When the cluster's version can't
+be found the latest stable client is downloaded. The download is
+checked against the SHA-256 checksums the mirror publishes.`
+
+	installOcCmdExample = `
+  # Download the client for the current cluster
+  %[1]s install-oc
+
+  # Download the OpenShift 4.12 client
+  %[1]s install-oc --version 4.12`
+)
+
+// clientlessCommands don't need oc, so aren't held up offering to
+// install it.
+var clientlessCommands = map[string]bool{
+	"install-oc":        true,
+	"doctor":            true,
+	"validate-manifest": true,
+	"completion":        true,
+	"help":              true,
+	"__complete":        true,
+	"__completeNoDesc":  true,
+}
+
+type InstallOcConfig struct {
+	Server  string
+	Version string
+}
+
+func init() {
+	RootCmd.AddCommand(newInstallOcCmd("ocf"))
+}
+
+func newInstallOcCmd(commandName string) *cobra.Command {
+	config := &InstallOcConfig{}
+	cmd := &cobra.Command{
+		Use:     "install-oc",
+		Short:   "Download the oc client.",
+		Long:    installOcCmdLong,
+		Example: fmt.Sprintf(installOcCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&config.Server, "server", "", "", fmt.Sprintf("API server of the cluster to match the client to. Defaults to the %s environment variable or the current kubeconfig context", app.LoginServerEnv))
+	cmd.Flags().StringVarP(&config.Version, "version", "", "", "OpenShift version of the client to download, such as 4.12, or 'stable' for the latest. Defaults to the cluster's version")
+
+	return cmd
+}
+
+func (config *InstallOcConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	path, err := app.InstallOc(app.InstallOcOptions{Server: config.Server, Version: config.Version})
+	if err != nil {
+		return err
+	}
+	log.Printf("Installed %s", path)
+	return nil
+}
+
+// offerToInstallOc offers to download oc when it's needed but
+// missing, instead of every command failing to run it.
+func offerToInstallOc(cmd *cobra.Command) {
+	if Platform != oc.PlatformOpenShift || clientlessCommands[cmd.Name()] || exec.BinDir == "" || !isInteractive() {
+		return
+	}
+	if _, err := exec.LookPath("oc"); err == nil {
+		return
+	}
+	if !confirm(fmt.Sprintf("oc was not found. Download it to %s now? [y/N] ", exec.BinDir)) {
+		return
+	}
+	path, err := app.InstallOc(app.InstallOcOptions{})
+	if err != nil {
+		log.Warnf("%v", err)
+		return
+	}
+	log.Printf("Installed %s", path)
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	logsCmdLong = `
+Show an application's recent logs.
+
+The logs of its latest build (STG), of the pod that rolled out its
+latest revision (CELL), and of each running instance (APP/PROC/WEB/N)
+are merged into one stream in time order, like 'cf logs --recent'.
+--source limits them to some of those.`
+
+	logsCmdExample = `
+  # Show everything 'my-app' logged recently
+  %[1]s logs my-app
+
+  # Show only the logs of the running instances of 'my-app'
+  %[1]s logs my-app --source app
+
+  # Show how 'my-app' was built and rolled out
+  %[1]s logs my-app --source build,deploy`
+)
+
+type LogsConfig struct {
+	Sources []string
+}
+
+func init() {
+	RootCmd.AddCommand(newLogsCmd("ocf"))
+}
+
+func newLogsCmd(commandName string) *cobra.Command {
+	config := &LogsConfig{}
+	cmd := &cobra.Command{
+		Use:     "logs APP_NAME",
+		Short:   "Show an application's recent logs.",
+		Long:    logsCmdLong,
+		Example: fmt.Sprintf(logsCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&config.Sources, "source", "", app.LogSources, fmt.Sprintf("Sources of logs to show, some of %s", strings.Join(app.LogSources, ", ")))
+
+	return cmd
+}
+
+func (config *LogsConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 1 {
+		return errors.New("Error: Application name is required")
+	}
+
+	app := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	return app.Logs(config.Sources)
+}
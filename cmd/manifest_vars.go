@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/manifest"
+
+	"github.com/spf13/cobra"
+)
+
+// addVarsEnvFlag adds --vars-env, which takes an optional prefix.
+func addVarsEnvFlag(cmd *cobra.Command, prefix *string) {
+	cmd.Flags().StringVarP(prefix, "vars-env", "", "", fmt.Sprintf("Resolve ((var)) placeholders in the manifest from environment variables with this prefix, %s if none is given. ((db-password)) is read from CF_VAR_db-password or CF_VAR_DB_PASSWORD", manifest.DefaultVarsEnvPrefix))
+	cmd.Flags().Lookup("vars-env").NoOptDefVal = manifest.DefaultVarsEnvPrefix
+}
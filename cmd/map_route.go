@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	mapRouteCmdLong = `
+Add a route to an application.
+
+This command emulates Cloud Foundry's 'cf map-route' command but
+targeting OpenShift instead. A route is created for the domain, or for
+a host under it when --hostname is given. With --path, only requests
+for that path and below are sent to the application, so several
+applications can share a host.
+
+On the k8s platform an ingress is created instead of a route.`
+
+	mapRouteCmdExample = `
+  # Send requests for example.com to my-app
+  %[1]s map-route my-app example.com
+
+  # Send requests for api.example.com/v1 to my-api
+  %[1]s map-route my-api example.com --hostname api --path /v1`
+)
+
+type MapRouteConfig struct {
+	Hostname string
+	Path     string
+}
+
+func init() {
+	RootCmd.AddCommand(newMapRouteCmd("ocf"))
+}
+
+func newMapRouteCmd(commandName string) *cobra.Command {
+	config := &MapRouteConfig{}
+	cmd := &cobra.Command{
+		Use:     "map-route",
+		Short:   "Add a route to an application.",
+		Long:    mapRouteCmdLong,
+		Example: fmt.Sprintf(mapRouteCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&config.Hostname, "hostname", "n", "", "Hostname for the route, prepended to the domain")
+	cmd.Flags().StringVarP(&config.Path, "path", "", "", "Path for the route, such as /api")
+
+	return cmd
+}
+
+func (config *MapRouteConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 2 {
+		return errors.New("Error: Application name and domain are required")
+	}
+
+	host := args[1]
+	if config.Hostname != "" {
+		host = fmt.Sprint(config.Hostname, ".", host)
+	}
+	app := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	return app.MapRoute(host, config.Path)
+}
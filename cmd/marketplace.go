@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	marketplaceCmdLong = `
+List the services that can be created.
+
+This command emulates Cloud Foundry's 'cf marketplace' command but
+targeting OpenShift instead. Each service offering is a template in
+the openshift project. Templates ending in -persistent are an
+offering's default plan and those ending in -ephemeral its ephemeral
+plan, so postgresql-persistent is the default plan of postgresql.`
+
+	marketplaceCmdExample = `
+  # List the service offerings and their plans
+  %[1]s marketplace`
+
+	createServiceCmdLong = `
+Create a service from the marketplace.
+
+This command emulates Cloud Foundry's 'cf create-service' command but
+targeting OpenShift instead. The plan's template is instantiated with
+generated credentials, and everything it creates is labeled with the
+service's name so it can be bound with 'bind-service'.`
+
+	createServiceCmdExample = `
+  # Create a PostgreSQL database named 'mydb'
+  %[1]s create-service postgresql default mydb
+
+  # Bind it to the application 'my-app'
+  %[1]s bind-service my-app mydb`
+)
+
+type MarketplaceConfig struct {
+}
+
+type CreateServiceConfig struct {
+	Offering string
+	Plan     string
+	Service  string
+}
+
+func init() {
+	RootCmd.AddCommand(newMarketplaceCmd("ocf"))
+	RootCmd.AddCommand(newCreateServiceCmd("ocf"))
+}
+
+func newMarketplaceCmd(commandName string) *cobra.Command {
+	config := &MarketplaceConfig{}
+	cmd := &cobra.Command{
+		Use:     "marketplace",
+		Short:   "List the services that can be created.",
+		Long:    marketplaceCmdLong,
+		Example: fmt.Sprintf(marketplaceCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (config *MarketplaceConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	offerings, err := app.Marketplace(commandContext)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "service\tplans\tdescription")
+	for _, offering := range offerings {
+		var plans []string
+		for _, plan := range offering.Plans {
+			plans = append(plans, plan.Name)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", offering.Name, strings.Join(plans, ", "), offering.Description)
+	}
+	w.Flush()
+	return nil
+}
+
+func newCreateServiceCmd(commandName string) *cobra.Command {
+	config := &CreateServiceConfig{}
+	cmd := &cobra.Command{
+		Use:     "create-service SERVICE PLAN SERVICE_INSTANCE",
+		Short:   "Create a service from the marketplace.",
+		Long:    createServiceCmdLong,
+		Example: fmt.Sprintf(createServiceCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (config *CreateServiceConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 3 {
+		return errors.New("Error: Service offering, plan, and service instance name are required")
+	}
+	config.Offering = args[0]
+	config.Plan = args[1]
+	config.Service = args[2]
+
+	err := app.CreateService(commandContext, config.Offering, config.Plan, config.Service)
+	if err != nil {
+		return err
+	}
+	log.Infof("Created service %s. Run 'ocf bind-service APP %s' to bind it", config.Service, config.Service)
+	return nil
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	migrateBindingsCmdLong = `
+Move service binding credentials into secrets.
+
+Older versions of this tool stored service binding credentials, such
+as database passwords, as plain environment variables on the
+application's deployment config. This command moves the credentials of
+every service bound to an application into a secret per binding and
+references them from the deployment config instead.`
+
+	migrateBindingsCmdExample = `
+  # Move the binding credentials of all services bound to 'my-app' into secrets
+  %[1]s migrate-service-bindings my-app`
+)
+
+type MigrateBindingsConfig struct {
+	Application string
+}
+
+func init() {
+	RootCmd.AddCommand(newMigrateBindingsCmd("ocf"))
+}
+
+func newMigrateBindingsCmd(commandName string) *cobra.Command {
+	config := &MigrateBindingsConfig{}
+	cmd := &cobra.Command{
+		Use:     "migrate-service-bindings",
+		Short:   "Move service binding credentials into secrets.",
+		Long:    migrateBindingsCmdLong,
+		Example: fmt.Sprintf(migrateBindingsCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (config *MigrateBindingsConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 1 {
+		return errors.New("Error: Application name is required")
+	}
+
+	app := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	err := app.MigrateServiceBindings()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
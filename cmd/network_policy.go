@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	addNetworkPolicyCmdLong = `
+Allow an application to reach another one directly.
+
+This command emulates Cloud Foundry's 'cf add-network-policy' command
+but targeting OpenShift instead. A network policy is created allowing
+traffic from the source application's pods to the destination's on the
+given port, which is 8080 by default.
+
+Once an application is the destination of any network policy, only
+the sources its policies allow can reach its pods directly. On
+OpenShift, the routers are still allowed so its routes keep working.`
+
+	addNetworkPolicyCmdExample = `
+  # Allow frontend to reach backend on port 8080
+  %[1]s add-network-policy frontend --destination-app backend
+
+  # Allow frontend to reach backend on UDP ports 9000 to 9010
+  %[1]s add-network-policy frontend --destination-app backend --protocol udp --port 9000-9010`
+)
+
+type AddNetworkPolicyConfig struct {
+	DestinationApp string
+	Protocol       string
+	Port           string
+}
+
+func init() {
+	RootCmd.AddCommand(newAddNetworkPolicyCmd("ocf"))
+}
+
+func newAddNetworkPolicyCmd(commandName string) *cobra.Command {
+	config := &AddNetworkPolicyConfig{}
+	cmd := &cobra.Command{
+		Use:     "add-network-policy SOURCE_APP --destination-app DESTINATION_APP",
+		Short:   "Allow an application to reach another one directly.",
+		Long:    addNetworkPolicyCmdLong,
+		Example: fmt.Sprintf(addNetworkPolicyCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&config.DestinationApp, "destination-app", "", "", "Application the source can reach")
+	cmd.Flags().StringVarP(&config.Protocol, "protocol", "", "tcp", "Protocol to allow, 'tcp' or 'udp'")
+	cmd.Flags().StringVarP(&config.Port, "port", "", app.DefaultNetworkPolicyPort, "Port or range of ports to allow, like 8080-8090")
+
+	return cmd
+}
+
+func (config *AddNetworkPolicyConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 1 {
+		return errors.New("Error: Source application name is required")
+	}
+	if config.DestinationApp == "" {
+		return errors.New("Error: --destination-app is required")
+	}
+
+	app := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	return app.AddNetworkPolicy(config.DestinationApp, config.Protocol, config.Port)
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	planCmdLong = `
+Write what a push would change to a plan file.
+
+Takes the same arguments and flags as 'push', but instead of pushing
+it records every application with what pushing it would change, like
+new applications, environment, routes, and instances. Once the plan is
+reviewed, 'apply' pushes exactly what it records.`
+
+	planCmdExample = `
+  # Plan pushing the applications in manifest.yml
+  %[1]s plan --out plan.json
+
+  # Push them after reviewing the plan
+  %[1]s apply plan.json`
+)
+
+func init() {
+	RootCmd.AddCommand(newPlanCmd("ocf"))
+}
+
+func newPlanCmd(commandName string) *cobra.Command {
+	config := &PushConfig{}
+	cmd := &cobra.Command{
+		Use:     "plan",
+		Short:   "Write what a push would change to a plan file.",
+		Long:    planCmdLong,
+		Example: fmt.Sprintf(planCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+	config.addFlags(cmd)
+	cmd.Flags().StringVarP(&config.PlanFile, "out", "", "plan.json", "File to write the plan to")
+
+	return cmd
+}
+
+func writePlan(file string, apps []app.Application, options app.PushOptions) error {
+	plan, err := app.NewPlan(commandContext, apps, options)
+	if err != nil {
+		return err
+	}
+	for _, planned := range plan.Apps {
+		printChanges(planned.Application.Name, planned.Changes)
+	}
+	err = plan.Write(file)
+	if err != nil {
+		return err
+	}
+	log.Infof("Wrote the plan to %s. Run 'ocf apply %s' to push it", file, file)
+	return nil
+}
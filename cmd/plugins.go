@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/manifest"
+	"github.com/bbrowning/ocf/pkg/oc"
+
+	"github.com/spf13/cobra"
+)
+
+// pluginPrefix starts the name of every plugin executable, so
+// 'ocf-hello' on the PATH becomes 'ocf hello'.
+const pluginPrefix = "ocf-"
+
+// pluginDirs returns the directories searched for plugins: the user's
+// ~/.ocf/plugins directory followed by the PATH.
+func pluginDirs() []string {
+	var dirs []string
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".ocf", "plugins"))
+	}
+	return append(dirs, filepath.SplitList(os.Getenv("PATH"))...)
+}
+
+// findPlugins returns the path of every plugin executable in dirs by
+// plugin name. Earlier directories win when a name appears twice.
+func findPlugins(dirs []string) map[string]string {
+	plugins := make(map[string]string)
+	for _, dir := range dirs {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			command, ok := exec.CommandName(file)
+			name := strings.TrimPrefix(command, pluginPrefix)
+			if !ok || name == command || name == "" {
+				continue
+			}
+			if _, ok := plugins[name]; !ok {
+				plugins[name] = filepath.Join(dir, file.Name())
+			}
+		}
+	}
+	return plugins
+}
+
+// addPluginCommands adds a subcommand for each plugin that doesn't
+// clash with a built-in command.
+func addPluginCommands(root *cobra.Command, plugins map[string]string) {
+	var names []string
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if cmd, _, err := root.Find([]string{name}); err == nil && cmd != root {
+			continue
+		}
+		root.AddCommand(newPluginCmd(name, plugins[name]))
+	}
+}
+
+func newPluginCmd(name string, path string) *cobra.Command {
+	return &cobra.Command{
+		Use:   name,
+		Short: fmt.Sprintf("Plugin %s", path),
+		// The plugin parses its own flags
+		DisableFlagParsing: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			pluginCmd := new(exec.DefaultExecer).Command(path, args...)
+			pluginCmd.SetEnv(pluginEnv())
+			pluginCmd.AttachStdIO()
+			pluginCmd.SetTimeout(0)
+			// The plugin gets the user's interrupts itself
+			err := pluginCmd.Run(context.Background())
+			if exitErr, ok := err.(*osexec.ExitError); ok {
+				exit(exitErr.ExitCode())
+			} else if err != nil {
+				log.Errorf("err: %v", err)
+				exit(1)
+			}
+		},
+	}
+}
+
+// pluginEnv describes what ocf is targeting to plugins: the
+// platform, the cluster client, the current project, and the
+// application in the current directory's manifest if it only has one.
+func pluginEnv() []string {
+	client := "oc"
+	if Platform == oc.PlatformKubernetes {
+		client = "kubectl"
+	}
+	env := []string{
+		fmt.Sprint(platformEnv, "=", Platform),
+		fmt.Sprint("OCF_CLIENT=", client),
+	}
+	if binary, err := os.Executable(); err == nil {
+		env = append(env, fmt.Sprint("OCF_BINARY=", binary))
+	}
+	if project, err := app.CurrentProject(commandContext); err == nil {
+		env = append(env, fmt.Sprint("OCF_PROJECT=", strings.TrimSpace(project)))
+	}
+	if path, err := manifest.Find(""); err == nil {
+		if m, err := manifest.Load(path, nil); err == nil && len(m.Applications) == 1 {
+			env = append(env, fmt.Sprint("OCF_APP_NAME=", m.Applications[0].Name))
+		}
+	}
+	return env
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/spf13/cobra"
+)
+
+func TestFindPlugins(t *testing.T) {
+	first, err := ioutil.TempDir("", "ocf-plugins")
+	assert.Nil(t, err)
+	defer os.RemoveAll(first)
+	second, err := ioutil.TempDir("", "ocf-plugins")
+	assert.Nil(t, err)
+	defer os.RemoveAll(second)
+
+	ioutil.WriteFile(filepath.Join(first, "ocf-hello"), []byte("#!/bin/sh\n"), 0755)
+	ioutil.WriteFile(filepath.Join(first, "ocf-notes.txt"), []byte(""), 0644)
+	ioutil.WriteFile(filepath.Join(second, "ocf-hello"), []byte("#!/bin/sh\n"), 0755)
+	ioutil.WriteFile(filepath.Join(second, "ocf-push"), []byte("#!/bin/sh\n"), 0755)
+	ioutil.WriteFile(filepath.Join(second, "other"), []byte("#!/bin/sh\n"), 0755)
+
+	plugins := findPlugins([]string{first, second, filepath.Join(first, "missing")})
+	assert.Equal(t, map[string]string{
+		"hello": filepath.Join(first, "ocf-hello"),
+		"push":  filepath.Join(second, "ocf-push"),
+	}, plugins)
+}
+
+func TestAddPluginCommandsSkipsBuiltins(t *testing.T) {
+	root := &cobra.Command{Use: "ocf"}
+	root.AddCommand(&cobra.Command{Use: "push", Run: func(*cobra.Command, []string) {}})
+	addPluginCommands(root, map[string]string{"push": "/bin/ocf-push", "hello": "/bin/ocf-hello"})
+
+	cmd, _, err := root.Find([]string{"hello"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Plugin /bin/ocf-hello", cmd.Short)
+	cmd, _, err = root.Find([]string{"push"})
+	assert.Nil(t, err)
+	assert.Equal(t, "", cmd.Short)
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	portForwardCmdLong = `
+Forward local ports to an application or service in the cluster.
+
+Given an application, ports are forwarded to one of its running pods,
+by default port 8080 that applications listen on. With --service,
+ports are forwarded to a service such as a bound database instead, by
+default to the ports the service exposes.
+
+Ports are given like 'oc port-forward' takes them: LOCAL:REMOTE, or
+just PORT to use the same port locally. Forwarding continues until
+interrupted with Ctrl-C.`
+
+	portForwardCmdExample = `
+  # Reach my-app at localhost:8080
+  %[1]s port-forward my-app
+
+  # Reach my-app's port 8080 at localhost:9000
+  %[1]s port-forward my-app 9000:8080
+
+  # Reach the rails-postgres service at localhost:5432
+  %[1]s port-forward --service rails-postgres 5432`
+)
+
+type PortForwardConfig struct {
+	Service string
+}
+
+func init() {
+	RootCmd.AddCommand(newPortForwardCmd("ocf"))
+}
+
+func newPortForwardCmd(commandName string) *cobra.Command {
+	config := &PortForwardConfig{}
+	cmd := &cobra.Command{
+		Use:     "port-forward",
+		Short:   "Forward local ports to an application or service.",
+		Long:    portForwardCmdLong,
+		Example: fmt.Sprintf(portForwardCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&config.Service, "service", "", "", "Forward to this service instead of an application")
+
+	return cmd
+}
+
+func (config *PortForwardConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if config.Service != "" {
+		return app.PortForwardService(commandContext, config.Service, args)
+	}
+	if len(args) < 1 {
+		return errors.New("Error: Application name or --service is required")
+	}
+
+	app := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	return app.PortForward(args[1:])
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	promoteCmdLong = `
+Finish rolling out an application's canary.
+
+The application is updated to run the canary's version, all of its
+traffic is sent back to it, and the canary pushed with
+'push --strategy canary' is removed.`
+
+	promoteCmdExample = `
+  # Replace 'my-app' with its canary
+  %[1]s promote my-app`
+)
+
+type PromoteConfig struct {
+}
+
+func init() {
+	RootCmd.AddCommand(newPromoteCmd("ocf"))
+}
+
+func newPromoteCmd(commandName string) *cobra.Command {
+	config := &PromoteConfig{}
+	cmd := &cobra.Command{
+		Use:     "promote APP_NAME",
+		Short:   "Finish rolling out an application's canary.",
+		Long:    promoteCmdLong,
+		Example: fmt.Sprintf(promoteCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (config *PromoteConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 1 {
+		return errors.New("Error: Application name is required")
+	}
+
+	app := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	return app.Promote()
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	purgeCmdLong = `
+Remove service bindings from every application in the current project.
+
+This command is loosely modeled on Cloud Foundry's 'cf
+purge-service-offering' command. Given a service name, it removes that
+service's bindings from every application without contacting the
+service itself. Without a service name, it removes only orphaned
+bindings whose backing deployment config, secret, or config map no
+longer exists, such as after a service was deleted with 'oc delete'.`
+
+	purgeCmdExample = `
+  # Remove all bindings of the 'rails-postgres' service
+  %[1]s purge-service-offering rails-postgres
+
+  # Remove bindings to services that no longer exist
+  %[1]s purge-service-offering`
+)
+
+type PurgeConfig struct {
+	Service string
+}
+
+func init() {
+	RootCmd.AddCommand(newPurgeCmd("ocf"))
+}
+
+func newPurgeCmd(commandName string) *cobra.Command {
+	config := &PurgeConfig{}
+	cmd := &cobra.Command{
+		Use:     "purge-service-offering",
+		Short:   "Remove service bindings from every application.",
+		Long:    purgeCmdLong,
+		Example: fmt.Sprintf(purgeCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (config *PurgeConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) > 1 {
+		return errors.New("Error: At most one service name may be given")
+	}
+	if len(args) == 1 {
+		config.Service = args[0]
+	}
+
+	purged, err := app.PurgeServiceBindings(commandContext, config.Service)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Removed %d service binding(s)", len(purged))
+	return nil
+}
@@ -1,6 +1,5 @@
-// The command flags, their descriptions, and some of the logic for
-// merging manifests with flags come from Cloud Foundry's 'cf'
-// tool. See the NOTICE file for more information.
+// The command flags and their descriptions come from Cloud Foundry's
+// 'cf' tool. See the NOTICE file for more information.
 
 package cmd
 
@@ -10,13 +9,15 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
+	"time"
 
 	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/manifest"
+	"github.com/bbrowning/ocf/pkg/oc"
 
-	"github.com/ghodss/yaml"
-	"github.com/imdario/mergo"
 	"github.com/spf13/cobra"
 )
 
@@ -40,23 +41,104 @@ below.`
   %[1]s push
 
   # Update an existing application with a manifest.yml
-  %[1]s push`
+  %[1]s push
+
+  # Push my-app using only command-line flags, ignoring any manifest
+  %[1]s push my-app --no-manifest -m 512M
+
+  # Fill in ((memory)) in the manifest from the environment
+  CF_VAR_memory=512M %[1]s push --vars-env
+
+  # Push my-app again whenever its files change
+  %[1]s push my-app --watch
+
+  # Run a droplet downloaded from Cloud Foundry without staging it again
+  %[1]s push my-app --droplet droplet.tgz
+
+  # Build my-app in the cluster from the main branch of a Git repository
+  %[1]s push my-app --git https://github.com/org/repo#main
+
+  # Fail the push unless my-app's /health responds with 200 within a minute
+  %[1]s push my-app --smoke-test /health --smoke-test-timeout 1m
+
+  # Send 10 percent of my-app's traffic to the new version, then finish the rollout
+  %[1]s push my-app --strategy canary --canary-weight 10
+  %[1]s promote my-app
+
+  # Push from a CI pipeline, logging in with a token
+  OCF_TOKEN=$TOKEN OCF_SERVER=https://api.example.com:6443 %[1]s push --ci
+
+  # Follow the progress of pushing my-app as JSON events
+  %[1]s push my-app --output-events -
+
+  # List the steps pushing my-app would run, then push without its hooks
+  %[1]s push my-app --dry-run
+  %[1]s push my-app --skip-steps pre-push-hook,post-push-hook`
 )
 
+// workloadEnv is the environment variable that overrides the default
+// --workload
+const workloadEnv = "OCF_WORKLOAD"
+
+// buildersConfigEnv is the environment variable that overrides the
+// default --builders-config
+const buildersConfigEnv = "OCF_BUILDERS_CONFIG"
+
 // PushConfig contains all the necessary configuration for the push command
 type PushConfig struct {
-	Buildpack    string
-	Command      string
-	ManifestPath string
-	Instances    int
-	Disk         string
-	Memory       string
-	Path         string
-	Image        string
-}
-
-type Manifest struct {
-	Applications []app.Application `json:"applications"`
+	Buildpack       string
+	Command         string
+	ManifestPath    string
+	NoManifest      bool
+	All             bool
+	Instances       int
+	Disk            string
+	Memory          string
+	CPU             string
+	GracePeriod     int
+	Path            string
+	Image           string
+	BuildersConfig  string
+	CommandMode     string
+	ImageTimeout    time.Duration
+	Workload        string
+	Registry        string
+	Domain          string
+	Serve           string
+	GitOpsDir       string
+	GitOpsOnly      bool
+	DiffOnly        bool
+	AsyncBuild      bool
+	LockWait        time.Duration
+	CleanupOnCancel bool
+	CleanupOnFail   string
+	OutputEvents    string
+	CI              bool
+	// PlanFile is where plan writes what the push would do instead
+	// of pushing
+	PlanFile     string
+	Compression  string
+	RouteType    string
+	Port         int
+	VarsEnv      string
+	Watch        bool
+	Prune        bool
+	Droplet      string
+	AuditFile    string
+	IgnoreQuota  bool
+	SkipSteps    []string
+	DryRun       bool
+	SmokeTest    string
+	SmokeStatus  int
+	SmokeTimeout time.Duration
+	Strategy     string
+	CanaryWeight int
+	Tag          string
+	Spread       string
+	Internal     bool
+	// NoBuildMetadata skips recording where the push came from
+	NoBuildMetadata bool
+	Git             string
 }
 
 func init() {
@@ -73,88 +155,460 @@ func newPushCmd(commandName string) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			err := config.Run(args)
 			if err != nil {
-				fmt.Printf("err: %v\n", err)
+				log.Errorf("err: %v", err)
+				if config.CI {
+					exit(ciExitCode(err))
+				}
+				// Failed pushes have always exited non-zero
+				exit(1)
 			}
 		},
 	}
+	config.addFlags(cmd)
 
+	return cmd
+}
+
+// addFlags adds push's flags to cmd, which is push or a command, like
+// plan, that takes the same flags.
+func (config *PushConfig) addFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&config.Buildpack, "buildpack", "b", "", "Custom buildpack by Git URL (e.g. 'https://github.com/cloudfoundry/java-buildpack.git') or Git URL with a branch or tag (e.g. 'https://github.com/cloudfoundry/java-buildpack.git#v3.3.0' for 'v3.3.0' tag). To use built-in buildpacks only, specify 'default' or 'null'")
 	cmd.Flags().StringVarP(&config.Command, "command", "c", "", "Startup command, set to null to reset to default start command")
 	cmd.Flags().StringVarP(&config.ManifestPath, "manifest-path", "f", "", "Path to manifest")
+	cmd.Flags().BoolVarP(&config.NoManifest, "no-manifest", "", false, "Ignore manifest file")
+	cmd.Flags().BoolVarP(&config.All, "all", "", false, "Apply command line flags to every app when pushing multiple apps from a manifest")
+	addVarsEnvFlag(cmd, &config.VarsEnv)
 	// cmd.Flags().IntVarP(&config.Instances, "instances", "i", 1, "Number of instances")
 	// cmd.Flags().StringVarP(&config.Disk, "disk", "k", "", "Disk limit (e.g. 256M, 1024M, 1G)")
-	cmd.Flags().StringVarP(&config.Memory, "memory", "m", "", "Memory limit (e.g. 256M, 1024M, 1G)")
+	cmd.Flags().StringVarP(&config.Memory, "memory", "m", "", "Memory limit (e.g. 256M, 1024M, 1G). Applications without one get the default memory limit of the project's limit range")
+	cmd.Flags().StringVarP(&config.CPU, "cpu", "", "", "CPU limit in cores or millicores (e.g. 0.5, 2, 500m)")
+	cmd.Flags().IntVarP(&config.GracePeriod, "termination-grace-period", "", 0, "Seconds instances have to exit after being sent SIGTERM before they're killed, defaulting to the cluster's 30")
 	cmd.Flags().StringVarP(&config.Path, "path", "p", "", "Path to app directory or to a zip file of the contents of the app directory")
-	cmd.Flags().StringVarP(&config.Image, "image", "", "bbrowning/openshift-cloudfoundry-docker19", "Base Docker image to use when building and deploying applications")
+	cmd.Flags().StringVarP(&config.Image, "image", "", "", fmt.Sprintf("Base Docker image to use when building and deploying applications. Defaults to the image --builders-config maps the application's stack or language to, or %s", app.DefaultImage))
+	cmd.Flags().StringVarP(&config.BuildersConfig, "builders-config", "", defaultBuildersConfig(), fmt.Sprintf("File mapping application languages and manifest stacks to builder images. The default can be changed with the %s environment variable", buildersConfigEnv))
+	cmd.Flags().StringVarP(&config.Workload, "workload", "", defaultWorkload(), fmt.Sprintf("Type of object created to run new applications, 'deploymentconfig' or 'deployment'. The default can be changed with the %s environment variable", workloadEnv))
+	cmd.Flags().StringVarP(&config.Registry, "registry", "", "", "Registry to push built images to when using the k8s platform (e.g. 'quay.io/myorg')")
+	cmd.Flags().StringVarP(&config.Domain, "domain", "", "", "Domain for application ingresses when using the k8s platform, without which no ingress is created. On OpenShift, routes are created for hosts under this domain instead of the default one, selecting the router that serves it. The domain is checked against those the cluster's routers serve")
+	cmd.Flags().StringVarP(&config.Serve, "serve", "", "", "Alternative way to run applications instead of deployments. Only 'knative' is supported, which runs a revisioned Knative Service that scales to zero")
+	cmd.Flags().StringVarP(&config.GitOpsDir, "gitops-dir", "", "", "Write the application's manifests to this directory in a kustomize layout and apply them from there")
+	cmd.Flags().BoolVarP(&config.GitOpsOnly, "gitops-only", "", false, "Only write manifests to --gitops-dir without applying them")
+	cmd.Flags().BoolVarP(&config.DiffOnly, "diff-only", "", false, "Show what push would change in the cluster and exit without applying it")
+	cmd.Flags().BoolVarP(&config.Watch, "watch", "", false, "Keep running after the push, pushing again whenever the application's files change. Ruby, Node.js, Python, and PHP applications on OpenShift have changed files synced into their running pods instead")
+	cmd.Flags().BoolVarP(&config.Prune, "prune", "", false, "Remove what earlier pushes left behind that no longer matches the manifest and flags: build-env entries, CF_COMMAND and MEMORY_LIMIT without a command or memory, and the default route once replaced. Services and ingresses whose port, type, or host changed are recreated")
+	cmd.Flags().StringVarP(&config.Droplet, "droplet", "", "", fmt.Sprintf("Cloud Foundry droplet tarball to run instead of building the application from source. It's wrapped in an image with a Docker build, based on --image or %s", app.DefaultDropletImage))
+	cmd.Flags().StringVarP(&config.AuditFile, "audit-file", "", "", "Write a JSON record of every command the push runs and every object it creates to this file")
+	cmd.Flags().BoolVarP(&config.IgnoreQuota, "ignore-quota", "", false, "Push even if the project's resource quotas or limit ranges don't leave room for the application's memory and instances, warning instead of failing")
+	cmd.Flags().StringVarP(&config.SmokeTest, "smoke-test", "", "", "After pushing, request this path on the application's route, or this full URL, until it responds with --smoke-test-status, failing the push if it doesn't")
+	cmd.Flags().IntVarP(&config.SmokeStatus, "smoke-test-status", "", 200, "HTTP status the smoke test waits for")
+	cmd.Flags().DurationVarP(&config.SmokeTimeout, "smoke-test-timeout", "", app.DefaultSmokeTestTimeout, "How long the smoke test waits for the application to respond")
+	cmd.Flags().StringVarP(&config.Strategy, "strategy", "", app.StrategyRolling, "How the new version replaces the running one: 'rolling' to replace it, or 'canary' to run it alongside and send it --canary-weight percent of the traffic until 'ocf promote' or 'ocf abort'")
+	cmd.Flags().IntVarP(&config.CanaryWeight, "canary-weight", "", app.DefaultCanaryWeight, "Percentage of traffic sent to a canary, from 1 to 99")
+	cmd.Flags().StringVarP(&config.Spread, "spread", "", app.SpreadZone, "How instances of applications with more than one are spread across the cluster: 'zone' across zones and then nodes, like Cloud Foundry's availability zones, 'node' across nodes, or 'off'")
+	cmd.Flags().BoolVarP(&config.NoBuildMetadata, "no-build-metadata", "", false, "Don't record the Git commit, branch, and version, the build time, and who pushed as ocf/ annotations and OCF_ environment variables of the application")
+	cmd.Flags().StringVarP(&config.Git, "git", "", "", "Build the application in the cluster from this Git repository URL, with an optional #branch, tag, or commit, instead of uploading the application directory. Prints the build's webhook URLs and secrets, which start a build on every push to the repository")
+	cmd.Flags().StringVarP(&config.Tag, "tag", "", "", "Deploy the image an earlier push built and tagged, such as '20240102-150405-1a2b3c4', instead of building the application again. Each build is tagged with its time and the Git SHA of its source")
+	cmd.Flags().BoolVarP(&config.CI, "ci", "", false, fmt.Sprintf("Push from a CI pipeline: never prompt, logging in with the token in %s, write events to stdout unless --output-events is given, retry temporary failures, and exit with 2 for login, 3 for build, or 4 for deployment failures", app.LoginTokenEnv))
+	cmd.Flags().StringVarP(&config.OutputEvents, "output-events", "", "", "Write newline-delimited JSON events for each step of the push, and the output of its builds, to this file, or to stdout if it's '-'")
+	cmd.Flags().StringSliceVarP(&config.SkipSteps, "skip-steps", "", nil, "Steps of the push not to run, such as 'quota' or 'pre-push-hook'. See --dry-run for the steps of a push")
+	cmd.Flags().BoolVarP(&config.DryRun, "dry-run", "", false, "List the steps a push would run without running them")
+	cmd.Flags().BoolVarP(&config.AsyncBuild, "async-build", "", false, "Start builds without streaming their logs, polling their status until they finish")
+	cmd.Flags().DurationVarP(&config.LockWait, "lock-wait", "", 0, "How long to wait for another push of the same application to finish instead of failing immediately")
+	cmd.Flags().BoolVarP(&config.CleanupOnCancel, "cleanup-on-cancel", "", false, "Delete the objects created by a push that's interrupted with Ctrl-C")
+	cmd.Flags().StringVarP(&config.CleanupOnFail, "cleanup-on-failure", "", app.CleanupPrompt, "Whether to delete the objects created by an application's first push when it fails: 'prompt', 'always', or 'never'. Prompting keeps them when running non-interactively")
+	cmd.Flags().StringVarP(&config.Compression, "compression", "", app.CompressionDefault, "Compression of the application archive uploaded to the build: 'none', 'fast', 'default', or 'best'")
+	cmd.Flags().StringVarP(&config.RouteType, "route-type", "", app.RouteTypeHTTP, "How to expose applications: 'http' with a route, or ingress on the k8s platform, or 'tcp' with a load balancer service")
+	cmd.Flags().IntVarP(&config.Port, "port", "", 0, "External port of a TCP route. Defaults to 8080, the port applications listen on")
+	cmd.Flags().BoolVarP(&config.Internal, "internal", "", false, "Only expose applications inside the cluster, through their service at APP.PROJECT.svc, without a route or ingress, like Cloud Foundry's apps.internal routes. Manifest routes under apps.internal do the same")
+	cmd.Flags().DurationVarP(&config.ImageTimeout, "image-timeout", "", app.DefaultImageTimeout, "How long to wait for a built image to appear in its image stream before deploying")
+	cmd.Flags().StringVarP(&config.CommandMode, "command-mode", "", app.CommandModeCF, "How to apply a custom start command: 'cf' to pass it to the base image as CF_COMMAND or 'native' to set it as the container's command")
+}
 
-	return cmd
+func defaultWorkload() string {
+	if workload := os.Getenv(workloadEnv); workload != "" {
+		return workload
+	}
+	return app.WorkloadDeploymentConfig
+}
+
+// defaultBuildersConfig returns the builders config in the user's
+// ~/.ocf directory, if they have a home directory.
+func defaultBuildersConfig() string {
+	if path := os.Getenv(buildersConfigEnv); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ocf", "builders.yml")
+}
+
+// loadBuilders loads the builders config, which is optional unless a
+// path other than the default was given.
+func (config *PushConfig) loadBuilders() (*app.Builders, error) {
+	if config.BuildersConfig == "" {
+		return nil, nil
+	}
+	builders, err := app.LoadBuilders(config.BuildersConfig)
+	if os.IsNotExist(err) && config.BuildersConfig == defaultBuildersConfig() {
+		return nil, nil
+	}
+	return builders, err
 }
 
 func (config *PushConfig) Run(args []string) error {
-	debugf("Config: %+v\n", config)
+	log.Debugf("Config: %+v", config)
+
+	if config.CommandMode != app.CommandModeCF && config.CommandMode != app.CommandModeNative {
+		return errors.New(fmt.Sprintf("Error: Invalid command mode %s, must be %s or %s", config.CommandMode, app.CommandModeCF, app.CommandModeNative))
+	}
+
+	if config.Workload != app.WorkloadDeploymentConfig && config.Workload != app.WorkloadDeployment {
+		return errors.New(fmt.Sprintf("Error: Invalid workload %s, must be %s or %s", config.Workload, app.WorkloadDeploymentConfig, app.WorkloadDeployment))
+	}
+
+	if config.Serve != "" && config.Serve != app.ServeKnative {
+		return errors.New(fmt.Sprintf("Error: Invalid serve option %s, must be %s", config.Serve, app.ServeKnative))
+	}
+
+	if !app.ValidCompression(config.Compression) {
+		return errors.New(fmt.Sprintf("Error: Invalid compression %s, must be none, fast, default, or best", config.Compression))
+	}
+
+	if config.RouteType != app.RouteTypeHTTP && config.RouteType != app.RouteTypeTCP {
+		return errors.New(fmt.Sprintf("Error: Invalid route type %s, must be %s or %s", config.RouteType, app.RouteTypeHTTP, app.RouteTypeTCP))
+	}
+	if config.Port != 0 && config.RouteType != app.RouteTypeTCP {
+		return errors.New("Error: --port requires --route-type tcp")
+	}
+	if config.Port < 0 || config.Port > 65535 {
+		return errors.New(fmt.Sprintf("Error: Invalid port %d, must be between 1 and 65535", config.Port))
+	}
+	if config.RouteType == app.RouteTypeTCP && config.Serve != "" {
+		return errors.New("Error: --route-type tcp can't be combined with --serve")
+	}
+
+	if config.GitOpsOnly && config.GitOpsDir == "" {
+		return errors.New("Error: --gitops-only requires --gitops-dir")
+	}
+	if config.GitOpsDir != "" && config.Serve != "" {
+		return errors.New("Error: --gitops-dir can't be combined with --serve")
+	}
+	if config.SmokeTest != "" && config.GitOpsOnly {
+		return errors.New("Error: --smoke-test can't be used with --gitops-only")
+	}
+	if config.SmokeTest != "" && (config.SmokeStatus < 100 || config.SmokeStatus > 599) {
+		return errors.New(fmt.Sprintf("Error: Invalid smoke test status %d", config.SmokeStatus))
+	}
+
+	if config.CI && config.Watch {
+		return errors.New("Error: --ci can't be used with --watch")
+	}
+	if config.CI {
+		app.NonInteractive = true
+		if config.OutputEvents == "" {
+			config.OutputEvents = "-"
+		}
+	}
+	switch config.CleanupOnFail {
+	case app.CleanupPrompt, app.CleanupAlways, app.CleanupNever:
+	default:
+		return errors.New(fmt.Sprintf("Error: Invalid --cleanup-on-failure %s, must be %s, %s, or %s", config.CleanupOnFail, app.CleanupPrompt, app.CleanupAlways, app.CleanupNever))
+	}
+	if config.Strategy != app.StrategyRolling && config.Strategy != app.StrategyCanary {
+		return errors.New(fmt.Sprintf("Error: Invalid strategy %s, must be %s or %s", config.Strategy, app.StrategyRolling, app.StrategyCanary))
+	}
+	if config.Strategy == app.StrategyCanary {
+		switch {
+		case Platform == oc.PlatformKubernetes:
+			return errors.New("Error: --strategy canary needs OpenShift routes, so isn't supported on the k8s platform")
+		case config.RouteType == app.RouteTypeTCP || config.Serve != "" || config.GitOpsDir != "" || config.Watch:
+			return errors.New("Error: --strategy canary can't be combined with --route-type tcp, --serve, --gitops-dir, or --watch")
+		case config.CanaryWeight < 1 || config.CanaryWeight > 99:
+			return errors.New(fmt.Sprintf("Error: Invalid canary weight %d, must be between 1 and 99", config.CanaryWeight))
+		}
+	}
+
+	switch config.Spread {
+	case app.SpreadZone, app.SpreadNode, app.SpreadOff:
+	default:
+		return errors.New(fmt.Sprintf("Error: Invalid spread %s, must be %s, %s, or %s", config.Spread, app.SpreadZone, app.SpreadNode, app.SpreadOff))
+	}
+	if config.Internal && (config.RouteType == app.RouteTypeTCP || config.Serve != "" || config.Strategy == app.StrategyCanary) {
+		return errors.New("Error: --internal can't be combined with --route-type tcp, --serve, or --strategy canary")
+	}
+	if config.Tag != "" {
+		switch {
+		case Platform == oc.PlatformKubernetes:
+			return errors.New("Error: --tag needs OpenShift image streams, so isn't supported on the k8s platform")
+		case config.Droplet != "" || config.Watch:
+			return errors.New("Error: --tag can't be combined with --droplet or --watch")
+		}
+	}
+	if config.Git != "" {
+		switch {
+		case Platform == oc.PlatformKubernetes:
+			return errors.New("Error: --git needs OpenShift build configurations, so isn't supported on the k8s platform")
+		case config.Droplet != "" || config.Tag != "" || config.Watch || config.GitOpsDir != "":
+			return errors.New("Error: --git can't be combined with --droplet, --tag, --watch, or --gitops-dir")
+		}
+		if _, _, err := app.ParseGitURL(config.Git); err != nil {
+			return err
+		}
+	}
 
 	manifestApps, err := config.getManifestApps()
 	if err != nil {
 		return err
 	}
-	debugf("manifestApps: %+v\n", manifestApps)
+	log.Debugf("manifestApps: %+v", manifestApps)
 
 	flagsApp, err := config.getFlagsApp(args)
 	if err != nil {
 		return err
 	}
-	debugf("flagsApp: %+v\n", flagsApp)
+	log.Debugf("flagsApp: %+v", flagsApp)
 
-	mergedApps, err := mergeAppsFromManifestAndFlags(manifestApps, flagsApp)
+	mergedApps, err := manifest.Merge(manifestApps, flagsApp, config.All)
 	if err != nil {
 		return err
 	}
-	debugf("mergedApps: %+v\n", mergedApps)
-	debugf("\n\n\n")
+	log.Debugf("mergedApps: %+v", mergedApps)
 
 	for _, app := range mergedApps {
 		if app.Name == "" {
 			return errors.New("Error: no name found for app")
 		}
+	}
 
-		app.Push(config.Image)
+	err = manifest.ValidatePaths(mergedApps)
+	if err != nil {
+		return err
+	}
+
+	builders, err := config.loadBuilders()
+	if err != nil {
+		return err
+	}
+	// An explicit --image is used whatever the stack
+	if config.Image == "" {
+		for i := range mergedApps {
+			err = builders.CheckStack(&mergedApps[i])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	options := app.PushOptions{
+		Image:            config.Image,
+		Builders:         builders,
+		CommandMode:      config.CommandMode,
+		ImageTimeout:     config.ImageTimeout,
+		Workload:         config.Workload,
+		Registry:         config.Registry,
+		Domain:           config.Domain,
+		Serve:            config.Serve,
+		GitOpsDir:        config.GitOpsDir,
+		GitOpsOnly:       config.GitOpsOnly,
+		AsyncBuild:       config.AsyncBuild,
+		LockWait:         config.LockWait,
+		CleanupOnCancel:  config.CleanupOnCancel,
+		CleanupOnFailure: config.CleanupOnFail,
+		Compression:      config.Compression,
+		RouteType:        config.RouteType,
+		RoutePort:        config.Port,
+		Prune:            config.Prune,
+		AuditFile:        config.AuditFile,
+		IgnoreQuota:      config.IgnoreQuota,
+		SkipSteps:        config.SkipSteps,
+		DryRun:           config.DryRun,
+		SmokeTest:        config.SmokeTest,
+		SmokeTestStatus:  config.SmokeStatus,
+		SmokeTestTimeout: config.SmokeTimeout,
+		Strategy:         config.Strategy,
+		CanaryWeight:     config.CanaryWeight,
+		Tag:              config.Tag,
+		Spread:           config.Spread,
+		Internal:         config.Internal,
+		NoBuildMetadata:  config.NoBuildMetadata,
+		Git:              config.Git,
+	}
+	if isInteractive() {
+		options.ConfirmCleanup = confirm
+	}
+	if config.Droplet != "" {
+		options.Droplet, err = checkDroplet(config.Droplet, mergedApps, config.Watch)
+		if err != nil {
+			return err
+		}
+	}
+	if config.Tag != "" {
+		err = checkTag(mergedApps)
+		if err != nil {
+			return err
+		}
+	}
+	if config.Git != "" {
+		err = checkGit(mergedApps)
+		if err != nil {
+			return err
+		}
+	}
+	if config.Watch {
+		if len(mergedApps) != 1 {
+			return errors.New("Error: Only one application can be pushed with --watch")
+		}
+		if config.DiffOnly || config.GitOpsOnly {
+			return errors.New("Error: --watch can't be used with --diff-only or --gitops-only")
+		}
+	}
+
+	if config.PlanFile != "" {
+		if config.Watch || config.DiffOnly || config.DryRun || config.GitOpsOnly {
+			return errors.New("Error: plan can't be used with --watch, --diff-only, --dry-run, or --gitops-only")
+		}
+		return writePlan(config.PlanFile, mergedApps, options)
+	}
+
+	if config.AuditFile != "" && !config.DiffOnly {
+		// Each push appends its audit to a fresh file
+		err = ioutil.WriteFile(config.AuditFile, nil, 0600)
+		if err != nil {
+			return err
+		}
+	}
+
+	if config.OutputEvents != "" {
+		events, closeEvents, err := openEvents(config.OutputEvents)
+		if err != nil {
+			return err
+		}
+		defer closeEvents()
+		options.Events = events
+	}
+
+	for _, app := range mergedApps {
+		app.WithContext(commandContext)
+		if config.DiffOnly {
+			changes, err := app.Diff(options)
+			if err != nil {
+				return err
+			}
+			printChanges(app.Name, changes)
+			continue
+		}
+		if config.Watch {
+			err = app.Watch(options)
+		} else if config.CI {
+			err = withRetries(ciRetries, func() error { return app.Push(options) })
+		} else {
+			err = app.Push(options)
+		}
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (config *PushConfig) getManifestApps() ([]app.Application, error) {
-	var path string
-	var err error
-	if config.ManifestPath != "" {
-		path = config.ManifestPath
+// openEvents returns the event stream written to file, or to stdout
+// if it's "-" with messages moved to stderr out of its way. Commands'
+// attached output, like build logs, becomes events too.
+func openEvents(file string) (*app.EventStream, func(), error) {
+	w := os.Stdout
+	if file == "-" {
+		log.SetOutput(os.Stderr)
 	} else {
-		path, err = os.Getwd()
+		f, err := os.Create(file)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+		w = f
 	}
+	events := app.NewEventStream(w)
+	exec.Stdout = events
+	exec.Stderr = events
+	return events, func() {
+		exec.Stdout = os.Stdout
+		exec.Stderr = os.Stderr
+		if w != os.Stdout {
+			w.Close()
+		}
+	}, nil
+}
 
-	if info, err := os.Stat(path); err == nil && info.IsDir() {
-		path = filepath.Join(path, "manifest.yml")
+// checkDroplet returns the absolute path of a droplet given with
+// --droplet, which runs a single application instead of its source.
+func checkDroplet(droplet string, apps []app.Application, watch bool) (string, error) {
+	if len(apps) != 1 {
+		return "", errors.New("Error: Only one application can be pushed with --droplet")
 	}
-	y, err := ioutil.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return []app.Application{}, nil
-		} else {
-			return nil, err
+	if apps[0].IsDocker() {
+		return "", errors.New("Error: --droplet can't be used with a Docker image")
+	}
+	if watch {
+		return "", errors.New("Error: --droplet can't be used with --watch")
+	}
+	if _, err := os.Stat(droplet); err != nil {
+		return "", errors.New(fmt.Sprintf("Error: Droplet %s not found", droplet))
+	}
+	return filepath.Abs(droplet)
+}
+
+// checkTag checks that a build's tag given with --tag deploys a
+// single application built from source.
+func checkTag(apps []app.Application) error {
+	if len(apps) != 1 {
+		return errors.New("Error: Only one application can be pushed with --tag")
+	}
+	if apps[0].IsDocker() {
+		return errors.New("Error: --tag can't be used with a Docker image")
+	}
+	return nil
+}
+
+// checkGit checks that a Git repository given with --git builds a
+// single application from source.
+func checkGit(apps []app.Application) error {
+	if len(apps) != 1 {
+		return errors.New("Error: Only one application can be pushed with --git")
+	}
+	if apps[0].IsDocker() {
+		return errors.New("Error: --git can't be used with a Docker image")
+	}
+	return nil
+}
+
+func printChanges(appName string, changes []app.Change) {
+	if len(changes) == 0 {
+		log.Infof("No configuration changes to %s", appName)
+		return
+	}
+	log.Infof("Changes to %s:", appName)
+	for _, change := range changes {
+		log.Printf("  %s", change)
+	}
+}
+
+func (config *PushConfig) getManifestApps() ([]app.Application, error) {
+	if config.NoManifest {
+		if config.ManifestPath != "" {
+			return nil, errors.New("Error: The following arguments cannot be used together: --no-manifest, -f")
 		}
+		return []app.Application{}, nil
 	}
 
-	var m Manifest
-	err = yaml.Unmarshal(y, &m)
+	path, err := manifest.Find(config.ManifestPath)
 	if err != nil {
 		return nil, err
 	}
-	debugf("manifest: %+v\n", m)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return []app.Application{}, nil
+	}
 
-	return m.Applications, nil
+	return manifest.ReadApps(path, manifest.VarsFromEnv(config.VarsEnv))
 }
 
 func (config *PushConfig) getFlagsApp(args []string) (app.Application, error) {
@@ -178,85 +632,33 @@ func (config *PushConfig) getFlagsApp(args []string) (app.Application, error) {
 
 	if config.Memory != "" {
 		mem := strings.TrimSuffix(strings.ToUpper(config.Memory), "B")
-		matched, err := regexp.MatchString("^\\d+[EPTGMK]?$", mem)
-		if err != nil {
-			return app, err
-		}
-		if !matched {
+		if !manifest.ByteSizeRegexp.MatchString(mem) {
 			return app, errors.New("Memory string must be in the format of 8690K, 256M, 256MB, 1G, 1GB, etc")
 		}
 		app.Memory = mem
 	}
 
-	if config.Path != "" {
-		app.Path = config.Path
+	if config.GracePeriod < 0 {
+		return app, errors.New("Termination grace period must not be negative")
 	}
-
-	return app, nil
-}
-
-func mergeAppsFromManifestAndFlags(manifestApps []app.Application, flagsApp app.Application) ([]app.Application, error) {
-	var err error
-	var apps []app.Application
-
-	switch len(manifestApps) {
-	case 0:
-		if flagsApp.Name == "" {
-			return nil, errors.New("Manifest file is not found in the current directory, please provide either an app name or manifest")
-		}
-		err = addApp(&apps, flagsApp)
-	case 1:
-		mergo.MergeWithOverwrite(&manifestApps[0], flagsApp)
-		err = addApp(&apps, manifestApps[0])
-	default:
-		selectedAppName := flagsApp.Name
-
-		// TODO: Check for flags and multiple apps error condition
-
-		if selectedAppName != "" {
-			var foundApp bool
-			for _, currentApp := range manifestApps {
-				if currentApp.Name == selectedAppName {
-					foundApp = true
-					err = addApp(&apps, currentApp)
-				}
-			}
-			if !foundApp {
-				err = errors.New(fmt.Sprintf("Could not find app named %s in manifest", selectedAppName))
-			}
-		} else {
-			for _, manifestApp := range manifestApps {
-				err = addApp(&apps, manifestApp)
-			}
-		}
-	}
-
-	if err != nil {
-		return nil, err
+	if config.GracePeriod > 0 {
+		app.GracePeriod = config.GracePeriod
 	}
 
-	return apps, nil
-}
-
-func addApp(apps *[]app.Application, app app.Application) error {
-	if app.Name == "" {
-		return errors.New("App name is a required field")
+	if config.CPU != "" {
+		if !manifest.CPURegexp.MatchString(config.CPU) {
+			return app, errors.New("CPU must be a number of cores or millicores, such as 0.5, 2, or 500m")
+		}
+		app.CPU = config.CPU
 	}
 
-	if app.Path == "" {
-		cwd, err := os.Getwd()
+	if config.Path != "" {
+		path, err := filepath.Abs(config.Path)
 		if err != nil {
-			return err
+			return app, err
 		}
-		app.Path = cwd
+		app.Path = path
 	}
 
-	*apps = append(*apps, app)
-	return nil
-}
-
-func debugf(format string, v ...interface{}) {
-	if Debug {
-		fmt.Printf(format, v...)
-	}
+	return app, nil
 }
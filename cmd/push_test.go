@@ -1 +1,79 @@
 package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bbrowning/ocf/pkg/app"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetManifestAppsWithNoManifest(t *testing.T) {
+	withManifestDir(t, func(dir string) {
+		writeManifest(t, dir, "manifest.yml", "applications:\n- name: foo\n")
+
+		config := &PushConfig{ManifestPath: dir}
+		apps, err := config.getManifestApps()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(apps))
+
+		config.NoManifest = true
+		_, err = config.getManifestApps()
+		assert.NotNil(t, err)
+
+		config.ManifestPath = ""
+		apps, err = config.getManifestApps()
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(apps))
+	})
+}
+
+func TestCheckDroplet(t *testing.T) {
+	_, err := checkDroplet("push_test.go", []app.Application{{Name: "foo"}, {Name: "bar"}}, false)
+	assert.NotNil(t, err)
+
+	_, err = checkDroplet("push_test.go", []app.Application{{Name: "foo", Docker: &app.Docker{Image: "nginx"}}}, false)
+	assert.NotNil(t, err)
+
+	_, err = checkDroplet("missing.tgz", []app.Application{{Name: "foo"}}, false)
+	assert.NotNil(t, err)
+
+	droplet, err := checkDroplet("push_test.go", []app.Application{{Name: "foo"}}, false)
+	assert.Nil(t, err)
+	assert.True(t, filepath.IsAbs(droplet))
+}
+
+func TestCheckTag(t *testing.T) {
+	assert.NotNil(t, checkTag([]app.Application{{Name: "foo"}, {Name: "bar"}}))
+	assert.NotNil(t, checkTag([]app.Application{{Name: "foo", Docker: &app.Docker{Image: "nginx"}}}))
+	assert.Nil(t, checkTag([]app.Application{{Name: "foo"}}))
+}
+
+func TestCheckGit(t *testing.T) {
+	assert.NotNil(t, checkGit([]app.Application{{Name: "foo"}, {Name: "bar"}}))
+	assert.NotNil(t, checkGit([]app.Application{{Name: "foo", Docker: &app.Docker{Image: "nginx"}}}))
+	assert.Nil(t, checkGit([]app.Application{{Name: "foo"}}))
+}
+
+func withManifestDir(t *testing.T, handler func(string)) {
+	dir, err := ioutil.TempDir("", "ocf-manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	handler(dir)
+}
+
+func writeManifest(t *testing.T, dir string, name string, contents string) string {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	restartCmdLong = `
+Restart an application.
+
+This command emulates Cloud Foundry's 'cf restart' command but
+targeting OpenShift instead. The application is redeployed and the
+command waits for the new deployment to finish rolling out.`
+
+	restartCmdExample = `
+  # Restart the application 'my-app'
+  %[1]s restart my-app`
+)
+
+type RestartConfig struct {
+	Application string
+}
+
+func init() {
+	RootCmd.AddCommand(newRestartCmd("ocf"))
+}
+
+func newRestartCmd(commandName string) *cobra.Command {
+	config := &RestartConfig{}
+	cmd := &cobra.Command{
+		Use:     "restart",
+		Short:   "Restart an application.",
+		Long:    restartCmdLong,
+		Example: fmt.Sprintf(restartCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (config *RestartConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 1 {
+		return errors.New("Error: Application name is required")
+	}
+
+	app := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	return app.Restart()
+}
+
+// restartAfterEnvChange restarts the application if requested or, when
+// running interactively, if the user agrees. Otherwise it tells the
+// user how to make the change take effect, like 'cf' does.
+func restartAfterEnvChange(app *app.Application, restart bool) error {
+	if !restart && isInteractive() {
+		restart = confirm(fmt.Sprintf("Restart %s now so the change takes effect? [y/N] ", app.Name))
+	}
+	if !restart {
+		log.Printf("TIP: Use 'ocf restart %s' to ensure your env variable changes take effect", app.Name)
+		return nil
+	}
+	return app.Restart()
+}
+
+func isInteractive() bool {
+	if app.NonInteractive {
+		return false
+	}
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func confirm(prompt string) bool {
+	fmt.Print(prompt)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	restartAppInstanceCmdLong = `
+Restart a single instance of an application.
+
+This command emulates Cloud Foundry's 'cf restart-app-instance' command
+but targeting OpenShift instead. The pod running the instance is
+deleted and replaced with a new one, while the application's other
+instances keep running. Instances are numbered from 0 in the order
+'app' lists them.`
+
+	restartAppInstanceCmdExample = `
+  # Restart the first instance of 'my-app'
+  %[1]s restart-app-instance my-app 0`
+)
+
+type RestartAppInstanceConfig struct {
+}
+
+func init() {
+	RootCmd.AddCommand(newRestartAppInstanceCmd("ocf"))
+}
+
+func newRestartAppInstanceCmd(commandName string) *cobra.Command {
+	config := &RestartAppInstanceConfig{}
+	cmd := &cobra.Command{
+		Use:     "restart-app-instance APP_NAME INDEX",
+		Short:   "Restart a single instance of an application.",
+		Long:    restartAppInstanceCmdLong,
+		Example: fmt.Sprintf(restartAppInstanceCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (config *RestartAppInstanceConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 2 {
+		return errors.New("Error: Application name and instance index are required")
+	}
+	index, err := strconv.Atoi(args[1])
+	if err != nil || index < 0 {
+		return errors.New(fmt.Sprintf("Error: Invalid instance index %s, must be 0 or more", args[1]))
+	}
+
+	app := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	return app.RestartInstance(index)
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	revisionsCmdLong = `
+List the revisions of an application.
+
+This command emulates Cloud Foundry's 'cf revisions' command but
+targeting OpenShift instead. Every push records a revision with when
+it happened, who pushed, the Git commit of the application's source
+if it's in a Git repository, and the image that was rolled out. The
+revision that's currently deployed is marked.
+
+Revision numbers are the ones 'rollback --to' takes.`
+
+	revisionsCmdExample = `
+  # List the revisions of my-app
+  %[1]s revisions my-app
+
+  # Roll my-app back to revision 3
+  %[1]s rollback my-app --to 3`
+)
+
+type RevisionsConfig struct {
+}
+
+func init() {
+	RootCmd.AddCommand(newRevisionsCmd("ocf"))
+}
+
+func newRevisionsCmd(commandName string) *cobra.Command {
+	config := &RevisionsConfig{}
+	cmd := &cobra.Command{
+		Use:     "revisions APP_NAME",
+		Short:   "List the revisions of an application.",
+		Long:    revisionsCmdLong,
+		Example: fmt.Sprintf(revisionsCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (config *RevisionsConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 1 {
+		return errors.New("Error: Application name is required")
+	}
+
+	app := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	revisions, err := app.Revisions()
+	if err != nil {
+		return err
+	}
+	if len(revisions) == 0 {
+		log.Infof("No revisions recorded for %s yet, they're recorded by each push", app.Name)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "revision\ttime\tuser\tgit sha\ttag\timage")
+	// Newest first, like 'cf revisions'
+	for i := len(revisions) - 1; i >= 0; i-- {
+		revision := revisions[i]
+		number := fmt.Sprint(revision.Number)
+		if revision.Deployed {
+			number = fmt.Sprint(number, "(deployed)")
+		}
+		sha := revision.GitSHA
+		if len(sha) > 7 {
+			sha = sha[:7]
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", number, revision.Time, revision.User, sha, revision.Tag, revision.Image)
+	}
+	w.Flush()
+	return nil
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	rollbackCmdLong = `
+Roll an application back to a previous revision.
+
+Without --to, the application is rolled back to its last successful
+revision. The command waits for the rollback to finish rolling out so
+a bad push can be recovered from quickly. Revision numbers are the ones
+listed by 'revisions'.`
+
+	rollbackCmdExample = `
+  # Roll 'my-app' back to its last successful revision
+  %[1]s rollback my-app
+
+  # List the revisions of 'my-app'
+  %[1]s rollback my-app --list
+
+  # Roll 'my-app' back to revision 3
+  %[1]s rollback my-app --to 3`
+)
+
+type RollbackConfig struct {
+	To   int
+	List bool
+}
+
+func init() {
+	RootCmd.AddCommand(newRollbackCmd("ocf"))
+}
+
+func newRollbackCmd(commandName string) *cobra.Command {
+	config := &RollbackConfig{}
+	cmd := &cobra.Command{
+		Use:     "rollback APP_NAME",
+		Short:   "Roll an application back to a previous revision.",
+		Long:    rollbackCmdLong,
+		Example: fmt.Sprintf(rollbackCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().IntVarP(&config.To, "to", "", 0, "Revision to roll back to, defaulting to the last successful one")
+	cmd.Flags().BoolVarP(&config.List, "list", "", false, "List the application's revisions without rolling back")
+
+	return cmd
+}
+
+func (config *RollbackConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 1 {
+		return errors.New("Error: Application name is required")
+	}
+	if config.To < 0 {
+		return errors.New("Error: --to must be a positive revision number")
+	}
+
+	app := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	history, err := app.RolloutHistory()
+	if err != nil {
+		return err
+	}
+	fmt.Print(history)
+	if config.List {
+		return nil
+	}
+	return app.Rollback(config.To)
+}
@@ -1,8 +1,16 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
 
 	"github.com/spf13/cobra"
 )
@@ -18,22 +26,97 @@ run Cloud Foundry applications on OpenShift.`,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	//	Run: func(cmd *cobra.Command, args []string) { },
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		err := app.SelectPlatform(Platform)
+		if err != nil {
+			return err
+		}
+		err = setupLogging()
+		if err == nil {
+			offerToInstallOc(cmd)
+		}
+		return err
+	},
 }
 
-var Debug bool
+var (
+	Debug     bool
+	Quiet     bool
+	LogLevel  string
+	LogFormat string
+	Platform  string
+)
+
+// commandContext is cancelled when the user interrupts ocf, stopping
+// the commands it's running.
+var commandContext = context.Background()
+
+// platformEnv is the environment variable that overrides the default
+// --platform
+const platformEnv = "OCF_PLATFORM"
 
 // Execute adds all child commands to the root command sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	commandContext = ctx
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		// A second interrupt kills us outright
+		signal.Stop(signals)
+		log.Infof("Interrupted, stopping")
+		cancel()
+	}()
+
+	addPluginCommands(RootCmd, findPlugins(pluginDirs()))
 	if err := RootCmd.Execute(); err != nil {
 		fmt.Println(err)
-		os.Exit(-1)
+		exit(-1)
 	}
+	app.RemoveLoginConfig()
+}
+
+// exit exits with code, first removing the temporary kubeconfig a
+// token was logged in with since os.Exit skips deferred calls.
+func exit(code int) {
+	app.RemoveLoginConfig()
+	os.Exit(code)
 }
 
 func init() {
 	// Here you will define your flags and configuration settings.
 	// Cobra supports Persistent Flags, which, if defined here,
 	// will be global for your application.
-	RootCmd.PersistentFlags().BoolVarP(&Debug, "debug", "", false, "Enable debug logging")
+	RootCmd.PersistentFlags().BoolVarP(&Debug, "debug", "", false, "Enable debug logging, the same as --log-level=debug")
+	RootCmd.PersistentFlags().BoolVarP(&Quiet, "quiet", "q", false, "Only log warnings and errors, the same as --log-level=warn")
+	RootCmd.PersistentFlags().StringVarP(&LogLevel, "log-level", "", log.InfoLevel.String(), "Least severe messages to log: debug, info, warn, or error")
+	RootCmd.PersistentFlags().StringVarP(&LogFormat, "log-format", "", log.FormatText, fmt.Sprintf("Format of log messages, %s or %s", log.FormatText, log.FormatJSON))
+	RootCmd.PersistentFlags().BoolVarP(&app.NonInteractive, "non-interactive", "", false, fmt.Sprintf("Fail instead of prompting, such as to log in. A token to log in with can be given in the %s environment variable, or read from stdin if it's '-'", app.LoginTokenEnv))
+	RootCmd.PersistentFlags().DurationVarP(&exec.RequestTimeout, "request-timeout", "", exec.DefaultRequestTimeout, "How long quick cluster commands, like getting or applying objects, can run before they're killed. 0 means no timeout")
+	RootCmd.PersistentFlags().DurationVarP(&exec.BuildTimeout, "build-timeout", "", exec.DefaultBuildTimeout, "How long builds, rollouts, image pushes, and file syncs can run before they're killed. 0 means no timeout")
+	RootCmd.PersistentFlags().StringVarP(&Platform, "platform", "", defaultPlatform(), fmt.Sprintf("Type of cluster to target, 'openshift' using oc or 'k8s' using kubectl. The default can be changed with the %s environment variable", platformEnv))
+}
+
+func defaultPlatform() string {
+	if platform := os.Getenv(platformEnv); platform != "" {
+		return platform
+	}
+	return oc.PlatformOpenShift
+}
+
+func setupLogging() error {
+	level, err := log.ParseLevel(LogLevel)
+	if err != nil {
+		return err
+	}
+	if Debug {
+		level = log.DebugLevel
+	} else if Quiet {
+		level = log.WarnLevel
+	}
+	log.SetLevel(level)
+	return log.SetFormat(LogFormat)
 }
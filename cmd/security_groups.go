@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	securityGroupsCmdLong = `
+List the security groups in the project.
+
+This command emulates Cloud Foundry's 'cf security-groups' command but
+targeting OpenShift instead. Security groups are network policies
+created by 'create-security-group', listed with their rules and the
+applications bound to them.`
+
+	securityGroupsCmdExample = `
+  # List the security groups
+  %[1]s security-groups`
+
+	createSecurityGroupCmdLong = `
+Create a security group from a file of rules.
+
+This command emulates Cloud Foundry's 'cf create-security-group'
+command but targeting OpenShift instead. The rules file is a JSON list
+in the same format Cloud Foundry takes, each rule allowing egress to a
+destination IP address or CIDR block:
+
+  [{"protocol": "tcp", "destination": "10.0.11.0/24", "ports": "80,443"}]
+
+The protocol is tcp, udp, or all. Network policies can't allow icmp,
+or destinations given as address ranges. The group is a network policy
+that applies to no applications until they're bound to it.`
+
+	createSecurityGroupCmdExample = `
+  # Create the security group 'databases' from rules.json
+  %[1]s create-security-group databases rules.json`
+
+	bindSecurityGroupCmdLong = `
+Bind a security group to an application.
+
+This command is like Cloud Foundry's 'cf bind-security-group' command
+but targeting OpenShift instead, and binds the group to one
+application rather than a space. Once an application is bound to any
+security group, its instances can only reach what its groups allow,
+so bind a group allowing DNS too if the application needs it.`
+
+	bindSecurityGroupCmdExample = `
+  # Allow 'my-app' what the 'databases' security group does
+  %[1]s bind-security-group databases my-app`
+
+	unbindSecurityGroupCmdLong = `
+Unbind a security group from an application.
+
+This command is like Cloud Foundry's 'cf unbind-security-group' command
+but targeting OpenShift instead. Once an application is unbound from
+every security group, its instances can reach anything again.`
+
+	unbindSecurityGroupCmdExample = `
+  # Stop allowing 'my-app' what the 'databases' security group does
+  %[1]s unbind-security-group databases my-app`
+)
+
+type SecurityGroupsConfig struct {
+}
+
+type CreateSecurityGroupConfig struct {
+	Name  string
+	Rules string
+}
+
+type BindSecurityGroupConfig struct {
+	Group       string
+	Application string
+}
+
+type UnbindSecurityGroupConfig struct {
+	Group       string
+	Application string
+}
+
+func init() {
+	RootCmd.AddCommand(newSecurityGroupsCmd("ocf"))
+	RootCmd.AddCommand(newCreateSecurityGroupCmd("ocf"))
+	RootCmd.AddCommand(newBindSecurityGroupCmd("ocf"))
+	RootCmd.AddCommand(newUnbindSecurityGroupCmd("ocf"))
+}
+
+func newSecurityGroupsCmd(commandName string) *cobra.Command {
+	config := &SecurityGroupsConfig{}
+	cmd := &cobra.Command{
+		Use:     "security-groups",
+		Short:   "List the security groups in the project.",
+		Long:    securityGroupsCmdLong,
+		Example: fmt.Sprintf(securityGroupsCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (config *SecurityGroupsConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	groups, err := app.SecurityGroups(commandContext)
+	if err != nil {
+		return err
+	}
+	if len(groups) == 0 {
+		fmt.Println("No security groups found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "name\trules\tapps")
+	for _, group := range groups {
+		var rules []string
+		for _, rule := range group.Rules {
+			if rule.Ports == "" {
+				rules = append(rules, fmt.Sprint(rule.Protocol, " ", rule.Destination))
+			} else {
+				rules = append(rules, fmt.Sprint(rule.Protocol, " ", rule.Destination, ":", rule.Ports))
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", group.Name, strings.Join(rules, ", "), strings.Join(group.Apps, ", "))
+	}
+	w.Flush()
+	return nil
+}
+
+func newCreateSecurityGroupCmd(commandName string) *cobra.Command {
+	config := &CreateSecurityGroupConfig{}
+	cmd := &cobra.Command{
+		Use:     "create-security-group SECURITY_GROUP PATH_TO_JSON_RULES_FILE",
+		Short:   "Create a security group from a file of rules.",
+		Long:    createSecurityGroupCmdLong,
+		Example: fmt.Sprintf(createSecurityGroupCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (config *CreateSecurityGroupConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 2 {
+		return errors.New("Error: Security group name and rules file are required")
+	}
+	config.Name = args[0]
+	config.Rules = args[1]
+
+	rules, err := app.LoadSecurityGroupRules(config.Rules)
+	if err != nil {
+		return err
+	}
+	err = app.CreateSecurityGroup(commandContext, config.Name, rules)
+	if err != nil {
+		return err
+	}
+	log.Infof("Created security group %s", config.Name)
+	return nil
+}
+
+func newBindSecurityGroupCmd(commandName string) *cobra.Command {
+	config := &BindSecurityGroupConfig{}
+	cmd := &cobra.Command{
+		Use:     "bind-security-group SECURITY_GROUP APP_NAME",
+		Short:   "Bind a security group to an application.",
+		Long:    bindSecurityGroupCmdLong,
+		Example: fmt.Sprintf(bindSecurityGroupCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (config *BindSecurityGroupConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 2 {
+		return errors.New("Error: Security group name and application name are required")
+	}
+	config.Group = args[0]
+	config.Application = args[1]
+
+	app := (&app.Application{Name: config.Application}).WithContext(commandContext)
+	err := app.BindSecurityGroup(config.Group)
+	if err != nil {
+		return err
+	}
+	log.Infof("Bound security group %s to %s", config.Group, config.Application)
+	return nil
+}
+
+func newUnbindSecurityGroupCmd(commandName string) *cobra.Command {
+	config := &UnbindSecurityGroupConfig{}
+	cmd := &cobra.Command{
+		Use:     "unbind-security-group SECURITY_GROUP APP_NAME",
+		Short:   "Unbind a security group from an application.",
+		Long:    unbindSecurityGroupCmdLong,
+		Example: fmt.Sprintf(unbindSecurityGroupCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (config *UnbindSecurityGroupConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 2 {
+		return errors.New("Error: Security group name and application name are required")
+	}
+	config.Group = args[0]
+	config.Application = args[1]
+
+	app := (&app.Application{Name: config.Application}).WithContext(commandContext)
+	err := app.UnbindSecurityGroup(config.Group)
+	if err != nil {
+		return err
+	}
+	log.Infof("Unbound security group %s from %s", config.Group, config.Application)
+	return nil
+}
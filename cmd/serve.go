@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	serveCmdLong = `
+Serve ocf's operations as an HTTP and JSON API.
+
+Tools written against the Cloud Foundry API can be pointed at this
+server while they're migrated. Every request must give the token from
+--token or the OCF_SERVE_TOKEN environment variable as a bearer token.
+Operations run one at a time, as the user ocf is logged in as.
+
+  GET    /v1/apps                  list applications
+  POST   /v1/apps/NAME/push        push the application in the "app"
+                                   field of a multipart form, in manifest
+                                   form, with a zip of its source in the
+                                   "source" field, streaming its progress
+                                   as server-sent events
+  POST   /v1/apps/NAME/bindings    bind {"service": ..., "parameters": ...}
+  PUT    /v1/apps/NAME/instances   scale to {"instances": ...}
+  DELETE /v1/apps/NAME             delete the application
+
+Pushed applications can't have hooks or paths, which would run
+commands and read files on the machine running the server.`
+
+	serveCmdExample = `
+  # Serve the API on port 8443 with TLS
+  OCF_SERVE_TOKEN=$(openssl rand -hex 32) %[1]s serve --listen :8443 --tls-cert tls.crt --tls-key tls.key`
+)
+
+// serveTokenEnv holds the API's token when --token isn't given.
+const serveTokenEnv = "OCF_SERVE_TOKEN"
+
+type ServeConfig struct {
+	Listen  string
+	Token   string
+	TLSCert string
+	TLSKey  string
+	Image   string
+}
+
+func init() {
+	RootCmd.AddCommand(newServeCmd("ocf"))
+}
+
+func newServeCmd(commandName string) *cobra.Command {
+	config := &ServeConfig{}
+	cmd := &cobra.Command{
+		Use:     "serve",
+		Short:   "Serve ocf's operations as an HTTP and JSON API.",
+		Long:    serveCmdLong,
+		Example: fmt.Sprintf(serveCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+				exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&config.Listen, "listen", "", "localhost:8080", "Address to listen on")
+	cmd.Flags().StringVarP(&config.Token, "token", "", "", fmt.Sprintf("Bearer token requests must give. Defaults to the %s environment variable", serveTokenEnv))
+	cmd.Flags().StringVarP(&config.TLSCert, "tls-cert", "", "", "Certificate to serve TLS with")
+	cmd.Flags().StringVarP(&config.TLSKey, "tls-key", "", "", "Key of the TLS certificate")
+	cmd.Flags().StringVarP(&config.Image, "docker-image", "i", "", "Base Docker image to build pushed applications with")
+
+	return cmd
+}
+
+func (config *ServeConfig) Run(args []string) error {
+	// Keep the token out of debug output
+	redacted := *config
+	if redacted.Token != "" {
+		redacted.Token = "REDACTED"
+	}
+	log.Debugf("Config: %+v", redacted)
+
+	token := config.Token
+	if token == "" {
+		token = os.Getenv(serveTokenEnv)
+	}
+	if token == "" {
+		return errors.New(fmt.Sprintf("Error: A token is required, give one with --token or %s", serveTokenEnv))
+	}
+	if (config.TLSCert == "") != (config.TLSKey == "") {
+		return errors.New("Error: --tls-cert and --tls-key must be given together")
+	}
+	// There's no one to answer prompts
+	app.NonInteractive = true
+
+	server := &app.Server{
+		Token: token,
+		Options: app.PushOptions{
+			Image:            config.Image,
+			CleanupOnFailure: app.CleanupAlways,
+		},
+	}
+	httpServer := &http.Server{Addr: config.Listen, Handler: server.Handler()}
+	go func() {
+		<-commandContext.Done()
+		httpServer.Shutdown(context.Background())
+	}()
+
+	log.Infof("Serving the ocf API on %s", config.Listen)
+	var err error
+	if config.TLSCert != "" {
+		err = httpServer.ListenAndServeTLS(config.TLSCert, config.TLSKey)
+	} else {
+		err = httpServer.ListenAndServe()
+	}
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
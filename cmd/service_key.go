@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	createServiceKeyCmdLong = `
+Create a key for a service.
+
+This command emulates Cloud Foundry's 'cf create-service-key' command
+but targeting OpenShift instead. The key's credentials are stored in a
+secret named SERVICE-key-KEY so they can be used without binding the
+service to an application. PostgreSQL services, and MySQL services
+whose root password is set, get a new database user for each key.
+Other services get a copy of their existing credentials.`
+
+	createServiceKeyCmdExample = `
+  # Create the key 'ci' for the 'rails-postgres' service
+  %[1]s create-service-key rails-postgres ci`
+
+	serviceKeysCmdLong = `
+List the keys of a service.
+
+This command emulates Cloud Foundry's 'cf service-keys' command but
+targeting OpenShift instead.`
+
+	serviceKeysCmdExample = `
+  # List the keys of the 'rails-postgres' service
+  %[1]s service-keys rails-postgres`
+)
+
+type CreateServiceKeyConfig struct {
+	Service string
+	Key     string
+}
+
+type ServiceKeysConfig struct {
+	Service string
+}
+
+func init() {
+	RootCmd.AddCommand(newCreateServiceKeyCmd("ocf"))
+	RootCmd.AddCommand(newServiceKeysCmd("ocf"))
+}
+
+func newCreateServiceKeyCmd(commandName string) *cobra.Command {
+	config := &CreateServiceKeyConfig{}
+	cmd := &cobra.Command{
+		Use:     "create-service-key",
+		Short:   "Create a key for a service.",
+		Long:    createServiceKeyCmdLong,
+		Example: fmt.Sprintf(createServiceKeyCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (config *CreateServiceKeyConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 2 {
+		return errors.New("Error: Service name and key name are required")
+	}
+	config.Service = args[0]
+	config.Key = args[1]
+
+	secretName, err := app.CreateServiceKey(commandContext, config.Service, config.Key)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Created service key %s, credentials are in secret %s", config.Key, secretName)
+	return nil
+}
+
+func newServiceKeysCmd(commandName string) *cobra.Command {
+	config := &ServiceKeysConfig{}
+	cmd := &cobra.Command{
+		Use:     "service-keys",
+		Short:   "List the keys of a service.",
+		Long:    serviceKeysCmdLong,
+		Example: fmt.Sprintf(serviceKeysCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (config *ServiceKeysConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 1 {
+		return errors.New("Error: Service name is required")
+	}
+	config.Service = args[0]
+
+	keys, err := app.ServiceKeys(commandContext, config.Service)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		fmt.Printf("No service key for service instance %s\n", config.Service)
+		return nil
+	}
+	fmt.Println("name")
+	for _, key := range keys {
+		fmt.Println(key)
+	}
+	return nil
+}
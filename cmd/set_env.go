@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	setEnvCmdLong = `
+Set an environment variable for an application.
+
+This command emulates Cloud Foundry's 'cf set-env' command but
+targeting OpenShift instead. Variables only needed while building the
+application, such as credentials for a private package repository,
+can be set with --build so they never reach the running application.
+
+A variable's value can be read from a file with --from-file, and every
+variable of a dotenv file can be set at once with --env-file. The
+changes are shown first, and when running interactively you're asked
+to confirm them.`
+
+	setEnvCmdExample = `
+  # Set LOG_LEVEL for the running application 'my-app'
+  %[1]s set-env my-app LOG_LEVEL debug
+
+  # Set NPM_TOKEN only while building the application 'my-app'
+  %[1]s set-env my-app NPM_TOKEN s3cr3t --build
+
+  # Set VCAP_CONFIG to the contents of config.json
+  %[1]s set-env my-app VCAP_CONFIG --from-file config.json
+
+  # Set every variable in .env.production
+  %[1]s set-env my-app --env-file .env.production`
+)
+
+type SetEnvConfig struct {
+	Build    bool
+	Restart  bool
+	FromFile string
+	EnvFile  string
+}
+
+func init() {
+	RootCmd.AddCommand(newSetEnvCmd("ocf"))
+}
+
+func newSetEnvCmd(commandName string) *cobra.Command {
+	config := &SetEnvConfig{}
+	cmd := &cobra.Command{
+		Use:     "set-env",
+		Short:   "Set an environment variable for an application.",
+		Long:    setEnvCmdLong,
+		Example: fmt.Sprintf(setEnvCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&config.Build, "build", "", false, "Set the variable on the application's build instead of its deployment")
+	cmd.Flags().BoolVarP(&config.Restart, "restart", "", false, "Restart the application afterwards so the change takes effect")
+	cmd.Flags().StringVarP(&config.FromFile, "from-file", "", "", "Read the variable's value from this file, without its final newline")
+	cmd.Flags().StringVarP(&config.EnvFile, "env-file", "", "", "Set every variable in this dotenv file of NAME=VALUE lines")
+
+	return cmd
+}
+
+func (config *SetEnvConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if config.FromFile != "" && config.EnvFile != "" {
+		return errors.New("Error: The following arguments cannot be used together: --from-file, --env-file")
+	}
+
+	var application *app.Application
+	var err error
+	switch {
+	case config.EnvFile != "":
+		application, err = config.setEnvFile(args)
+	case config.FromFile != "":
+		if len(args) != 2 {
+			return errors.New("Error: Application name and variable name are required")
+		}
+		var contents []byte
+		contents, err = ioutil.ReadFile(config.FromFile)
+		if err != nil {
+			return err
+		}
+		value := strings.TrimSuffix(strings.TrimSuffix(string(contents), "\n"), "\r")
+		application = (&app.Application{Name: args[0]}).WithContext(commandContext)
+		err = application.SetEnv(args[1], value, config.Build)
+	default:
+		if len(args) != 3 {
+			return errors.New("Error: Application name, variable name, and value are required")
+		}
+		application = (&app.Application{Name: args[0]}).WithContext(commandContext)
+		err = application.SetEnv(args[1], args[2], config.Build)
+	}
+	if err != nil || application == nil {
+		return err
+	}
+
+	if config.Build {
+		// Build variables only take effect on the next push
+		return nil
+	}
+	return restartAfterEnvChange(application, config.Restart)
+}
+
+// setEnvFile sets the variables of the dotenv file, returning nil for
+// the application if nothing changed.
+func (config *SetEnvConfig) setEnvFile(args []string) (*app.Application, error) {
+	if len(args) != 1 {
+		return nil, errors.New("Error: Only the application name is given with --env-file")
+	}
+	contents, err := ioutil.ReadFile(config.EnvFile)
+	if err != nil {
+		return nil, err
+	}
+	env, err := app.ParseEnvFile(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	application := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	changes, err := application.SetEnvs(env, config.Build, func(changes []app.Change) bool {
+		printChanges(application.Name, changes)
+		return !isInteractive() || confirm("Apply these changes? [y/N] ")
+	})
+	if err != nil || len(changes) == 0 {
+		return nil, err
+	}
+	return application, nil
+}
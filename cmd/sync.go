@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	syncCmdLong = `
+Copy local changes into an application's running pods.
+
+The application directory is copied with 'oc rsync' into the source
+directory of each running pod, avoiding a full build for small changes
+to Ruby, Node.js, Python, and PHP applications. Synced files are lost
+when a pod restarts, so push again once you're done.
+
+Most application servers don't notice changed files on their own. Use
+--restart-command to run a command in each pod after syncing, such as
+one that signals the server to reload.`
+
+	syncCmdExample = `
+  # Copy the current directory into my-app's pods
+  %[1]s sync my-app
+
+  # Copy ./web into my-app's pods and have Puma restart its workers
+  %[1]s sync my-app -p ./web --restart-command 'kill -USR2 1'`
+)
+
+type SyncConfig struct {
+	Path           string
+	RestartCommand string
+}
+
+func init() {
+	RootCmd.AddCommand(newSyncCmd("ocf"))
+}
+
+func newSyncCmd(commandName string) *cobra.Command {
+	config := &SyncConfig{}
+	cmd := &cobra.Command{
+		Use:     "sync",
+		Short:   "Copy local changes into an application's running pods.",
+		Long:    syncCmdLong,
+		Example: fmt.Sprintf(syncCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&config.Path, "path", "p", "", "Path to app directory, defaulting to the current directory")
+	cmd.Flags().StringVarP(&config.RestartCommand, "restart-command", "", "", "Shell command run in each pod after syncing to restart the application")
+
+	return cmd
+}
+
+func (config *SyncConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	if len(args) != 1 {
+		return errors.New("Error: Application name is required")
+	}
+
+	path := config.Path
+	if path == "" {
+		path = "."
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return errors.New(fmt.Sprintf("Error: %s is not a directory", path))
+	}
+
+	app := (&app.Application{Name: args[0], Path: path}).WithContext(commandContext)
+	return app.Sync(config.RestartCommand)
+}
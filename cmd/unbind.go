@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
 
 	"github.com/spf13/cobra"
 )
@@ -26,6 +27,7 @@ information below.`
 type UnbindConfig struct {
 	Application string
 	Service     string
+	Restart     bool
 }
 
 func init() {
@@ -42,26 +44,34 @@ func newUnbindCmd(commandName string) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			err := config.Run(args)
 			if err != nil {
-				fmt.Printf("err: %v\n", err)
+				log.Errorf("err: %v", err)
 			}
 		},
 	}
 
+	cmd.Flags().BoolVarP(&config.Restart, "restart", "", false, "Restart the application afterwards so the change takes effect")
+	cmd.Flags().BoolVarP(&config.Restart, "restage", "", false, "Alias for --restart; bindings only change the runtime environment so no rebuild is needed")
+
 	return cmd
 }
 
 func (config *UnbindConfig) Run(args []string) error {
-	debugf("Config: %+v\n", config)
+	log.Debugf("Config: %+v", config)
 
 	if len(args) != 2 {
 		return errors.New("Error: Application name and service name are required")
 	}
 
-	app := &app.Application{Name: args[0]}
-	err := app.UnbindService(args[1])
+	app := (&app.Application{Name: args[0]}).WithContext(commandContext)
+	// The build stops referencing the binding before its secret goes
+	err := app.UnbindServiceFromBuild(args[1])
+	if err != nil {
+		return err
+	}
+	err = app.UnbindService(args[1])
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return restartAfterEnvChange(app, config.Restart)
 }
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/manifest"
+)
+
+const (
+	validateManifestCmdLong = `
+Validate an application manifest.
+
+Checks the manifest, and any manifests it inherits from, for invalid
+value types, malformed memory and disk sizes, duplicate application
+names, and keys that will be ignored. The same validation runs
+automatically before every push.
+
+Legacy keys such as buildpack, host, and domain are reported as
+deprecated. Manifests with 'version: 1' follow Cloud Foundry's
+versioned schema, which also deprecates inherit and top-level
+properties.`
+
+	validateManifestCmdExample = `
+  # Validate the manifest.yml in the current directory
+  %[1]s validate-manifest
+
+  # Validate a specific manifest
+  %[1]s validate-manifest -f path/to/manifest.yml`
+)
+
+type ValidateManifestConfig struct {
+	ManifestPath string
+	VarsEnv      string
+}
+
+func init() {
+	RootCmd.AddCommand(newValidateManifestCmd("ocf"))
+}
+
+func newValidateManifestCmd(commandName string) *cobra.Command {
+	config := &ValidateManifestConfig{}
+	cmd := &cobra.Command{
+		Use:     "validate-manifest",
+		Short:   "Validate an application manifest.",
+		Long:    validateManifestCmdLong,
+		Example: fmt.Sprintf(validateManifestCmdExample, commandName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.Run(args)
+			if err != nil {
+				log.Errorf("err: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&config.ManifestPath, "manifest-path", "f", "", "Path to manifest")
+	addVarsEnvFlag(cmd, &config.VarsEnv)
+
+	return cmd
+}
+
+func (config *ValidateManifestConfig) Run(args []string) error {
+	log.Debugf("Config: %+v", config)
+
+	path, err := manifest.Find(config.ManifestPath)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return errors.New(fmt.Sprintf("Error: Manifest %s not found", path))
+	}
+
+	problems, err := manifest.Validate(path, manifest.VarsFromEnv(config.VarsEnv))
+	if err != nil {
+		return err
+	}
+	err = manifest.ReportProblems(problems)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Manifest %s is valid", path)
+	return nil
+}
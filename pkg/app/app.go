@@ -1,47 +1,238 @@
 package app
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/log"
 	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
 )
 
 type Application struct {
-	Name      string   `json:"name"`
-	Buildpack string   `json:"buildpack"`
-	Command   string   `json:"command"`
-	DiskQuota string   `json:"disk_quota"`
-	Instances int      `json:"instances"`
-	Memory    string   `json:"memory"`
-	Path      string   `json:"path"`
-	Services  []string `json:"services"`
-	oc        oc.Oc
+	Name         string            `json:"name"`
+	Buildpack    string            `json:"buildpack"`
+	Stack        string            `json:"stack"`
+	Command      string            `json:"command"`
+	DiskQuota    string            `json:"disk_quota"`
+	Instances    int               `json:"instances"`
+	Memory       string            `json:"memory"`
+	CPU          string            `json:"cpu,omitempty"`
+	Timeout      int               `json:"timeout,omitempty"`
+	GracePeriod  int               `json:"termination-grace-period,omitempty"`
+	Path         string            `json:"path"`
+	Services     []string          `json:"services"`
+	Docker       *Docker           `json:"docker,omitempty"`
+	BuildEnv     map[string]string `json:"build-env,omitempty"`
+	Hooks        *Hooks            `json:"hooks,omitempty"`
+	Routes       []Route           `json:"routes,omitempty"`
+	Processes    []Process         `json:"processes,omitempty"`
+	Sidecars     []Sidecar         `json:"sidecars,omitempty"`
+	Project      string            `json:"project,omitempty"`
+	NodeSelector map[string]string `json:"node-selector,omitempty"`
+	Tolerations  []Toleration      `json:"tolerations,omitempty"`
+	oc           oc.Oc
+	execer       exec.Execer
+	options      PushOptions
+	kind         string
+	created      []string
+	// ctx cancels the application's commands, set by WithContext
+	ctx context.Context
+	// processType is set on copies made by processApp
+	processType string
+	// tag is the image stream tag the push built or deployed
+	tag string
+	// updated is set when the push updates an existing deployment,
+	// which is then redeployed
+	updated bool
 }
 
+// PushOptions contains the settings for a push that come from the
+// command line rather than from the application itself.
+type PushOptions struct {
+	// Image is the base Docker image used to build and run the app.
+	// If empty, one is picked from Builders
+	Image string
+	// Builders maps applications to builder images when Image isn't
+	// set, falling back to DefaultImage
+	Builders *Builders
+	// CommandMode controls how a custom start command is applied, as
+	// either CommandModeCF or CommandModeNative
+	CommandMode string
+	// ImageTimeout is how long to wait for a build's image to appear
+	// in its image stream, defaulting to DefaultImageTimeout
+	ImageTimeout time.Duration
+	// Workload is the type of object created to run new applications,
+	// either WorkloadDeploymentConfig or WorkloadDeployment
+	Workload string
+	// Registry is where images built locally for Kubernetes are
+	// pushed, such as "quay.io/myorg"
+	Registry string
+	// Domain is the domain used for the host of a Kubernetes
+	// application's ingress
+	Domain string
+	// Serve is an alternative way to run applications instead of
+	// deployments, currently only ServeKnative
+	Serve string
+	// GitOpsDir is a directory the application's manifests are
+	// written to, in a kustomize layout, and applied from
+	GitOpsDir string
+	// GitOpsOnly only writes manifests to GitOpsDir without applying
+	// them
+	GitOpsOnly bool
+	// AsyncBuild polls the build's status instead of streaming its
+	// logs
+	AsyncBuild bool
+	// LockWait is how long to wait for another push of the same
+	// application to finish, failing immediately if 0
+	LockWait time.Duration
+	// CleanupOnCancel deletes the objects created by a push that was
+	// cancelled part way through
+	CleanupOnCancel bool
+	// CleanupOnFailure is whether the objects created by an
+	// application's failed first push are deleted, one of
+	// CleanupPrompt, CleanupAlways, or CleanupNever
+	CleanupOnFailure string
+	// ConfirmCleanup asks the user the prompt, returning whether they
+	// agreed. CleanupPrompt keeps the objects when it's nil
+	ConfirmCleanup func(prompt string) bool `json:"-"`
+	// Compression is how the archive of the application directory
+	// uploaded to the build is compressed, one of the Compression
+	// constants
+	Compression string
+	// RouteType is how the application is exposed, either
+	// RouteTypeHTTP or RouteTypeTCP
+	RouteType string
+	// RoutePort is the external port of a TCP route, defaulting to
+	// the port the application listens on
+	RoutePort int
+	// Prune removes or recreates what earlier pushes left in the
+	// cluster that no longer matches the application
+	Prune bool
+	// Droplet is a Cloud Foundry droplet to run instead of building
+	// the application from source
+	Droplet string
+	// AuditFile is a JSON file the commands run and objects created
+	// by the push are appended to
+	AuditFile string
+	// IgnoreQuota pushes even when the project's quotas or limit
+	// ranges won't allow the application's memory or instances,
+	// warning instead of failing
+	IgnoreQuota bool
+	// SkipSteps names steps of the push pipeline not to run
+	SkipSteps []string
+	// DryRun lists the steps of the push pipeline without running
+	// them
+	DryRun bool
+	// SmokeTest is a path on the application's route, or a full URL,
+	// requested after the push until it responds with
+	// SmokeTestStatus
+	SmokeTest string
+	// SmokeTestStatus is the status the smoke test waits for,
+	// defaulting to 200
+	SmokeTestStatus int
+	// SmokeTestTimeout is how long the smoke test waits, defaulting
+	// to DefaultSmokeTestTimeout
+	SmokeTestTimeout time.Duration
+	// Strategy is how the new version replaces the running one,
+	// either StrategyRolling or StrategyCanary
+	Strategy string
+	// CanaryWeight is the percentage of traffic sent to a canary
+	CanaryWeight int
+	// Tag is an image stream tag of an earlier build to deploy
+	// instead of building the application
+	Tag string
+	// Spread is how instances are spread across the cluster, one of
+	// SpreadZone, SpreadNode, or SpreadOff, defaulting to SpreadZone
+	Spread string
+	// Internal only exposes the application through its service
+	// inside the cluster, without a route or ingress
+	Internal bool
+	// NoBuildMetadata skips recording the Git commit, branch, and
+	// version, build time, and pusher on the application
+	NoBuildMetadata bool
+	// Git is a Git repository URL, with an optional #branch, tag, or
+	// commit, the cluster builds the application from instead of the
+	// application directory
+	Git string
+	// Events, if set, gets the events of the push as it runs
+	Events *EventStream `json:"-"`
+}
+
+const (
+	// WorkloadDeploymentConfig runs applications with an OpenShift
+	// DeploymentConfig created by 'oc run'
+	WorkloadDeploymentConfig string = "deploymentconfig"
+	// WorkloadDeployment runs applications with a Kubernetes apps/v1
+	// Deployment
+	WorkloadDeployment string = "deployment"
+)
+
+const DefaultImageTimeout = 2 * time.Minute
+
+var imagePollInterval = 2 * time.Second
+
 const BoundServices string = "CF_BOUND_SERVICES"
 const BuildpackUrl string = "BUILDPACK_URL"
 
-func (app *Application) Push(image string) {
+const (
+	// CommandModeCF passes the start command to the builder image via
+	// the CF_COMMAND environment variable
+	CommandModeCF string = "cf"
+	// CommandModeNative sets the start command as the container's
+	// command so images without CF support also honor it
+	CommandModeNative string = "native"
+)
+
+// Push builds and deploys the application by running the steps of
+// its push pipeline.
+func (app *Application) Push(options PushOptions) (err error) {
+	image := options.Image
+	if image == "" && options.Droplet != "" {
+		image = DefaultDropletImage
+	} else if image == "" {
+		image = options.Builders.Image(app, DefaultImage)
+	}
+	app.place(options.Builders.Placement(app))
+	app.options = options
 	app.setupDefaults()
-	app.ensureLoggedIn()
-	// TODO: help user select the correct project instead of just
-	// assuming they've already done that
-	app.displayProject()
-	app.ensureBuildExists(image)
-	app.startBuild()
-	app.ensureDeploymentExists()
-	app.ensureServiceExists()
-	app.ensureRouteExists()
-	app.displayRoute()
+	if options.AuditFile != "" {
+		writeAudit := app.startAudit(options.AuditFile)
+		defer func() { writeAudit(err) }()
+	}
+
+	state := &PushState{App: app, Image: image}
+	defer state.finish()
+	pipeline := &Pipeline{
+		Steps:  app.pushSteps(),
+		Skip:   options.SkipSteps,
+		DryRun: options.DryRun,
+		Events: options.Events,
+	}
+	err = pipeline.Run(app.context(), state)
+	if err != nil {
+		app.cleanupIfFailed()
+	}
+	if err != nil && state.failedStep != "" {
+		err = &StepError{Step: state.failedStep, Err: err}
+	}
+	return err
 }
 
-func (app *Application) BindService(service string) error {
+// BindService binds service to the application. The optional
+// parameters are a JSON object made available to the application
+// alongside the service's credentials.
+func (app *Application) BindService(service string, parameters string) error {
 	app.setupDefaults()
-	app.ensureLoggedIn()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
 	app.displayProject()
 
 	appExists, err := app.deploymentExists()
@@ -53,12 +244,12 @@ func (app *Application) BindService(service string) error {
 	}
 
 	envPrefix := envPrefixFromService(service)
-	env, err := app.envForServiceBinding(service, envPrefix)
+	serviceKind, err := app.serviceKind(service)
 	if err != nil {
 		return err
 	}
 
-	appEnv, err := app.oc.Env("dc", app.Name)
+	appEnv, err := app.oc.Env(app.context(), app.workloadKind(), app.Name)
 	if err != nil {
 		return err
 	}
@@ -70,19 +261,45 @@ func (app *Application) BindService(service string) error {
 	}
 	boundServices = strings.TrimLeft(fmt.Sprint(boundServices, " ", envPrefix), " ")
 
-	env[BoundServices] = boundServices
+	if serviceKind != "dc" {
+		// Existing secrets and config maps are referenced directly
+		// with each of their keys prefixed by the service name
+		err = app.oc.SetEnvFrom(app.context(), app.workloadKind(), app.Name, fmt.Sprint(serviceKind, "/", service),
+			fmt.Sprint(envPrefix, "_"), withBindingParameters(map[string]string{
+				BoundServices:                   boundServices,
+				fmt.Sprint(envPrefix, "_LABEL"): UserProvidedLabel,
+				serviceNameEnv(envPrefix):       service,
+			}, envPrefix, parameters))
+		if err != nil {
+			return err
+		}
+		return app.ensureVcapServices()
+	}
 
-	err = app.oc.SetEnv("dc", app.Name, env)
+	env, err := app.envForServiceBinding(service, envPrefix)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	secretName, err := app.ensureBindingSecret(envPrefix, withBindingParameters(env, envPrefix, parameters))
+	if err != nil {
+		return err
+	}
+
+	err = app.oc.SetEnvFrom(app.context(), app.workloadKind(), app.Name, fmt.Sprint("secret/", secretName), "",
+		map[string]string{BoundServices: boundServices, serviceNameEnv(envPrefix): service})
+	if err != nil {
+		return err
+	}
+
+	return app.ensureVcapServices()
 }
 
 func (app *Application) UnbindService(service string) error {
 	app.setupDefaults()
-	app.ensureLoggedIn()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
 	app.displayProject()
 
 	appExists, err := app.deploymentExists()
@@ -94,7 +311,7 @@ func (app *Application) UnbindService(service string) error {
 	}
 
 	envPrefix := envPrefixFromService(service)
-	appEnv, err := app.oc.Env("dc", app.Name)
+	appEnv, err := app.oc.Env(app.context(), app.workloadKind(), app.Name)
 	if err != nil {
 		return err
 	}
@@ -111,115 +328,418 @@ func (app *Application) UnbindService(service string) error {
 		newEnv[BoundServices] = strings.Trim(
 			strings.Replace(appEnv[BoundServices], envPrefix, "", -1), " ")
 
-		err = app.oc.SetEnv("dc", app.Name, newEnv)
+		secretName := app.bindingSecretName(envPrefix)
+		secretExists, err := app.oc.Exists(app.context(), "secret", secretName)
 		if err != nil {
 			return err
 		}
+		if secretExists {
+			secretKeys, err := app.oc.DataKeys(app.context(), "secret", secretName)
+			if err != nil {
+				return err
+			}
+			for _, key := range secretKeys {
+				newEnv[key] = "-"
+			}
+		} else if serviceKind, err := app.serviceKind(service); err == nil && serviceKind != "dc" {
+			serviceKeys, err := app.oc.DataKeys(app.context(), serviceKind, service)
+			if err != nil {
+				return err
+			}
+			for _, key := range serviceKeys {
+				newEnv[envNameFromKey(envPrefix, key)] = "-"
+			}
+		}
+
+		err = app.oc.SetEnv(app.context(), app.workloadKind(), app.Name, newEnv)
+		if err != nil {
+			return err
+		}
+
+		if secretExists {
+			err = app.oc.Delete(app.context(), "secret", secretName)
+			if err != nil {
+				return err
+			}
+		}
 	} else {
 		return errors.New(fmt.Sprintf("Error: Service %s not bound to application %s\n", service, app.Name))
 	}
 
-	return nil
+	return app.ensureVcapServices()
+}
+
+// SetEnv sets an environment variable on the application's
+// deployment config, or on its build config if build is true.
+func (app *Application) SetEnv(name string, value string, build bool) error {
+	err := oc.CheckEnvName(name)
+	if err != nil {
+		return err
+	}
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+
+	var exists bool
+	if build {
+		exists, err = app.oc.Exists(app.context(), "bc", app.Name)
+	} else {
+		exists, err = app.deploymentExists()
+	}
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New(fmt.Sprintf("Error: Application %s not found\n", app.Name))
+	}
+
+	objType := app.workloadKind()
+	if build {
+		objType = "bc"
+	}
+
+	return app.oc.SetEnv(app.context(), objType, app.Name, map[string]string{name: value})
 }
 
 func (app *Application) setupDefaults() {
 	if app.oc == nil {
-		app.oc = new(oc.DefaultOc)
+		app.oc = oc.NewCache(newOc(app.Project))
+	}
+	if app.execer == nil {
+		app.execer = new(exec.DefaultExecer)
 	}
 }
 
-func (app *Application) ensureLoggedIn() {
-	loggedIn := app.oc.LoggedIn()
-	if !loggedIn {
-		loginCmd := app.oc.Exec("login")
-		loginCmd.AttachStdIO()
-		err := loginCmd.Run()
-		if err != nil {
-			exitWithError(err)
-		}
+// WithContext sets the context that cancels the application's
+// commands, such as when the user presses Ctrl-C, and returns app.
+func (app *Application) WithContext(ctx context.Context) *Application {
+	app.ctx = ctx
+	return app
+}
+
+// context returns the context the application's commands run with.
+func (app *Application) context() context.Context {
+	if app.ctx == nil {
+		return context.Background()
+	}
+	return app.ctx
+}
+
+// checkLoggedIn logs in if needed, returning an error if it can't.
+func (app *Application) checkLoggedIn() error {
+	_, err := app.oc.Capabilities(app.context())
+	if err != nil {
+		return err
 	}
+	loggedIn := app.oc.LoggedIn(app.context())
+	if !loggedIn && app.kubernetes() {
+		return errors.New("Error: Unable to access the Kubernetes cluster. Check your kubectl configuration.")
+	} else if !loggedIn {
+		return app.login()
+	}
+	return nil
 }
 
 func (app *Application) displayProject() error {
-	project, err := app.oc.Project()
-	fmt.Printf("Using project %s\n", project)
+	project, err := app.oc.Project(app.context())
+	log.Printf("Using project %s", project)
 	return err
 }
 
-func (app *Application) ensureBuildExists(image string) {
-	exists, err := app.oc.Exists("bc", app.Name)
+func (app *Application) ensureBuildExists(image string) error {
+	exists, err := app.oc.Exists(app.context(), "bc", app.Name)
 	if err != nil {
-		exitWithError(err)
+		return err
 	} else if !exists {
 		env := make(map[string]string)
+		for key, value := range app.BuildEnv {
+			env[key] = value
+		}
 		if app.Buildpack != "" {
 			env[BuildpackUrl] = app.Buildpack
 		}
-		app.oc.NewBuild(image, app.Name, env)
+		app.created = append(app.created, "bc", "is")
+		if app.oc.NewBuild(app.context(), image, app.Name, env) == nil {
+			app.own("bc", "is")
+		}
 	} else {
-		fmt.Printf("==> Build configuration already exists for %s, updating\n", app.Name)
-		buildEnv, err := app.oc.Env("bc", app.Name)
+		log.Infof("Build configuration already exists for %s, updating", app.Name)
+		buildEnv, err := app.oc.Env(app.context(), "bc", app.Name)
 		if err != nil {
-			exitWithError(err)
+			return err
+		}
+		changedEnv := make(map[string]string)
+		for key, value := range app.BuildEnv {
+			if buildEnv[key] != value {
+				changedEnv[key] = value
+			}
 		}
 		if app.Buildpack != buildEnv[BuildpackUrl] {
-			app.oc.SetEnv("bc", app.Name, map[string]string{BuildpackUrl: app.Buildpack})
+			changedEnv[BuildpackUrl] = app.Buildpack
+		}
+		if len(changedEnv) > 0 {
+			app.oc.SetEnv(app.context(), "bc", app.Name, changedEnv)
 		}
 	}
+	return nil
 }
 
-func (app *Application) startBuild() {
+func (app *Application) startBuild() error {
 	var pathArg string
 	if fi, err := os.Stat(app.Path); err != nil || fi.IsDir() {
-		pathArg = fmt.Sprint("--from-dir=", app.Path)
+		dir := app.Path
+		if dir == "" {
+			dir = "."
+		}
+		archive, err := app.archiveAppDir(dir)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(archive)
+		pathArg = fmt.Sprint("--from-archive=", archive)
 	} else {
 		pathArg = fmt.Sprint("--from-file=", app.Path)
 	}
-	startBuildCmd := app.oc.Exec("start-build", app.Name, pathArg, "--follow")
+	return app.runBuild(pathArg)
+}
+
+// runBuild starts a build of the application from pathArg, or from its
+// build config's source if there's none, either following its logs or
+// polling it with --async-build.
+func (app *Application) runBuild(pathArg ...string) error {
+	if app.options.AsyncBuild {
+		return app.startAsyncBuild(pathArg...)
+	}
+	startBuildCmd := app.oc.Exec(append(append([]string{"start-build", app.Name}, pathArg...), "--follow")...)
 	startBuildCmd.AttachStdIO()
-	fmt.Printf("==> Starting build with command: %s\n", startBuildCmd.ArgsString())
-	err := startBuildCmd.Run()
+	startBuildCmd.SetTimeout(exec.BuildTimeout)
+	log.Infof("Starting build with command: %s", startBuildCmd.ArgsString())
+	err := startBuildCmd.Run(app.context())
 	if err != nil {
-		exitWithError(err)
+		return err
 	}
+	return nil
 }
 
+// deploymentExists checks for the application's deployment config or
+// deployment, remembering which of the two it found.
 func (app *Application) deploymentExists() (bool, error) {
-	return app.oc.Exists("dc", app.Name)
+	for _, kind := range workloadKinds(app.oc) {
+		exists, err := app.oc.Exists(app.context(), kind, app.Name)
+		if err != nil {
+			return false, err
+		}
+		if exists {
+			app.kind = kind
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// workloadKind returns the type of object running the application,
+// either "dc" or "deployment". Existing applications keep whichever
+// they were created with, and Kubernetes only has deployments.
+func (app *Application) workloadKind() string {
+	if app.kind != "" {
+		return app.kind
+	}
+	if app.options.Workload == WorkloadDeployment || app.kubernetes() {
+		return "deployment"
+	}
+	return "dc"
 }
 
-func (app *Application) ensureDeploymentExists() {
+func (app *Application) ensureDeploymentExists() error {
 	exists, err := app.deploymentExists()
 	if err != nil {
-		exitWithError(err)
+		return err
 	}
 	if !exists {
-		repoAndImage, err := app.oc.Exec("get", "is", app.Name, "-o", "template", "--template={{.status.dockerImageRepository}}").CombinedOutput()
+		repoAndImage, err := app.deploymentImage()
 		if err != nil {
-			exitWithOutputAndError(repoAndImage, err)
+			return withOutput(repoAndImage, err)
 		}
-		env, err := app.envForServiceBindings()
+		env, secretNames, err := app.envForServiceBindings()
 		if err != nil {
-			exitWithError(err)
+			return err
 		}
-		newCmd := app.oc.Exec(app.createDeploymentArgs(string(repoAndImage), env)...)
-		fmt.Printf("==> Creating deployment config with command: %s\n", newCmd.ArgsString())
-		output, err := newCmd.CombinedOutput()
-		fmt.Println(string(output))
-		if err != nil {
-			exitWithError(err)
+		app.created = append(app.created, app.workloadKind())
+		if app.workloadKind() == "deployment" {
+			err = app.createDeployment(string(repoAndImage), env)
+			if err != nil {
+				return err
+			}
+			app.own(app.workloadKind())
+		} else {
+			newCmd := app.oc.Exec(app.createDeploymentArgs(string(repoAndImage), env)...)
+			log.Infof("Creating deployment config with command: %s", newCmd.ArgsString())
+			output, err := newCmd.CombinedOutput(app.context())
+			log.Printf("%s", output)
+			if err != nil {
+				return err
+			}
+			app.own(app.workloadKind())
+			err = app.addInstanceEnv()
+			if err != nil {
+				return err
+			}
+		}
+		for _, secretName := range secretNames {
+			err = app.oc.SetEnvFrom(app.context(), app.workloadKind(), app.Name, fmt.Sprint("secret/", secretName), "", nil)
+			if err != nil {
+				return err
+			}
 		}
 	} else {
-		fmt.Printf("==> Deployment config already exists for %s, redeploying\n", app.Name)
-		output, err := app.oc.Exec("deploy", app.Name, "--latest").CombinedOutput()
+		log.Infof("Deployment already exists for %s, redeploying", app.Name)
+		app.updated = true
+		app.adopt()
+		err = app.removeInstanceIndex()
+		if err != nil {
+			return err
+		}
+		if app.IsDocker() {
+			err = app.updateDockerImage()
+			if err != nil {
+				return err
+			}
+		}
+		return app.updateDeployment()
+	}
+	return nil
+}
+
+// updateDeployment applies the instances, memory, CPU, and environment
+// the application sets to its existing deployment, the settings a new
+// one is created with.
+func (app *Application) updateDeployment() error {
+	target := fmt.Sprint(app.workloadKind(), "/", app.Name)
+	if app.Instances > 0 {
+		log.Infof("Scaling %s to %d instances", app.Name, app.Instances)
+		output, err := app.oc.Exec("scale", target, fmt.Sprint("--replicas=", app.Instances)).CombinedOutput(app.context())
+		if err != nil {
+			return errors.New(fmt.Sprintf("Error scaling %s: %s\n", app.Name, output))
+		}
+	}
+	if resourcesArgs := app.resourcesArgs(); len(resourcesArgs) > 0 {
+		args := append([]string{"set", "resources", target, fmt.Sprint("--containers=", app.Name)}, resourcesArgs...)
+		output, err := app.oc.Exec(args...).CombinedOutput(app.context())
+		if err != nil {
+			return errors.New(fmt.Sprintf("Error updating the resources of %s: %s\n", app.Name, output))
+		}
+	}
+	bindingEnv, secretNames, err := app.envForServiceBindings()
+	if err != nil {
+		return err
+	}
+	env := make(map[string]string)
+	for _, envVar := range app.deploymentEnv(bindingEnv) {
+		split := strings.SplitN(envVar, "=", 2)
+		env[split[0]] = split[1]
+	}
+	if len(env) > 0 {
+		err = app.oc.SetEnv(app.context(), app.workloadKind(), app.Name, env)
+		if err != nil {
+			return err
+		}
+	}
+	for _, secretName := range secretNames {
+		err = app.oc.SetEnvFrom(app.context(), app.workloadKind(), app.Name, fmt.Sprint("secret/", secretName), "", nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// configureDeployment brings the settings of the application's
+// deployment that aren't set when it's created up to date, whether it
+// was just created, by a batched apply or not, or already existed.
+func (app *Application) configureDeployment() error {
+	err := app.ensureHealthChecks()
+	if err != nil {
+		return err
+	}
+	err = app.ensureGracePeriod()
+	if err != nil {
+		return err
+	}
+	err = app.ensureSpread()
+	if err != nil {
+		return err
+	}
+	err = app.ensurePlacement()
+	if err != nil {
+		return err
+	}
+	return app.ensureSidecars()
+}
+
+// redeployUpdated redeploys the application if the push updated an
+// existing deployment. New deployments roll out when they're created.
+func (app *Application) redeployUpdated() error {
+	if !app.updated {
+		return nil
+	}
+	return app.redeploy()
+}
+
+// deploymentImage returns the image to deploy, which is either the
+// app's Docker image or the output of its build.
+func (app *Application) deploymentImage() ([]byte, error) {
+	if app.IsDocker() {
+		return []byte(app.Docker.Image), nil
+	}
+	if app.kubernetes() {
+		return []byte(app.registryImage()), nil
+	}
+	return app.waitForImage()
+}
+
+// waitForImage waits for the build's image stream to have both a
+// repository and a latest tag, since on fresh clusters the image
+// stream status can lag behind the build finishing.
+func (app *Application) waitForImage() ([]byte, error) {
+	timeout := app.options.ImageTimeout
+	if timeout == 0 {
+		timeout = DefaultImageTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		imageStream := &types.ImageStream{}
+		err := oc.Get(app.context(), app.oc, "is", app.Name, imageStream)
 		if err != nil {
-			exitWithOutputAndError(output, err)
+			return nil, err
 		}
+		repository := imageStream.Status.DockerImageRepository
+		if repository != "" {
+			tagExists, err := app.oc.Exists(app.context(), "istag", fmt.Sprint(app.Name, ":latest"))
+			if err != nil {
+				return nil, err
+			}
+			if tagExists {
+				return []byte(repository), nil
+			}
+		}
+		if time.Now().After(deadline) {
+			if repository == "" {
+				return nil, errors.New(fmt.Sprintf("Error: Image stream %s has no docker image repository after %v. Is the integrated registry configured?", app.Name, timeout))
+			}
+			return nil, errors.New(fmt.Sprintf("Error: Image stream tag %s:latest not found after %v", app.Name, timeout))
+		}
+		time.Sleep(imagePollInterval)
 	}
 }
 
-func (app *Application) envForServiceBindings() ([]string, error) {
+// envForServiceBindings creates a binding secret for each of the
+// app's services, returning the plain environment variables and the
+// names of the secrets the deployment should reference.
+func (app *Application) envForServiceBindings() ([]string, []string, error) {
 	var env []string
+	var secretNames []string
 	var serviceNames []string
 	if len(app.Services) > 0 {
 		for _, service := range app.Services {
@@ -227,20 +747,23 @@ func (app *Application) envForServiceBindings() ([]string, error) {
 			serviceNames = append(serviceNames, envPrefix)
 			serviceEnv, err := app.envForServiceBinding(service, envPrefix)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
-			for key, value := range serviceEnv {
-				env = append(env, fmt.Sprint(key, "=", value))
+			secretName, err := app.ensureBindingSecret(envPrefix, serviceEnv)
+			if err != nil {
+				return nil, nil, err
 			}
+			secretNames = append(secretNames, secretName)
+			env = append(env, fmt.Sprint(serviceNameEnv(envPrefix), "=", service))
 		}
 		env = append(env, fmt.Sprint(BoundServices, "=", strings.Join(serviceNames, " ")))
 	}
-	return env, nil
+	return env, secretNames, nil
 }
 
 func (app *Application) envForServiceBinding(service string, envPrefix string) (map[string]string, error) {
 	env := make(map[string]string)
-	serviceEnv, err := app.oc.Env("dc", service)
+	serviceEnv, err := app.oc.Env(app.context(), "dc", service)
 	if err != nil {
 		return nil, err
 	}
@@ -264,6 +787,18 @@ func (app *Application) envForServiceBinding(service string, envPrefix string) (
 		}
 	}
 	env[fmt.Sprint(envPrefix, "_LABEL")] = label
+	if label == "" {
+		app.brokeredCredentials(service, envPrefix, env)
+		label = env[fmt.Sprint(envPrefix, "_LABEL")]
+	}
+
+	uri, err := app.serviceURI(service, label, env[fmt.Sprint(envPrefix, "_USER")],
+		env[fmt.Sprint(envPrefix, "_PASSWORD")], env[fmt.Sprint(envPrefix, "_DATABASE")])
+	if err != nil {
+		log.Warnf("Skipping %s_URI for service %s: %v", envPrefix, service, err)
+	} else if uri != "" {
+		env[fmt.Sprint(envPrefix, "_URI")] = uri
+	}
 	return env, nil
 }
 
@@ -272,71 +807,124 @@ func envPrefixFromService(service string) string {
 }
 
 func (app *Application) createDeploymentArgs(repoAndImage string, env []string) []string {
-	var limits string
+	args := []string{"run", app.Name, fmt.Sprint("--image=", repoAndImage)}
+	args = append(args, app.resourcesArgs()...)
+	// One flag per variable, since values can contain commas
+	for _, envVar := range app.deploymentEnv(env) {
+		args = append(args, fmt.Sprint("--env=", envVar))
+	}
+	if app.nativeCommand() {
+		args = append(args, "--command", "--")
+		args = append(args, app.containerCommand()...)
+	}
+	return args
+}
+
+// resourcesArgs returns the --limits and --requests arguments setting
+// the application's memory and CPU. The CPU is requested as well as
+// limited, so the scheduler reserves it.
+func (app *Application) resourcesArgs() []string {
+	var limits []string
 	if app.Memory != "" {
-		limits = fmt.Sprint("--limits=memory=", app.Memory)
-		env = append(env, fmt.Sprint("MEMORY_LIMIT=", app.Memory))
-	} else {
-		limits = ""
+		limits = append(limits, fmt.Sprint("memory=", app.containerMemory()))
+	}
+	if app.CPU != "" {
+		limits = append(limits, fmt.Sprint("cpu=", app.CPU))
 	}
-	if app.Command != "" {
+	if len(limits) == 0 {
+		return nil
+	}
+	args := []string{fmt.Sprint("--limits=", strings.Join(limits, ","))}
+	if app.CPU != "" {
+		args = append(args, fmt.Sprint("--requests=cpu=", app.CPU))
+	}
+	return args
+}
+
+// deploymentEnv adds the environment variables derived from the
+// application's settings to env.
+func (app *Application) deploymentEnv(env []string) []string {
+	if app.Memory != "" {
+		env = append(env, fmt.Sprint("MEMORY_LIMIT=", app.containerMemory()))
+	}
+	if app.Command != "" && !app.nativeCommand() {
 		env = append(env, fmt.Sprint("CF_COMMAND=", app.Command))
 	}
-	envStr := fmt.Sprint("--env=", strings.Join(env, ","))
-	return []string{"run", app.Name, fmt.Sprint("--image=", repoAndImage),
-		limits, envStr}
+	return env
 }
 
-func (app *Application) ensureServiceExists() {
-	output, err := app.oc.Exec("get", "svc", app.Name).CombinedOutput()
+// nativeCommand returns true if the start command should be set as the
+// container's command instead of being passed via CF_COMMAND.
+func (app *Application) nativeCommand() bool {
+	// Docker images know nothing about CF_COMMAND
+	return app.Command != "" &&
+		(app.options.CommandMode == CommandModeNative || app.IsDocker())
+}
+
+// containerCommand returns the command of a container running the
+// start command, which passes SIGTERM on to all of its processes.
+func (app *Application) containerCommand() []string {
+	return []string{"/bin/sh", "-c", signalForwarder, "sh", app.Command}
+}
+
+func (app *Application) ensureServiceExists() error {
+	output, err := app.oc.Exec("get", "svc", app.Name).CombinedOutput(app.context())
 	if strings.Contains(string(output), "not found") {
-		newCmd := app.oc.Exec("expose", "dc", app.Name, "--port=8080")
-		fmt.Printf("==> Creating service with command: %s\n", newCmd.ArgsString())
-		output, err = newCmd.CombinedOutput()
-		fmt.Println(string(output))
+		app.created = append(app.created, "svc")
+		newCmd := app.oc.Exec(app.exposeArgs()...)
+		log.Infof("Creating service with command: %s", newCmd.ArgsString())
+		output, err = newCmd.CombinedOutput(app.context())
+		log.Printf("%s", output)
 		if err != nil {
-			exitWithError(err)
+			return err
 		}
+		app.own("svc")
 	} else if err != nil {
-		exitWithOutputAndError(output, err)
+		return withOutput(output, err)
 	} else {
-		fmt.Printf("==> Service already exists for %s, skipping creating one\n", app.Name)
+		log.Infof("Service already exists for %s, skipping creating one", app.Name)
 	}
+	return nil
 }
 
-func (app *Application) ensureRouteExists() {
-	output, err := app.oc.Exec("get", "route", app.Name).CombinedOutput()
+func (app *Application) ensureRouteExists() error {
+	output, err := app.oc.Exec("get", "route", app.Name).CombinedOutput(app.context())
 	if strings.Contains(string(output), "not found") {
-		newCmd := app.oc.Exec("expose", "svc", app.Name)
-		fmt.Printf("==> Creating route with command: %s\n", newCmd.ArgsString())
-		output, err = newCmd.CombinedOutput()
-		fmt.Println(string(output))
+		app.created = append(app.created, "route")
+		args := []string{"expose", "svc", app.Name}
+		if app.options.Domain != "" {
+			args = append(args, fmt.Sprint("--hostname=", app.ingressHost()))
+		}
+		newCmd := app.oc.Exec(args...)
+		log.Infof("Creating route with command: %s", newCmd.ArgsString())
+		output, err = newCmd.CombinedOutput(app.context())
+		log.Printf("%s", output)
 		if err != nil {
-			exitWithError(err)
+			return err
 		}
+		app.own("route")
 	} else if err != nil {
-		exitWithOutputAndError(output, err)
+		return withOutput(output, err)
 	} else {
-		fmt.Printf("==> Route already exists for %s, skipping creating one\n", app.Name)
+		log.Infof("Route already exists for %s, skipping creating one", app.Name)
 	}
+	return nil
 }
 
-func (app *Application) displayRoute() {
-	output, err := app.oc.Exec("get", "route", app.Name, "-o", "template",
-		"--template={{.spec.host}}").CombinedOutput()
+func (app *Application) displayRoute() error {
+	route := &types.Route{}
+	err := oc.Get(app.context(), app.oc, "route", app.Name, route)
 	if err != nil {
-		exitWithOutputAndError(output, err)
+		return err
 	} else {
-		fmt.Printf("==> Your application is available at %s\n", output)
+		log.Infof("Your application is available at %s", route.Spec.Host)
 	}
+	return nil
 }
 
-func exitWithError(err error) {
-	fmt.Fprintln(os.Stderr, err)
-	os.Exit(1)
-}
-
-func exitWithOutputAndError(output []byte, err error) {
-	fmt.Println(string(output))
-	exitWithError(err)
+// withOutput prints the output of a failed command before returning
+// its error.
+func withOutput(output []byte, err error) error {
+	log.Printf("%s", output)
+	return err
 }
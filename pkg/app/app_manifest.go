@@ -0,0 +1,214 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+// ManifestApp is an application as written to a manifest by
+// 'ocf create-app-manifest', using the keys Cloud Foundry does.
+type ManifestApp struct {
+	Name      string            `yaml:"name"`
+	Buildpack string            `yaml:"buildpack,omitempty"`
+	Path      string            `yaml:"path,omitempty"`
+	Docker    *Docker           `yaml:"docker,omitempty"`
+	Command   string            `yaml:"command,omitempty"`
+	Instances int               `yaml:"instances,omitempty"`
+	Memory    string            `yaml:"memory,omitempty"`
+	CPU       string            `yaml:"cpu,omitempty"`
+	Env       map[string]string `yaml:"env,omitempty"`
+	BuildEnv  map[string]string `yaml:"build-env,omitempty"`
+	Services  []string          `yaml:"services,omitempty"`
+	Routes    []Route           `yaml:"routes,omitempty"`
+}
+
+// CreateAppManifest reads the settings of the running application and
+// returns them as a manifest, like 'cf create-app-manifest'.
+func (app *Application) CreateAppManifest() ([]byte, error) {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return nil, err
+	}
+	app.displayProject()
+
+	appExists, err := app.deploymentExists()
+	if err != nil {
+		return nil, err
+	}
+	if !appExists {
+		return nil, errors.New(fmt.Sprintf("Error: Application %s not found\n", app.Name))
+	}
+
+	manifestApp, err := app.manifestApp()
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(map[string][]*ManifestApp{"applications": {manifestApp}})
+}
+
+func (app *Application) manifestApp() (*ManifestApp, error) {
+	manifestApp := &ManifestApp{Name: app.Name}
+
+	workload, err := app.liveWorkload()
+	if err != nil {
+		return nil, err
+	}
+	container := workload.Spec.Template.Spec.Containers[0]
+	manifestApp.Instances = workload.Spec.Replicas
+	manifestApp.Memory = container.Resources.Limits["memory"]
+	manifestApp.CPU = container.Resources.Limits["cpu"]
+
+	var plainEnv []types.EnvVar
+	for _, envVar := range container.Env {
+		if envVar.ValueFrom == nil {
+			plainEnv = append(plainEnv, envVar)
+		}
+	}
+	manifestApp.Command, manifestApp.Services, manifestApp.Env = app.manifestEnv(plainEnv)
+	if manifestApp.Command == "" && len(container.Command) >= 3 && container.Command[0] == "/bin/sh" {
+		// A native command, set as the container's command, last
+		// after the signal forwarder of newer pushes
+		manifestApp.Command = container.Command[len(container.Command)-1]
+	}
+
+	buildExists := false
+	if !app.kubernetes() {
+		buildExists, err = app.oc.Exists(app.context(), "bc", app.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if buildExists {
+		buildEnv, err := app.oc.Env(app.context(), "bc", app.Name)
+		if err != nil {
+			return nil, err
+		}
+		manifestApp.Buildpack = buildEnv[BuildpackUrl]
+		delete(buildEnv, BuildpackUrl)
+		if len(buildEnv) > 0 {
+			manifestApp.BuildEnv = buildEnv
+		}
+	} else if !app.kubernetes() {
+		// Without a build, the application runs an existing image
+		manifestApp.Docker = &Docker{Image: container.Image}
+	}
+
+	manifestApp.Routes, err = app.liveRoutes()
+	return manifestApp, err
+}
+
+// manifestEnv sorts the application's plain environment variables
+// into its command, the services bound to it, and the rest, leaving
+// out the ones ocf sets itself.
+func (app *Application) manifestEnv(env []types.EnvVar) (string, []string, map[string]string) {
+	var command string
+	var prefixes []string
+	rest := make(map[string]string)
+	for _, envVar := range env {
+		switch envVar.Name {
+		case "CF_COMMAND":
+			command = envVar.Value
+		case BoundServices:
+			prefixes = strings.Fields(envVar.Value)
+		default:
+			rest[envVar.Name] = envVar.Value
+		}
+	}
+
+	ignored := map[string]bool{"MEMORY_LIMIT": true, "VCAP_APPLICATION": true}
+	for _, envVar := range app.instanceEnv() {
+		ignored[envVar["name"].(string)] = true
+	}
+	for _, key := range buildMetadataKeys {
+		ignored[key.env] = true
+	}
+	for name := range rest {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(name, fmt.Sprint(prefix, "_")) {
+				ignored[name] = true
+			}
+		}
+		if ignored[name] {
+			delete(rest, name)
+		}
+	}
+	if len(rest) == 0 {
+		rest = nil
+	}
+
+	var services []string
+	for _, prefix := range prefixes {
+		services = append(services, app.serviceFromEnvPrefix(prefix))
+	}
+	return command, services, rest
+}
+
+// serviceFromEnvPrefix guesses the name of the service bound with
+// envPrefix, which loses the case and dashes of the name. Dashed
+// lower case names are by far the most common, so they're tried first.
+func (app *Application) serviceFromEnvPrefix(envPrefix string) string {
+	candidates := []string{
+		strings.ToLower(strings.Replace(envPrefix, "_", "-", -1)),
+		strings.ToLower(envPrefix),
+	}
+	for _, candidate := range candidates {
+		if _, err := app.serviceKind(candidate); err == nil {
+			return candidate
+		}
+	}
+	return candidates[0]
+}
+
+// liveRoutes returns the hosts and paths the application is reached at
+// through its default route and any manifest routes.
+func (app *Application) liveRoutes() ([]Route, error) {
+	var routes []Route
+	seen := make(map[string]bool)
+	addRoute := func(host string, path string) {
+		route := strings.TrimRight(fmt.Sprint(host, path), "/")
+		if host != "" && !seen[route] {
+			seen[route] = true
+			routes = append(routes, Route{Route: route})
+		}
+	}
+
+	if app.kubernetes() {
+		list := &types.IngressList{}
+		err := oc.GetSelected(app.context(), app.oc, "ingress", fmt.Sprint("app=", app.Name), list)
+		if err != nil {
+			return nil, err
+		}
+		for _, ingress := range list.Items {
+			for _, rule := range ingress.Spec.Rules {
+				for _, path := range rule.HTTP.Paths {
+					addRoute(rule.Host, path.Path)
+				}
+			}
+		}
+	} else {
+		// The default route has the service's labels rather than
+		// the app label, so it's looked up by name
+		defaultRoute, err := app.liveHost()
+		if err != nil {
+			return nil, err
+		}
+		addRoute(defaultRoute, "")
+		list := &types.RouteList{}
+		err = oc.GetSelected(app.context(), app.oc, "route", fmt.Sprint("app=", app.Name), list)
+		if err != nil {
+			return nil, err
+		}
+		for _, route := range list.Items {
+			addRoute(route.Spec.Host, route.Spec.Path)
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Route < routes[j].Route })
+	return routes, nil
+}
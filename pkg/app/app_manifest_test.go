@@ -0,0 +1,64 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestManifestApp(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", kind: "dc"}
+
+	dcCmd := &mocks.ExecCmd{}
+	dcCmd.On("CombinedOutput").Return([]byte(`{"spec":{"replicas":2,"template":{"spec":{"containers":[{
+		"name":"foo",
+		"image":"172.30.1.1:5000/myproject/foo@sha256:abc",
+		"resources":{"limits":{"memory":"512M"}},
+		"env":[
+			{"name":"MEMORY_LIMIT","value":"512M"},
+			{"name":"CF_COMMAND","value":"bundle exec puma"},
+			{"name":"CF_BOUND_SERVICES","value":"RAILS_POSTGRES"},
+			{"name":"RAILS_POSTGRES_LABEL","value":"postgresql"},
+			{"name":"RAILS_POSTGRES_PASSWORD","valueFrom":{"secretKeyRef":{"name":"foo-rails-postgres-binding","key":"RAILS_POSTGRES_PASSWORD"}}},
+			{"name":"PORT","value":"8080"},
+			{"name":"RAILS_ENV","value":"production"}
+		]}]}}}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "dc", "foo", "-o", "json"}).Return(dcCmd)
+	oc.On("Exists", "dc", "rails-postgres").Return(true, nil)
+	oc.On("Exists", "bc", "foo").Return(true, nil)
+	oc.On("Env", "bc", "foo").Return(map[string]string{
+		"BUILDPACK_URL":  "https://github.com/cloudfoundry/ruby-buildpack",
+		"BUNDLE_WITHOUT": "test",
+	}, nil)
+	oc.On("Exists", "route", "foo").Return(true, nil)
+	routeCmd := &mocks.ExecCmd{}
+	routeCmd.On("CombinedOutput").Return([]byte(`{"spec":{"host":"foo-myproject.apps.example.com"}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "route", "foo", "-o", "json"}).Return(routeCmd)
+	routesCmd := &mocks.ExecCmd{}
+	routesCmd.On("CombinedOutput").Return([]byte(`{"items":[{"spec":{"host":"example.com","path":"/api"}}]}`), nil)
+	oc.Execer.On("Oc", []string{"get", "route", "--selector=app=foo", "-o", "json"}).Return(routesCmd)
+
+	manifestApp, err := app.manifestApp()
+	assert.Nil(t, err)
+	manifest, err := yaml.Marshal(manifestApp)
+	assert.Nil(t, err)
+	assert.Equal(t, `name: foo
+buildpack: https://github.com/cloudfoundry/ruby-buildpack
+command: bundle exec puma
+instances: 2
+memory: 512M
+env:
+    RAILS_ENV: production
+build-env:
+    BUNDLE_WITHOUT: test
+services:
+    - rails-postgres
+routes:
+    - route: example.com/api
+    - route: foo-myproject.apps.example.com
+`, string(manifest))
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+// AppSummary is the state of one application as shown by 'ocf apps'.
+type AppSummary struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Instances string `json:"instances"`
+	Host      string `json:"host"`
+}
+
+// ListApplications summarizes every application in the current
+// project, looking each one up in parallel.
+func ListApplications(ctx context.Context) ([]AppSummary, error) {
+	app := &Application{ctx: ctx}
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return nil, err
+	}
+	app.displayProject()
+	return listApplications(ctx, app.oc)
+}
+
+func listApplications(ctx context.Context, o oc.Oc) ([]AppSummary, error) {
+	owned, err := ownedWorkloads(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+	var apps []*Application
+	for _, kind := range workloadKinds(o) {
+		names, err := o.List(ctx, kind, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			// Once ocf has recorded what it created, workloads it
+			// didn't create aren't applications
+			if len(owned) > 0 && owned[name] != kind {
+				continue
+			}
+			apps = append(apps, &Application{Name: name, oc: o, ctx: ctx, kind: kind})
+		}
+	}
+
+	summaries := make([]AppSummary, len(apps))
+	var tasks []func() error
+	for i := range apps {
+		app, summary := apps[i], &summaries[i]
+		summary.Name = app.Name
+		summary.Kind = app.kind
+		tasks = append(tasks, func() error {
+			workload := &types.DeploymentConfig{}
+			err := oc.Get(ctx, app.oc, app.kind, app.Name, workload)
+			summary.Instances = fmt.Sprintf("%d/%d", workload.Status.ReadyReplicas, workload.Spec.Replicas)
+			return err
+		}, func() error {
+			host, err := app.liveHost()
+			summary.Host = host
+			return err
+		})
+	}
+	err = exec.Parallel(exec.DefaultParallelism, tasks...)
+	return summaries, err
+}
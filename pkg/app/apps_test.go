@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestListApplications(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.On("List", "dc", "").Return([]string{"foo"}, nil)
+	oc.On("List", "deployment", "").Return([]string{"bar"}, nil)
+	oc.On("Exists", "route", "foo").Return(true, nil)
+	oc.On("Exists", "route", "bar").Return(false, nil)
+
+	instancesCmd := &mocks.ExecCmd{}
+	instancesCmd.On("CombinedOutput").Return([]byte(`{"spec": {"replicas": 2}, "status": {"readyReplicas": 1}}`), nil)
+	hostCmd := &mocks.ExecCmd{}
+	hostCmd.On("CombinedOutput").Return([]byte(`{"spec": {"host": "foo.example.com"}}`), nil)
+	oc.Execer.On("Oc", mock.MatchedBy(func(args []string) bool {
+		return args[1] == "route"
+	})).Return(hostCmd)
+	oc.Execer.On("Oc", mock.Anything).Return(instancesCmd)
+
+	summaries, err := listApplications(context.Background(), oc)
+	assert.Nil(t, err)
+	assert.Equal(t, []AppSummary{
+		{Name: "foo", Kind: "dc", Instances: "1/2", Host: "foo.example.com"},
+		{Name: "bar", Kind: "deployment", Instances: "1/2"},
+	}, summaries)
+}
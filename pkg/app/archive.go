@@ -0,0 +1,199 @@
+package app
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+const (
+	// CompressionNone uploads a plain tar archive
+	CompressionNone string = "none"
+	// CompressionFast favors speed over size
+	CompressionFast string = "fast"
+	// CompressionDefault balances speed and size
+	CompressionDefault string = "default"
+	// CompressionBest favors size over speed
+	CompressionBest string = "best"
+)
+
+var compressionLevels = map[string]int{
+	CompressionFast:    gzip.BestSpeed,
+	CompressionDefault: gzip.DefaultCompression,
+	CompressionBest:    gzip.BestCompression,
+}
+
+// ValidCompression returns true if compression is one of the
+// Compression constants.
+func ValidCompression(compression string) bool {
+	_, ok := compressionLevels[compression]
+	return ok || compression == CompressionNone
+}
+
+// archiveAppDir packages the application directory into a temporary
+// archive for the build, showing progress along the way. The caller
+// must remove the returned file.
+func (app *Application) archiveAppDir(dir string) (string, error) {
+	file, err := ioutil.TempFile("", fmt.Sprint("ocf-", app.Name, "-"))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	progress := newProgressBar(fmt.Sprint("Packaging ", app.Name))
+	err = archiveDir(dir, file, app.options.Compression, progress.update)
+	progress.done()
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	log.Infof("Uploading %s archive of %s", formatBytes(info.Size()), dir)
+	return file.Name(), nil
+}
+
+// archiveDir writes a tar archive of dir to w, compressed according
+// to compression, calling progress with the bytes of file contents
+// archived so far and the total.
+func archiveDir(dir string, w io.Writer, compression string, progress func(int64, int64)) error {
+	if compression == "" {
+		compression = CompressionDefault
+	}
+	if !ValidCompression(compression) {
+		return errors.New(fmt.Sprintf("Error: Invalid compression %s", compression))
+	}
+
+	var total int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			total += info.Size()
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if compression != CompressionNone {
+		gz, err := gzip.NewWriterLevel(w, compressionLevels[compression])
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+
+	var done int64
+	progress(done, total)
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(path)
+			if err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if exec.Windows() && info.Mode().IsRegular() {
+			// Windows has no executable bits, so files are
+			// archived as executable, like 'cf push' does, or
+			// scripts such as mvnw couldn't run
+			header.Mode = 0755
+		}
+		if info.IsDir() && !strings.HasSuffix(header.Name, "/") {
+			header.Name += "/"
+		}
+		if err = tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		written, err := io.Copy(tw, f)
+		done += written
+		progress(done, total)
+		return err
+	})
+}
+
+// progressBar redraws a single line with how far along a task is.
+type progressBar struct {
+	label   string
+	percent int
+	hidden  bool
+}
+
+func newProgressBar(label string) *progressBar {
+	// Redrawing a line only makes sense for people reading text
+	return &progressBar{label: label, percent: -1, hidden: log.JSON() || !log.Enabled(log.InfoLevel) || NonInteractive}
+}
+
+func (bar *progressBar) update(done int64, total int64) {
+	percent := 100
+	if total > 0 {
+		percent = int(done * 100 / total)
+	}
+	if bar.hidden || percent == bar.percent {
+		return
+	}
+	bar.percent = percent
+	const width = 30
+	filled := percent * width / 100
+	fmt.Fprintf(log.Output(), "\r==> %s [%s%s] %3d%% (%s / %s)", bar.label,
+		strings.Repeat("#", filled), strings.Repeat(" ", width-filled),
+		percent, formatBytes(done), formatBytes(total))
+}
+
+func (bar *progressBar) done() {
+	if !bar.hidden {
+		fmt.Fprintln(log.Output())
+	}
+}
+
+// formatBytes returns a human-readable size like "12.3 MB".
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
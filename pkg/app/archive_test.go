@@ -0,0 +1,62 @@
+package app
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocf-archive")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "lib"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "app.js"), []byte("hello"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "lib", "util.js"), []byte("world!"), 0644))
+
+	for _, compression := range []string{CompressionNone, CompressionBest} {
+		var buf bytes.Buffer
+		var lastDone, lastTotal int64
+		err = archiveDir(dir, &buf, compression, func(done int64, total int64) {
+			lastDone, lastTotal = done, total
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(11), lastDone)
+		assert.Equal(t, int64(11), lastTotal)
+
+		var r io.Reader = &buf
+		if compression != CompressionNone {
+			r, err = gzip.NewReader(r)
+			assert.Nil(t, err)
+		}
+		var names []string
+		tr := tar.NewReader(r)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			assert.Nil(t, err)
+			names = append(names, header.Name)
+		}
+		assert.Equal(t, []string{"app.js", "lib/", "lib/util.js"}, names)
+	}
+}
+
+func TestArchiveDirInvalidCompression(t *testing.T) {
+	err := archiveDir(".", ioutil.Discard, "zip", func(int64, int64) {})
+	assert.NotNil(t, err)
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KB", formatBytes(1536))
+	assert.Equal(t, "500.0 MB", formatBytes(500*1024*1024))
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// PushAudit records what a push did to the cluster: every command it
+// ran and the objects it created.
+type PushAudit struct {
+	App      string             `json:"app"`
+	Project  string             `json:"project"`
+	User     string             `json:"user"`
+	Started  string             `json:"started"`
+	Finished string             `json:"finished"`
+	Error    string             `json:"error,omitempty"`
+	Created  []string           `json:"created"`
+	Commands []exec.AuditRecord `json:"commands"`
+}
+
+// startAudit records the commands run until the returned function
+// appends the audit of the push, and the error it failed with, to
+// file. file holds a JSON array of the audits of every push written
+// to it.
+func (app *Application) startAudit(file string) func(error) {
+	audit := exec.StartAudit()
+	started := time.Now()
+	project, _ := app.oc.Project(app.context())
+	return func(pushErr error) {
+		exec.StopAudit()
+		pushAudit := PushAudit{
+			App:      app.Name,
+			Project:  project,
+			User:     app.pusher(),
+			Started:  started.UTC().Format(time.RFC3339),
+			Finished: time.Now().UTC().Format(time.RFC3339),
+			Commands: audit.Records(),
+		}
+		for _, kind := range app.created {
+			pushAudit.Created = append(pushAudit.Created, fmt.Sprint(kind, "/", app.Name))
+		}
+		if pushErr != nil {
+			pushAudit.Error = pushErr.Error()
+		}
+		err := appendAudit(file, pushAudit)
+		if err != nil {
+			log.Warnf("Couldn't write the audit of %s to %s: %v", app.Name, file, err)
+		}
+	}
+}
+
+func appendAudit(file string, pushAudit PushAudit) error {
+	var audits []PushAudit
+	data, err := ioutil.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(data) > 0 {
+		err = json.Unmarshal(data, &audits)
+		if err != nil {
+			return err
+		}
+	}
+	audits = append(audits, pushAudit)
+	data, err = json.MarshalIndent(audits, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, append(data, '\n'), 0600)
+}
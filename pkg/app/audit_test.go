@@ -0,0 +1,42 @@
+package app
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestStartAuditAppendsToFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocf-audit-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "audit.json")
+
+	oc := mocks.NewMockOc()
+	whoamiCmd := &mocks.ExecCmd{}
+	whoamiCmd.On("CombinedOutput").Return([]byte("alice\n"), nil)
+	oc.Execer.On("Oc", []string{"whoami"}).Return(whoamiCmd)
+
+	for _, name := range []string{"foo", "bar"} {
+		app := Application{oc: oc, Name: name}
+		writeAudit := app.startAudit(file)
+		app.created = append(app.created, "dc", "svc")
+		writeAudit(nil)
+	}
+
+	data, err := ioutil.ReadFile(file)
+	assert.Nil(t, err)
+	var audits []PushAudit
+	assert.Nil(t, json.Unmarshal(data, &audits))
+	assert.Equal(t, 2, len(audits))
+	assert.Equal(t, "foo", audits[0].App)
+	assert.Equal(t, "test-project", audits[0].Project)
+	assert.Equal(t, "alice", audits[0].User)
+	assert.Equal(t, []string{"dc/bar", "svc/bar"}, audits[1].Created)
+}
@@ -0,0 +1,192 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// batchable returns true if the application can be created with a
+// single apply: a new OpenShift application built from uploaded source,
+// run by a deployment config, and exposed by its default route, whose
+// image change trigger rolls it out once the first build finishes. The
+// steps after the apply configure the rest of the deployment config.
+func (app *Application) batchable() bool {
+	if app.buildFlow() != buildBinary || app.deployFlow() != deployDefault ||
+		app.exposeFlow() != exposeRoute || app.workloadKind() != "dc" {
+		return false
+	}
+	buildExists, err := app.oc.Exists(app.context(), "bc", app.Name)
+	if err != nil || buildExists {
+		return false
+	}
+	appExists, err := app.deploymentExists()
+	return err == nil && !appExists
+}
+
+// applyNewApplication creates the image stream, build config,
+// deployment config, service, and route of a new application in one
+// apply instead of a command for each.
+func (app *Application) applyNewApplication(builder string) error {
+	env, secretNames, err := app.envForServiceBindings()
+	if err != nil {
+		return err
+	}
+	list, err := app.newApplicationList(builder, env, secretNames)
+	if err != nil {
+		return err
+	}
+	app.created = append(app.created, "is", "bc", "dc", "svc", "route")
+	log.Infof("Creating build, deployment, service, and route for %s", app.Name)
+	err = app.oc.Apply(app.context(), list)
+	if err != nil {
+		return err
+	}
+	app.own("is", "bc", "dc", "svc", "route")
+	return nil
+}
+
+func (app *Application) newApplicationList(builder string, env []string, secretNames []string) ([]byte, error) {
+	labels := map[string]string{"app": app.Name}
+	latest := map[string]string{"kind": "ImageStreamTag", "name": fmt.Sprint(app.Name, ":latest")}
+
+	var buildEnv []map[string]string
+	for key, value := range app.BuildEnv {
+		buildEnv = append(buildEnv, map[string]string{"name": key, "value": value})
+	}
+	if app.Buildpack != "" {
+		buildEnv = append(buildEnv, map[string]string{"name": BuildpackUrl, "value": app.Buildpack})
+	}
+
+	replicas := app.Instances
+	if replicas < 1 {
+		replicas = 1
+	}
+	// The image change trigger replaces the image once it's built
+	container := app.container(" ", env, secretNames)
+	app.addInstanceEnvTo(container)
+
+	items := []interface{}{
+		map[string]interface{}{
+			"apiVersion": "image.openshift.io/v1",
+			"kind":       "ImageStream",
+			"metadata":   map[string]interface{}{"name": app.Name, "labels": labels},
+		},
+		map[string]interface{}{
+			"apiVersion": "build.openshift.io/v1",
+			"kind":       "BuildConfig",
+			"metadata":   map[string]interface{}{"name": app.Name, "labels": labels},
+			"spec": map[string]interface{}{
+				"source": map[string]string{"type": "Binary"},
+				"strategy": map[string]interface{}{
+					"type": "Source",
+					"sourceStrategy": map[string]interface{}{
+						"from": map[string]string{"kind": "DockerImage", "name": builder},
+						"env":  buildEnv,
+					},
+				},
+				"output": map[string]interface{}{"to": latest},
+			},
+		},
+		map[string]interface{}{
+			"apiVersion": "apps.openshift.io/v1",
+			"kind":       "DeploymentConfig",
+			"metadata":   map[string]interface{}{"name": app.Name, "labels": labels},
+			"spec": map[string]interface{}{
+				"replicas": replicas,
+				"selector": labels,
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{"labels": labels},
+					"spec": map[string]interface{}{
+						"containers": []interface{}{container},
+					},
+				},
+				"triggers": []interface{}{
+					map[string]string{"type": "ConfigChange"},
+					map[string]interface{}{
+						"type": "ImageChange",
+						"imageChangeParams": map[string]interface{}{
+							"automatic":      true,
+							"containerNames": []string{app.Name},
+							"from":           latest,
+						},
+					},
+				},
+			},
+		},
+		app.serviceObject(),
+		app.routeObject(),
+	}
+	list := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      items,
+	}
+	return json.MarshalIndent(list, "", "  ")
+}
+
+func (app *Application) serviceObject() map[string]interface{} {
+	labels := map[string]string{"app": app.Name}
+	spec := map[string]interface{}{
+		"selector": labels,
+		"ports":    []interface{}{map[string]int{"port": app.servicePort(), "targetPort": defaultPort}},
+	}
+	if app.tcp() {
+		spec["type"] = "LoadBalancer"
+	}
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]interface{}{"name": app.Name, "labels": labels},
+		"spec":       spec,
+	}
+}
+
+func (app *Application) routeObject() map[string]interface{} {
+	spec := map[string]interface{}{
+		"to": map[string]string{"kind": "Service", "name": app.Name},
+	}
+	// Like ensureRouteExists, the router picks the host unless a
+	// domain is given
+	if app.options.Domain != "" {
+		spec["host"] = app.ingressHost()
+	}
+	return map[string]interface{}{
+		"apiVersion": "route.openshift.io/v1",
+		"kind":       "Route",
+		"metadata": map[string]interface{}{
+			"name":   app.Name,
+			"labels": map[string]string{"app": app.Name},
+		},
+		"spec": spec,
+	}
+}
+
+func (app *Application) ingressObject() map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "networking.k8s.io/v1",
+		"kind":       "Ingress",
+		"metadata": map[string]interface{}{
+			"name":   app.Name,
+			"labels": map[string]string{"app": app.Name},
+		},
+		"spec": map[string]interface{}{
+			"rules": []interface{}{map[string]interface{}{
+				"host": app.ingressHost(),
+				"http": map[string]interface{}{
+					"paths": []interface{}{map[string]interface{}{
+						"path":     "/",
+						"pathType": "Prefix",
+						"backend": map[string]interface{}{
+							"service": map[string]interface{}{
+								"name": app.Name,
+								"port": map[string]int{"number": 8080},
+							},
+						},
+					}},
+				},
+			}},
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestNewApplicationList(t *testing.T) {
+	app := Application{oc: mocks.NewMockOc(), Name: "foo", Buildpack: "https://example.com/bp.git"}
+	manifest, err := app.newApplicationList("builder:latest", []string{"A=b"}, []string{"foo-db-binding"})
+	assert.Nil(t, err)
+
+	var list struct {
+		Kind  string
+		Items []struct {
+			Kind string
+			Spec map[string]interface{}
+		}
+	}
+	assert.Nil(t, json.Unmarshal(manifest, &list))
+	assert.Equal(t, "List", list.Kind)
+	var kinds []string
+	for _, item := range list.Items {
+		kinds = append(kinds, item.Kind)
+	}
+	assert.Equal(t, []string{"ImageStream", "BuildConfig", "DeploymentConfig", "Service", "Route"}, kinds)
+	assert.Contains(t, string(manifest), `"name": "BUILDPACK_URL"`)
+	assert.Contains(t, string(manifest), `"type": "ImageChange"`)
+}
+
+func TestBatchableOnlyForNewApps(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.On("Exists", "bc", "foo").Return(false, nil)
+	oc.On("Exists", "dc", "foo").Return(false, nil)
+	oc.On("Exists", "deployment", "foo").Return(false, nil)
+	oc.On("Exists", "bc", "bar").Return(true, nil)
+
+	assert.True(t, (&Application{oc: oc, Name: "foo"}).batchable())
+	assert.False(t, (&Application{oc: oc, Name: "bar"}).batchable())
+	assert.False(t, (&Application{oc: oc, Name: "foo", Docker: &Docker{Image: "nginx"}}).batchable())
+	assert.False(t, (&Application{oc: oc, Name: "foo", Routes: []Route{{Route: "foo.example.com"}}}).batchable())
+	// Sidecars are added by the steps after the apply
+	assert.True(t, (&Application{oc: oc, Name: "foo", Sidecars: []Sidecar{{Name: "proxy", Command: "proxy"}}}).batchable())
+}
+
+func TestApplyNewApplicationHonorsDomain(t *testing.T) {
+	oc := mocks.NewMockOc()
+	var manifest []byte
+	oc.On("Apply", mock.Anything).Run(func(args mock.Arguments) {
+		manifest = args.Get(0).([]byte)
+	}).Return(nil)
+	app := Application{oc: oc, Name: "foo", options: PushOptions{Domain: "apps.example.com"}}
+
+	assert.Nil(t, app.applyNewApplication("builder:latest"))
+	var list struct {
+		Items []struct {
+			Kind string
+			Spec map[string]interface{}
+		}
+	}
+	assert.Nil(t, json.Unmarshal(manifest, &list))
+	route := list.Items[len(list.Items)-1]
+	assert.Equal(t, "Route", route.Kind)
+	assert.Equal(t, "foo.apps.example.com", route.Spec["host"])
+}
@@ -0,0 +1,259 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// UserProvidedLabel is the label of services bound from existing
+// secrets or config maps, matching Cloud Foundry's label for
+// user-provided services.
+const UserProvidedLabel string = "user-provided"
+
+// uriSchemes maps service labels to the scheme of their connection
+// URIs.
+var uriSchemes = map[string]string{
+	"postgresql": "postgres",
+	"mysql":      "mysql",
+	"mongodb":    "mongodb",
+}
+
+var invalidEnvChars = regexp.MustCompile("[^A-Za-z0-9_]")
+
+// serviceKind returns the kind of object backing a service: "dc" for
+// a database deployed alongside the app, or "secret" or "configmap"
+// for existing credentials. Kubernetes has no database templates, so
+// only secrets and config maps are services there.
+func (app *Application) serviceKind(service string) (string, error) {
+	kind, err := app.findServiceKind(service)
+	if err != nil {
+		return "", err
+	}
+	if kind == "" {
+		return "", errors.New(fmt.Sprintf("Error: Service %s not found\n", service))
+	}
+	return kind, nil
+}
+
+// findServiceKind is serviceKind, but returns an empty kind rather
+// than an error when no object backs the service, so callers can tell
+// a missing service from a failure to look it up.
+func (app *Application) findServiceKind(service string) (string, error) {
+	kinds := []string{"dc", "secret", "configmap"}
+	if app.kubernetes() {
+		kinds = kinds[1:]
+	}
+	for _, kind := range kinds {
+		exists, err := app.oc.Exists(app.context(), kind, service)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return kind, nil
+		}
+	}
+	return "", nil
+}
+
+// serviceNameEnv returns the name of the environment variable
+// recording the name of the service bound with envPrefix, which
+// can't be recovered from the prefix itself.
+func serviceNameEnv(envPrefix string) string {
+	return fmt.Sprint(envPrefix, "_SERVICE_NAME")
+}
+
+// BindServiceToBuild makes a service bound with BindService available
+// to the application's builds too, for buildpacks that need it while
+// staging, like ones running database migrations.
+func (app *Application) BindServiceToBuild(service string, parameters string) error {
+	app.setupDefaults()
+	exists, err := app.oc.Exists(app.context(), "bc", app.Name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New(fmt.Sprintf("Error: Application %s has no build to bind %s to\n", app.Name, service))
+	}
+
+	envPrefix := envPrefixFromService(service)
+	serviceKind, err := app.serviceKind(service)
+	if err != nil {
+		return err
+	}
+	source := fmt.Sprint("secret/", app.bindingSecretName(envPrefix))
+	prefix := ""
+	env := make(map[string]string)
+	if serviceKind != "dc" {
+		source = fmt.Sprint(serviceKind, "/", service)
+		prefix = fmt.Sprint(envPrefix, "_")
+		env = withBindingParameters(map[string]string{
+			fmt.Sprint(envPrefix, "_LABEL"): UserProvidedLabel,
+		}, envPrefix, parameters)
+	}
+	log.Infof("Binding %s to the builds of %s", service, app.Name)
+	return app.oc.SetEnvFrom(app.context(), "bc", app.Name, source, prefix, env)
+}
+
+// UnbindServiceFromBuild removes a service bound with
+// BindServiceToBuild from the application's builds. It does nothing if
+// the service was only bound at runtime.
+func (app *Application) UnbindServiceFromBuild(service string) error {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	if app.kubernetes() {
+		return nil
+	}
+	exists, err := app.oc.Exists(app.context(), "bc", app.Name)
+	if err != nil || !exists {
+		return err
+	}
+	names, err := app.oc.EnvNames(app.context(), "bc", app.Name)
+	if err != nil {
+		return err
+	}
+	envPrefix := fmt.Sprint(envPrefixFromService(service), "_")
+	env := make(map[string]string)
+	for _, name := range names {
+		if strings.HasPrefix(name, envPrefix) {
+			env[name] = "-"
+		}
+	}
+	if len(env) == 0 {
+		return nil
+	}
+	return app.oc.SetEnv(app.context(), "bc", app.Name, env)
+}
+
+// serviceURI builds a connection URI, like the uri credential Cloud
+// Foundry services provide in VCAP_SERVICES, from the service's
+// cluster address and credentials. Services with an unknown label
+// have no URI.
+func (app *Application) serviceURI(service string, label string, user string, password string, database string) (string, error) {
+	scheme, ok := uriSchemes[label]
+	if !ok {
+		return "", nil
+	}
+	address, err := app.oc.ServiceAddress(app.context(), service)
+	if err != nil {
+		return "", err
+	}
+	uri := url.URL{
+		Scheme: scheme,
+		Host:   address,
+		Path:   fmt.Sprint("/", database),
+	}
+	if user != "" {
+		uri.User = url.UserPassword(user, password)
+	}
+	return uri.String(), nil
+}
+
+// withBindingParameters adds the JSON binding parameters, if any, to
+// a binding's environment as <PREFIX>_PARAMETERS.
+func withBindingParameters(env map[string]string, envPrefix string, parameters string) map[string]string {
+	if parameters != "" {
+		env[fmt.Sprint(envPrefix, "_PARAMETERS")] = parameters
+	}
+	return env
+}
+
+// envNameFromKey returns the name of the environment variable that
+// references key when a secret or config map is bound with envPrefix.
+func envNameFromKey(envPrefix string, key string) string {
+	return strings.ToUpper(fmt.Sprint(envPrefix, "_", invalidEnvChars.ReplaceAllString(key, "_")))
+}
+
+// bindingSecretName returns the name of the secret holding the
+// credentials for the service with the given env prefix.
+func (app *Application) bindingSecretName(envPrefix string) string {
+	name := fmt.Sprint(app.Name, "-", envPrefix, "-binding")
+	return strings.ToLower(strings.Replace(name, "_", "-", -1))
+}
+
+// ensureBindingSecret stores a service binding's credentials in a
+// secret, replacing any previous secret for the same binding, so they
+// aren't visible as plain environment variables on the deployment.
+func (app *Application) ensureBindingSecret(envPrefix string, env map[string]string) (string, error) {
+	secretName := app.bindingSecretName(envPrefix)
+	return secretName, app.replaceSecret(secretName, env)
+}
+
+// replaceSecret creates the secret secretName holding data, deleting
+// any previous secret of the same name first.
+func (app *Application) replaceSecret(secretName string, data map[string]string) error {
+	exists, err := app.oc.Exists(app.context(), "secret", secretName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		err = app.oc.Delete(app.context(), "secret", secretName)
+		if err != nil {
+			return err
+		}
+	}
+	return app.oc.CreateSecret(app.context(), secretName, data)
+}
+
+// MigrateServiceBindings moves the credentials of services bound
+// before binding secrets existed out of plain environment variables
+// and into a binding secret.
+func (app *Application) MigrateServiceBindings() error {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+
+	appExists, err := app.deploymentExists()
+	if err != nil {
+		return err
+	}
+	if !appExists {
+		return errors.New(fmt.Sprintf("Error: Application %s not found\n", app.Name))
+	}
+
+	appEnv, err := app.oc.Env(app.context(), app.workloadKind(), app.Name)
+	if err != nil {
+		return err
+	}
+
+	var migrated int
+	for _, envPrefix := range strings.Fields(appEnv[BoundServices]) {
+		plainEnv := make(map[string]string)
+		for key, value := range appEnv {
+			// The service name stays plain so purge-service-bindings
+			// can read it
+			if key == serviceNameEnv(envPrefix) {
+				continue
+			}
+			if strings.HasPrefix(key, fmt.Sprint(envPrefix, "_")) {
+				plainEnv[key] = value
+			}
+		}
+		if len(plainEnv) == 0 {
+			continue
+		}
+
+		secretName, err := app.ensureBindingSecret(envPrefix, plainEnv)
+		if err != nil {
+			return err
+		}
+		// Setting the same variable names from the secret replaces
+		// their plain values
+		err = app.oc.SetEnvFrom(app.context(), app.workloadKind(), app.Name, fmt.Sprint("secret/", secretName), "", nil)
+		if err != nil {
+			return err
+		}
+		migrated++
+	}
+
+	log.Infof("Migrated %d service binding(s) for %s", migrated, app.Name)
+	return nil
+}
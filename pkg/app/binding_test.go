@@ -0,0 +1,195 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestBindingSecretName(t *testing.T) {
+	app := Application{Name: "my-app"}
+	assert.Equal(t, "my-app-rails-postgres-binding", app.bindingSecretName("RAILS_POSTGRES"))
+}
+
+func TestEnvNameFromKey(t *testing.T) {
+	assert.Equal(t, "MY_SECRET_API_KEY", envNameFromKey("MY_SECRET", "api-key"))
+	assert.Equal(t, "MY_SECRET_TLS_CRT", envNameFromKey("MY_SECRET", "tls.crt"))
+}
+
+func TestBindServiceFromSecret(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	oc.On("Exists", "dc", "foo").Return(true, nil)
+	oc.On("Exists", "dc", "my-secret").Return(false, nil)
+	oc.On("Exists", "secret", "my-secret").Return(true, nil)
+	oc.On("Env", "dc", "foo").Return(map[string]string{}, nil).Once()
+	boundEnv := map[string]string{
+		BoundServices:            "MY_SECRET",
+		"MY_SECRET_LABEL":        UserProvidedLabel,
+		"MY_SECRET_SERVICE_NAME": "my-secret",
+	}
+	oc.On("SetEnvFrom", "dc", "foo", "secret/my-secret", "MY_SECRET_", boundEnv).Return(nil)
+
+	// The secret's data are the credentials in VCAP_SERVICES
+	oc.On("Env", "dc", "foo").Return(boundEnv, nil)
+	oc.On("Exists", "secret", "foo-my-secret-binding").Return(false, nil)
+	oc.On("Data", "secret", "my-secret").Return(map[string]string{"api-key": "xyz"}, nil)
+	oc.On("Exists", "secret", "foo-vcap-services").Return(false, nil)
+	oc.On("CreateSecret", "foo-vcap-services", map[string]string{
+		VcapServices: `{"user-provided":[{"name":"my-secret","label":"user-provided","credentials":{"api-key":"xyz"}}]}`,
+	}).Return(nil)
+	oc.On("SetEnvFrom", "dc", "foo", "secret/foo-vcap-services", "", map[string]string(nil)).Return(nil)
+
+	err := app.BindService("my-secret", "")
+	assert.Nil(t, err)
+	oc.AssertExpectations(t)
+}
+
+func TestBindServiceWithParameters(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	oc.On("Exists", "dc", "foo").Return(true, nil)
+	oc.On("Exists", "dc", "my-db").Return(true, nil)
+	oc.On("Env", "dc", "foo").Return(map[string]string{}, nil).Once()
+	oc.On("Env", "dc", "my-db").Return(map[string]string{"MYSQL_USER": "bar"}, nil)
+	oc.On("ServiceAddress", "my-db").Return("172.30.1.3:3306", nil)
+	oc.On("Exists", "secret", "foo-my-db-binding").Return(false, nil).Once()
+	bindingSecret := map[string]string{
+		"MY_DB_USER":       "bar",
+		"MY_DB_LABEL":      "mysql",
+		"MY_DB_URI":        "mysql://bar:@172.30.1.3:3306/",
+		"MY_DB_PARAMETERS": `{"role":"readonly"}`,
+	}
+	oc.On("CreateSecret", "foo-my-db-binding", bindingSecret).Return(nil)
+	boundEnv := map[string]string{
+		BoundServices:        "MY_DB",
+		"MY_DB_SERVICE_NAME": "my-db",
+	}
+	oc.On("SetEnvFrom", "dc", "foo", "secret/foo-my-db-binding", "", boundEnv).Return(nil)
+
+	// The parameters are part of the credentials in VCAP_SERVICES
+	oc.On("Env", "dc", "foo").Return(boundEnv, nil)
+	oc.On("Exists", "secret", "foo-my-db-binding").Return(true, nil)
+	oc.On("Data", "secret", "foo-my-db-binding").Return(bindingSecret, nil)
+	oc.On("Exists", "secret", "foo-vcap-services").Return(false, nil)
+	oc.On("CreateSecret", "foo-vcap-services", map[string]string{
+		VcapServices: `{"mysql":[{"name":"my-db","label":"mysql","credentials":` +
+			`{"parameters":{"role":"readonly"},"uri":"mysql://bar:@172.30.1.3:3306/","user":"bar"}}]}`,
+	}).Return(nil)
+	oc.On("SetEnvFrom", "dc", "foo", "secret/foo-vcap-services", "", map[string]string(nil)).Return(nil)
+
+	err := app.BindService("my-db", `{"role":"readonly"}`)
+	assert.Nil(t, err)
+	oc.AssertExpectations(t)
+}
+
+func TestBindServiceNotFound(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	oc.On("Exists", "dc", "foo").Return(true, nil)
+	oc.On("Exists", "dc", "missing").Return(false, nil)
+	oc.On("Exists", "secret", "missing").Return(false, nil)
+	oc.On("Exists", "configmap", "missing").Return(false, nil)
+
+	err := app.BindService("missing", "")
+	assert.NotNil(t, err)
+}
+
+func TestUnbindServiceFromConfigMap(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	existingEnv := map[string]string{
+		BoundServices:     "MY_CONFIG",
+		"MY_CONFIG_LABEL": UserProvidedLabel,
+	}
+	oc.On("Exists", "dc", "foo").Return(true, nil)
+	oc.On("Env", "dc", "foo").Return(existingEnv, nil).Once()
+	oc.On("Env", "dc", "foo").Return(map[string]string{BoundServices: ""}, nil)
+	oc.On("Exists", "secret", "foo-vcap-services").Return(false, nil)
+	oc.On("Exists", "secret", "foo-my-config-binding").Return(false, nil)
+	oc.On("Exists", "dc", "my-config").Return(false, nil)
+	oc.On("Exists", "secret", "my-config").Return(false, nil)
+	oc.On("Exists", "configmap", "my-config").Return(true, nil)
+	oc.On("DataKeys", "configmap", "my-config").Return([]string{"log.level"}, nil)
+	oc.On("SetEnv", "dc", "foo", map[string]string{
+		BoundServices:         "",
+		"MY_CONFIG_LABEL":     "-",
+		"MY_CONFIG_LOG_LEVEL": "-",
+	}).Return(nil)
+
+	err := app.UnbindService("my-config")
+	assert.Nil(t, err)
+	oc.AssertExpectations(t)
+}
+
+func TestBindServiceToBuild(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	oc.On("Exists", "bc", "foo").Return(true, nil)
+	oc.On("Exists", "dc", "my-db").Return(true, nil)
+	oc.On("SetEnvFrom", "bc", "foo", "secret/foo-my-db-binding", "", map[string]string{}).Return(nil)
+	assert.Nil(t, app.BindServiceToBuild("my-db", ""))
+
+	oc.On("Exists", "dc", "my-secret").Return(false, nil)
+	oc.On("Exists", "secret", "my-secret").Return(true, nil)
+	oc.On("SetEnvFrom", "bc", "foo", "secret/my-secret", "MY_SECRET_", map[string]string{
+		"MY_SECRET_LABEL": UserProvidedLabel,
+	}).Return(nil)
+	assert.Nil(t, app.BindServiceToBuild("my-secret", ""))
+	oc.AssertExpectations(t)
+}
+
+func TestBindServiceToBuildWithoutBuild(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	oc.On("Exists", "bc", "foo").Return(false, nil)
+	assert.NotNil(t, app.BindServiceToBuild("my-db", ""))
+}
+
+func TestUnbindServiceFromBuild(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	oc.On("Exists", "bc", "foo").Return(true, nil)
+	oc.On("EnvNames", "bc", "foo").Return([]string{"BUILDPACK_URL", "MY_DB_USER", "MY_DB_PASSWORD", "MY_DBX_USER"}, nil)
+	oc.On("SetEnv", "bc", "foo", map[string]string{"MY_DB_USER": "-", "MY_DB_PASSWORD": "-"}).Return(nil)
+	assert.Nil(t, app.UnbindServiceFromBuild("my-db"))
+
+	// Services only bound at runtime leave the build alone
+	assert.Nil(t, app.UnbindServiceFromBuild("other"))
+	oc.AssertNumberOfCalls(t, "SetEnv", 1)
+}
+
+func TestMigrateServiceBindings(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	existingEnv := map[string]string{
+		"FOO":                    "bar",
+		BoundServices:            "OLD_SERVICE NEW_SERVICE",
+		"OLD_SERVICE_USER":       "user",
+		"OLD_SERVICE_PASSWORD":   "pass",
+		"OLD_SERVICES_UNRELATED": "x",
+	}
+
+	oc.On("Exists", "dc", "foo").Return(true, nil)
+	oc.On("Env", "dc", "foo").Return(existingEnv, nil)
+	oc.On("Exists", "secret", "foo-old-service-binding").Return(false, nil)
+	oc.On("CreateSecret", "foo-old-service-binding", map[string]string{
+		"OLD_SERVICE_USER":     "user",
+		"OLD_SERVICE_PASSWORD": "pass",
+	}).Return(nil)
+	oc.On("SetEnvFrom", "dc", "foo", "secret/foo-old-service-binding", "", map[string]string(nil)).Return(nil)
+
+	err := app.MigrateServiceBindings()
+	assert.Nil(t, err)
+	oc.AssertExpectations(t)
+}
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+var buildPollInterval = 5 * time.Second
+
+// BuildLogs prints the logs of one of the application's builds, such
+// as "3" or "my-app-3", or of its latest build if build is empty.
+func (app *Application) BuildLogs(build string, follow bool) error {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+	if app.kubernetes() {
+		return errors.New("Error: Build logs are not available on Kubernetes since images are built locally")
+	}
+
+	exists, err := app.oc.Exists(app.context(), "bc", app.Name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New(fmt.Sprintf("Error: Build configuration %s not found\n", app.Name))
+	}
+
+	logsCmd := app.oc.Exec(app.buildLogsArgs(build, follow)...)
+	logsCmd.AttachStdIO()
+	logsCmd.SetTimeout(0)
+	return logsCmd.Run(app.context())
+}
+
+func (app *Application) buildLogsArgs(build string, follow bool) []string {
+	target := fmt.Sprint("bc/", app.Name)
+	if build != "" {
+		if !strings.HasPrefix(build, fmt.Sprint(app.Name, "-")) {
+			build = fmt.Sprint(app.Name, "-", build)
+		}
+		target = fmt.Sprint("build/", build)
+	}
+	args := []string{"logs", target}
+	if follow {
+		args = append(args, "--follow")
+	}
+	return args
+}
+
+// startAsyncBuild starts a build without streaming its logs and polls
+// until it finishes.
+func (app *Application) startAsyncBuild(pathArg ...string) error {
+	startBuildCmd := app.oc.Exec(append(append([]string{"start-build", app.Name}, pathArg...), "-o", "name")...)
+	log.Infof("Starting build with command: %s", startBuildCmd.ArgsString())
+	output, err := startBuildCmd.CombinedOutput(app.context())
+	if err != nil {
+		return withOutput(output, err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	build := lines[len(lines)-1]
+	build = build[strings.LastIndex(build, "/")+1:]
+	log.Infof("Build %s started. Run 'ocf build-logs %s --build %s' to see its logs", build, app.Name, build)
+
+	err = app.waitForBuild(build)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// waitForBuild polls a build's phase until it completes, returning an
+// error if it doesn't succeed.
+func (app *Application) waitForBuild(build string) error {
+	var lastPhase string
+	for {
+		status := &types.Build{}
+		err := oc.Get(app.context(), app.oc, "build", build, status)
+		if err != nil {
+			return err
+		}
+		phase := status.Status.Phase
+		if phase != lastPhase {
+			log.Infof("Build %s is %s", build, phase)
+			lastPhase = phase
+		}
+		switch phase {
+		case "Complete":
+			return nil
+		case "Failed", "Error", "Cancelled":
+			return errors.New(fmt.Sprintf("Error: Build %s %s. Run 'ocf build-logs %s --build %s' to see why", build, strings.ToLower(phase), app.Name, build))
+		}
+		time.Sleep(buildPollInterval)
+	}
+}
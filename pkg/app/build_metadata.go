@@ -0,0 +1,78 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// buildMetadataKeys maps what's recorded about each push to the
+// annotation and environment variable it's recorded in.
+var buildMetadataKeys = []struct {
+	annotation string
+	env        string
+}{
+	{"ocf/git-commit", "OCF_GIT_COMMIT"},
+	{"ocf/git-branch", "OCF_GIT_BRANCH"},
+	{"ocf/app-version", "OCF_APP_VERSION"},
+	{"ocf/build-time", "OCF_BUILD_TIME"},
+	{"ocf/pushed-by", "OCF_PUSHED_BY"},
+}
+
+// buildMetadata returns the source and pusher of a push made at now,
+// in the order of buildMetadataKeys. Git values are empty when the
+// source isn't in a Git repository.
+func (app *Application) buildMetadata(now time.Time) []string {
+	commit := app.gitSHA()
+	var branch, version string
+	if commit != "" {
+		branch = app.git("rev-parse", "--abbrev-ref", "HEAD")
+		if branch == "HEAD" {
+			// Detached
+			branch = ""
+		}
+		version = app.git("describe", "--tags", "--always", "--dirty")
+	}
+	return []string{commit, branch, version, now.UTC().Format(time.RFC3339), app.pusher()}
+}
+
+// ensureBuildMetadata records where the pushed application came from
+// on its deployment config or deployment, and in its instances'
+// environment, so running applications can be traced to their source.
+func (app *Application) ensureBuildMetadata() error {
+	if app.options.NoBuildMetadata {
+		return nil
+	}
+	annotations := make(map[string]string)
+	var env []map[string]string
+	for i, value := range app.buildMetadata(time.Now()) {
+		annotations[buildMetadataKeys[i].annotation] = value
+		env = append(env, map[string]string{"name": buildMetadataKeys[i].env, "value": value})
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"annotations": annotations},
+				"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{
+						"name": app.Name,
+						"env":  env,
+					}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("Recording build metadata of %s", app.Name)
+	output, err := app.oc.Exec("patch", app.workloadKind(), app.Name, "-p", string(patch)).CombinedOutput(app.context())
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error recording the build metadata of %s: %s\n", app.Name, output))
+	}
+	return nil
+}
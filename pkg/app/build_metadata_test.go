@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func gitCommand(execer *mocks.Execer, output string, err error, args ...string) {
+	cmd := &mocks.ExecCmd{}
+	cmd.On("CombinedOutput").Return([]byte(output), err)
+	execer.On("Command", "git", append([]string{"-C", "/src/foo"}, args...)).Return(cmd)
+}
+
+func TestBuildMetadata(t *testing.T) {
+	oc := mocks.NewMockOc()
+	execer := &mocks.Execer{}
+	whoamiCmd := &mocks.ExecCmd{}
+	whoamiCmd.On("CombinedOutput").Return([]byte("bob\n"), nil)
+	oc.Execer.On("Oc", []string{"whoami"}).Return(whoamiCmd)
+	gitCommand(execer, "0123456789abcdef\n", nil, "rev-parse", "HEAD")
+	gitCommand(execer, "main\n", nil, "rev-parse", "--abbrev-ref", "HEAD")
+	gitCommand(execer, "v1.2.0-3-g0123456\n", nil, "describe", "--tags", "--always", "--dirty")
+
+	app := Application{oc: oc, execer: execer, Name: "foo", Path: "/src/foo"}
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	assert.Equal(t, []string{"0123456789abcdef", "main", "v1.2.0-3-g0123456", "2024-01-02T15:04:05Z", "bob"},
+		app.buildMetadata(now))
+}
+
+func TestBuildMetadataOutsideGit(t *testing.T) {
+	oc := mocks.NewMockOc()
+	execer := &mocks.Execer{}
+	whoamiCmd := &mocks.ExecCmd{}
+	whoamiCmd.On("CombinedOutput").Return([]byte("bob\n"), nil)
+	oc.Execer.On("Oc", []string{"whoami"}).Return(whoamiCmd)
+	gitCommand(execer, "fatal: not a git repository", errors.New("exit status 128"), "rev-parse", "HEAD")
+
+	app := Application{oc: oc, execer: execer, Name: "foo", Path: "/src/foo"}
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	assert.Equal(t, []string{"", "", "", "2024-01-02T15:04:05Z", "bob"}, app.buildMetadata(now))
+	execer.AssertNumberOfCalls(t, "Command", 1)
+}
+
+func TestEnsureBuildMetadata(t *testing.T) {
+	oc := mocks.NewMockOc()
+	execer := &mocks.Execer{}
+	whoamiCmd := &mocks.ExecCmd{}
+	whoamiCmd.On("CombinedOutput").Return([]byte("bob\n"), nil)
+	oc.Execer.On("Oc", []string{"whoami"}).Return(whoamiCmd)
+	gitCommand(execer, "", errors.New("exit status 128"), "rev-parse", "HEAD")
+	patchCmd := &mocks.ExecCmd{}
+	patchCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", mock.MatchedBy(func(args []string) bool {
+		return len(args) == 5 && args[0] == "patch" && args[1] == "dc" && args[2] == "foo" &&
+			strings.Contains(args[4], `"ocf/pushed-by":"bob"`) && strings.Contains(args[4], `{"name":"OCF_PUSHED_BY","value":"bob"}`)
+	})).Return(patchCmd)
+
+	app := Application{oc: oc, execer: execer, Name: "foo", Path: "/src/foo", kind: "dc"}
+	assert.Nil(t, app.ensureBuildMetadata())
+	oc.Execer.AssertExpectations(t)
+
+	// Opting out patches nothing
+	app = Application{oc: mocks.NewMockOc(), Name: "foo", kind: "dc"}
+	app.options.NoBuildMetadata = true
+	assert.Nil(t, app.ensureBuildMetadata())
+}
+
+func TestBatchedPushRecordsBuildMetadata(t *testing.T) {
+	oc := mocks.NewMockOc()
+	execer := &mocks.Execer{}
+	whoamiCmd := &mocks.ExecCmd{}
+	whoamiCmd.On("CombinedOutput").Return([]byte("bob\n"), nil)
+	oc.Execer.On("Oc", []string{"whoami"}).Return(whoamiCmd)
+	gitCommand(execer, "", errors.New("exit status 128"), "rev-parse", "HEAD")
+	patchCmd := &mocks.ExecCmd{}
+	patchCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", mock.MatchedBy(func(args []string) bool {
+		return len(args) == 5 && args[0] == "patch" && strings.Contains(args[4], `"ocf/pushed-by":"bob"`)
+	})).Return(patchCmd)
+
+	app := Application{oc: oc, execer: execer, Name: "foo", Path: "/src/foo", kind: "dc"}
+	var steps []Step
+	for _, step := range app.deploySteps() {
+		if step.Name() == "deployment" || step.Name() == "build-metadata" {
+			steps = append(steps, step)
+		}
+	}
+	pipeline := &Pipeline{Steps: steps}
+	assert.Nil(t, pipeline.Run(context.Background(), &PushState{App: &app, batched: true}))
+	oc.Execer.AssertExpectations(t)
+}
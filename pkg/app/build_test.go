@@ -0,0 +1,49 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestBuildLogsArgs(t *testing.T) {
+	app := Application{Name: "foo"}
+	assert.Equal(t, []string{"logs", "bc/foo"}, app.buildLogsArgs("", false))
+	assert.Equal(t, []string{"logs", "build/foo-3", "--follow"}, app.buildLogsArgs("3", true))
+	assert.Equal(t, []string{"logs", "build/foo-3"}, app.buildLogsArgs("foo-3", false))
+}
+
+func TestWaitForBuild(t *testing.T) {
+	original := buildPollInterval
+	buildPollInterval = time.Millisecond
+	defer func() { buildPollInterval = original }()
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	runningCmd := &mocks.ExecCmd{}
+	runningCmd.On("CombinedOutput").Return([]byte(`{"status": {"phase": "Running"}}`), nil)
+	completeCmd := &mocks.ExecCmd{}
+	completeCmd.On("CombinedOutput").Return([]byte(`{"status": {"phase": "Complete"}}`), nil)
+	args := []string{"get", "build", "foo-2", "-o", "json"}
+	oc.Execer.On("Oc", args).Return(runningCmd).Once()
+	oc.Execer.On("Oc", args).Return(completeCmd)
+
+	assert.Nil(t, app.waitForBuild("foo-2"))
+	oc.Execer.AssertExpectations(t)
+}
+
+func TestWaitForFailedBuild(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	failedCmd := &mocks.ExecCmd{}
+	failedCmd.On("CombinedOutput").Return([]byte(`{"status": {"phase": "Failed"}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "build", "foo-2", "-o", "json"}).Return(failedCmd)
+
+	err := app.waitForBuild("foo-2")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "build-logs foo --build foo-2")
+}
@@ -0,0 +1,142 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// DefaultImage is the builder image used when neither --image nor a
+// builders config picks one.
+const DefaultImage string = "bbrowning/openshift-cloudfoundry-docker19"
+
+// Builders maps applications to the builder images used to build and
+// run them, so teams can pin images per language or stack centrally
+// instead of passing --image to every push. A builders config looks
+// like:
+//
+//	languages:
+//	  java: quay.io/myorg/java-builder:11
+//	  node: quay.io/myorg/node-builder:18
+//	stacks:
+//	  cflinuxfs4: quay.io/myorg/cflinuxfs4-builder
+//	placements:
+//	  windows2019:
+//	    node-selector:
+//	      kubernetes.io/os: windows
+//	    tolerations:
+//	    - key: os
+//	      value: windows
+//	      effect: NoSchedule
+type Builders struct {
+	// Languages maps a language detected from the application's
+	// files, such as "java" or "node", to a builder image
+	Languages map[string]string `json:"languages"`
+	// Stacks maps a manifest's stack, such as "cflinuxfs3", to a
+	// builder image
+	Stacks map[string]string `json:"stacks"`
+	// Placements maps a manifest's stack to the nodes its
+	// applications run on, for those without a node selector or
+	// tolerations of their own
+	Placements map[string]Placement `json:"placements"`
+}
+
+// LoadBuilders reads a builders config from path.
+func LoadBuilders(path string) (*Builders, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	builders := &Builders{}
+	err = yaml.Unmarshal(contents, builders)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error parsing builders config %s: %v", path, err))
+	}
+	return builders, nil
+}
+
+// Image returns the builder image for app, preferring one mapped to
+// its stack over one mapped to its detected language, and falling back
+// to defaultImage.
+func (builders *Builders) Image(app *Application, defaultImage string) string {
+	if builders == nil {
+		return defaultImage
+	}
+	if image, ok := builders.Stacks[app.Stack]; ok && app.Stack != "" {
+		return image
+	}
+	if image, ok := builders.Languages[DetectLanguage(app.Path)]; ok {
+		return image
+	}
+	return defaultImage
+}
+
+// linuxStacks are the Cloud Foundry stacks DefaultImage can stand in
+// for.
+var linuxStacks = map[string]bool{
+	"cflinuxfs2": true,
+	"cflinuxfs3": true,
+	"cflinuxfs4": true,
+}
+
+// CheckStack returns an error if app's stack has no builder image,
+// either from the builders config or because DefaultImage supports
+// it.
+func (builders *Builders) CheckStack(app *Application) error {
+	if app.Stack == "" || linuxStacks[app.Stack] {
+		return nil
+	}
+	if builders != nil {
+		if _, ok := builders.Stacks[app.Stack]; ok {
+			return nil
+		}
+	}
+	if strings.HasPrefix(app.Stack, "windows") {
+		return errors.New(fmt.Sprintf("Error: Application %s uses the %s stack, but Windows applications are not supported. Map the stack to a Windows builder image in a builders config to push it", app.Name, app.Stack))
+	}
+	var stacks []string
+	for stack := range linuxStacks {
+		stacks = append(stacks, stack)
+	}
+	sort.Strings(stacks)
+	return errors.New(fmt.Sprintf("Error: Application %s uses the unsupported stack %s, must be one of %s or mapped to a builder image in a builders config", app.Name, app.Stack, strings.Join(stacks, ", ")))
+}
+
+// languageMarkers lists, in order of precedence, the files whose
+// presence identifies an application's language. Patterns are matched
+// against the top level of the application directory.
+var languageMarkers = []struct {
+	language string
+	patterns []string
+}{
+	{"java", []string{"pom.xml", "build.gradle", "build.gradle.kts", "*.jar", "*.war"}},
+	{"ruby", []string{"Gemfile"}},
+	{"node", []string{"package.json"}},
+	{"go", []string{"go.mod", "Godeps", "glide.yaml", "*.go"}},
+	{"python", []string{"requirements.txt", "setup.py", "Pipfile"}},
+	{"php", []string{"composer.json"}},
+}
+
+// DetectLanguage guesses an application's language from the files in
+// dir, returning an empty string if dir isn't a directory or nothing
+// matches.
+func DetectLanguage(dir string) string {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return ""
+	}
+	for _, marker := range languageMarkers {
+		for _, pattern := range marker.patterns {
+			matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+			if len(matches) > 0 {
+				return marker.language
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocf-builders")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.Equal(t, "", DetectLanguage(dir))
+	ioutil.WriteFile(filepath.Join(dir, "package.json"), []byte("{}"), 0644)
+	assert.Equal(t, "node", DetectLanguage(dir))
+	ioutil.WriteFile(filepath.Join(dir, "app.jar"), []byte(""), 0644)
+	assert.Equal(t, "java", DetectLanguage(dir))
+	assert.Equal(t, "", DetectLanguage(filepath.Join(dir, "app.jar")))
+}
+
+func TestCheckStack(t *testing.T) {
+	var noBuilders *Builders
+	assert.Nil(t, noBuilders.CheckStack(&Application{}))
+	assert.Nil(t, noBuilders.CheckStack(&Application{Stack: "cflinuxfs3"}))
+
+	err := noBuilders.CheckStack(&Application{Name: "foo", Stack: "windows2016"})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Windows applications are not supported")
+
+	err = noBuilders.CheckStack(&Application{Name: "foo", Stack: "bogus"})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "must be one of cflinuxfs2, cflinuxfs3, cflinuxfs4")
+
+	builders := &Builders{Stacks: map[string]string{"windows2016": "windows-builder"}}
+	assert.Nil(t, builders.CheckStack(&Application{Stack: "windows2016"}))
+}
+
+func TestBuildersImage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocf-builders")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "Gemfile"), []byte(""), 0644)
+
+	config := filepath.Join(dir, "builders.yml")
+	ioutil.WriteFile(config, []byte("languages:\n  ruby: ruby-builder\nstacks:\n  cflinuxfs4: fs4-builder\n"), 0644)
+	builders, err := LoadBuilders(config)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "ruby-builder", builders.Image(&Application{Path: dir}, DefaultImage))
+	assert.Equal(t, "fs4-builder", builders.Image(&Application{Path: dir, Stack: "cflinuxfs4"}, DefaultImage))
+	assert.Equal(t, DefaultImage, builders.Image(&Application{Path: filepath.Join(dir, "missing")}, DefaultImage))
+
+	var noBuilders *Builders
+	assert.Equal(t, DefaultImage, noBuilders.Image(&Application{Path: dir}, DefaultImage))
+}
@@ -0,0 +1,328 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+const (
+	// StrategyRolling replaces the application's instances with the
+	// new version
+	StrategyRolling string = "rolling"
+	// StrategyCanary runs the new version alongside the old one and
+	// sends CanaryWeight percent of its traffic there until it's
+	// promoted or aborted
+	StrategyCanary string = "canary"
+)
+
+// DefaultCanaryWeight is the percentage of traffic a canary gets.
+const DefaultCanaryWeight = 10
+
+// canaryLabel marks a canary's objects with the application they're a
+// canary of.
+const canaryLabel = "ocf/canary"
+
+func (app *Application) canary() bool {
+	return app.options.Strategy == StrategyCanary
+}
+
+// canaryName is the name of the canary's deployment and service.
+func (app *Application) canaryName() string {
+	return fmt.Sprint(app.Name, "-canary")
+}
+
+// canarySteps returns the steps that deploy the new version as a
+// canary instead of replacing the running one.
+func (app *Application) canarySteps() []Step {
+	return []Step{
+		appStep("canary-deployment", (*Application).ensureCanaryDeployment),
+		appStep("canary-service", (*Application).ensureCanaryService),
+		appStep("canary-routes", func(app *Application) error {
+			return app.weighCanary(app.options.CanaryWeight)
+		}),
+	}
+}
+
+// pinStable points the running version at the image it runs now and
+// pauses its triggers, so building the canary doesn't replace it.
+func (app *Application) pinStable() error {
+	exists, err := app.deploymentExists()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New(fmt.Sprintf("Error: Application %s isn't running yet, so it can't have a canary. Push it without --strategy canary first\n", app.Name))
+	}
+	if app.IsDocker() {
+		// Docker images are only changed by pushes
+		return nil
+	}
+	image, err := app.latestImage()
+	if err != nil {
+		return err
+	}
+	workload := fmt.Sprint(app.workloadKind(), "/", app.Name)
+	output, err := app.oc.Exec("set", "triggers", workload, "--manual").CombinedOutput(app.context())
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error pausing the triggers of %s: %s\n", app.Name, output))
+	}
+	output, err = app.oc.Exec("set", "image", workload, fmt.Sprint(app.Name, "=", image)).CombinedOutput(app.context())
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error pinning the image of %s: %s\n", app.Name, output))
+	}
+	return nil
+}
+
+// latestImage returns the image the application's build last
+// produced, by digest so it can't change underneath us.
+func (app *Application) latestImage() (string, error) {
+	output, err := app.oc.Exec("get", "istag", fmt.Sprint(app.Name, ":latest"),
+		"-o", "jsonpath={.image.dockerImageReference}").CombinedOutput(app.context())
+	image := strings.TrimSpace(string(output))
+	if err != nil || image == "" {
+		return "", errors.New(fmt.Sprintf("Error getting the latest image of %s: %s\n", app.Name, output))
+	}
+	return image, nil
+}
+
+func (app *Application) ensureCanaryDeployment() error {
+	image := ""
+	if app.IsDocker() {
+		image = app.Docker.Image
+	} else {
+		latest, err := app.latestImage()
+		if err != nil {
+			return err
+		}
+		image = latest
+	}
+	env, secretNames, err := app.envForServiceBindings()
+	if err != nil {
+		return err
+	}
+	labels := map[string]string{"app": app.canaryName(), canaryLabel: app.Name}
+	manifest, err := app.workloadManifest(app.canaryName(), labels, image, env, secretNames)
+	if err != nil {
+		return err
+	}
+	log.Infof("Deploying canary %s", app.canaryName())
+	err = app.oc.Apply(app.context(), manifest)
+	if err != nil {
+		return err
+	}
+	app.ownObject("deployment", app.canaryName())
+	return nil
+}
+
+func (app *Application) ensureCanaryService() error {
+	service := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata": map[string]interface{}{
+			"name":   app.canaryName(),
+			"labels": map[string]string{"app": app.canaryName(), canaryLabel: app.Name},
+		},
+		"spec": map[string]interface{}{
+			"selector": map[string]string{"app": app.canaryName()},
+			"ports": []interface{}{map[string]interface{}{
+				"name":       fmt.Sprint(app.servicePort(), "-tcp"),
+				"port":       app.servicePort(),
+				"targetPort": app.servicePort(),
+			}},
+		},
+	}
+	manifest, err := json.Marshal(service)
+	if err != nil {
+		return err
+	}
+	err = app.oc.Apply(app.context(), manifest)
+	if err != nil {
+		return err
+	}
+	app.ownObject("svc", app.canaryName())
+	return nil
+}
+
+// serviceRoutes returns the names of the routes sending traffic to
+// the application's service.
+func (app *Application) serviceRoutes() ([]string, error) {
+	list := &types.RouteList{}
+	err := oc.GetSelected(app.context(), app.oc, "route", fmt.Sprint("app=", app.Name), list)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, route := range list.Items {
+		if route.Spec.To.Name == app.Name {
+			names = append(names, route.Metadata.Name)
+		}
+	}
+	return names, nil
+}
+
+// weighCanary sends weight percent of the traffic of each of the
+// application's routes to its canary, or none and removes the canary
+// from them if weight is 0.
+func (app *Application) weighCanary(weight int) error {
+	routes, err := app.serviceRoutes()
+	if err != nil {
+		return err
+	}
+	if len(routes) == 0 && weight > 0 {
+		return errors.New(fmt.Sprintf("Error: Application %s has no routes to send canary traffic through\n", app.Name))
+	}
+	spec := map[string]interface{}{
+		"to":                map[string]interface{}{"weight": 100 - weight},
+		"alternateBackends": nil,
+	}
+	if weight > 0 {
+		spec["alternateBackends"] = []interface{}{map[string]interface{}{
+			"kind":   "Service",
+			"name":   app.canaryName(),
+			"weight": weight,
+		}}
+	}
+	patch, err := json.Marshal(map[string]interface{}{"spec": spec})
+	if err != nil {
+		return err
+	}
+	for _, route := range routes {
+		output, err := app.oc.Exec("patch", "route", route, "--type=merge", "-p", string(patch)).CombinedOutput(app.context())
+		if err != nil {
+			return errors.New(fmt.Sprintf("Error updating route %s: %s\n", route, output))
+		}
+	}
+	if weight > 0 {
+		log.Infof("Sending %d%% of %s's traffic to its canary. Run 'ocf promote %s' to finish the rollout or 'ocf abort %s' to roll it back", weight, app.Name, app.Name, app.Name)
+	}
+	return nil
+}
+
+// canaryImage returns the image the canary runs.
+func (app *Application) canaryImage() (string, error) {
+	output, err := app.oc.Exec("get", "deployment", app.canaryName(),
+		"-o", fmt.Sprintf("jsonpath={.spec.template.spec.containers[?(@.name==\"%s\")].image}", app.Name)).CombinedOutput(app.context())
+	image := strings.TrimSpace(string(output))
+	if err != nil || image == "" {
+		return "", errors.New(fmt.Sprintf("Error: Application %s has no canary: %s\n", app.Name, output))
+	}
+	return image, nil
+}
+
+// stableImage returns the image the running version is pinned to.
+func (app *Application) stableImage() (string, error) {
+	output, err := app.oc.Exec("get", app.workloadKind(), app.Name,
+		"-o", fmt.Sprintf("jsonpath={.spec.template.spec.containers[?(@.name==\"%s\")].image}", app.Name)).CombinedOutput(app.context())
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("Error getting the image of %s: %s\n", app.Name, output))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// Promote replaces the running version of the application with its
+// canary, then removes the canary.
+func (app *Application) Promote() error {
+	err := app.setupCanaryCommand()
+	if err != nil {
+		return err
+	}
+	image, err := app.canaryImage()
+	if err != nil {
+		return err
+	}
+	workload := fmt.Sprint(app.workloadKind(), "/", app.Name)
+	log.Infof("Promoting the canary of %s", app.Name)
+	output, err := app.oc.Exec("set", "image", workload, fmt.Sprint(app.Name, "=", image)).CombinedOutput(app.context())
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error promoting the canary of %s: %s\n", app.Name, output))
+	}
+	err = app.resumeTriggers()
+	if err != nil {
+		return err
+	}
+	err = app.waitForRollout()
+	if err != nil {
+		return err
+	}
+	return app.removeCanary()
+}
+
+// Abort removes the application's canary, leaving the running version
+// as it was.
+func (app *Application) Abort() error {
+	err := app.setupCanaryCommand()
+	if err != nil {
+		return err
+	}
+	if _, err = app.canaryImage(); err != nil {
+		return err
+	}
+	log.Infof("Aborting the canary of %s", app.Name)
+	err = app.removeCanary()
+	if err != nil {
+		return err
+	}
+	stable, err := app.stableImage()
+	if err != nil {
+		return err
+	}
+	if strings.Contains(stable, "@sha256:") {
+		// Point the build's tag back at the running version, or
+		// resuming its triggers would deploy the aborted canary
+		output, err := app.oc.Exec("tag", stable, fmt.Sprint(app.Name, ":latest")).CombinedOutput(app.context())
+		if err != nil {
+			return errors.New(fmt.Sprintf("Error restoring the image of %s: %s\n", app.Name, output))
+		}
+	}
+	return app.resumeTriggers()
+}
+
+func (app *Application) setupCanaryCommand() error {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+	if app.kubernetes() {
+		return errors.New("Error: Canaries need OpenShift routes, so aren't supported on Kubernetes")
+	}
+	exists, err := app.deploymentExists()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New(fmt.Sprintf("Error: Application %s not found\n", app.Name))
+	}
+	return nil
+}
+
+func (app *Application) resumeTriggers() error {
+	output, err := app.oc.Exec("set", "triggers", fmt.Sprint(app.workloadKind(), "/", app.Name), "--auto").CombinedOutput(app.context())
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error resuming the triggers of %s: %s\n", app.Name, output))
+	}
+	return nil
+}
+
+// removeCanary sends all traffic back to the application's own
+// service and deletes the canary.
+func (app *Application) removeCanary() error {
+	err := app.weighCanary(0)
+	if err != nil {
+		return err
+	}
+	for _, objType := range []string{"svc", "deployment"} {
+		err = app.oc.Delete(app.context(), objType, app.canaryName())
+		if err != nil {
+			return err
+		}
+		app.disown(objType, app.canaryName())
+	}
+	return nil
+}
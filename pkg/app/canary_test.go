@@ -0,0 +1,58 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func mockRoutes(oc *mocks.Oc) {
+	routesCmd := &mocks.ExecCmd{}
+	routesCmd.On("CombinedOutput").Return([]byte(`{"items":[
+		{"metadata":{"name":"foo"},"spec":{"to":{"name":"foo"}}},
+		{"metadata":{"name":"foo-canary"},"spec":{"to":{"name":"foo-canary"}}}]}`), nil)
+	oc.Execer.On("Oc", []string{"get", "route", "--selector=app=foo", "-o", "json"}).Return(routesCmd)
+}
+
+func TestWeighCanary(t *testing.T) {
+	oc := mocks.NewMockOc()
+	mockRoutes(oc)
+	patchCmd := &mocks.ExecCmd{}
+	patchCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", []string{"patch", "route", "foo", "--type=merge", "-p",
+		`{"spec":{"alternateBackends":[{"kind":"Service","name":"foo-canary","weight":10}],"to":{"weight":90}}}`}).Return(patchCmd)
+	oc.Execer.On("Oc", []string{"patch", "route", "foo", "--type=merge", "-p",
+		`{"spec":{"alternateBackends":null,"to":{"weight":100}}}`}).Return(patchCmd)
+
+	app := Application{oc: oc, Name: "foo"}
+	assert.Nil(t, app.weighCanary(10))
+	assert.Nil(t, app.weighCanary(0))
+	oc.Execer.AssertExpectations(t)
+}
+
+func TestPinStable(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.On("Exists", "dc", "foo").Return(true, nil)
+	istagCmd := &mocks.ExecCmd{}
+	istagCmd.On("CombinedOutput").Return([]byte("registry/test-project/foo@sha256:abc\n"), nil)
+	oc.Execer.On("Oc", []string{"get", "istag", "foo:latest", "-o", "jsonpath={.image.dockerImageReference}"}).Return(istagCmd)
+	okCmd := &mocks.ExecCmd{}
+	okCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", []string{"set", "triggers", "dc/foo", "--manual"}).Return(okCmd)
+	oc.Execer.On("Oc", []string{"set", "image", "dc/foo", "foo=registry/test-project/foo@sha256:abc"}).Return(okCmd)
+
+	app := Application{oc: oc, Name: "foo"}
+	assert.Nil(t, app.pinStable())
+	oc.Execer.AssertExpectations(t)
+}
+
+func TestPinStableWithoutApp(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.On("Exists", "dc", "foo").Return(false, nil)
+	oc.On("Exists", "deployment", "foo").Return(false, nil)
+
+	app := Application{oc: oc, Name: "foo"}
+	assert.NotNil(t, app.pinStable())
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+const (
+	// CleanupPrompt asks whether to delete what a failed first push
+	// created, keeping it when there's no one to ask
+	CleanupPrompt string = "prompt"
+	// CleanupAlways deletes what a failed first push created
+	CleanupAlways string = "always"
+	// CleanupNever keeps what a failed first push created
+	CleanupNever string = "never"
+)
+
+// cleanupIfCancelled deletes the objects this push created if it was
+// cancelled and cleanup was requested, so the next push starts fresh.
+func (app *Application) cleanupIfCancelled() {
+	if app.context().Err() == nil || !app.options.CleanupOnCancel {
+		return
+	}
+	app.deleteCreated()
+}
+
+// cleanupIfFailed deletes the objects a failed push created when it
+// was the application's first, so half-created builds and deployments
+// don't confuse the next attempt.
+func (app *Application) cleanupIfFailed() {
+	if app.context().Err() != nil || !app.createdApp() {
+		return
+	}
+	switch app.options.CleanupOnFailure {
+	case CleanupAlways:
+	case CleanupPrompt:
+		prompt := fmt.Sprintf("The first push of %s failed. Delete the %s it created? [y/N] ", app.Name, strings.Join(app.created, ", "))
+		if app.options.ConfirmCleanup == nil || !app.options.ConfirmCleanup(prompt) {
+			app.keepCreated()
+			return
+		}
+	default:
+		app.keepCreated()
+		return
+	}
+	app.deleteCreated()
+}
+
+// createdApp reports whether this push created the application rather
+// than updating one that was already there.
+func (app *Application) createdApp() bool {
+	for _, kind := range app.created {
+		switch kind {
+		case "bc", "dc", "deployment":
+			return true
+		}
+	}
+	return false
+}
+
+func (app *Application) keepCreated() {
+	var objects []string
+	for _, kind := range app.created {
+		objects = append(objects, fmt.Sprint(kind, "/", app.Name))
+	}
+	log.Warnf("Keeping the objects the failed push created. Delete them with 'oc delete %s' before pushing again if it fails the same way", strings.Join(objects, " "))
+}
+
+func (app *Application) deleteCreated() {
+	// Cleaning up after a cancelled push can't use the cancelled
+	// context
+	ctx := context.Background()
+	for i := len(app.created) - 1; i >= 0; i-- {
+		kind := app.created[i]
+		log.Infof("Deleting %s %s", kind, app.Name)
+		err := app.oc.Delete(ctx, kind, app.Name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		err = app.oc.Disown(ctx, app.Name, kind, app.Name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	app.created = nil
+}
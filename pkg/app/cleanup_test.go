@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestCleanupIfCancelled(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", created: []string{"bc", "is", "dc"}}
+	app.options.CleanupOnCancel = true
+
+	// Nothing is deleted while the push is still running
+	app.cleanupIfCancelled()
+	oc.AssertNotCalled(t, "Delete", "dc", "foo")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app.WithContext(ctx)
+	cancel()
+
+	oc.On("Delete", "dc", "foo").Return(nil).Once()
+	oc.On("Delete", "is", "foo").Return(nil).Once()
+	oc.On("Delete", "bc", "foo").Return(nil).Once()
+	app.cleanupIfCancelled()
+	oc.AssertExpectations(t)
+	assert.Empty(t, app.created)
+}
+
+func TestCleanupIfFailed(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", created: []string{"bc", "is"}}
+	app.options.CleanupOnFailure = CleanupPrompt
+	var prompts []string
+	app.options.ConfirmCleanup = func(prompt string) bool {
+		prompts = append(prompts, prompt)
+		return len(prompts) > 1
+	}
+
+	// Declining keeps what was created
+	app.cleanupIfFailed()
+	assert.Equal(t, []string{"The first push of foo failed. Delete the bc, is it created? [y/N] "}, prompts)
+	assert.Equal(t, []string{"bc", "is"}, app.created)
+
+	oc.On("Delete", "is", "foo").Return(nil).Once()
+	oc.On("Delete", "bc", "foo").Return(nil).Once()
+	app.cleanupIfFailed()
+	oc.AssertExpectations(t)
+	assert.Empty(t, app.created)
+}
+
+func TestCleanupIfFailedOnlyAfterFirstPush(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", created: []string{"route"}}
+	app.options.CleanupOnFailure = CleanupAlways
+
+	app.cleanupIfFailed()
+	oc.AssertNotCalled(t, "Delete", "route", "foo")
+	assert.Equal(t, []string{"route"}, app.created)
+}
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bbrowning/ocf/pkg/oc"
+)
+
+const (
+	// CompleteApps completes application names
+	CompleteApps string = "apps"
+	// CompleteServices completes backing service names, which run as
+	// deployment configs or deployments just like applications
+	CompleteServices string = "services"
+)
+
+// completionTimeout bounds how long a shell waits on the cluster
+// when completing names.
+var completionTimeout = 2 * time.Second
+
+// completionCacheTTL is how long completed names are reused, so
+// pressing tab repeatedly doesn't query the cluster each time.
+var completionCacheTTL = 30 * time.Second
+
+// completionCacheDir holds cached names, one file per project.
+var completionCacheDir = filepath.Join(os.TempDir(), fmt.Sprintf("ocf-completion-%d", os.Getuid()))
+
+type completionCache struct {
+	Time  time.Time `json:"time"`
+	Names []string  `json:"names"`
+}
+
+// CompletionNames returns the names of the given kind in the current
+// project for shell completion. It never prompts to log in.
+func CompletionNames(ctx context.Context, kind string) ([]string, error) {
+	if kind != CompleteApps && kind != CompleteServices {
+		return nil, errors.New(fmt.Sprintf("Error: Invalid completion %s, must be %s or %s", kind, CompleteApps, CompleteServices))
+	}
+	ctx, cancel := context.WithTimeout(ctx, completionTimeout)
+	defer cancel()
+	return completionNames(ctx, newOc(""))
+}
+
+func completionNames(ctx context.Context, o oc.Oc) ([]string, error) {
+	project, err := o.Project(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cacheFile := filepath.Join(completionCacheDir, fmt.Sprint(o.Platform(), "-", strings.TrimSpace(project), ".json"))
+	cache := completionCache{}
+	if contents, err := ioutil.ReadFile(cacheFile); err == nil {
+		if json.Unmarshal(contents, &cache) == nil && time.Since(cache.Time) < completionCacheTTL {
+			return cache.Names, nil
+		}
+	}
+
+	var names []string
+	for _, kind := range workloadKinds(o) {
+		kindNames, err := o.List(ctx, kind, "")
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, kindNames...)
+	}
+	sort.Strings(names)
+
+	// Completion still works without the cache, so ignore errors
+	contents, err := json.Marshal(completionCache{Time: time.Now(), Names: names})
+	if err == nil && os.MkdirAll(completionCacheDir, 0700) == nil {
+		ioutil.WriteFile(cacheFile, contents, 0600)
+	}
+	return names, nil
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestCompletionNamesAreCached(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocf-completion")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	completionCacheDir = dir
+
+	oc := mocks.NewMockOc()
+	oc.On("List", "dc", "").Return([]string{"foo"}, nil).Once()
+	oc.On("List", "deployment", "").Return([]string{"bar"}, nil).Once()
+
+	names, err := completionNames(context.Background(), oc)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"bar", "foo"}, names)
+
+	names, err = completionNames(context.Background(), oc)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"bar", "foo"}, names)
+	oc.AssertExpectations(t)
+}
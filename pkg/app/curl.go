@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/oc"
+)
+
+// Curl makes an authenticated request to the cluster's API with the
+// current session, like 'cf curl'. data is the request body, or the
+// name of a file holding it when it starts with '@'.
+func Curl(ctx context.Context, method string, path string, data string) ([]byte, error) {
+	app := &Application{ctx: ctx}
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return nil, err
+	}
+	return curl(ctx, app.oc, method, path, data)
+}
+
+func curl(ctx context.Context, o oc.Oc, method string, path string, data string) ([]byte, error) {
+	if !strings.HasPrefix(path, "/") {
+		path = fmt.Sprint("/", path)
+	}
+	method = strings.ToUpper(method)
+	if method == "" {
+		method = "GET"
+		if data != "" {
+			method = "POST"
+		}
+	}
+
+	var args []string
+	switch method {
+	case "GET":
+		args = []string{"get", "--raw", path}
+	case "DELETE":
+		args = []string{"delete", "--raw", path}
+	case "POST":
+		args = []string{"create", "--raw", path}
+	case "PUT":
+		args = []string{"replace", "--raw", path}
+	default:
+		return nil, errors.New(fmt.Sprintf("Error: Unsupported method %s, must be GET, POST, PUT, or DELETE\n", method))
+	}
+
+	if method == "POST" || method == "PUT" {
+		file := strings.TrimPrefix(data, "@")
+		if file == data {
+			tmpFile, err := ioutil.TempFile("", "ocf-curl")
+			if err != nil {
+				return nil, err
+			}
+			defer os.Remove(tmpFile.Name())
+			_, err = tmpFile.WriteString(data)
+			tmpFile.Close()
+			if err != nil {
+				return nil, err
+			}
+			file = tmpFile.Name()
+		}
+		args = append(args, "-f", file)
+	} else if data != "" {
+		return nil, errors.New(fmt.Sprintf("Error: A request body can't be sent with %s\n", method))
+	}
+
+	output, err := o.Exec(args...).CombinedOutput(ctx)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error requesting %s: %s\n", path, output))
+	}
+	return output, nil
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestCurlGet(t *testing.T) {
+	oc := mocks.NewMockOc()
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(`{"kind":"RouteList"}`), nil)
+	oc.Execer.On("Oc", []string{"get", "--raw", "/apis/route.openshift.io/v1/routes"}).Return(getCmd)
+
+	output, err := curl(context.Background(), oc, "", "apis/route.openshift.io/v1/routes", "")
+	assert.Nil(t, err)
+	assert.Equal(t, `{"kind":"RouteList"}`, string(output))
+	oc.Execer.AssertExpectations(t)
+}
+
+func TestCurlPostWithData(t *testing.T) {
+	oc := mocks.NewMockOc()
+	createCmd := &mocks.ExecCmd{}
+	createCmd.On("CombinedOutput").Return([]byte("{}"), nil)
+	oc.Execer.On("Oc", mock.MatchedBy(func(args []string) bool {
+		return len(args) == 5 && args[0] == "create" && args[2] == "/api/v1/namespaces/foo/configmaps" && args[3] == "-f"
+	})).Return(createCmd)
+
+	_, err := curl(context.Background(), oc, "", "/api/v1/namespaces/foo/configmaps", `{"kind":"ConfigMap"}`)
+	assert.Nil(t, err)
+	oc.Execer.AssertExpectations(t)
+}
+
+func TestCurlPutFromFile(t *testing.T) {
+	oc := mocks.NewMockOc()
+	replaceCmd := &mocks.ExecCmd{}
+	replaceCmd.On("CombinedOutput").Return([]byte("{}"), nil)
+	oc.Execer.On("Oc", []string{"replace", "--raw", "/api/v1/foo", "-f", "body.json"}).Return(replaceCmd)
+
+	_, err := curl(context.Background(), oc, "put", "/api/v1/foo", "@body.json")
+	assert.Nil(t, err)
+	oc.Execer.AssertExpectations(t)
+}
+
+func TestCurlRejectsUnsupportedMethods(t *testing.T) {
+	oc := mocks.NewMockOc()
+	_, err := curl(context.Background(), oc, "PATCH", "/api/v1/foo", "{}")
+	assert.NotNil(t, err)
+
+	_, err = curl(context.Background(), oc, "GET", "/api/v1/foo", "{}")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,216 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// createDeployment creates an apps/v1 Deployment for the application,
+// the Kubernetes equivalent of the deployment config 'oc run' creates.
+func (app *Application) createDeployment(image string, env []string) error {
+	manifest, err := app.deploymentManifest(image, env, nil)
+	if err != nil {
+		return err
+	}
+	log.Infof("Creating deployment %s", app.Name)
+	err = app.oc.Apply(app.context(), manifest)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// deploymentManifest returns an apps/v1 Deployment running image with
+// env, plus every key of the secrets in secretNames.
+func (app *Application) deploymentManifest(image string, env []string, secretNames []string) ([]byte, error) {
+	return app.workloadManifest(app.Name, map[string]string{"app": app.Name}, image, env, secretNames)
+}
+
+// workloadManifest returns an apps/v1 Deployment named name that runs
+// the application's container, with labels on both it and its pods.
+func (app *Application) workloadManifest(name string, labels map[string]string, image string, env []string, secretNames []string) ([]byte, error) {
+	container := app.container(image, env, secretNames)
+	app.addInstanceEnvTo(container)
+	checks := app.healthChecks()
+	for probe, check := range checks {
+		container[probe] = check
+	}
+
+	replicas := app.Instances
+	if replicas < 1 {
+		replicas = 1
+	}
+
+	metadata := map[string]interface{}{
+		"name":   name,
+		"labels": labels,
+	}
+	if !app.IsDocker() && !app.kubernetes() {
+		// Roll out new builds automatically, like a deployment
+		// config's image change trigger
+		trigger, err := json.Marshal([]map[string]interface{}{{
+			"from":      map[string]string{"kind": "ImageStreamTag", "name": fmt.Sprint(app.Name, ":latest")},
+			"fieldPath": fmt.Sprintf("spec.template.spec.containers[?(@.name==\"%s\")].image", app.Name),
+		}})
+		if err != nil {
+			return nil, err
+		}
+		metadata["annotations"] = map[string]string{
+			"image.openshift.io/triggers": string(trigger),
+		}
+	}
+
+	podSpec := map[string]interface{}{
+		"containers": append([]interface{}{container}, app.sidecarContainers(image, env, secretNames)...),
+	}
+	if app.GracePeriod > 0 {
+		podSpec["terminationGracePeriodSeconds"] = app.GracePeriod
+	}
+	if constraints := app.topologySpread(labels); constraints != nil {
+		podSpec["topologySpreadConstraints"] = constraints
+	}
+	app.addPlacementTo(podSpec)
+	spec := map[string]interface{}{
+		"replicas": replicas,
+		"selector": map[string]interface{}{"matchLabels": labels},
+		"template": map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": labels},
+			"spec":     podSpec,
+		},
+	}
+	if checks != nil {
+		spec["progressDeadlineSeconds"] = app.progressDeadline()
+	}
+
+	deployment := map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   metadata,
+		"spec":       spec,
+	}
+	return json.MarshalIndent(deployment, "", "  ")
+}
+
+// container returns the container spec shared by every kind of
+// workload that runs the application.
+func (app *Application) container(image string, env []string, secretNames []string) map[string]interface{} {
+	var containerEnv []interface{}
+	for _, envVar := range app.deploymentEnv(env) {
+		split := strings.SplitN(envVar, "=", 2)
+		if len(split) == 2 {
+			containerEnv = append(containerEnv, map[string]string{"name": split[0], "value": split[1]})
+		}
+	}
+
+	container := map[string]interface{}{
+		"name":  app.Name,
+		"image": image,
+		"env":   containerEnv,
+	}
+	limits := make(map[string]string)
+	if app.Memory != "" {
+		limits["memory"] = app.containerMemory()
+	}
+	if app.CPU != "" {
+		limits["cpu"] = app.CPU
+	}
+	if len(limits) > 0 {
+		resources := map[string]interface{}{"limits": limits}
+		if app.CPU != "" {
+			resources["requests"] = map[string]string{"cpu": app.CPU}
+		}
+		container["resources"] = resources
+	}
+	if app.nativeCommand() {
+		container["command"] = app.containerCommand()
+	}
+	var envFrom []interface{}
+	for _, secretName := range secretNames {
+		envFrom = append(envFrom, map[string]interface{}{
+			"secretRef": map[string]string{"name": secretName},
+		})
+	}
+	if len(envFrom) > 0 {
+		container["envFrom"] = envFrom
+	}
+	return container
+}
+
+// instanceEnv returns the variables Cloud Foundry sets on every
+// instance of an application, taking the ones that differ between
+// pods from the downward API. Kubernetes doesn't number a
+// deployment's pods, so there's no CF_INSTANCE_INDEX.
+func (app *Application) instanceEnv() []map[string]interface{} {
+	fromField := func(name string, fieldPath string) map[string]interface{} {
+		return map[string]interface{}{
+			"name": name,
+			"valueFrom": map[string]interface{}{
+				"fieldRef": map[string]string{"fieldPath": fieldPath},
+			},
+		}
+	}
+	return []map[string]interface{}{
+		{"name": "PORT", "value": "8080"},
+		{"name": "CF_INSTANCE_PORT", "value": "8080"},
+		fromField("CF_INSTANCE_GUID", "metadata.uid"),
+		fromField("CF_INSTANCE_IP", "status.podIP"),
+		fromField("CF_INSTANCE_INTERNAL_IP", "status.podIP"),
+		// Only variables defined earlier in the list can be referenced
+		{"name": "CF_INSTANCE_ADDR", "value": "$(CF_INSTANCE_IP):8080"},
+	}
+}
+
+// addInstanceEnvTo adds instanceEnv to a container spec. Knative
+// services go without, since Knative reserves PORT and disallows the
+// downward API by default.
+func (app *Application) addInstanceEnvTo(container map[string]interface{}) {
+	env, _ := container["env"].([]interface{})
+	for _, envVar := range app.instanceEnv() {
+		env = append(env, envVar)
+	}
+	container["env"] = env
+}
+
+// addInstanceEnv adds instanceEnv to a deployment config created by
+// 'oc run', which can only set plain values.
+func (app *Application) addInstanceEnv() error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{
+						"name": app.Name,
+						"env":  app.instanceEnv(),
+					}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("Adding Cloud Foundry instance variables to %s", app.Name)
+	output, err := app.oc.Exec("patch", "dc", app.Name, "-p", string(patch)).CombinedOutput(app.context())
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error adding instance variables to %s: %s\n", app.Name, output))
+	}
+	return nil
+}
+
+// removeInstanceIndex removes the CF_INSTANCE_INDEX of "0" that earlier
+// pushes set on single instance applications, so it can't go stale when
+// they're scaled up.
+func (app *Application) removeInstanceIndex() error {
+	env, err := app.oc.Env(app.context(), app.workloadKind(), app.Name)
+	if err != nil {
+		return err
+	}
+	if _, ok := env["CF_INSTANCE_INDEX"]; !ok {
+		return nil
+	}
+	return app.oc.SetEnv(app.context(), app.workloadKind(), app.Name, map[string]string{"CF_INSTANCE_INDEX": "-"})
+}
@@ -0,0 +1,121 @@
+package app
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestWorkloadKind(t *testing.T) {
+	app := Application{}
+	assert.Equal(t, "dc", app.workloadKind())
+
+	app.options.Workload = WorkloadDeployment
+	assert.Equal(t, "deployment", app.workloadKind())
+
+	app.kind = "dc"
+	assert.Equal(t, "dc", app.workloadKind())
+}
+
+func TestDeploymentExistsFindsDeployment(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.On("Exists", "dc", "foo").Return(false, nil)
+	oc.On("Exists", "deployment", "foo").Return(true, nil)
+	app := Application{oc: oc, Name: "foo"}
+
+	exists, err := app.deploymentExists()
+	assert.Nil(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "deployment", app.workloadKind())
+}
+
+func TestDeploymentManifest(t *testing.T) {
+	app := Application{Name: "foo", Memory: "512M", CPU: "250m", Instances: 2}
+	manifest, err := app.deploymentManifest("172.30.1.1:5000/p/foo", []string{"A=b"}, nil)
+	assert.Nil(t, err)
+
+	var deployment struct {
+		Kind     string
+		Metadata struct {
+			Annotations map[string]string
+		}
+		Spec struct {
+			Replicas int
+			Template struct {
+				Spec struct {
+					Containers []struct {
+						Image     string
+						Env       []map[string]interface{}
+						Resources struct {
+							Limits   map[string]string
+							Requests map[string]string
+						}
+					}
+				}
+			}
+		}
+	}
+	assert.Nil(t, json.Unmarshal(manifest, &deployment))
+	assert.Equal(t, "Deployment", deployment.Kind)
+	assert.Equal(t, 2, deployment.Spec.Replicas)
+	assert.Contains(t, deployment.Metadata.Annotations["image.openshift.io/triggers"], "foo:latest")
+	container := deployment.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "172.30.1.1:5000/p/foo", container.Image)
+	assert.Equal(t, "512M", container.Resources.Limits["memory"])
+	assert.Equal(t, "250m", container.Resources.Limits["cpu"])
+	assert.Equal(t, "250m", container.Resources.Requests["cpu"])
+	assert.Equal(t, []map[string]interface{}{
+		{"name": "A", "value": "b"},
+		{"name": "MEMORY_LIMIT", "value": "512M"},
+	}, container.Env[:2])
+	var names []string
+	for _, envVar := range container.Env[2:] {
+		names = append(names, envVar["name"].(string))
+	}
+	assert.Equal(t, []string{"PORT", "CF_INSTANCE_PORT", "CF_INSTANCE_GUID", "CF_INSTANCE_IP",
+		"CF_INSTANCE_INTERNAL_IP", "CF_INSTANCE_ADDR"}, names)
+	assert.Equal(t, map[string]interface{}{"fieldRef": map[string]interface{}{"fieldPath": "status.podIP"}},
+		container.Env[5]["valueFrom"])
+}
+
+func TestInstanceEnvHasNoIndex(t *testing.T) {
+	app := Application{Name: "foo"}
+	for _, envVar := range app.instanceEnv() {
+		assert.NotEqual(t, "CF_INSTANCE_INDEX", envVar["name"])
+	}
+}
+
+func TestAddInstanceEnv(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	patchCmd := &mocks.ExecCmd{}
+	patchCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", mock.MatchedBy(func(args []string) bool {
+		return args[0] == "patch" && args[2] == "foo" &&
+			strings.Contains(args[4], `"containers":[{"env":[{"name":"PORT","value":"8080"}`)
+	})).Return(patchCmd)
+
+	assert.Nil(t, app.addInstanceEnv())
+	oc.Execer.AssertExpectations(t)
+}
+
+func TestScaleRemovesInstanceIndex(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", Project: "p"}
+
+	oc.On("Exists", "dc", "foo").Return(true, nil)
+	oc.On("Env", "dc", "foo").Return(map[string]string{"CF_INSTANCE_INDEX": "0"}, nil)
+	oc.On("SetEnv", "dc", "foo", map[string]string{"CF_INSTANCE_INDEX": "-"}).Return(nil)
+	scaleCmd := &mocks.ExecCmd{}
+	scaleCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", []string{"scale", "dc/foo", "--replicas=3"}).Return(scaleCmd)
+
+	assert.Nil(t, app.Scale(3))
+	oc.AssertExpectations(t)
+}
@@ -0,0 +1,224 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+// Change describes a difference between the application as pushed and
+// as it's currently running.
+type Change struct {
+	Field   string `json:"field"`
+	Live    string `json:"live,omitempty"`
+	Desired string `json:"desired,omitempty"`
+}
+
+func (change Change) String() string {
+	switch {
+	case change.Live == "":
+		return fmt.Sprintf("+ %s: %s", change.Field, change.Desired)
+	case change.Desired == "":
+		return fmt.Sprintf("- %s: %s", change.Field, change.Live)
+	default:
+		return fmt.Sprintf("~ %s: %s => %s", change.Field, change.Live, change.Desired)
+	}
+}
+
+// Diff compares the application with what's running in the cluster
+// without changing anything. Settings the application leaves unset
+// aren't compared.
+func (app *Application) Diff(options PushOptions) ([]Change, error) {
+	app.options = options
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return nil, err
+	}
+	return app.changes()
+}
+
+// displayChanges prints what the push is about to change, before it
+// changes anything.
+func (app *Application) displayChanges() error {
+	changes, err := app.changes()
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		log.Infof("No configuration changes to %s", app.Name)
+		return nil
+	}
+	log.Infof("Changes to %s:", app.Name)
+	for _, change := range changes {
+		log.Printf("  %s", change)
+	}
+	return nil
+}
+
+// changes returns the differences between the application and what's
+// running in the cluster.
+func (app *Application) changes() ([]Change, error) {
+	exists, err := app.deploymentExists()
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return []Change{{Field: "application", Desired: app.Name}}, nil
+	}
+
+	var changes []Change
+	addChange := func(field string, live string, desired string) {
+		if live != desired {
+			changes = append(changes, Change{Field: field, Live: live, Desired: desired})
+		}
+	}
+
+	workload, err := app.liveWorkload()
+	if err != nil {
+		return nil, err
+	}
+	container := workload.Spec.Template.Spec.Containers[0]
+	if app.IsDocker() {
+		image, err := app.specImage(workload, container)
+		if err != nil {
+			return nil, err
+		}
+		addChange("image", image, app.Docker.Image)
+	}
+	if app.Instances > 0 {
+		addChange("instances", fmt.Sprint(workload.Spec.Replicas), fmt.Sprint(app.Instances))
+	}
+	if app.Memory != "" {
+		addChange("memory", container.Resources.Limits["memory"], app.containerMemory())
+	}
+	if app.CPU != "" {
+		addChange("cpu", container.Resources.Limits["cpu"], app.CPU)
+	}
+
+	liveEnv := make(map[string]string)
+	for _, envVar := range container.Env {
+		if envVar.ValueFrom == nil {
+			liveEnv[envVar.Name] = envVar.Value
+		}
+	}
+	desiredEnv := make(map[string]string)
+	for _, envVar := range app.deploymentEnv(nil) {
+		split := strings.SplitN(envVar, "=", 2)
+		desiredEnv[split[0]] = split[1]
+	}
+	if len(app.Services) > 0 {
+		var serviceNames []string
+		for _, service := range app.Services {
+			serviceNames = append(serviceNames, envPrefixFromService(service))
+		}
+		desiredEnv[BoundServices] = strings.Join(serviceNames, " ")
+	}
+	var envNames []string
+	for name := range desiredEnv {
+		envNames = append(envNames, name)
+	}
+	sort.Strings(envNames)
+	for _, name := range envNames {
+		addChange(fmt.Sprint("env ", name), liveEnv[name], desiredEnv[name])
+	}
+
+	if !app.IsDocker() && !app.kubernetes() {
+		buildEnv, err := app.oc.Env(app.context(), "bc", app.Name)
+		if err != nil {
+			return nil, err
+		}
+		addChange("buildpack", buildEnv[BuildpackUrl], app.Buildpack)
+	}
+
+	if len(app.Routes) > 0 {
+		err = app.routeChanges(addChange)
+		if err != nil {
+			return nil, err
+		}
+		return changes, nil
+	}
+	host, err := app.liveHost()
+	if err != nil {
+		return nil, err
+	}
+	if host == "" {
+		if !app.kubernetes() {
+			addChange("route", "", "<generated>")
+		} else if app.options.Domain != "" {
+			addChange("ingress", "", app.ingressHost())
+		}
+	} else if app.kubernetes() && app.options.Domain != "" {
+		addChange("ingress", host, app.ingressHost())
+	}
+	return changes, nil
+}
+
+// routeChanges compares the manifest's routes with the routes or
+// ingresses exposing the application.
+func (app *Application) routeChanges(addChange func(field string, live string, desired string)) error {
+	liveRoutes, err := app.liveRoutes()
+	if err != nil {
+		return err
+	}
+	live := make(map[string]bool)
+	for _, route := range liveRoutes {
+		live[route.Route] = true
+	}
+	desired := make(map[string]bool)
+	for _, route := range app.Routes {
+		desired[strings.TrimRight(route.Route, "/")] = true
+	}
+
+	var routes []string
+	for route := range live {
+		routes = append(routes, route)
+	}
+	for route := range desired {
+		if !live[route] {
+			routes = append(routes, route)
+		}
+	}
+	sort.Strings(routes)
+	for _, route := range routes {
+		switch {
+		case !desired[route]:
+			addChange("route", route, "")
+		case !live[route]:
+			addChange("route", "", route)
+		}
+	}
+	return nil
+}
+
+// specImage returns the image the workload was deployed from. An image
+// change trigger resolves the container's image to a digest, so the
+// image the trigger follows is returned instead.
+func (app *Application) specImage(workload *types.DeploymentConfig, container types.Container) (string, error) {
+	for _, trigger := range workload.Spec.Triggers {
+		params := trigger.ImageChangeParams
+		if trigger.Type != "ImageChange" || params == nil {
+			continue
+		}
+		switch params.From.Kind {
+		case "DockerImage":
+			return params.From.Name, nil
+		case "ImageStreamTag":
+			split := strings.SplitN(params.From.Name, ":", 2)
+			stream := &types.ImageStream{}
+			err := oc.Get(app.context(), app.oc, "is", split[0], stream)
+			if err != nil {
+				return "", err
+			}
+			for _, tag := range stream.Spec.Tags {
+				if len(split) == 2 && tag.Name == split[1] && tag.From != nil && tag.From.Kind == "DockerImage" {
+					return tag.From.Name, nil
+				}
+			}
+		}
+	}
+	return container.Image, nil
+}
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestDiffNewApplication(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.On("Exists", "dc", "foo").Return(false, nil)
+	oc.On("Exists", "deployment", "foo").Return(false, nil)
+	app := Application{oc: oc, Name: "foo"}
+
+	changes, err := app.Diff(PushOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []Change{{Field: "application", Desired: "foo"}}, changes)
+}
+
+func TestDiffExistingApplication(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.On("Exists", "dc", "foo").Return(true, nil)
+	oc.On("Exists", "route", "foo").Return(true, nil)
+	oc.On("Env", "bc", "foo").Return(map[string]string{BuildpackUrl: "old-bp"}, nil)
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(liveDeploymentJSON), nil)
+	hostCmd := &mocks.ExecCmd{}
+	hostCmd.On("CombinedOutput").Return([]byte(`{"spec": {"host": "foo.example.com"}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "dc", "foo", "-o", "json"}).Return(getCmd)
+	oc.Execer.On("Oc", []string{"get", "route", "foo", "-o", "json"}).Return(hostCmd)
+
+	app := Application{oc: oc, Name: "foo", Memory: "1G", Instances: 2,
+		Buildpack: "new-bp", Services: []string{"my-db"}}
+	changes, err := app.Diff(PushOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []Change{
+		{Field: "memory", Live: "512M", Desired: "1G"},
+		{Field: "env CF_BOUND_SERVICES", Desired: "MY_DB"},
+		{Field: "env MEMORY_LIMIT", Live: "512M", Desired: "1G"},
+		{Field: "buildpack", Live: "old-bp", Desired: "new-bp"},
+	}, changes)
+	assert.Equal(t, "~ memory: 512M => 1G", changes[0].String())
+}
+
+func TestDiffComparesTriggerImage(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.On("Exists", "dc", "foo").Return(true, nil)
+	oc.On("Exists", "route", "foo").Return(true, nil)
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(`{"spec": {
+	  "replicas": 1,
+	  "template": {"spec": {"containers": [{"image": "172.30.1.1:5000/p/foo@sha256:abc"}]}},
+	  "triggers": [{"type": "ImageChange", "imageChangeParams": {"from": {"kind": "ImageStreamTag", "name": "foo:latest"}}}]
+	}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "dc", "foo", "-o", "json"}).Return(getCmd)
+	isCmd := &mocks.ExecCmd{}
+	isCmd.On("CombinedOutput").Return([]byte(`{"spec": {"tags": [
+	  {"name": "latest", "from": {"kind": "DockerImage", "name": "nginx:1.19"}}
+	]}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "is", "foo", "-o", "json"}).Return(isCmd)
+	hostCmd := &mocks.ExecCmd{}
+	hostCmd.On("CombinedOutput").Return([]byte(`{"spec": {"host": "foo.example.com"}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "route", "foo", "-o", "json"}).Return(hostCmd)
+
+	app := Application{oc: oc, Name: "foo", Docker: &Docker{Image: "nginx:1.19"}}
+	changes, err := app.Diff(PushOptions{})
+	assert.Nil(t, err)
+	assert.Empty(t, changes)
+
+	app.Docker.Image = "nginx:1.21"
+	changes, err = app.Diff(PushOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []Change{{Field: "image", Live: "nginx:1.19", Desired: "nginx:1.21"}}, changes)
+}
+
+func TestDiffRoutes(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.On("Exists", "dc", "foo").Return(true, nil)
+	oc.On("Exists", "route", "foo").Return(false, nil)
+	oc.On("Env", "bc", "foo").Return(map[string]string{}, nil)
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(liveDeploymentJSON), nil)
+	oc.Execer.On("Oc", []string{"get", "dc", "foo", "-o", "json"}).Return(getCmd)
+	routesCmd := &mocks.ExecCmd{}
+	routesCmd.On("CombinedOutput").Return([]byte(`{"items": [
+	  {"spec": {"host": "foo.example.com"}},
+	  {"spec": {"host": "old.example.com", "path": "/api"}}
+	]}`), nil)
+	oc.Execer.On("Oc", []string{"get", "route", "--selector=app=foo", "-o", "json"}).Return(routesCmd)
+
+	app := Application{oc: oc, Name: "foo",
+		Routes: []Route{{Route: "foo.example.com"}, {Route: "new.example.com/"}}}
+	changes, err := app.Diff(PushOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []Change{
+		{Field: "route", Desired: "new.example.com"},
+		{Field: "route", Live: "old.example.com/api"},
+	}, changes)
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+// DockerPassword is the environment variable holding the password
+// for Docker images that require a username, matching 'cf push'.
+const DockerPassword string = "CF_DOCKER_PASSWORD"
+
+const defaultDockerRegistry string = "docker.io"
+
+// Docker describes a prebuilt image to run instead of building the
+// application from source.
+type Docker struct {
+	Image    string `json:"image" yaml:"image"`
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+}
+
+// IsDocker returns true if the application runs a prebuilt Docker
+// image instead of being built from source.
+func (app *Application) IsDocker() bool {
+	return app.Docker != nil && app.Docker.Image != ""
+}
+
+func (app *Application) pullSecretName() string {
+	return fmt.Sprint(app.Name, "-docker")
+}
+
+// ensureDockerPullSecret creates a pull secret for images that
+// require credentials and links it to the default service account.
+func (app *Application) ensureDockerPullSecret() error {
+	if app.Docker.Username == "" {
+		return nil
+	}
+	password := os.Getenv(DockerPassword)
+	if password == "" {
+		return errors.New(fmt.Sprintf("Error: Environment variable %s not set", DockerPassword))
+	}
+
+	secretName := app.pullSecretName()
+	exists, err := app.oc.Exists(app.context(), "secret", secretName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		log.Infof("Pull secret already exists for %s, skipping creating one", app.Name)
+		return nil
+	}
+
+	credentialArgs := []string{
+		fmt.Sprint("--docker-server=", dockerRegistry(app.Docker.Image)),
+		fmt.Sprint("--docker-username=", app.Docker.Username),
+		fmt.Sprint("--docker-password=", password),
+		fmt.Sprint("--docker-email=", app.Docker.Username),
+	}
+	var newArgs, linkArgs []string
+	if app.kubernetes() {
+		newArgs = append([]string{"create", "secret", "docker-registry", secretName}, credentialArgs...)
+		linkArgs, err = app.pullSecretPatchArgs(secretName)
+		if err != nil {
+			return err
+		}
+	} else {
+		newArgs = append([]string{"secrets", "new-dockercfg", secretName}, credentialArgs...)
+		linkArgs = []string{"secrets", "link", "default", secretName, "--for=pull"}
+	}
+	log.Infof("Creating pull secret %s for %s", secretName, app.Docker.Image)
+	output, err := app.oc.Exec(newArgs...).CombinedOutput(app.context())
+	if err != nil {
+		return withOutput(output, err)
+	}
+	output, err = app.oc.Exec(linkArgs...).CombinedOutput(app.context())
+	if err != nil {
+		return withOutput(output, err)
+	}
+	return nil
+}
+
+// pullSecretPatchArgs returns the arguments that add secretName to
+// the pull secrets of the default service account, keeping the ones
+// it already has.
+func (app *Application) pullSecretPatchArgs(secretName string) ([]string, error) {
+	account := &types.ServiceAccount{}
+	err := oc.Get(app.context(), app.oc, "serviceaccount", "default", account)
+	if err != nil {
+		return nil, err
+	}
+	secret := map[string]string{"name": secretName}
+	op := map[string]interface{}{"op": "add", "path": "/imagePullSecrets/-", "value": secret}
+	if len(account.ImagePullSecrets) == 0 {
+		op["path"] = "/imagePullSecrets"
+		op["value"] = []map[string]string{secret}
+	}
+	patch, err := json.Marshal([]interface{}{op})
+	if err != nil {
+		return nil, err
+	}
+	return []string{"patch", "serviceaccount", "default", "--type=json", "-p", string(patch)}, nil
+}
+
+func (app *Application) updateDockerImage() error {
+	updateCmd := app.oc.Exec("set", "image", fmt.Sprint(app.workloadKind(), "/", app.Name),
+		fmt.Sprint(app.Name, "=", app.Docker.Image))
+	log.Infof("Updating image with command: %s", updateCmd.ArgsString())
+	output, err := updateCmd.CombinedOutput(app.context())
+	if err != nil {
+		return withOutput(output, err)
+	}
+	return nil
+}
+
+// dockerRegistry returns the registry host portion of an image
+// reference, defaulting to Docker Hub.
+func dockerRegistry(image string) string {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && strings.ContainsAny(parts[0], ".:") {
+		return parts[0]
+	}
+	return defaultDockerRegistry
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestIsDocker(t *testing.T) {
+	assert.False(t, (&Application{}).IsDocker())
+	assert.False(t, (&Application{Docker: &Docker{}}).IsDocker())
+	assert.True(t, (&Application{Docker: &Docker{Image: "nginx"}}).IsDocker())
+}
+
+func TestDockerRegistry(t *testing.T) {
+	assert.Equal(t, "docker.io", dockerRegistry("nginx"))
+	assert.Equal(t, "docker.io", dockerRegistry("library/nginx:latest"))
+	assert.Equal(t, "quay.io", dockerRegistry("quay.io/foo/bar"))
+	assert.Equal(t, "localhost:5000", dockerRegistry("localhost:5000/bar"))
+}
+
+func TestCreateDeploymentArgsDockerCommand(t *testing.T) {
+	app := Application{Command: "nginx -g daemon", Docker: &Docker{Image: "nginx"}}
+	args := app.createDeploymentArgs("nginx", []string{})
+	assert.Equal(t, []string{"/bin/sh", "-c", signalForwarder, "sh", "nginx -g daemon"}, args[len(args)-5:])
+	assert.NotContains(t, strings.Join(args, " "), "CF_COMMAND")
+}
+
+func TestPullSecretPatchAppends(t *testing.T) {
+	oc := mocks.NewMockOc()
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(`{"imagePullSecrets": [{"name": "existing"}]}`), nil).Once()
+	getCmd.On("CombinedOutput").Return([]byte(`{}`), nil)
+	oc.Execer.On("Oc", []string{"get", "serviceaccount", "default", "-o", "json"}).Return(getCmd)
+	app := Application{oc: oc, Name: "foo"}
+
+	args, err := app.pullSecretPatchArgs("foo-docker")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"patch", "serviceaccount", "default", "--type=json", "-p",
+		`[{"op":"add","path":"/imagePullSecrets/-","value":{"name":"foo-docker"}}]`}, args)
+
+	args, err = app.pullSecretPatchArgs("foo-docker")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"patch", "serviceaccount", "default", "--type=json", "-p",
+		`[{"op":"add","path":"/imagePullSecrets","value":[{"name":"foo-docker"}]}]`}, args)
+}
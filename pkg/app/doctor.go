@@ -0,0 +1,224 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/oc"
+)
+
+// lookPath finds commands on the PATH, and is replaced by tests.
+var lookPath = exec.LookPath
+
+// Diagnosis is the result of one of doctor's checks, with how to fix
+// it when it failed.
+type Diagnosis struct {
+	Check  string
+	OK     bool
+	Detail string
+	Remedy string
+}
+
+// DoctorOptions are what doctor checks beyond the client and cluster.
+type DoctorOptions struct {
+	// Image is the builder image that should be pullable
+	Image string
+	// Registry is where images are pushed when using the k8s
+	// platform
+	Registry string
+}
+
+// Doctor checks that the client, cluster, and project are ready for
+// push, stopping at the first failure later checks depend on.
+func Doctor(ctx context.Context, options DoctorOptions) []Diagnosis {
+	app := &Application{ctx: ctx}
+	app.options.Registry = options.Registry
+	app.setupDefaults()
+	image := options.Image
+	if image == "" {
+		image = DefaultImage
+	}
+	return app.doctor(image)
+}
+
+func (app *Application) doctor(image string) []Diagnosis {
+	var diagnoses []Diagnosis
+	for _, check := range []func() Diagnosis{
+		app.diagnoseClient,
+		app.diagnoseLogin,
+		app.diagnoseProject,
+	} {
+		diagnosis := check()
+		diagnoses = append(diagnoses, diagnosis)
+		if !diagnosis.OK {
+			return diagnoses
+		}
+	}
+	diagnoses = append(diagnoses, app.diagnoseRegistry())
+	if app.kubernetes() {
+		diagnoses = append(diagnoses, app.diagnoseBuildTools())
+	}
+	return append(diagnoses, app.diagnoseBuilder(image), app.diagnoseRouter())
+}
+
+func (app *Application) clientBinary() string {
+	if app.kubernetes() {
+		return "kubectl"
+	}
+	return "oc"
+}
+
+func (app *Application) diagnoseClient() Diagnosis {
+	binary := app.clientBinary()
+	diagnosis := Diagnosis{Check: "client"}
+	path, err := lookPath(binary)
+	if err != nil {
+		diagnosis.Detail = fmt.Sprintf("%s was not found on the PATH", binary)
+		if app.kubernetes() {
+			diagnosis.Remedy = "Install kubectl from https://kubernetes.io/docs/tasks/tools/ and add it to your PATH"
+		} else {
+			diagnosis.Remedy = "Run 'ocf install-oc', or install oc from https://mirror.openshift.com/pub/openshift-v4/clients/ocp/latest/ and add it to your PATH"
+		}
+		return diagnosis
+	}
+	capabilities, err := app.oc.Capabilities(app.context())
+	if err != nil {
+		diagnosis.Detail = strings.TrimSpace(err.Error())
+		minimum := oc.MinimumOcVersion
+		if app.kubernetes() {
+			minimum = oc.MinimumKubectlVersion
+		}
+		diagnosis.Remedy = fmt.Sprintf("Upgrade %s at %s to %s or newer", binary, path, minimum)
+		return diagnosis
+	}
+	diagnosis.OK = true
+	diagnosis.Detail = fmt.Sprintf("%s %s at %s", binary, capabilities.Version, path)
+	return diagnosis
+}
+
+func (app *Application) diagnoseLogin() Diagnosis {
+	diagnosis := Diagnosis{Check: "login"}
+	if app.oc.LoggedIn(app.context()) {
+		diagnosis.OK = true
+		diagnosis.Detail = "Logged in to the cluster"
+		return diagnosis
+	}
+	if app.kubernetes() {
+		diagnosis.Detail = "Unable to access the cluster"
+		diagnosis.Remedy = "Check that 'kubectl config current-context' names a reachable cluster and that your credentials are valid"
+	} else {
+		diagnosis.Detail = "Not logged in"
+		diagnosis.Remedy = "Run 'oc login <cluster URL>', or 'ocf push' to be prompted to log in"
+	}
+	return diagnosis
+}
+
+func (app *Application) diagnoseProject() Diagnosis {
+	diagnosis := Diagnosis{Check: "project"}
+	project, err := app.oc.Project(app.context())
+	project = strings.TrimSpace(project)
+	if err == nil && project != "" {
+		diagnosis.OK = true
+		diagnosis.Detail = fmt.Sprintf("Using project %s", project)
+		return diagnosis
+	}
+	diagnosis.Detail = "No project is selected"
+	if app.kubernetes() {
+		diagnosis.Remedy = "Run 'kubectl config set-context --current --namespace=<name>'"
+	} else {
+		diagnosis.Remedy = "Run 'oc project <name>' to use an existing project or 'oc new-project <name>' to create one"
+	}
+	return diagnosis
+}
+
+func (app *Application) diagnoseRegistry() Diagnosis {
+	diagnosis := Diagnosis{Check: "registry"}
+	if app.kubernetes() {
+		if app.options.Registry == "" {
+			diagnosis.Detail = "No registry was given to push built images to"
+			diagnosis.Remedy = "Pass --registry with a registry you can push to, such as quay.io/<user>"
+			return diagnosis
+		}
+		diagnosis.OK = true
+		diagnosis.Detail = fmt.Sprintf("Pushing built images to %s", app.options.Registry)
+		return diagnosis
+	}
+	output, err := app.oc.Exec("registry", "info").CombinedOutput(app.context())
+	if err != nil {
+		diagnosis.Detail = fmt.Sprintf("The integrated image registry is not available: %s", strings.TrimSpace(string(output)))
+		diagnosis.Remedy = "Ask your cluster administrator to enable the integrated image registry, which builds push their images to"
+		return diagnosis
+	}
+	diagnosis.OK = true
+	diagnosis.Detail = fmt.Sprintf("Integrated registry at %s", strings.TrimSpace(string(output)))
+	return diagnosis
+}
+
+// diagnoseBuildTools checks for the tools Kubernetes builds run
+// locally.
+func (app *Application) diagnoseBuildTools() Diagnosis {
+	diagnosis := Diagnosis{Check: "build tools"}
+	var missing []string
+	for _, tool := range []string{"s2i", "docker"} {
+		if _, err := lookPath(tool); err != nil {
+			missing = append(missing, tool)
+		}
+	}
+	if len(missing) > 0 {
+		diagnosis.Detail = fmt.Sprintf("%s not found on the PATH", strings.Join(missing, " and "))
+		diagnosis.Remedy = "Install s2i from https://github.com/openshift/source-to-image/releases and docker, which build applications on Kubernetes"
+		return diagnosis
+	}
+	diagnosis.OK = true
+	diagnosis.Detail = "s2i and docker found"
+	return diagnosis
+}
+
+func (app *Application) diagnoseBuilder(image string) Diagnosis {
+	diagnosis := Diagnosis{Check: "builder image"}
+	var output []byte
+	var err error
+	if app.kubernetes() {
+		output, err = app.execer.Command("docker", "manifest", "inspect", image).CombinedOutput(app.context())
+	} else {
+		output, err = app.oc.Exec("image", "info", image).CombinedOutput(app.context())
+	}
+	if err != nil {
+		diagnosis.Detail = fmt.Sprintf("Unable to pull %s: %s", image, strings.TrimSpace(string(output)))
+		diagnosis.Remedy = fmt.Sprintf("Check that %s is reachable from here and the cluster, or mirror it and push with --image", dockerRegistry(image))
+		return diagnosis
+	}
+	diagnosis.OK = true
+	diagnosis.Detail = fmt.Sprintf("%s is pullable", image)
+	return diagnosis
+}
+
+func (app *Application) diagnoseRouter() Diagnosis {
+	diagnosis := Diagnosis{Check: "router"}
+	if app.kubernetes() {
+		output, err := app.oc.Exec("get", "ingressclasses", "-o", "name").CombinedOutput(app.context())
+		classes := strings.Fields(string(output))
+		if err != nil || len(classes) == 0 {
+			diagnosis.Detail = "No ingress controller was found"
+			diagnosis.Remedy = "Install an ingress controller such as ingress-nginx, or push with --route-type tcp"
+			return diagnosis
+		}
+		for i, class := range classes {
+			classes[i] = strings.TrimPrefix(class, "ingressclass.networking.k8s.io/")
+		}
+		diagnosis.OK = true
+		diagnosis.Detail = fmt.Sprintf("Ingress classes %s", strings.Join(classes, ", "))
+		return diagnosis
+	}
+	domains := clusterDomains(app.context(), app.oc)
+	if len(domains) == 0 {
+		diagnosis.Detail = "Unable to find a router domain"
+		diagnosis.Remedy = "Ask your cluster administrator whether a router is deployed, or push with --domain"
+		return diagnosis
+	}
+	diagnosis.OK = true
+	diagnosis.Detail = fmt.Sprintf("Routing %s", strings.Join(domains, ", "))
+	return diagnosis
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+func withLookPath(t *testing.T, missing ...string) {
+	original := lookPath
+	lookPath = func(file string) (string, error) {
+		for _, name := range missing {
+			if name == file {
+				return "", errors.New("not found")
+			}
+		}
+		return "/usr/bin/" + file, nil
+	}
+	t.Cleanup(func() { lookPath = original })
+}
+
+func checksFailed(diagnoses []Diagnosis) []string {
+	var failed []string
+	for _, diagnosis := range diagnoses {
+		if !diagnosis.OK {
+			failed = append(failed, diagnosis.Check)
+		}
+	}
+	return failed
+}
+
+func TestDoctor(t *testing.T) {
+	withLookPath(t)
+	oc := mocks.NewMockOc()
+	oc.OcCapabilities = &types.Capabilities{Version: types.Version{Major: 4, Minor: 12}}
+	registryCmd := &mocks.ExecCmd{}
+	registryCmd.On("CombinedOutput").Return([]byte("image-registry.openshift-image-registry.svc:5000\n"), nil)
+	oc.Execer.On("Oc", []string{"registry", "info"}).Return(registryCmd)
+	imageCmd := &mocks.ExecCmd{}
+	imageCmd.On("CombinedOutput").Return([]byte("error: unauthorized"), errors.New("exit status 1"))
+	oc.Execer.On("Oc", []string{"image", "info", DefaultImage}).Return(imageCmd)
+	controllersCmd := &mocks.ExecCmd{}
+	controllersCmd.On("CombinedOutput").Return([]byte(`{"items":[{"status":{"domain":"apps.example.com"}}]}`), nil)
+	oc.Execer.On("Oc", []string{"get", "ingresscontrollers.operator.openshift.io",
+		"-n", "openshift-ingress-operator", "-o", "json"}).Return(controllersCmd)
+	configCmd := &mocks.ExecCmd{}
+	configCmd.On("CombinedOutput").Return([]byte(`{"spec":{"domain":"apps.example.com"}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "ingresses.config.openshift.io", "cluster", "-o", "json"}).Return(configCmd)
+
+	app := Application{oc: oc}
+	diagnoses := app.doctor(DefaultImage)
+	assert.Equal(t, []string{"builder image"}, checksFailed(diagnoses))
+	assert.Equal(t, "oc 4.12 at /usr/bin/oc", diagnoses[0].Detail)
+	assert.Equal(t, "Using project test-project", diagnoses[2].Detail)
+	assert.Equal(t, "Routing apps.example.com", diagnoses[5].Detail)
+	assert.Contains(t, diagnoses[4].Remedy, "docker.io")
+}
+
+func TestDoctorStopsWithoutClient(t *testing.T) {
+	withLookPath(t, "oc")
+	app := Application{oc: mocks.NewMockOc()}
+	diagnoses := app.doctor(DefaultImage)
+	assert.Len(t, diagnoses, 1)
+	assert.Equal(t, []string{"client"}, checksFailed(diagnoses))
+	assert.Contains(t, diagnoses[0].Remedy, "ocf install-oc")
+}
+
+func TestDoctorKubernetes(t *testing.T) {
+	withLookPath(t, "s2i")
+	oc := mocks.NewMockOc()
+	oc.PlatformName = "k8s"
+	execer := &mocks.Execer{}
+	inspectCmd := &mocks.ExecCmd{}
+	inspectCmd.On("CombinedOutput").Return([]byte("{}"), nil)
+	execer.On("Command", "docker", []string{"manifest", "inspect", DefaultImage}).Return(inspectCmd)
+	classesCmd := &mocks.ExecCmd{}
+	classesCmd.On("CombinedOutput").Return([]byte("ingressclass.networking.k8s.io/nginx\n"), nil)
+	oc.Execer.On("Oc", []string{"get", "ingressclasses", "-o", "name"}).Return(classesCmd)
+
+	app := Application{oc: oc, execer: execer}
+	diagnoses := app.doctor(DefaultImage)
+	assert.Equal(t, []string{"registry", "build tools"}, checksFailed(diagnoses))
+	assert.Equal(t, "s2i not found on the PATH", diagnoses[4].Detail)
+	assert.Equal(t, "Ingress classes nginx", diagnoses[6].Detail)
+
+	app.options.Registry = "quay.io/myorg"
+	assert.Equal(t, []string{"build tools"}, checksFailed(app.doctor(DefaultImage)))
+}
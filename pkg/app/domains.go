@@ -0,0 +1,100 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+// clusterDomains returns the domains the cluster's routers serve
+// routes for, or none if they can't be found out, such as on
+// Kubernetes or without permission to read them.
+func clusterDomains(ctx context.Context, o oc.Oc) []string {
+	if o.Platform() == oc.PlatformKubernetes {
+		return nil
+	}
+	found := make(map[string]bool)
+
+	// Each router shard has its own domain, but listing them
+	// usually takes more than a developer's permissions
+	output, err := o.Exec("get", "ingresscontrollers.operator.openshift.io",
+		"-n", "openshift-ingress-operator", "-o", "json").CombinedOutput(ctx)
+	controllers := &types.IngressControllerList{}
+	if err == nil && json.Unmarshal(output, controllers) == nil {
+		for _, controller := range controllers.Items {
+			found[controller.Status.Domain] = true
+		}
+	} else {
+		log.Debugf("Unable to list ingress controllers: %s", output)
+	}
+
+	config := &types.IngressConfig{}
+	err = oc.Get(ctx, o, "ingresses.config.openshift.io", "cluster", config)
+	if err == nil {
+		found[config.Spec.Domain] = true
+		found[config.Spec.AppsDomain] = true
+	} else {
+		log.Debugf("Unable to get the cluster ingress config: %v", err)
+	}
+
+	var domains []string
+	for domain := range found {
+		if domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+// checkHost returns an error if host isn't under one of domains, so a
+// route for it would never be reachable. Any host is allowed when the
+// domains aren't known.
+func checkHost(host string, domains []string) error {
+	if len(domains) == 0 {
+		return nil
+	}
+	host = strings.ToLower(host)
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, fmt.Sprint(".", domain)) {
+			return nil
+		}
+	}
+	return errors.New(fmt.Sprintf("Error: %s isn't served by this cluster's routers, so it would be unreachable. Use a host under one of: %s\n",
+		host, strings.Join(domains, ", ")))
+}
+
+// checkDomains makes sure --domain and the application's manifest
+// routes are served by the cluster before anything is built.
+func (app *Application) checkDomains() error {
+	if app.options.Domain == "" && len(app.Routes) == 0 {
+		return nil
+	}
+	domains := clusterDomains(app.context(), app.oc)
+	if app.options.Domain != "" {
+		err := checkHost(app.options.Domain, domains)
+		if err != nil {
+			return err
+		}
+	}
+	for _, route := range app.Routes {
+		if internalRoute(route.Route) {
+			continue
+		}
+		host, _, err := ParseRoute(route.Route)
+		if err == nil {
+			err = checkHost(host, domains)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
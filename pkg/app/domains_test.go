@@ -0,0 +1,46 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestClusterDomains(t *testing.T) {
+	oc := mocks.NewMockOc()
+	controllersCmd := &mocks.ExecCmd{}
+	controllersCmd.On("CombinedOutput").Return([]byte(`{"items":[{"status":{"domain":"apps.example.com"}},{"status":{"domain":"internal.example.com"}}]}`), nil)
+	oc.Execer.On("Oc", []string{"get", "ingresscontrollers.operator.openshift.io",
+		"-n", "openshift-ingress-operator", "-o", "json"}).Return(controllersCmd)
+	configCmd := &mocks.ExecCmd{}
+	configCmd.On("CombinedOutput").Return([]byte(`{"spec":{"domain":"apps.example.com"}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "ingresses.config.openshift.io", "cluster", "-o", "json"}).Return(configCmd)
+
+	assert.Equal(t, []string{"apps.example.com", "internal.example.com"}, clusterDomains(context.Background(), oc))
+}
+
+func TestClusterDomainsWithoutPermission(t *testing.T) {
+	oc := mocks.NewMockOc()
+	forbiddenCmd := &mocks.ExecCmd{}
+	forbiddenCmd.On("CombinedOutput").Return([]byte("Error from server (Forbidden)"), errors.New("exit status 1"))
+	oc.Execer.On("Oc", []string{"get", "ingresscontrollers.operator.openshift.io",
+		"-n", "openshift-ingress-operator", "-o", "json"}).Return(forbiddenCmd)
+	oc.Execer.On("Oc", []string{"get", "ingresses.config.openshift.io", "cluster", "-o", "json"}).Return(forbiddenCmd)
+
+	assert.Empty(t, clusterDomains(context.Background(), oc))
+}
+
+func TestCheckHost(t *testing.T) {
+	domains := []string{"apps.example.com"}
+	assert.Nil(t, checkHost("foo.apps.example.com", domains))
+	assert.Nil(t, checkHost("apps.example.com", domains))
+	assert.Nil(t, checkHost("foo.elsewhere.com", nil))
+
+	err := checkHost("foo.example.com", domains)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "apps.example.com")
+}
@@ -0,0 +1,220 @@
+package app
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+// DefaultDropletImage is the image droplets run on when no --image is
+// given, the stack Cloud Foundry stages them for.
+const DefaultDropletImage string = "cloudfoundry/cflinuxfs4"
+
+const dropletDockerfile = `FROM %s
+ADD droplet.tgz /home/vcap/
+COPY launcher.sh start_command /home/vcap/
+RUN chmod 755 /home/vcap/launcher.sh && chgrp -R 0 /home/vcap && chmod -R g=u /home/vcap
+USER 2000
+WORKDIR /home/vcap/app
+ENV HOME=/home/vcap/app PORT=8080
+EXPOSE 8080
+CMD ["/home/vcap/launcher.sh"]
+`
+
+// dropletLauncher sets up the environment like Cloud Foundry's
+// launcher before running CF_COMMAND or the detected start command.
+const dropletLauncher = `#!/bin/bash
+cd /home/vcap/app
+for profile in /home/vcap/app/.profile.d/*.sh; do
+  [ -f "$profile" ] && . "$profile"
+done
+[ -f /home/vcap/app/.profile ] && . /home/vcap/app/.profile
+# Pass SIGTERM on to all of the command's processes, like Cloud Foundry
+trap 'trap "" TERM; kill -TERM 0; wait' TERM
+/bin/bash -c "${CF_COMMAND:-$(cat /home/vcap/start_command)}" &
+wait $!
+`
+
+// buildDroplet wraps the droplet in an image that runs it on image
+// with a Docker build, instead of staging the application again.
+func (app *Application) buildDroplet(image string) error {
+	dir, err := app.dropletBuildDir(image)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if app.kubernetes() {
+		return app.buildDropletImage(dir)
+	}
+	err = app.ensureDropletBuildExists()
+	if err != nil {
+		return err
+	}
+	return app.runBuild(fmt.Sprint("--from-dir=", dir))
+}
+
+// buildDropletImage builds the droplet image locally and pushes it to
+// the registry, since Kubernetes has no builds of its own.
+func (app *Application) buildDropletImage(dir string) error {
+	if app.options.Registry == "" {
+		return errors.New("Error: A registry is required to push droplets on Kubernetes")
+	}
+	image := app.registryImage()
+	buildCmd := app.execer.Command("docker", "build", "-t", image, dir)
+	buildCmd.AttachStdIO()
+	buildCmd.SetTimeout(exec.BuildTimeout)
+	log.Infof("Building droplet image with command: %s", buildCmd.ArgsString())
+	err := buildCmd.Run(app.context())
+	if err != nil {
+		return err
+	}
+
+	pushCmd := app.execer.Command("docker", "push", image)
+	pushCmd.AttachStdIO()
+	pushCmd.SetTimeout(exec.BuildTimeout)
+	log.Infof("Pushing image with command: %s", pushCmd.ArgsString())
+	err = pushCmd.Run(app.context())
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ensureDropletBuildExists creates a binary Docker build for the
+// application. Builds from source can't build droplets, so an
+// existing one has to be deleted first.
+func (app *Application) ensureDropletBuildExists() error {
+	exists, err := app.oc.Exists(app.context(), "bc", app.Name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		buildConfig := &types.BuildConfig{}
+		err = oc.Get(app.context(), app.oc, "bc", app.Name, buildConfig)
+		if err != nil {
+			return err
+		}
+		if buildConfig.Spec.Strategy.Type != "Docker" {
+			return errors.New(fmt.Sprintf("Error: Build configuration %s builds from source, delete it with 'oc delete bc %s' to push a droplet\n", app.Name, app.Name))
+		}
+		log.Infof("Build configuration already exists for %s, skipping creating one", app.Name)
+		return nil
+	}
+
+	app.created = append(app.created, "bc", "is")
+	newCmd := app.oc.Exec("new-build", "--binary=true", "--strategy=docker", fmt.Sprint("--name=", app.Name))
+	log.Infof("Creating build with command: %s", newCmd.ArgsString())
+	output, err := newCmd.CombinedOutput(app.context())
+	log.Printf("%s", output)
+	if err != nil {
+		return err
+	}
+	app.own("bc", "is")
+	return nil
+}
+
+// dropletBuildDir writes a Docker build context for running the
+// droplet on image, returning its directory.
+func (app *Application) dropletBuildDir(image string) (string, error) {
+	startCommand, err := dropletStartCommand(app.options.Droplet)
+	if err != nil {
+		return "", err
+	}
+	if startCommand == "" && app.Command == "" {
+		return "", errors.New(fmt.Sprintf("Error: Droplet %s has no start command, give one with -c\n", app.options.Droplet))
+	}
+
+	dir, err := ioutil.TempDir("", "ocf-droplet")
+	if err != nil {
+		return "", err
+	}
+	files := map[string]string{
+		"Dockerfile":    fmt.Sprintf(dropletDockerfile, image),
+		"launcher.sh":   dropletLauncher,
+		"start_command": startCommand,
+	}
+	for name, content := range files {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			os.RemoveAll(dir)
+			return "", err
+		}
+	}
+	err = copyFile(app.options.Droplet, filepath.Join(dir, "droplet.tgz"))
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// dropletStartCommand returns the start command the buildpack detected
+// when staging the droplet, from its staging_info.yml.
+func dropletStartCommand(droplet string) (string, error) {
+	file, err := os.Open(droplet)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("Error: Droplet %s isn't a gzipped tarball: %v\n", droplet, err))
+	}
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return "", errors.New(fmt.Sprintf("Error: Droplet %s has no staging_info.yml\n", droplet))
+		}
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimPrefix(header.Name, "./") != "staging_info.yml" {
+			continue
+		}
+		stagingInfo := &struct {
+			StartCommand string `yaml:"start_command"`
+		}{}
+		data, err := ioutil.ReadAll(tarReader)
+		if err != nil {
+			return "", err
+		}
+		err = yaml.Unmarshal(data, stagingInfo)
+		if err != nil {
+			return "", errors.New(fmt.Sprintf("Error parsing staging_info.yml of droplet %s: %v\n", droplet, err))
+		}
+		return stagingInfo.StartCommand, nil
+	}
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	closeErr := out.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func writeDroplet(t *testing.T, dir string, stagingInfo string) string {
+	droplet := filepath.Join(dir, "droplet.tgz")
+	file, err := os.Create(droplet)
+	assert.Nil(t, err)
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+	assert.Nil(t, tarWriter.WriteHeader(&tar.Header{Name: "./app/", Typeflag: tar.TypeDir, Mode: 0755}))
+	if stagingInfo != "" {
+		assert.Nil(t, tarWriter.WriteHeader(&tar.Header{Name: "./staging_info.yml", Mode: 0644, Size: int64(len(stagingInfo))}))
+		_, err = tarWriter.Write([]byte(stagingInfo))
+		assert.Nil(t, err)
+	}
+	tarWriter.Close()
+	gzipWriter.Close()
+	file.Close()
+	return droplet
+}
+
+func TestDropletBuildDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocf-droplet-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	droplet := writeDroplet(t, dir, `{"detected_buildpack":"ruby","start_command":"bundle exec rackup -p $PORT"}`)
+
+	app := Application{Name: "foo", options: PushOptions{Droplet: droplet}}
+	buildDir, err := app.dropletBuildDir("registry.example.com/cflinuxfs4")
+	assert.Nil(t, err)
+	defer os.RemoveAll(buildDir)
+
+	dockerfile, err := ioutil.ReadFile(filepath.Join(buildDir, "Dockerfile"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(dockerfile), "FROM registry.example.com/cflinuxfs4\n")
+	startCommand, err := ioutil.ReadFile(filepath.Join(buildDir, "start_command"))
+	assert.Nil(t, err)
+	assert.Equal(t, "bundle exec rackup -p $PORT", string(startCommand))
+	_, err = os.Stat(filepath.Join(buildDir, "droplet.tgz"))
+	assert.Nil(t, err)
+}
+
+func TestDropletBuildDirWithoutStartCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocf-droplet-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	droplet := writeDroplet(t, dir, "")
+
+	app := Application{Name: "foo", options: PushOptions{Droplet: droplet}}
+	_, err = app.dropletBuildDir(DefaultDropletImage)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "has no staging_info.yml")
+}
+
+func TestEnsureDropletBuildExists(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+	oc.On("Exists", "bc", "foo").Return(false, nil)
+	newCmd := &mocks.ExecCmd{}
+	newCmd.On("ArgsString").Return("")
+	newCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", []string{"new-build", "--binary=true", "--strategy=docker", "--name=foo"}).Return(newCmd)
+
+	assert.Nil(t, app.ensureDropletBuildExists())
+	oc.Execer.AssertExpectations(t)
+	assert.Equal(t, []string{"bc", "is"}, app.created)
+}
+
+func TestEnsureDropletBuildExistsFromSource(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+	oc.On("Exists", "bc", "foo").Return(true, nil)
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(`{"spec":{"strategy":{"type":"Source"}}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "bc", "foo", "-o", "json"}).Return(getCmd)
+
+	err := app.ensureDropletBuildExists()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "builds from source")
+}
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+)
+
+// ParseEnvFile reads the NAME=VALUE lines of a dotenv file. Blank
+// lines and comments are skipped and lines can start with "export".
+// Values can be single quoted, to be taken literally, or double
+// quoted, where \n, \", and \\ are escapes. Unquoted values end at a
+// " #" comment.
+func ParseEnvFile(contents []byte) (map[string]string, error) {
+	env := make(map[string]string)
+	for i, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		split := strings.SplitN(line, "=", 2)
+		if len(split) != 2 {
+			return nil, errors.New(fmt.Sprintf("Error: Line %d isn't NAME=VALUE\n", i+1))
+		}
+		name := strings.TrimSpace(split[0])
+		err := oc.CheckEnvName(name)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Error on line %d: %v", i+1, err))
+		}
+		value, err := envFileValue(strings.TrimSpace(split[1]))
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Error on line %d: %v\n", i+1, err))
+		}
+		env[name] = value
+	}
+	return env, nil
+}
+
+func envFileValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", errors.New("unterminated single quote")
+		}
+		return value[1 : end+1], nil
+	case strings.HasPrefix(value, `"`):
+		var unquoted strings.Builder
+		for i := 1; i < len(value); i++ {
+			switch value[i] {
+			case '"':
+				return unquoted.String(), nil
+			case '\\':
+				if i+1 < len(value) {
+					i++
+					if value[i] == 'n' {
+						unquoted.WriteByte('\n')
+					} else {
+						unquoted.WriteByte(value[i])
+					}
+					continue
+				}
+			}
+			unquoted.WriteByte(value[i])
+		}
+		return "", errors.New("unterminated double quote")
+	}
+	if comment := strings.Index(value, " #"); comment >= 0 {
+		value = strings.TrimSpace(value[:comment])
+	}
+	return value, nil
+}
+
+// SetEnvs sets every variable of env on the application's deployment,
+// or on its build config if build is true, in a single change. Unless
+// confirm is nil, it's shown the changes first and nothing is set if
+// it doesn't agree. The changes made are returned.
+func (app *Application) SetEnvs(env map[string]string, build bool, confirm func([]Change) bool) ([]Change, error) {
+	for name := range env {
+		err := oc.CheckEnvName(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return nil, err
+	}
+	app.displayProject()
+
+	var exists bool
+	var err error
+	if build {
+		exists, err = app.oc.Exists(app.context(), "bc", app.Name)
+	} else {
+		exists, err = app.deploymentExists()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New(fmt.Sprintf("Error: Application %s not found\n", app.Name))
+	}
+
+	objType := app.workloadKind()
+	if build {
+		objType = "bc"
+	}
+	live, err := app.oc.Env(app.context(), objType, app.Name)
+	if err != nil {
+		return nil, err
+	}
+	changes := envChanges(live, env)
+	if len(changes) == 0 {
+		log.Infof("No changes to the environment of %s", app.Name)
+		return nil, nil
+	}
+	if confirm != nil && !confirm(changes) {
+		return nil, nil
+	}
+	err = app.oc.SetEnv(app.context(), objType, app.Name, env)
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func envChanges(live map[string]string, desired map[string]string) []Change {
+	var names []string
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var changes []Change
+	for _, name := range names {
+		if live[name] != desired[name] {
+			changes = append(changes, Change{Field: fmt.Sprint("env ", name), Live: live[name], Desired: desired[name]})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	env, err := ParseEnvFile([]byte(`
+# Production settings
+export LOG_LEVEL=info
+DATABASE_URL=postgres://db:5432/app?sslmode=require # the primary
+VCAP_CONFIG='{"a": 1, "b": "c=d"}'
+GREETING="hello\n\"world\""
+EMPTY=
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"LOG_LEVEL":    "info",
+		"DATABASE_URL": "postgres://db:5432/app?sslmode=require",
+		"VCAP_CONFIG":  `{"a": 1, "b": "c=d"}`,
+		"GREETING":     "hello\n\"world\"",
+		"EMPTY":        "",
+	}, env)
+
+	_, err = ParseEnvFile([]byte("FOO=bar\nnot a variable\n"))
+	assert.Contains(t, err.Error(), "Line 2")
+	_, err = ParseEnvFile([]byte("1FOO=bar\n"))
+	assert.Contains(t, err.Error(), "line 1")
+	_, err = ParseEnvFile([]byte(`FOO="bar`))
+	assert.Contains(t, err.Error(), "unterminated")
+}
+
+func TestSetEnvs(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.On("Exists", "dc", "foo").Return(true, nil)
+	oc.On("Env", "dc", "foo").Return(map[string]string{"A": "1", "B": "2"}, nil)
+	app := Application{oc: oc, Name: "foo"}
+	env := map[string]string{"A": "1", "B": "3", "C": "4"}
+
+	var shown []Change
+	changes, err := app.SetEnvs(env, false, func(changes []Change) bool {
+		shown = changes
+		return false
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, changes)
+	assert.Equal(t, []Change{{Field: "env B", Live: "2", Desired: "3"}, {Field: "env C", Desired: "4"}}, shown)
+
+	oc.On("SetEnv", "dc", "foo", env).Return(nil).Once()
+	changes, err = app.SetEnvs(env, false, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, shown, changes)
+	oc.AssertExpectations(t)
+}
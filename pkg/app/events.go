@@ -0,0 +1,120 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// EventPhaseStart is emitted when a step of the push starts
+	EventPhaseStart string = "phase-start"
+	// EventPhaseEnd is emitted when a step of the push finishes,
+	// whether or not it succeeded
+	EventPhaseEnd string = "phase-end"
+	// EventLog is a line of output from a command the step ran, like
+	// a build
+	EventLog string = "log"
+	// EventError is emitted with the error a push failed with
+	EventError string = "error"
+	// EventDone is emitted by Done once a push finishes, with its
+	// error if it failed
+	EventDone string = "done"
+)
+
+// Event is one line of a push's event stream.
+type Event struct {
+	Time  string `json:"time"`
+	Type  string `json:"type"`
+	App   string `json:"app,omitempty"`
+	Phase string `json:"phase,omitempty"`
+	// Status is "ok", "failed", or "skipped" for EventPhaseEnd
+	Status string `json:"status,omitempty"`
+	// Seconds is how long the phase ran for EventPhaseEnd
+	Seconds float64 `json:"seconds,omitempty"`
+	Text    string  `json:"text,omitempty"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// EventStream writes the events of pushes as newline-delimited JSON.
+// Written output, like streamed build logs, becomes EventLog events
+// of the current phase, one per line.
+type EventStream struct {
+	mutex   sync.Mutex
+	w       io.Writer
+	app     string
+	phase   string
+	pending bytes.Buffer
+}
+
+// NewEventStream returns an EventStream writing to w.
+func NewEventStream(w io.Writer) *EventStream {
+	return &EventStream{w: w}
+}
+
+// Write turns each complete line of p into an EventLog event.
+func (stream *EventStream) Write(p []byte) (int, error) {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	stream.pending.Write(p)
+	for {
+		i := bytes.IndexByte(stream.pending.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+		line := string(bytes.TrimRight(stream.pending.Next(i+1), "\r\n"))
+		stream.emit(Event{Type: EventLog, Text: line})
+	}
+	return len(p), nil
+}
+
+func (stream *EventStream) startPhase(app string, phase string) {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	stream.app = app
+	stream.phase = phase
+	stream.emit(Event{Type: EventPhaseStart})
+}
+
+func (stream *EventStream) endPhase(status string, started time.Time, err error) {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	if stream.pending.Len() > 0 {
+		stream.emit(Event{Type: EventLog, Text: stream.pending.String()})
+		stream.pending.Reset()
+	}
+	end := Event{Type: EventPhaseEnd, Status: status}
+	if !started.IsZero() {
+		end.Seconds = time.Since(started).Seconds()
+	}
+	stream.emit(end)
+	if err != nil {
+		stream.emit(Event{Type: EventError, Error: err.Error()})
+	}
+	stream.phase = ""
+}
+
+// Done emits an EventDone event for app's push.
+func (stream *EventStream) Done(app string, err error) {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	stream.app = app
+	done := Event{Type: EventDone, Status: "ok"}
+	if err != nil {
+		done.Status = "failed"
+		done.Error = err.Error()
+	}
+	stream.emit(done)
+}
+
+// emit writes event as the current application and phase. The
+// caller holds the mutex.
+func (stream *EventStream) emit(event Event) {
+	event.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	event.App = stream.app
+	event.Phase = stream.phase
+	line, _ := json.Marshal(event)
+	stream.w.Write(append(line, '\n'))
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readEvents(t *testing.T, output string) []Event {
+	var events []Event
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		var event Event
+		assert.Nil(t, json.Unmarshal([]byte(line), &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestPipelineEvents(t *testing.T) {
+	var output bytes.Buffer
+	stream := NewEventStream(&output)
+	failed := errors.New("build failed")
+	steps := []Step{
+		NewStep("build", func(ctx context.Context, state *PushState) error {
+			fmt.Fprint(stream, "Cloning...\nPushing")
+			return failed
+		}),
+	}
+	pipeline := &Pipeline{Steps: append(recordingSteps(new([]string), "quota"), steps...), Skip: []string{"quota"}, Events: stream}
+	assert.Equal(t, failed, pipeline.Run(context.Background(), &PushState{App: &Application{Name: "foo"}}))
+
+	events := readEvents(t, output.String())
+	var summary []string
+	for _, event := range events {
+		assert.Equal(t, "foo", event.App)
+		summary = append(summary, strings.Join([]string{event.Type, event.Phase, event.Status, event.Text, event.Error}, " "))
+	}
+	assert.Equal(t, []string{
+		"phase-start quota   ",
+		"phase-end quota skipped  ",
+		"phase-start build   ",
+		"log build  Cloning... ",
+		"log build  Pushing ",
+		"phase-end build failed  ",
+		"error build   build failed",
+	}, summary)
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// listFiles lists a directory or prints a file, like 'cf files'.
+const listFiles = `if [ -d "$1" ]; then ls -la "$1"; else cat "$1"; fi`
+
+// Files lists a directory, or prints a file, in one of the
+// application's instances. path is relative to the application's
+// directory unless it's absolute.
+func (app *Application) Files(path string, instance int) error {
+	if path == "" {
+		path = "."
+	}
+	return app.Exec([]string{"sh", "-c", listFiles, "sh", path}, instance, false)
+}
+
+// Exec runs command in the application's container in one of its
+// instances, with a terminal if tty is true. instance is an index as
+// 'ocf app' lists them, or -1 for the first running instance.
+func (app *Application) Exec(command []string, instance int, tty bool) error {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+
+	args, err := app.execArgs(command, instance, tty)
+	if err != nil {
+		return err
+	}
+	execCmd := app.oc.Exec(args...)
+	execCmd.AttachStdIO()
+	// Commands can run as long as the user needs
+	execCmd.SetTimeout(0)
+	log.Debugf("Running command: %s", execCmd.ArgsString())
+	return execCmd.Run(app.context())
+}
+
+func (app *Application) execArgs(command []string, instance int, tty bool) ([]string, error) {
+	appExists, err := app.deploymentExists()
+	if err != nil {
+		return nil, err
+	}
+	if !appExists {
+		return nil, errors.New(fmt.Sprintf("Error: Application %s not found\n", app.Name))
+	}
+	pod, err := app.instancePod(instance)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"exec", pod, "-c", app.Name}
+	if tty {
+		args = append(args, "-it")
+	}
+	if len(command) == 0 {
+		command = []string{"/bin/sh"}
+	}
+	return append(append(args, "--"), command...), nil
+}
+
+// instancePod returns the pod of the instance numbered index, or of
+// the first running instance if index is -1.
+func (app *Application) instancePod(index int) (string, error) {
+	if index < 0 {
+		pods, err := app.runningPods()
+		if err != nil {
+			return "", err
+		}
+		if len(pods) == 0 {
+			return "", errors.New(fmt.Sprintf("Error: No running pods found for %s\n", app.Name))
+		}
+		return pods[0], nil
+	}
+	pods, err := app.instancePods()
+	if err != nil {
+		return "", err
+	}
+	if index >= len(pods) {
+		return "", errors.New(fmt.Sprintf("Error: Instance %d of %s not found, it has %d instances\n", index, app.Name, len(pods)))
+	}
+	return pods[index], nil
+}
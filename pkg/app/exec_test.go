@@ -0,0 +1,34 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestExecArgs(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	oc.On("Exists", "dc", "foo").Return(true, nil)
+	runningCmd := &mocks.ExecCmd{}
+	runningCmd.On("CombinedOutput").Return([]byte("pod/foo-2-abcde\n"), nil)
+	oc.Execer.On("Oc", []string{"get", "pods", "--selector=deploymentconfig=foo",
+		"--field-selector=status.phase=Running", "-o", "name"}).Return(runningCmd)
+	podsCmd := &mocks.ExecCmd{}
+	podsCmd.On("CombinedOutput").Return([]byte(`{"items":[{"metadata":{"name":"foo-2-abcde"}},{"metadata":{"name":"foo-2-fghij"}}]}`), nil)
+	oc.Execer.On("Oc", []string{"get", "pods", "--selector=deploymentconfig=foo", "-o", "json"}).Return(podsCmd)
+
+	args, err := app.execArgs(nil, -1, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"exec", "foo-2-abcde", "-c", "foo", "-it", "--", "/bin/sh"}, args)
+
+	args, err = app.execArgs([]string{"ls", "logs"}, 1, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"exec", "foo-2-fghij", "-c", "foo", "--", "ls", "logs"}, args)
+
+	_, err = app.execArgs(nil, 2, false)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,184 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+// DefaultGCKeep is how many builds, replication controllers, and
+// build tags 'ocf gc' keeps by default.
+const DefaultGCKeep = 5
+
+// GCResult lists what GC deleted, or would delete on a dry run.
+type GCResult struct {
+	Builds                 []string
+	ReplicationControllers []string
+	Tags                   []string
+}
+
+// finishedBuildPhases are the phases of builds that can be deleted.
+var finishedBuildPhases = map[string]bool{
+	"Complete":  true,
+	"Failed":    true,
+	"Error":     true,
+	"Cancelled": true,
+}
+
+// GC deletes the application's finished builds, scaled down
+// replication controllers, and build tags beyond the newest keep of
+// each. The running replication controller and the tag deployed as
+// latest are always kept.
+func (app *Application) GC(keep int, dryRun bool) (*GCResult, error) {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return nil, err
+	}
+	app.displayProject()
+
+	if keep < 1 {
+		return nil, errors.New("Error: At least one of each must be kept")
+	}
+	appExists, err := app.deploymentExists()
+	if err != nil {
+		return nil, err
+	}
+	if !appExists {
+		return nil, errors.New(fmt.Sprintf("Error: Application %s not found\n", app.Name))
+	}
+
+	result, err := app.gcCandidates(keep)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	for _, build := range result.Builds {
+		log.Infof("Deleting build %s", build)
+		err = app.oc.Delete(app.context(), "build", build)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, controller := range result.ReplicationControllers {
+		log.Infof("Deleting %s %s", app.controllerKind(), controller)
+		err = app.oc.Delete(app.context(), app.controllerKind(), controller)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, tag := range result.Tags {
+		log.Infof("Deleting image stream tag %s:%s", app.Name, tag)
+		output, err := app.oc.Exec("tag", "-d", fmt.Sprint(app.Name, ":", tag)).CombinedOutput(app.context())
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Error deleting image stream tag %s:%s: %s\n", app.Name, tag, output))
+		}
+	}
+	return result, nil
+}
+
+// controllerKind returns the type of the objects the application's
+// workload keeps for its earlier rollouts.
+func (app *Application) controllerKind() string {
+	if app.workloadKind() == "dc" {
+		return "rc"
+	}
+	return "rs"
+}
+
+// gcCandidates returns what GC would delete.
+func (app *Application) gcCandidates(keep int) (*GCResult, error) {
+	result := &GCResult{}
+
+	controllerSelector := fmt.Sprint("app=", app.Name)
+	if app.workloadKind() == "dc" {
+		controllerSelector = fmt.Sprint("openshift.io/deployment-config.name=", app.Name)
+	}
+	controllers := &types.ReplicationControllerList{}
+	err := oc.GetSelected(app.context(), app.oc, app.controllerKind(), controllerSelector, controllers)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(controllers.Items, func(i, j int) bool {
+		return controllers.Items[i].Metadata.CreationTimestamp > controllers.Items[j].Metadata.CreationTimestamp
+	})
+	for i, controller := range controllers.Items {
+		if i >= keep && controller.Spec.Replicas == 0 {
+			result.ReplicationControllers = append(result.ReplicationControllers, controller.Metadata.Name)
+		}
+	}
+
+	if app.kubernetes() || app.IsDocker() {
+		// Images are built outside the cluster
+		return result, nil
+	}
+
+	builds := &types.BuildList{}
+	err = oc.GetSelected(app.context(), app.oc, "build", fmt.Sprint("openshift.io/build-config.name=", app.Name), builds)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(builds.Items, func(i, j int) bool {
+		return builds.Items[i].Metadata.CreationTimestamp > builds.Items[j].Metadata.CreationTimestamp
+	})
+	for i, build := range builds.Items {
+		if i >= keep && finishedBuildPhases[build.Status.Phase] {
+			result.Builds = append(result.Builds, build.Metadata.Name)
+		}
+	}
+
+	result.Tags, err = app.oldBuildTags(keep)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// oldBuildTags returns the build tags of the application's image
+// stream beyond the newest keep, other than the one latest points at.
+func (app *Application) oldBuildTags(keep int) ([]string, error) {
+	imageStream := &types.ImageStream{}
+	err := oc.Get(app.context(), app.oc, "is", app.Name, imageStream)
+	if err != nil {
+		return nil, err
+	}
+	latestImage := ""
+	var tags []string
+	images := make(map[string]string)
+	for _, tag := range imageStream.Status.Tags {
+		image := ""
+		if len(tag.Items) > 0 {
+			image = tag.Items[0].Image
+		}
+		if tag.Tag == "latest" {
+			latestImage = image
+			continue
+		}
+		// Only tags ocf made for its builds are removed
+		if len(tag.Tag) < len(buildTagFormat) {
+			continue
+		}
+		if _, err := time.Parse(buildTagFormat, tag.Tag[:len(buildTagFormat)]); err != nil {
+			continue
+		}
+		tags = append(tags, tag.Tag)
+		images[tag.Tag] = image
+	}
+	// Build tags start with their time, so sort newest first
+	sort.Sort(sort.Reverse(sort.StringSlice(tags)))
+
+	var old []string
+	for i, tag := range tags {
+		if i >= keep && images[tag] != latestImage {
+			old = append(old, tag)
+		}
+	}
+	return old, nil
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestGCCandidates(t *testing.T) {
+	oc := mocks.NewMockOc()
+	rcCmd := &mocks.ExecCmd{}
+	rcCmd.On("CombinedOutput").Return([]byte(`{"items":[
+		{"metadata":{"name":"foo-1","creationTimestamp":"2020-01-01T00:00:00Z"},"spec":{"replicas":0}},
+		{"metadata":{"name":"foo-3","creationTimestamp":"2020-01-03T00:00:00Z"},"spec":{"replicas":1}},
+		{"metadata":{"name":"foo-2","creationTimestamp":"2020-01-02T00:00:00Z"},"spec":{"replicas":0}}]}`), nil)
+	oc.Execer.On("Oc", []string{"get", "rc", "--selector=openshift.io/deployment-config.name=foo", "-o", "json"}).Return(rcCmd)
+	buildsCmd := &mocks.ExecCmd{}
+	buildsCmd.On("CombinedOutput").Return([]byte(`{"items":[
+		{"metadata":{"name":"foo-1","creationTimestamp":"2020-01-01T00:00:00Z"},"status":{"phase":"Complete"}},
+		{"metadata":{"name":"foo-2","creationTimestamp":"2020-01-02T00:00:00Z"},"status":{"phase":"Running"}},
+		{"metadata":{"name":"foo-3","creationTimestamp":"2020-01-03T00:00:00Z"},"status":{"phase":"Failed"}},
+		{"metadata":{"name":"foo-4","creationTimestamp":"2020-01-04T00:00:00Z"},"status":{"phase":"Complete"}}]}`), nil)
+	oc.Execer.On("Oc", []string{"get", "build", "--selector=openshift.io/build-config.name=foo", "-o", "json"}).Return(buildsCmd)
+	isCmd := &mocks.ExecCmd{}
+	isCmd.On("CombinedOutput").Return([]byte(`{"status":{"tags":[
+		{"tag":"latest","items":[{"image":"sha256:aaa"}]},
+		{"tag":"20200101-000000","items":[{"image":"sha256:aaa"}]},
+		{"tag":"20200102-000000-0123456","items":[{"image":"sha256:bbb"}]},
+		{"tag":"20200103-000000","items":[{"image":"sha256:ccc"}]},
+		{"tag":"stable","items":[{"image":"sha256:ddd"}]}]}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "is", "foo", "-o", "json"}).Return(isCmd)
+
+	app := Application{oc: oc, Name: "foo", kind: "dc"}
+	result, err := app.gcCandidates(1)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"foo-2", "foo-1"}, result.ReplicationControllers)
+	assert.Equal(t, []string{"foo-3", "foo-1"}, result.Builds)
+	// The oldest tag is deployed as latest and stable isn't a build tag
+	assert.Equal(t, []string{"20200102-000000-0123456"}, result.Tags)
+}
+
+func TestGCCandidatesOfDeployment(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.PlatformName = "k8s"
+	rsCmd := &mocks.ExecCmd{}
+	rsCmd.On("CombinedOutput").Return([]byte(`{"items":[
+		{"metadata":{"name":"foo-a","creationTimestamp":"2020-01-01T00:00:00Z"},"spec":{"replicas":0}},
+		{"metadata":{"name":"foo-b","creationTimestamp":"2020-01-02T00:00:00Z"},"spec":{"replicas":1}}]}`), nil)
+	oc.Execer.On("Oc", []string{"get", "rs", "--selector=app=foo", "-o", "json"}).Return(rsCmd)
+
+	app := Application{oc: oc, Name: "foo", kind: "deployment"}
+	result, err := app.gcCandidates(1)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"foo-a"}, result.ReplicationControllers)
+	assert.Empty(t, result.Builds)
+	assert.Empty(t, result.Tags)
+}
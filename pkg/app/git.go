@@ -0,0 +1,249 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+const (
+	// webHookSecretKey is the key of a webhook trigger's referenced
+	// secret holding its secret.
+	webHookSecretKey = "WebHookSecretKey"
+	// WebhookGitHub and WebhookGitLab are the Git hosts whose webhooks
+	// create-webhook configures
+	WebhookGitHub = "github"
+	WebhookGitLab = "gitlab"
+)
+
+// Webhook is a build config webhook that starts a build when pushing
+// to the application's Git repository.
+type Webhook struct {
+	Type   string `json:"type"`
+	URL    string `json:"url"`
+	Secret string `json:"secret"`
+}
+
+// ParseGitURL splits a Git URL like https://github.com/org/repo#branch
+// into the repository and the branch, tag, or commit to build.
+func ParseGitURL(url string) (string, string, error) {
+	repo, ref := url, ""
+	if i := strings.Index(url, "#"); i >= 0 {
+		repo, ref = url[:i], url[i+1:]
+	}
+	if !strings.Contains(repo, "://") && !strings.HasPrefix(repo, "git@") {
+		return "", "", errors.New(fmt.Sprintf("Error: %s is not a Git repository URL\n", url))
+	}
+	return repo, ref, nil
+}
+
+// ensureGitBuildExists creates a source build of the application from
+// its --git repository, or points an existing build at it.
+func (app *Application) ensureGitBuildExists(image string) error {
+	repo, ref, err := ParseGitURL(app.options.Git)
+	if err != nil {
+		return err
+	}
+	exists, err := app.oc.Exists(app.context(), "bc", app.Name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		err = app.ensureBuildExists(image)
+		if err != nil {
+			return err
+		}
+		return app.setGitSource(repo, ref)
+	}
+
+	source := repo
+	if ref != "" {
+		source = fmt.Sprint(repo, "#", ref)
+	}
+	args := []string{"new-build", fmt.Sprint(image, "~", source), "--strategy=source", fmt.Sprint("--name=", app.Name)}
+	env := make(map[string]string)
+	for key, value := range app.BuildEnv {
+		env[key] = value
+	}
+	if app.Buildpack != "" {
+		env[BuildpackUrl] = app.Buildpack
+	}
+	var envArgs []string
+	for key, value := range env {
+		err := oc.CheckEnvName(key)
+		if err != nil {
+			return err
+		}
+		envArgs = append(envArgs, fmt.Sprint(key, "=", value))
+	}
+	sort.Strings(envArgs)
+	for _, envArg := range envArgs {
+		args = append(args, "-e", envArg)
+	}
+	app.created = append(app.created, "bc", "is")
+	cmd := app.oc.Exec(args...)
+	log.Infof("Creating build with command: %s", cmd.ArgsString())
+	// oc new-build sometimes gives a non-zero exit status for ignorable errors
+	output, _ := cmd.CombinedOutput(app.context())
+	log.Printf("%s", output)
+	app.own("bc", "is")
+	return app.displayWebhooks()
+}
+
+// setGitSource points the application's build at repo and ref,
+// replacing a binary source from an earlier push.
+func (app *Application) setGitSource(repo string, ref string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"source": map[string]interface{}{
+				"type":   "Git",
+				"git":    map[string]string{"uri": repo, "ref": ref},
+				"binary": nil,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	output, err := app.oc.Exec("patch", "bc", app.Name, "--type=merge", "-p", string(patch)).CombinedOutput(app.context())
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error setting the Git source of %s: %s\n", app.Name, output))
+	}
+	return nil
+}
+
+// CreateWebhook adds a webhook for provider, github or gitlab, to the
+// build of an application pushed with --git, printing the URL and
+// secret to give the repository.
+func (app *Application) CreateWebhook(provider string) error {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+
+	if app.kubernetes() {
+		return errors.New("Error: Webhooks need OpenShift build configurations, so aren't supported on the k8s platform")
+	}
+	exists, err := app.oc.Exists(app.context(), "bc", app.Name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New(fmt.Sprintf("Error: Build configuration for %s not found\n", app.Name))
+	}
+	bc := &types.BuildConfig{}
+	err = oc.Get(app.context(), app.oc, "bc", app.Name, bc)
+	if err != nil {
+		return err
+	}
+	if bc.Spec.Source.Type != "Git" {
+		return errors.New(fmt.Sprintf("Error: %s isn't built from a Git repository, push it with --git first\n", app.Name))
+	}
+
+	webhooks, err := app.webhooks(bc)
+	if err != nil {
+		return err
+	}
+	if findWebhook(webhooks, provider) == nil {
+		log.Infof("Adding a %s webhook to the build of %s", provider, app.Name)
+		output, err := app.oc.Exec("set", "triggers", fmt.Sprint("bc/", app.Name), fmt.Sprint("--from-", provider)).CombinedOutput(app.context())
+		if err != nil {
+			return errors.New(fmt.Sprintf("Error adding a %s webhook to %s: %s\n", provider, app.Name, output))
+		}
+		err = oc.Get(app.context(), app.oc, "bc", app.Name, bc)
+		if err != nil {
+			return err
+		}
+		webhooks, err = app.webhooks(bc)
+		if err != nil {
+			return err
+		}
+	}
+	webhook := findWebhook(webhooks, provider)
+	if webhook == nil {
+		return errors.New(fmt.Sprintf("Error: %s webhook of %s not found\n", provider, app.Name))
+	}
+	log.Printf("Webhook URL: %s", webhook.URL)
+	log.Printf("Secret: %s", webhook.Secret)
+	return nil
+}
+
+// findWebhook returns the webhook of type kind, or nil if there's none.
+func findWebhook(webhooks []Webhook, kind string) *Webhook {
+	for i := range webhooks {
+		if webhooks[i].Type == kind {
+			return &webhooks[i]
+		}
+	}
+	return nil
+}
+
+// webhooks returns the webhooks of the application's build config bc.
+func (app *Application) webhooks(bc *types.BuildConfig) ([]Webhook, error) {
+	project, err := app.oc.Project(app.context())
+	if err != nil {
+		return nil, err
+	}
+	output, err := app.oc.Exec("whoami", "--show-server").CombinedOutput(app.context())
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error getting the cluster's address: %s\n", output))
+	}
+	server := strings.TrimSuffix(strings.TrimSpace(string(output)), "/")
+
+	var webhooks []Webhook
+	for _, trigger := range bc.Spec.Triggers {
+		var hook *types.WebHookTrigger
+		kind := strings.ToLower(trigger.Type)
+		switch kind {
+		case WebhookGitHub:
+			hook = trigger.GitHub
+		case WebhookGitLab:
+			hook = trigger.GitLab
+		case "bitbucket":
+			hook = trigger.Bitbucket
+		case "generic":
+			hook = trigger.Generic
+		}
+		if hook == nil {
+			continue
+		}
+		secret := hook.Secret
+		if secret == "" && hook.SecretReference != nil {
+			data, err := app.oc.Data(app.context(), "secret", hook.SecretReference.Name)
+			if err != nil {
+				return nil, err
+			}
+			secret = data[webHookSecretKey]
+		}
+		url := fmt.Sprintf("%s/apis/build.openshift.io/v1/namespaces/%s/buildconfigs/%s/webhooks/%s/%s",
+			server, project, app.Name, secret, kind)
+		webhooks = append(webhooks, Webhook{Type: kind, URL: url, Secret: secret})
+	}
+	return webhooks, nil
+}
+
+// displayWebhooks prints the URL and secret of each of the
+// application's webhooks, to add to its Git repository.
+func (app *Application) displayWebhooks() error {
+	bc := &types.BuildConfig{}
+	err := oc.Get(app.context(), app.oc, "bc", app.Name, bc)
+	if err != nil {
+		return err
+	}
+	webhooks, err := app.webhooks(bc)
+	if err != nil {
+		return err
+	}
+	for _, webhook := range webhooks {
+		log.Printf("%s webhook URL: %s", webhook.Type, webhook.URL)
+		log.Printf("%s webhook secret: %s", webhook.Type, webhook.Secret)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+const gitBuildConfig = `{
+  "spec": {
+    "source": {"type": "Git"},
+    "triggers": [
+      {"type": "GitHub", "github": {"secretReference": {"name": "foo-github-webhook-secret"}}},
+      {"type": "Generic", "generic": {"secret": "s3cr3t"}},
+      {"type": "ConfigChange"}
+    ]
+  }
+}`
+
+func TestParseGitURL(t *testing.T) {
+	repo, ref, err := ParseGitURL("https://github.com/org/repo#main")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://github.com/org/repo", repo)
+	assert.Equal(t, "main", ref)
+
+	repo, ref, err = ParseGitURL("git@github.com:org/repo.git")
+	assert.Nil(t, err)
+	assert.Equal(t, "git@github.com:org/repo.git", repo)
+	assert.Equal(t, "", ref)
+
+	_, _, err = ParseGitURL("../repo")
+	assert.NotNil(t, err)
+}
+
+func gitBuildOc() *mocks.Oc {
+	oc := mocks.NewMockOc()
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(gitBuildConfig), nil)
+	oc.Execer.On("Oc", []string{"get", "bc", "foo", "-o", "json"}).Return(getCmd)
+	serverCmd := &mocks.ExecCmd{}
+	serverCmd.On("CombinedOutput").Return([]byte("https://api.example.com:6443\n"), nil)
+	oc.Execer.On("Oc", []string{"whoami", "--show-server"}).Return(serverCmd)
+	oc.On("Data", "secret", "foo-github-webhook-secret").Return(map[string]string{webHookSecretKey: "abc"}, nil)
+	return oc
+}
+
+func TestWebhooks(t *testing.T) {
+	bc := &types.BuildConfig{}
+	assert.Nil(t, json.Unmarshal([]byte(gitBuildConfig), bc))
+	app := Application{oc: gitBuildOc(), Name: "foo"}
+	webhooks, err := app.webhooks(bc)
+	assert.Nil(t, err)
+	assert.Equal(t, []Webhook{
+		{Type: "github", Secret: "abc",
+			URL: "https://api.example.com:6443/apis/build.openshift.io/v1/namespaces/test-project/buildconfigs/foo/webhooks/abc/github"},
+		{Type: "generic", Secret: "s3cr3t",
+			URL: "https://api.example.com:6443/apis/build.openshift.io/v1/namespaces/test-project/buildconfigs/foo/webhooks/s3cr3t/generic"},
+	}, webhooks)
+}
+
+func TestEnsureGitBuildExistsWhenDoesnt(t *testing.T) {
+	oc := gitBuildOc()
+	oc.On("Exists", "bc", "foo").Return(false, nil)
+	newBuildCmd := &mocks.ExecCmd{}
+	newBuildCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", []string{"new-build", "my-image~https://github.com/org/repo#main", "--strategy=source",
+		"--name=foo", "-e", BuildpackUrl + "=bp"}).Return(newBuildCmd)
+
+	app := Application{oc: oc, Name: "foo", Buildpack: "bp"}
+	app.options.Git = "https://github.com/org/repo#main"
+	assert.Nil(t, app.ensureGitBuildExists("my-image"))
+	newBuildCmd.AssertExpectations(t)
+	assert.Equal(t, []string{"bc", "is"}, app.created)
+}
+
+func TestCreateWebhookWhenExists(t *testing.T) {
+	oc := gitBuildOc()
+	oc.On("Exists", "bc", "foo").Return(true, nil)
+	app := Application{oc: oc, Name: "foo"}
+	assert.Nil(t, app.CreateWebhook(WebhookGitHub))
+	oc.Execer.AssertNotCalled(t, "Oc", []string{"set", "triggers", "bc/foo", "--from-github"})
+}
+
+func TestCreateWebhookNotFromGit(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.On("Exists", "bc", "foo").Return(true, nil)
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(`{"spec": {"source": {"type": "Binary"}}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "bc", "foo", "-o", "json"}).Return(getCmd)
+	app := Application{oc: oc, Name: "foo"}
+	assert.NotNil(t, app.CreateWebhook(WebhookGitHub))
+}
+
+func TestCreateWebhookAddsTrigger(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.On("Exists", "bc", "foo").Return(true, nil)
+	serverCmd := &mocks.ExecCmd{}
+	serverCmd.On("CombinedOutput").Return([]byte("https://api.example.com:6443\n"), nil)
+	oc.Execer.On("Oc", []string{"whoami", "--show-server"}).Return(serverCmd)
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(`{"spec": {"source": {"type": "Git"}}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "bc", "foo", "-o", "json"}).Return(getCmd).Once()
+	triggered := &mocks.ExecCmd{}
+	triggered.On("CombinedOutput").Return([]byte(`{"spec": {"source": {"type": "Git"},
+	  "triggers": [{"type": "GitLab", "gitlab": {"secret": "xyz"}}]}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "bc", "foo", "-o", "json"}).Return(triggered)
+	setCmd := &mocks.ExecCmd{}
+	setCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", []string{"set", "triggers", "bc/foo", "--from-gitlab"}).Return(setCmd)
+
+	app := Application{oc: oc, Name: "foo"}
+	assert.Nil(t, app.CreateWebhook(WebhookGitLab))
+	setCmd.AssertExpectations(t)
+}
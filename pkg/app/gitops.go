@@ -0,0 +1,195 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+const kustomizationFile = "kustomization.yaml"
+
+// syncGitOps writes the application's manifests to a kustomize
+// directory named after it inside the GitOps directory and, unless
+// only writing was requested, applies them.
+func (app *Application) syncGitOps() error {
+	image, err := app.deploymentImage()
+	if err != nil {
+		return withOutput(image, err)
+	}
+	env, secretNames, err := app.envForServiceBindings()
+	if err != nil {
+		return err
+	}
+	manifests, err := app.gitOpsManifests(strings.TrimSpace(string(image)), env, secretNames)
+	if err != nil {
+		return err
+	}
+
+	appDir := filepath.Join(app.options.GitOpsDir, app.Name)
+	log.Infof("Writing manifests for %s to %s", app.Name, appDir)
+	err = writeKustomization(appDir, manifests)
+	if err != nil {
+		return err
+	}
+	err = addKustomizationResource(app.options.GitOpsDir, app.Name)
+	if err != nil {
+		return err
+	}
+	if app.options.GitOpsOnly {
+		return nil
+	}
+
+	applyCmd := app.oc.Exec("apply", "-k", appDir)
+	log.Infof("Applying manifests with command: %s", applyCmd.ArgsString())
+	output, err := applyCmd.CombinedOutput(app.context())
+	log.Printf("%s", output)
+	if err != nil {
+		return err
+	}
+	if app.tcp() {
+		return app.displayTCPEndpoint()
+	} else if len(app.Routes) > 0 {
+		for _, route := range app.Routes {
+			log.Infof("Your application is available at %s", route.Route)
+		}
+		return nil
+	} else if app.kubernetes() {
+		return app.displayIngress()
+	}
+	return app.displayRoute()
+}
+
+// gitOpsManifests renders the objects that run the application,
+// keyed by file name. Binding secrets are only referenced by name so
+// no credentials end up in the GitOps directory.
+func (app *Application) gitOpsManifests(image string, env []string, secretNames []string) (map[string][]byte, error) {
+	manifests := make(map[string][]byte)
+	deployment, err := app.deploymentManifest(image, env, secretNames)
+	if err != nil {
+		return nil, err
+	}
+	manifests["deployment.yaml"] = deployment
+
+	objects := map[string]map[string]interface{}{
+		"service.yaml": app.serviceObject(),
+	}
+	// The load balancer service is all a TCP route needs
+	switch {
+	case app.tcp():
+	case len(app.Routes) > 0:
+		for _, route := range app.Routes {
+			object, err := app.routeObjectFor(route.Route)
+			if err != nil {
+				return nil, err
+			}
+			objects[fmt.Sprint("route-", routeName(app.Name, route.Route), ".yaml")] = object
+		}
+	case !app.kubernetes():
+		objects["route.yaml"] = app.routeObject()
+	case app.options.Domain != "":
+		objects["ingress.yaml"] = app.ingressObject()
+	}
+	for file, object := range objects {
+		manifest, err := json.Marshal(object)
+		if err != nil {
+			return nil, err
+		}
+		manifests[file] = manifest
+	}
+
+	for file, manifest := range manifests {
+		converted, err := yaml.JSONToYAML(manifest)
+		if err != nil {
+			return nil, err
+		}
+		manifests[file] = converted
+	}
+	return manifests, nil
+}
+
+// writeKustomization writes manifests to dir along with a
+// kustomization listing only them.
+func writeKustomization(dir string, manifests map[string][]byte) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	var files []string
+	for file, manifest := range manifests {
+		err = ioutil.WriteFile(filepath.Join(dir, file), manifest, 0644)
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return writeKustomizationResources(dir, map[string]interface{}{}, files)
+}
+
+// addKustomizationResource adds resource to the kustomization in dir,
+// creating it if needed and keeping any other settings it has.
+func addKustomizationResource(dir string, resource string) error {
+	kustomization := make(map[string]interface{})
+	contents, err := ioutil.ReadFile(filepath.Join(dir, kustomizationFile))
+	if err == nil {
+		err = yaml.Unmarshal(contents, &kustomization)
+		if err != nil {
+			return errors.New(fmt.Sprintf("Error parsing %s: %v", filepath.Join(dir, kustomizationFile), err))
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	var resources []string
+	existing, _ := kustomization["resources"].([]interface{})
+	for _, r := range existing {
+		if r, ok := r.(string); ok {
+			if r == resource {
+				return nil
+			}
+			resources = append(resources, r)
+		}
+	}
+	resources = append(resources, resource)
+	return writeKustomizationResources(dir, kustomization, resources)
+}
+
+func writeKustomizationResources(dir string, kustomization map[string]interface{}, resources []string) error {
+	kustomization["apiVersion"] = "kustomize.config.k8s.io/v1beta1"
+	kustomization["kind"] = "Kustomization"
+	kustomization["resources"] = resources
+	contents, err := yaml.Marshal(kustomization)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, kustomizationFile), contents, 0644)
+}
+
+// DiffGitOps returns the differences between the manifests in a
+// GitOps directory and the objects in the cluster, or an empty string
+// if there are none.
+func DiffGitOps(ctx context.Context, dir string) (string, error) {
+	app := &Application{ctx: ctx}
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return "", err
+	}
+	app.displayProject()
+	output, err := app.oc.Exec("diff", "-k", dir).CombinedOutput(app.context())
+	// diff exits non-zero when there are differences, so only treat
+	// it as an error if it didn't print one
+	if err != nil && !strings.HasPrefix(string(output), "diff ") {
+		return "", errors.New(fmt.Sprintf("Error diffing %s: %s\n", dir, output))
+	}
+	return string(output), nil
+}
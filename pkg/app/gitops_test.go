@@ -0,0 +1,45 @@
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestGitOpsManifests(t *testing.T) {
+	app := Application{oc: mocks.NewMockOc(), Name: "foo"}
+	manifests, err := app.gitOpsManifests("172.30.1.1:5000/p/foo", nil, []string{"foo-db-binding"})
+	assert.Nil(t, err)
+	assert.Contains(t, string(manifests["deployment.yaml"]), "kind: Deployment")
+	assert.Contains(t, string(manifests["deployment.yaml"]), "name: foo-db-binding")
+	assert.Contains(t, string(manifests["service.yaml"]), "kind: Service")
+	assert.Contains(t, string(manifests["route.yaml"]), "kind: Route")
+	_, hasIngress := manifests["ingress.yaml"]
+	assert.False(t, hasIngress)
+}
+
+func TestAddKustomizationResource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocf-gitops")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, kustomizationFile)
+	err = ioutil.WriteFile(path, []byte("namespace: prod\nresources:\n- bar\n"), 0644)
+	assert.Nil(t, err)
+
+	assert.Nil(t, addKustomizationResource(dir, "foo"))
+	assert.Nil(t, addKustomizationResource(dir, "foo"))
+	contents, _ := ioutil.ReadFile(path)
+	assert.Equal(t, `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+namespace: prod
+resources:
+- bar
+- foo
+`, string(contents))
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+// guidAnnotation records the GUID generated for an application on its
+// first push, standing in for Cloud Foundry's application GUID.
+const guidAnnotation = "ocf/guid"
+
+// newGUID returns a random version 4 UUID.
+func newGUID() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// GUID returns the GUID of a pushed application. It only prints the
+// GUID, so it can be captured by scripts written for 'cf app --guid'.
+func (app *Application) GUID() (string, error) {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return "", err
+	}
+	guid, err := app.liveGUID()
+	if err != nil {
+		return "", err
+	}
+	if guid == "" {
+		return "", errors.New(fmt.Sprintf("Error: Application %s has no GUID yet, push it again to generate one\n", app.Name))
+	}
+	return guid, nil
+}
+
+// liveGUID returns the GUID annotated on the application's workload,
+// or "" if there's none.
+func (app *Application) liveGUID() (string, error) {
+	workload := &types.DeploymentConfig{}
+	err := oc.Get(app.context(), app.oc, app.workloadKind(), app.Name, workload)
+	if err != nil {
+		return "", err
+	}
+	return workload.Metadata.Annotations[guidAnnotation], nil
+}
+
+// ensureGUID annotates the application's workload with a new GUID if
+// it has none and sets VCAP_APPLICATION to match, which only rolls the
+// workload out again the first time.
+func (app *Application) ensureGUID() error {
+	guid, err := app.liveGUID()
+	if err != nil {
+		return err
+	}
+	if guid == "" {
+		guid, err = newGUID()
+		if err != nil {
+			return err
+		}
+		output, err := app.oc.Exec("annotate", app.workloadKind(), app.Name, fmt.Sprint(guidAnnotation, "=", guid)).CombinedOutput(app.context())
+		if err != nil {
+			return errors.New(fmt.Sprintf("Error annotating %s with its GUID: %s\n", app.Name, output))
+		}
+		log.Infof("Generated GUID %s for %s", guid, app.Name)
+	}
+	vcapApplication, err := app.vcapApplication(guid)
+	if err != nil {
+		return err
+	}
+	return app.oc.SetEnv(app.context(), app.workloadKind(), app.Name, map[string]string{"VCAP_APPLICATION": vcapApplication})
+}
+
+// vcapApplication returns the parts of Cloud Foundry's
+// VCAP_APPLICATION that don't differ between instances.
+func (app *Application) vcapApplication(guid string) (string, error) {
+	project, err := app.oc.Project(app.context())
+	if err != nil {
+		return "", err
+	}
+	vcap, err := json.Marshal(map[string]interface{}{
+		"application_id":   guid,
+		"application_name": app.Name,
+		"name":             app.Name,
+		"space_name":       project,
+	})
+	return string(vcap), err
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestNewGUID(t *testing.T) {
+	guid, err := newGUID()
+	assert.Nil(t, err)
+	assert.Regexp(t, regexp.MustCompile("^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$"), guid)
+
+	other, _ := newGUID()
+	assert.NotEqual(t, guid, other)
+}
+
+func TestEnsureGUIDGeneratesOnce(t *testing.T) {
+	oc := mocks.NewMockOc()
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(`{"metadata":{"name":"foo"}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "dc", "foo", "-o", "json"}).Return(getCmd)
+	annotateCmd := &mocks.ExecCmd{}
+	annotateCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", mock.MatchedBy(func(args []string) bool {
+		return len(args) == 4 && args[0] == "annotate" && args[1] == "dc" && args[2] == "foo" &&
+			regexp.MustCompile("^ocf/guid=[0-9a-f-]{36}$").MatchString(args[3])
+	})).Return(annotateCmd)
+	oc.On("SetEnv", "dc", "foo", mock.Anything).Return(nil)
+
+	app := Application{oc: oc, Name: "foo", kind: "dc"}
+	assert.Nil(t, app.ensureGUID())
+	oc.Execer.AssertExpectations(t)
+	oc.AssertExpectations(t)
+}
+
+func TestEnsureGUIDKeepsExisting(t *testing.T) {
+	oc := mocks.NewMockOc()
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(`{"metadata":{"name":"foo","annotations":{"ocf/guid":"1b4e28ba-2fa1-41d2-883f-0016d3cca427"}}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "dc", "foo", "-o", "json"}).Return(getCmd)
+	oc.On("SetEnv", "dc", "foo", map[string]string{
+		"VCAP_APPLICATION": `{"application_id":"1b4e28ba-2fa1-41d2-883f-0016d3cca427","application_name":"foo","name":"foo","space_name":"test-project"}`,
+	}).Return(nil)
+
+	app := Application{oc: oc, Name: "foo", kind: "dc"}
+	assert.Nil(t, app.ensureGUID())
+	oc.AssertExpectations(t)
+
+	guid, err := app.GUID()
+	assert.Nil(t, err)
+	assert.Equal(t, "1b4e28ba-2fa1-41d2-883f-0016d3cca427", guid)
+}
+
+func TestBatchedPushSetsGUID(t *testing.T) {
+	oc := mocks.NewMockOc()
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(`{"metadata":{"name":"foo"}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "dc", "foo", "-o", "json"}).Return(getCmd)
+	annotateCmd := &mocks.ExecCmd{}
+	annotateCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", mock.MatchedBy(func(args []string) bool {
+		return len(args) == 4 && args[0] == "annotate" && strings.HasPrefix(args[3], "ocf/guid=")
+	})).Return(annotateCmd)
+	oc.On("SetEnv", "dc", "foo", mock.Anything).Return(nil)
+
+	// The batched apply created the deployment config, so only the
+	// steps after it run
+	app := Application{oc: oc, Name: "foo", kind: "dc"}
+	var steps []Step
+	for _, step := range app.deploySteps() {
+		if step.Name() == "deployment" || step.Name() == "guid" {
+			steps = append(steps, step)
+		}
+	}
+	pipeline := &Pipeline{Steps: steps}
+	assert.Nil(t, pipeline.Run(context.Background(), &PushState{App: &app, batched: true}))
+	oc.Execer.AssertExpectations(t)
+	oc.AssertNotCalled(t, "Exists", "dc", "foo")
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+const (
+	// DefaultProgressDeadline is how many seconds a rollout may go
+	// without progress before it fails, the Kubernetes default
+	DefaultProgressDeadline = 600
+	// probePeriod is how many seconds apart health checks are
+	probePeriod = 10
+	// appPort is the port applications listen on, set as PORT
+	appPort = 8080
+)
+
+// healthChecks returns the readiness and liveness probes of the
+// application's web process, giving instances the manifest's timeout
+// to start listening as Cloud Foundry does. It returns nil without a
+// timeout or for other process types, which don't listen on a port.
+func (app *Application) healthChecks() map[string]interface{} {
+	if app.Timeout <= 0 || (app.processType != "" && app.processType != WebProcess) {
+		return nil
+	}
+	tcpSocket := map[string]int{"port": appPort}
+	return map[string]interface{}{
+		// Instances get no traffic until they listen, and only
+		// count as failed once the timeout has passed
+		"readinessProbe": map[string]interface{}{
+			"tcpSocket":        tcpSocket,
+			"periodSeconds":    probePeriod,
+			"failureThreshold": (app.Timeout + probePeriod - 1) / probePeriod,
+		},
+		// Instances that stop listening after starting are restarted
+		"livenessProbe": map[string]interface{}{
+			"tcpSocket":           tcpSocket,
+			"initialDelaySeconds": app.Timeout,
+			"periodSeconds":       probePeriod,
+		},
+	}
+}
+
+// progressDeadline returns how many seconds a rollout of the
+// application may take, extended by its timeout so slow starting
+// instances don't fail it.
+func (app *Application) progressDeadline() int {
+	return DefaultProgressDeadline + app.Timeout
+}
+
+// ensureHealthChecks sets the health checks and rollout deadline of
+// the application's existing deployment config or deployment, which
+// only rolls it out again if they changed.
+func (app *Application) ensureHealthChecks() error {
+	checks := app.healthChecks()
+	if checks == nil {
+		return nil
+	}
+	container := map[string]interface{}{"name": app.Name}
+	for probe, check := range checks {
+		container[probe] = check
+	}
+	spec := map[string]interface{}{
+		"template": map[string]interface{}{
+			"spec": map[string]interface{}{"containers": []interface{}{container}},
+		},
+	}
+	if app.workloadKind() == "dc" {
+		spec["strategy"] = map[string]interface{}{
+			"rollingParams": map[string]int{"timeoutSeconds": app.progressDeadline()},
+		}
+	} else {
+		spec["progressDeadlineSeconds"] = app.progressDeadline()
+	}
+	patch, err := json.Marshal(map[string]interface{}{"spec": spec})
+	if err != nil {
+		return err
+	}
+	log.Infof("Giving %s %d seconds to become healthy", app.Name, app.Timeout)
+	output, err := app.oc.Exec("patch", app.workloadKind(), app.Name, "-p", string(patch)).CombinedOutput(app.context())
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error setting the health checks of %s: %s\n", app.Name, output))
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestHealthChecks(t *testing.T) {
+	app := Application{Name: "foo"}
+	assert.Nil(t, app.healthChecks())
+
+	app.Timeout = 45
+	checks := app.healthChecks()
+	readiness := checks["readinessProbe"].(map[string]interface{})
+	assert.Equal(t, 5, readiness["failureThreshold"])
+	liveness := checks["livenessProbe"].(map[string]interface{})
+	assert.Equal(t, 45, liveness["initialDelaySeconds"])
+	assert.Equal(t, 645, app.progressDeadline())
+
+	// Workers don't listen on a port
+	worker := app.processApp(Process{Type: "worker"})
+	assert.Nil(t, worker.healthChecks())
+}
+
+func TestEnsureHealthChecks(t *testing.T) {
+	oc := mocks.NewMockOc()
+	patchCmd := &mocks.ExecCmd{}
+	patchCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", []string{"patch", "dc", "foo", "-p",
+		`{"spec":{"strategy":{"rollingParams":{"timeoutSeconds":780}},"template":{"spec":{"containers":[{"livenessProbe":{"initialDelaySeconds":180,"periodSeconds":10,"tcpSocket":{"port":8080}},"name":"foo","readinessProbe":{"failureThreshold":18,"periodSeconds":10,"tcpSocket":{"port":8080}}}]}}}}`}).Return(patchCmd)
+
+	app := Application{oc: oc, Name: "foo", kind: "dc", Timeout: 180}
+	assert.Nil(t, app.ensureHealthChecks())
+	oc.Execer.AssertExpectations(t)
+
+	// Without a timeout nothing is patched
+	app = Application{oc: mocks.NewMockOc(), Name: "foo", kind: "dc"}
+	assert.Nil(t, app.ensureHealthChecks())
+}
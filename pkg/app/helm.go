@@ -0,0 +1,247 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+
+	"github.com/ghodss/yaml"
+)
+
+// ExportHelmChart writes a Helm chart to dir that recreates the
+// running application, with its image, replicas, memory, environment,
+// and route host as values.
+func ExportHelmChart(ctx context.Context, name string, dir string) error {
+	app := &Application{ctx: ctx, Name: name}
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+	return exportHelmChart(ctx, app.oc, name, dir)
+}
+
+func exportHelmChart(ctx context.Context, o oc.Oc, name string, dir string) error {
+	app := &Application{Name: name, oc: o, ctx: ctx}
+	exists, err := app.deploymentExists()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New(fmt.Sprintf("Error: Application %s not found\n", name))
+	}
+
+	values, err := app.helmValues()
+	if err != nil {
+		return err
+	}
+	valuesYaml, err := yaml.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	files := map[string]string{
+		"Chart.yaml":                fmt.Sprintf(helmChartYaml, name),
+		"values.yaml":               string(valuesYaml),
+		"templates/deployment.yaml": helmDeploymentTemplate,
+		"templates/service.yaml":    helmServiceTemplate,
+	}
+	if app.kubernetes() {
+		files["templates/ingress.yaml"] = helmIngressTemplate
+	} else {
+		files["templates/route.yaml"] = helmRouteTemplate
+	}
+	for file, contents := range files {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// liveWorkload reads the application's deployment config or
+// deployment from the cluster.
+func (app *Application) liveWorkload() (*types.DeploymentConfig, error) {
+	workload := &types.DeploymentConfig{}
+	err := oc.Get(app.context(), app.oc, app.workloadKind(), app.Name, workload)
+	if err != nil {
+		return nil, err
+	}
+	if len(workload.Spec.Template.Spec.Containers) == 0 {
+		return nil, errors.New(fmt.Sprintf("Error: %s %s has no containers\n", app.workloadKind(), app.Name))
+	}
+	return workload, nil
+}
+
+// helmValues reads the live application into the values of its chart.
+func (app *Application) helmValues() (map[string]interface{}, error) {
+	workload, err := app.liveWorkload()
+	if err != nil {
+		return nil, err
+	}
+	container := workload.Spec.Template.Spec.Containers[0]
+
+	env := make(map[string]string)
+	var envFrom []map[string]string
+	for _, envVar := range container.Env {
+		switch {
+		case envVar.ValueFrom == nil:
+			env[envVar.Name] = envVar.Value
+		case envVar.ValueFrom.SecretKeyRef != nil:
+			envFrom = append(envFrom, map[string]string{"name": envVar.Name, "kind": "secret",
+				"source": envVar.ValueFrom.SecretKeyRef.Name, "key": envVar.ValueFrom.SecretKeyRef.Key})
+		case envVar.ValueFrom.ConfigMapKeyRef != nil:
+			envFrom = append(envFrom, map[string]string{"name": envVar.Name, "kind": "configMap",
+				"source": envVar.ValueFrom.ConfigMapKeyRef.Name, "key": envVar.ValueFrom.ConfigMapKeyRef.Key})
+		}
+	}
+
+	host, err := app.liveHost()
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"name":     app.Name,
+		"image":    container.Image,
+		"replicas": workload.Spec.Replicas,
+		"memory":   container.Resources.Limits["memory"],
+		"command":  container.Command,
+		"env":      env,
+		"envFrom":  envFrom,
+		"host":     host,
+	}, nil
+}
+
+// liveHost returns the host of the application's route, or ingress
+// on Kubernetes, or an empty string if it has none.
+func (app *Application) liveHost() (string, error) {
+	objType := "route"
+	if app.kubernetes() {
+		objType = "ingress"
+	}
+	exists, err := app.oc.Exists(app.context(), objType, app.Name)
+	if err != nil || !exists {
+		return "", err
+	}
+	if app.kubernetes() {
+		ingress := &types.Ingress{}
+		err = oc.Get(app.context(), app.oc, objType, app.Name, ingress)
+		return ingress.Host(), err
+	}
+	route := &types.Route{}
+	err = oc.Get(app.context(), app.oc, objType, app.Name, route)
+	return route.Spec.Host, err
+}
+
+const helmChartYaml = `apiVersion: v2
+name: %s
+description: Exported from ocf
+type: application
+version: 0.1.0
+`
+
+const helmDeploymentTemplate = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Values.name }}
+  labels:
+    app: {{ .Values.name }}
+spec:
+  replicas: {{ .Values.replicas }}
+  selector:
+    matchLabels:
+      app: {{ .Values.name }}
+  template:
+    metadata:
+      labels:
+        app: {{ .Values.name }}
+    spec:
+      containers:
+      - name: {{ .Values.name }}
+        image: {{ .Values.image | quote }}
+        {{- with .Values.command }}
+        command:
+        {{- toYaml . | nindent 8 }}
+        {{- end }}
+        ports:
+        - containerPort: 8080
+        env:
+        {{- range $name, $value := .Values.env }}
+        - name: {{ $name }}
+          value: {{ $value | quote }}
+        {{- end }}
+        {{- range .Values.envFrom }}
+        - name: {{ .name }}
+          valueFrom:
+            {{ .kind }}KeyRef:
+              name: {{ .source }}
+              key: {{ .key }}
+        {{- end }}
+        {{- with .Values.memory }}
+        resources:
+          limits:
+            memory: {{ . }}
+        {{- end }}
+`
+
+const helmServiceTemplate = `apiVersion: v1
+kind: Service
+metadata:
+  name: {{ .Values.name }}
+  labels:
+    app: {{ .Values.name }}
+spec:
+  selector:
+    app: {{ .Values.name }}
+  ports:
+  - port: 8080
+    targetPort: 8080
+`
+
+const helmRouteTemplate = `{{- if .Values.host }}
+apiVersion: route.openshift.io/v1
+kind: Route
+metadata:
+  name: {{ .Values.name }}
+  labels:
+    app: {{ .Values.name }}
+spec:
+  host: {{ .Values.host }}
+  to:
+    kind: Service
+    name: {{ .Values.name }}
+{{- end }}
+`
+
+const helmIngressTemplate = `{{- if .Values.host }}
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: {{ .Values.name }}
+  labels:
+    app: {{ .Values.name }}
+spec:
+  rules:
+  - host: {{ .Values.host }}
+    http:
+      paths:
+      - path: /
+        pathType: Prefix
+        backend:
+          service:
+            name: {{ .Values.name }}
+            port:
+              number: 8080
+{{- end }}
+`
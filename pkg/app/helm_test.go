@@ -0,0 +1,76 @@
+package app
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+const liveDeploymentJSON = `{
+  "spec": {
+    "replicas": 2,
+    "template": {"spec": {"containers": [{
+      "image": "172.30.1.1:5000/p/foo@sha256:abc",
+      "env": [
+        {"name": "MEMORY_LIMIT", "value": "512M"},
+        {"name": "DB_PASSWORD", "valueFrom": {"secretKeyRef": {"name": "foo-db-binding", "key": "DB_PASSWORD"}}}
+      ],
+      "resources": {"limits": {"memory": "512M"}}
+    }]}}
+  }
+}`
+
+func TestHelmValues(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", kind: "dc"}
+
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(liveDeploymentJSON), nil)
+	hostCmd := &mocks.ExecCmd{}
+	hostCmd.On("CombinedOutput").Return([]byte(`{"spec": {"host": "foo.example.com"}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "dc", "foo", "-o", "json"}).Return(getCmd)
+	oc.On("Exists", "route", "foo").Return(true, nil)
+	oc.Execer.On("Oc", []string{"get", "route", "foo", "-o", "json"}).Return(hostCmd)
+
+	values, err := app.helmValues()
+	assert.Nil(t, err)
+	assert.Equal(t, "172.30.1.1:5000/p/foo@sha256:abc", values["image"])
+	assert.Equal(t, 2, values["replicas"])
+	assert.Equal(t, "512M", values["memory"])
+	assert.Equal(t, "foo.example.com", values["host"])
+	assert.Equal(t, map[string]string{"MEMORY_LIMIT": "512M"}, values["env"])
+	assert.Equal(t, []map[string]string{{
+		"name": "DB_PASSWORD", "kind": "secret", "source": "foo-db-binding", "key": "DB_PASSWORD",
+	}}, values["envFrom"])
+}
+
+func TestExportHelmChart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocf-helm")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	oc := mocks.NewMockOc()
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(liveDeploymentJSON), nil)
+	oc.On("Exists", "dc", "foo").Return(false, nil)
+	oc.On("Exists", "deployment", "foo").Return(true, nil)
+	oc.On("Exists", "route", "foo").Return(false, nil)
+	oc.Execer.On("Oc", []string{"get", "deployment", "foo", "-o", "json"}).Return(getCmd)
+
+	err = exportHelmChart(context.Background(), oc, "foo", dir)
+	assert.Nil(t, err)
+	for _, file := range []string{"Chart.yaml", "values.yaml", "templates/deployment.yaml",
+		"templates/service.yaml", "templates/route.yaml"} {
+		_, err := os.Stat(filepath.Join(dir, file))
+		assert.Nil(t, err, file)
+	}
+	values, _ := ioutil.ReadFile(filepath.Join(dir, "values.yaml"))
+	assert.Contains(t, string(values), "replicas: 2")
+	assert.Contains(t, string(values), "name: foo")
+}
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+const (
+	// HookPrePush runs before an application is built, such as to
+	// migrate its database
+	HookPrePush string = "pre-push"
+	// HookPostPush runs once an application is deployed, such as to
+	// smoke test it
+	HookPostPush string = "post-push"
+)
+
+// Hooks are local commands run around a push. They run with 'sh -c'
+// from the application's directory, with OCF_APP_NAME, OCF_APP_URL,
+// OCF_PROJECT, and OCF_HOOK describing the push.
+type Hooks struct {
+	PrePush  string `json:"pre-push,omitempty"`
+	PostPush string `json:"post-push,omitempty"`
+}
+
+// runHook runs one of the application's hooks, if it has it.
+func (app *Application) runHook(hook string) error {
+	if app.Hooks == nil {
+		return nil
+	}
+	command := app.Hooks.PrePush
+	if hook == HookPostPush {
+		command = app.Hooks.PostPush
+	}
+	if command == "" {
+		return nil
+	}
+
+	env, err := app.hookEnv(hook)
+	if err != nil {
+		return err
+	}
+	shell, args := exec.Shell(command)
+	hookCmd := app.execer.Command(shell, args...)
+	hookCmd.SetEnv(env)
+	hookCmd.SetDir(app.hookDir())
+	hookCmd.AttachStdIO()
+	// Hooks are the user's own, and may take as long as they like
+	hookCmd.SetTimeout(0)
+	log.Infof("Running %s hook: %s", hook, command)
+	err = hookCmd.Run(app.context())
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error: %s hook %s failed: %v", hook, command, err))
+	}
+	return nil
+}
+
+// hookDir is the directory hooks run from, which is the application's
+// directory or the directory containing its zip file.
+func (app *Application) hookDir() string {
+	if info, err := os.Stat(app.Path); err == nil && !info.IsDir() {
+		return filepath.Dir(app.Path)
+	}
+	return app.Path
+}
+
+func (app *Application) hookEnv(hook string) ([]string, error) {
+	project, err := app.oc.Project(app.context())
+	if err != nil {
+		return nil, err
+	}
+	url, err := app.url()
+	if err != nil {
+		return nil, err
+	}
+	return []string{
+		fmt.Sprint("OCF_APP_NAME=", app.Name),
+		fmt.Sprint("OCF_APP_URL=", url),
+		fmt.Sprint("OCF_PROJECT=", strings.TrimSpace(project)),
+		fmt.Sprint("OCF_HOOK=", hook),
+	}, nil
+}
+
+// url returns the URL the application is available at, or an empty
+// string if it has none yet or is only reachable over TCP.
+func (app *Application) url() (string, error) {
+	if app.knative() {
+		exists, err := app.oc.Exists(app.context(), "ksvc", app.Name)
+		if err != nil || !exists {
+			return "", err
+		}
+		service := &types.KnativeService{}
+		err = oc.Get(app.context(), app.oc, "ksvc", app.Name, service)
+		return service.Status.URL, err
+	}
+	if app.tcp() {
+		return "", nil
+	}
+	host, err := app.liveHost()
+	if err != nil || host == "" {
+		return "", err
+	}
+	return fmt.Sprint("http://", host), nil
+}
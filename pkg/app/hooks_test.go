@@ -0,0 +1,35 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestRunHook(t *testing.T) {
+	oc := mocks.NewMockOc()
+	execer := &mocks.Execer{}
+	app := Application{oc: oc, execer: execer, Name: "foo", Path: "/tmp/foo",
+		Hooks: &Hooks{PostPush: "./smoke.sh"}}
+
+	oc.On("Exists", "route", "foo").Return(true, nil)
+	hostCmd := &mocks.ExecCmd{}
+	hostCmd.On("CombinedOutput").Return([]byte(`{"spec": {"host": "foo.example.com"}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "route", "foo", "-o", "json"}).Return(hostCmd)
+
+	hookCmd := &mocks.ExecCmd{}
+	hookCmd.On("SetEnv", []string{"OCF_APP_NAME=foo", "OCF_APP_URL=http://foo.example.com",
+		"OCF_PROJECT=test-project", "OCF_HOOK=post-push"}).Return()
+	hookCmd.On("SetDir", "/tmp/foo").Return()
+	hookCmd.On("AttachStdIO").Return()
+	hookCmd.On("Run").Return(nil)
+	execer.On("Command", "sh", []string{"-c", "./smoke.sh"}).Return(hookCmd)
+
+	// Without a pre-push hook nothing runs
+	assert.Nil(t, app.runHook(HookPrePush))
+	assert.Nil(t, app.runHook(HookPostPush))
+	hookCmd.AssertExpectations(t)
+	execer.AssertNumberOfCalls(t, "Command", 1)
+}
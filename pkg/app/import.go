@@ -0,0 +1,335 @@
+package app
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bbrowning/ocf/pkg/cf"
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// ImportOptions contains the settings for 'ocf import'. Any of API,
+// Token, Org, and Space that are empty come from the cf CLI's config.
+type ImportOptions struct {
+	API   string
+	Token string
+	Org   string
+	Space string
+	// Dir is where the manifest, application sources, and plan are
+	// written
+	Dir string
+	// Droplets also downloads each application's current droplet
+	Droplets bool
+}
+
+const importPlanFile = "import-plan.txt"
+
+// importedApp is an application read from Cloud Foundry along with
+// anything about it that needs a person's attention before pushing.
+type importedApp struct {
+	manifest *ManifestApp
+	notes    []string
+}
+
+// Import reads the applications in a Cloud Foundry space and writes a
+// manifest, their sources, and a plan for pushing them to OpenShift.
+func Import(options ImportOptions) error {
+	err := options.setDefaults()
+	if err != nil {
+		return err
+	}
+	client := cf.NewClient(options.API, options.Token)
+
+	log.Infof("Reading applications in %s/%s from %s", options.Org, options.Space, client.API)
+	spaceGUID, err := client.SpaceGUID(options.Org, options.Space)
+	if err != nil {
+		return err
+	}
+	cfApps, err := client.Apps(spaceGUID)
+	if err != nil {
+		return err
+	}
+	if len(cfApps) == 0 {
+		return errors.New(fmt.Sprintf("Error: No applications found in %s/%s\n", options.Org, options.Space))
+	}
+
+	err = os.MkdirAll(options.Dir, 0755)
+	if err != nil {
+		return err
+	}
+	var imported []*importedApp
+	for _, cfApp := range cfApps {
+		log.Infof("Importing %s", cfApp.Name)
+		app, err := importApp(client, cfApp, options)
+		if err != nil {
+			return err
+		}
+		imported = append(imported, app)
+	}
+
+	var manifestApps []*ManifestApp
+	for _, app := range imported {
+		manifestApps = append(manifestApps, app.manifest)
+	}
+	manifest, err := yaml.Marshal(map[string][]*ManifestApp{"applications": manifestApps})
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(filepath.Join(options.Dir, "manifest.yml"), manifest, 0644)
+	if err != nil {
+		return err
+	}
+	plan := importPlan(options, imported)
+	err = ioutil.WriteFile(filepath.Join(options.Dir, importPlanFile), []byte(plan), 0644)
+	if err != nil {
+		return err
+	}
+	log.Infof("Imported %d applications to %s", len(imported), options.Dir)
+	log.Printf("%s", plan)
+	return nil
+}
+
+func (options *ImportOptions) setDefaults() error {
+	if options.API == "" || options.Token == "" || options.Org == "" || options.Space == "" {
+		config, err := cf.ReadConfig()
+		if err != nil {
+			return errors.New(fmt.Sprintf("Error: Run 'cf login' or give the API, token, org, and space: %v\n", err))
+		}
+		if options.API == "" {
+			options.API = config.Target
+		}
+		if options.Token == "" {
+			options.Token = config.AccessToken
+		}
+		if options.Org == "" {
+			options.Org = config.OrganizationFields.Name
+		}
+		if options.Space == "" {
+			options.Space = config.SpaceFields.Name
+		}
+	}
+	if options.API == "" || options.Token == "" || options.Org == "" || options.Space == "" {
+		return errors.New("Error: A Cloud Foundry API, token, org, and space are required\n")
+	}
+	if options.Dir == "" {
+		options.Dir = "."
+	}
+	return nil
+}
+
+func importApp(client *cf.Client, cfApp cf.App, options ImportOptions) (*importedApp, error) {
+	app := &importedApp{manifest: &ManifestApp{Name: cfApp.Name}}
+	manifest := app.manifest
+
+	processes, err := client.Processes(cfApp.GUID)
+	if err != nil {
+		return nil, err
+	}
+	for _, process := range processes {
+		if process.Type != "web" {
+			app.notes = append(app.notes, fmt.Sprintf("The %s process wasn't imported, only web processes are", process.Type))
+			continue
+		}
+		manifest.Command = process.Command
+		manifest.Instances = process.Instances
+		if process.MemoryInMB > 0 {
+			manifest.Memory = fmt.Sprint(process.MemoryInMB, "M")
+		}
+	}
+	if cfApp.State == "STOPPED" {
+		app.notes = append(app.notes, "Stopped in Cloud Foundry, but will be started by 'ocf push'")
+	}
+
+	env, err := client.Env(cfApp.GUID)
+	if err != nil {
+		return nil, err
+	}
+	if len(env) > 0 {
+		manifest.Env = env
+	}
+	manifest.Services, err = client.ServiceInstances(cfApp.GUID)
+	if err != nil {
+		return nil, err
+	}
+	routes, err := client.Routes(cfApp.GUID)
+	if err != nil {
+		return nil, err
+	}
+	for _, route := range routes {
+		manifest.Routes = append(manifest.Routes, Route{Route: route.URL})
+	}
+
+	pkg, err := client.LatestPackage(cfApp.GUID)
+	if err != nil {
+		return nil, err
+	}
+	if cfApp.Docker() {
+		if pkg != nil {
+			manifest.Docker = &Docker{Image: pkg.Data.Image}
+		}
+	} else {
+		for i, buildpack := range cfApp.Lifecycle.Data.Buildpacks {
+			if i > 0 {
+				app.notes = append(app.notes, fmt.Sprintf("Only the first buildpack is used, %s was left out", buildpack))
+			} else if strings.Contains(buildpack, "://") {
+				manifest.Buildpack = buildpack
+			} else {
+				app.notes = append(app.notes, fmt.Sprintf("The %s system buildpack was left out, set buildpack to its Git URL if the builder image doesn't detect it", buildpack))
+			}
+		}
+		if pkg != nil && pkg.Type == "bits" && pkg.State == "READY" {
+			manifest.Path = cfApp.Name
+			err = downloadSource(client, pkg.GUID, filepath.Join(options.Dir, cfApp.Name))
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			app.notes = append(app.notes, "No uploaded source was found, set path to the application's source")
+		}
+	}
+
+	if options.Droplets && !cfApp.Docker() {
+		dropletGUID, err := client.CurrentDroplet(cfApp.GUID)
+		if err != nil {
+			return nil, err
+		}
+		if dropletGUID != "" {
+			droplet := fmt.Sprint(cfApp.Name, "-droplet.tgz")
+			log.Infof("Downloading the droplet of %s to %s", cfApp.Name, droplet)
+			err = client.Download(fmt.Sprintf("/v3/droplets/%s/download", dropletGUID), filepath.Join(options.Dir, droplet))
+			if err != nil {
+				return nil, err
+			}
+			app.notes = append(app.notes, fmt.Sprintf("Run the droplet without staging with 'ocf push %s --droplet %s'", cfApp.Name, filepath.Join(options.Dir, droplet)))
+		}
+	}
+	return app, nil
+}
+
+// downloadSource downloads the bits package with packageGUID and
+// extracts it into dir.
+func downloadSource(client *cf.Client, packageGUID string, dir string) error {
+	tmpFile, err := ioutil.TempFile("", "ocf-import")
+	if err != nil {
+		return err
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	log.Infof("Downloading source to %s", dir)
+	err = client.Download(fmt.Sprintf("/v3/packages/%s/download", packageGUID), tmpFile.Name())
+	if err != nil {
+		return err
+	}
+	return extractZip(tmpFile.Name(), dir)
+}
+
+func extractZip(file string, dir string) error {
+	reader, err := zip.OpenReader(file)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return extractZipFiles(reader.File, dir)
+}
+
+// extractZipFiles extracts the files of a zip archive into dir,
+// refusing any that would end up outside of it.
+func extractZipFiles(files []*zip.File, dir string) error {
+	for _, f := range files {
+		path := filepath.Join(dir, f.Name)
+		if !strings.HasPrefix(path, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return errors.New(fmt.Sprintf("Error: %s in the source archive is outside of it\n", f.Name))
+		}
+		if f.FileInfo().IsDir() {
+			err := os.MkdirAll(path, 0755)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return err
+		}
+		err = extractZipFile(f, path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractZipFile(f *zip.File, path string) error {
+	in, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	mode := f.Mode().Perm()
+	if mode == 0 {
+		mode = 0644
+	}
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	closeErr := out.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// importPlan describes the steps left to push the imported
+// applications to OpenShift.
+func importPlan(options ImportOptions, imported []*importedApp) string {
+	var services []string
+	seen := make(map[string]bool)
+	for _, app := range imported {
+		for _, service := range app.manifest.Services {
+			if !seen[service] {
+				seen[service] = true
+				services = append(services, service)
+			}
+		}
+	}
+
+	var plan strings.Builder
+	fmt.Fprintf(&plan, "Import plan for %d applications from %s/%s\n\n", len(imported), options.Org, options.Space)
+	step := 1
+	if len(services) > 0 {
+		fmt.Fprintf(&plan, "%d. Create these services in the project, they're bound by the manifest:\n", step)
+		for _, service := range services {
+			fmt.Fprintf(&plan, "     %s\n", service)
+		}
+		step++
+	}
+	fmt.Fprintf(&plan, "%d. Review %s, including the notes below\n", step, filepath.Join(options.Dir, "manifest.yml"))
+	step++
+	fmt.Fprintf(&plan, "%d. Push the applications:\n     ocf push -f %s\n", step, filepath.Join(options.Dir, "manifest.yml"))
+
+	header := false
+	for _, app := range imported {
+		if len(app.notes) == 0 {
+			continue
+		}
+		if !header {
+			plan.WriteString("\nNotes:\n")
+			header = true
+		}
+		for _, note := range app.notes {
+			fmt.Fprintf(&plan, "  %s: %s\n", app.manifest.Name, note)
+		}
+	}
+	return plan.String()
+}
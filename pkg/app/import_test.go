@@ -0,0 +1,65 @@
+package app
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/cf"
+)
+
+func TestImportApp(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocf-import-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	responses := map[string]string{
+		"/v3/apps/1/processes":             `{"resources":[{"type":"web","command":"bundle exec puma","instances":2,"memory_in_mb":512},{"type":"worker"}]}`,
+		"/v3/apps/1/environment_variables": `{"var":{"RAILS_ENV":"production"}}`,
+		"/v3/service_credential_bindings":  `{"included":{"service_instances":[{"name":"db"}]}}`,
+		"/v3/apps/1/routes":                `{"resources":[{"url":"foo.example.com/api"}]}`,
+		"/v3/packages":                     `{"resources":[{"guid":"p1","type":"bits","state":"READY"}]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v3/packages/p1/download" {
+			archive := zip.NewWriter(w)
+			f, _ := archive.Create("Gemfile")
+			fmt.Fprint(f, "source 'https://rubygems.org'")
+			archive.Close()
+			return
+		}
+		fmt.Fprint(w, responses[r.URL.Path])
+	}))
+	defer server.Close()
+
+	cfApp := cf.App{GUID: "1", Name: "foo"}
+	cfApp.Lifecycle.Data.Buildpacks = []string{"ruby_buildpack"}
+	imported, err := importApp(cf.NewClient(server.URL, "abc"), cfApp, ImportOptions{Dir: dir})
+	assert.Nil(t, err)
+	assert.Equal(t, &ManifestApp{
+		Name:      "foo",
+		Path:      "foo",
+		Command:   "bundle exec puma",
+		Instances: 2,
+		Memory:    "512M",
+		Env:       map[string]string{"RAILS_ENV": "production"},
+		Services:  []string{"db"},
+		Routes:    []Route{{Route: "foo.example.com/api"}},
+	}, imported.manifest)
+	assert.Equal(t, 2, len(imported.notes))
+
+	gemfile, err := ioutil.ReadFile(filepath.Join(dir, "foo", "Gemfile"))
+	assert.Nil(t, err)
+	assert.Equal(t, "source 'https://rubygems.org'", string(gemfile))
+
+	plan := importPlan(ImportOptions{Org: "org", Space: "dev", Dir: dir}, []*importedApp{imported})
+	assert.Contains(t, plan, "     db\n")
+	assert.Contains(t, plan, "foo: The worker process wasn't imported")
+}
@@ -0,0 +1,224 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+// AppInfo is the state of one application as shown by 'ocf app'.
+type AppInfo struct {
+	AppSummary
+	// Memory is the container memory limit, if any
+	Memory string
+	Pods   []PodInfo
+}
+
+// PodInfo is the state of one of an application's pods.
+type PodInfo struct {
+	Name  string
+	State string
+	Since string
+	// CPU and Memory are the pod's current usage, only filled in
+	// when metrics are requested and available
+	CPU    string
+	Memory string
+	// Restarts counts how often the pod's containers have restarted
+	Restarts int
+	// LastCrash describes the most recent time a container stopped,
+	// such as being OOMKilled, or is empty if none has
+	LastCrash string
+}
+
+// Info describes the application and each of its pods, including
+// their CPU and memory usage from the cluster's metrics API if metrics
+// is true.
+func (app *Application) Info(metrics bool) (*AppInfo, error) {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return nil, err
+	}
+	app.displayProject()
+
+	appExists, err := app.deploymentExists()
+	if err != nil {
+		return nil, err
+	}
+	if !appExists {
+		return nil, errors.New(fmt.Sprintf("Error: Application %s not found\n", app.Name))
+	}
+	return app.info(metrics)
+}
+
+func (app *Application) info(metrics bool) (*AppInfo, error) {
+	info := &AppInfo{AppSummary: AppSummary{Name: app.Name, Kind: app.workloadKind()}}
+
+	workload := &types.DeploymentConfig{}
+	err := oc.Get(app.context(), app.oc, info.Kind, app.Name, workload)
+	if err != nil {
+		return nil, err
+	}
+	info.Instances = fmt.Sprintf("%d/%d", workload.Status.ReadyReplicas, workload.Spec.Replicas)
+	if containers := workload.Spec.Template.Spec.Containers; len(containers) > 0 {
+		info.Memory = containers[0].Resources.Limits["memory"]
+	}
+	info.Host, err = app.liveHost()
+	if err != nil {
+		return nil, err
+	}
+
+	pods := &types.PodList{}
+	err = oc.GetSelected(app.context(), app.oc, "pods", app.podSelector(), pods)
+	if err != nil {
+		return nil, err
+	}
+	usage := make(map[string]types.PodMetrics)
+	if metrics {
+		usage, err = app.podMetrics()
+		if err != nil {
+			log.Warnf("Unable to get metrics for %s: %v", app.Name, err)
+		}
+	}
+	for _, pod := range pods.Items {
+		podInfo := PodInfo{
+			Name:      pod.Metadata.Name,
+			State:     podState(pod),
+			Since:     pod.Status.StartTime,
+			LastCrash: lastCrash(pod),
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			podInfo.Restarts += status.RestartCount
+		}
+		if podMetrics, ok := usage[pod.Metadata.Name]; ok {
+			podInfo.CPU, podInfo.Memory = formatUsage(podMetrics, pod)
+		}
+		info.Pods = append(info.Pods, podInfo)
+	}
+	return info, nil
+}
+
+// podState returns the state of a pod the way 'cf app' describes
+// instances: crashed if a container keeps failing, starting until
+// every container is ready, or otherwise the pod's phase.
+func podState(pod types.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if waiting := status.State.Waiting; waiting != nil && waiting.Reason == "CrashLoopBackOff" {
+			return "crashed"
+		}
+	}
+	if pod.Status.Phase == "Running" {
+		for _, status := range pod.Status.ContainerStatuses {
+			if !status.Ready {
+				return "starting"
+			}
+		}
+	}
+	return strings.ToLower(pod.Status.Phase)
+}
+
+// lastCrash describes the last termination of a pod's containers,
+// like "OOMKilled (exit code 137) at 2026-10-17T20:00:00Z".
+func lastCrash(pod types.Pod) string {
+	var crash string
+	var finishedAt string
+	for _, status := range pod.Status.ContainerStatuses {
+		terminated := status.LastState.Terminated
+		if terminated == nil || terminated.FinishedAt < finishedAt {
+			continue
+		}
+		finishedAt = terminated.FinishedAt
+		reason := terminated.Reason
+		if reason == "" {
+			reason = "Terminated"
+		}
+		crash = fmt.Sprintf("%s (exit code %d) at %s", reason, terminated.ExitCode, terminated.FinishedAt)
+		if message := strings.TrimSpace(terminated.Message); message != "" {
+			crash = fmt.Sprint(crash, ": ", message)
+		}
+	}
+	return crash
+}
+
+// podMetrics returns the resource usage of the application's pods by
+// pod name, read from the metrics.k8s.io API.
+func (app *Application) podMetrics() (map[string]types.PodMetrics, error) {
+	project, err := app.oc.Project(app.context())
+	if err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("/apis/metrics.k8s.io/v1beta1/namespaces/%s/pods?labelSelector=%s",
+		strings.TrimSpace(project), url.QueryEscape(app.podSelector()))
+	output, err := curl(app.context(), app.oc, "GET", path, "")
+	if err != nil {
+		return nil, err
+	}
+	list := &types.PodMetricsList{}
+	err = json.Unmarshal(output, list)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error parsing pod metrics: %v\n", err))
+	}
+	metrics := make(map[string]types.PodMetrics)
+	for _, item := range list.Items {
+		metrics[item.Metadata.Name] = item
+	}
+	return metrics, nil
+}
+
+// formatUsage returns the total CPU and memory used by a pod's
+// containers, showing memory against its limit if there is one.
+func formatUsage(metrics types.PodMetrics, pod types.Pod) (string, string) {
+	var cpu, memory, limit int64
+	for _, container := range metrics.Containers {
+		cpu += parseCPU(container.Usage["cpu"])
+		memory += parseMemory(container.Usage["memory"])
+	}
+	for _, container := range pod.Spec.Containers {
+		limit += parseMemory(container.Resources.Limits["memory"])
+	}
+	memoryUsage := formatBytes(memory)
+	if limit > 0 {
+		memoryUsage = fmt.Sprintf("%s of %s", memoryUsage, formatBytes(limit))
+	}
+	return fmt.Sprintf("%dm", cpu), memoryUsage
+}
+
+// parseCPU returns a Kubernetes CPU quantity, like "250m" or
+// "1234567n", in millicores.
+func parseCPU(quantity string) int64 {
+	divisors := map[string]float64{"n": 1e6, "u": 1e3, "m": 1}
+	for suffix, divisor := range divisors {
+		if strings.HasSuffix(quantity, suffix) {
+			value, _ := strconv.ParseFloat(strings.TrimSuffix(quantity, suffix), 64)
+			return int64(value / divisor)
+		}
+	}
+	cores, _ := strconv.ParseFloat(quantity, 64)
+	return int64(cores * 1000)
+}
+
+// parseMemory returns a Kubernetes memory quantity, like "512Mi" or
+// "1G", in bytes.
+func parseMemory(quantity string) int64 {
+	multipliers := []struct {
+		suffix     string
+		multiplier float64
+	}{
+		{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40},
+		{"k", 1e3}, {"K", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12},
+	}
+	for _, m := range multipliers {
+		if strings.HasSuffix(quantity, m.suffix) {
+			value, _ := strconv.ParseFloat(strings.TrimSuffix(quantity, m.suffix), 64)
+			return int64(value * m.multiplier)
+		}
+	}
+	value, _ := strconv.ParseFloat(quantity, 64)
+	return int64(value)
+}
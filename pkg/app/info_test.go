@@ -0,0 +1,69 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestInfoWithMetrics(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", kind: "dc"}
+
+	dcCmd := &mocks.ExecCmd{}
+	dcCmd.On("CombinedOutput").Return([]byte(`{"spec":{"replicas":1,"template":{"spec":{"containers":[{"name":"foo","resources":{"limits":{"memory":"512Mi"}}}]}}},"status":{"readyReplicas":1}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "dc", "foo", "-o", "json"}).Return(dcCmd)
+	routeCmd := &mocks.ExecCmd{}
+	routeCmd.On("CombinedOutput").Return([]byte(`{"spec":{"host":"foo.example.com"}}`), nil)
+	oc.On("Exists", "route", "foo").Return(true, nil)
+	oc.Execer.On("Oc", []string{"get", "route", "foo", "-o", "json"}).Return(routeCmd)
+	podsCmd := &mocks.ExecCmd{}
+	podsCmd.On("CombinedOutput").Return([]byte(`{"items":[{"metadata":{"name":"foo-1-abcde"},"spec":{"containers":[{"name":"foo","resources":{"limits":{"memory":"512Mi"}}}]},"status":{"phase":"Running","startTime":"2026-10-17T20:00:00Z"}}]}`), nil)
+	oc.Execer.On("Oc", []string{"get", "pods", "--selector=deploymentconfig=foo", "-o", "json"}).Return(podsCmd)
+	metricsCmd := &mocks.ExecCmd{}
+	metricsCmd.On("CombinedOutput").Return([]byte(`{"items":[{"metadata":{"name":"foo-1-abcde"},"containers":[{"name":"foo","usage":{"cpu":"2500000n","memory":"131072Ki"}}]}]}`), nil)
+	oc.Execer.On("Oc", []string{"get", "--raw", "/apis/metrics.k8s.io/v1beta1/namespaces/test-project/pods?labelSelector=deploymentconfig%3Dfoo"}).Return(metricsCmd)
+
+	info, err := app.info(true)
+	assert.Nil(t, err)
+	assert.Equal(t, "1/1", info.Instances)
+	assert.Equal(t, "512Mi", info.Memory)
+	assert.Equal(t, "foo.example.com", info.Host)
+	assert.Equal(t, []PodInfo{{
+		Name:   "foo-1-abcde",
+		State:  "running",
+		Since:  "2026-10-17T20:00:00Z",
+		CPU:    "2m",
+		Memory: "128.0 MB of 512.0 MB",
+	}}, info.Pods)
+}
+
+func TestInfoReportsCrashes(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", kind: "deployment"}
+
+	deploymentCmd := &mocks.ExecCmd{}
+	deploymentCmd.On("CombinedOutput").Return([]byte(`{"spec":{"replicas":1},"status":{"readyReplicas":0}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "deployment", "foo", "-o", "json"}).Return(deploymentCmd)
+	oc.On("Exists", "route", "foo").Return(false, nil)
+	podsCmd := &mocks.ExecCmd{}
+	podsCmd.On("CombinedOutput").Return([]byte(`{"items":[{"metadata":{"name":"foo-6d4cf56db6-x2v7k"},"status":{"phase":"Running","containerStatuses":[{"name":"foo","restartCount":3,"state":{"waiting":{"reason":"CrashLoopBackOff"}},"lastState":{"terminated":{"exitCode":137,"reason":"OOMKilled","finishedAt":"2026-10-17T20:05:00Z"}}}]}}]}`), nil)
+	oc.Execer.On("Oc", []string{"get", "pods", "--selector=app=foo", "-o", "json"}).Return(podsCmd)
+
+	info, err := app.info(false)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(info.Pods))
+	assert.Equal(t, "crashed", info.Pods[0].State)
+	assert.Equal(t, 3, info.Pods[0].Restarts)
+	assert.Equal(t, "OOMKilled (exit code 137) at 2026-10-17T20:05:00Z", info.Pods[0].LastCrash)
+}
+
+func TestParseQuantities(t *testing.T) {
+	assert.Equal(t, int64(250), parseCPU("250m"))
+	assert.Equal(t, int64(1500), parseCPU("1.5"))
+	assert.Equal(t, int64(512*1024*1024), parseMemory("512Mi"))
+	assert.Equal(t, int64(1000000000), parseMemory("1G"))
+	assert.Equal(t, int64(0), parseMemory(""))
+}
@@ -0,0 +1,373 @@
+package app
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+
+	"github.com/ghodss/yaml"
+)
+
+// DefaultOcMirror is where oc clients are downloaded from.
+const DefaultOcMirror = "https://mirror.openshift.com/pub/openshift-v4/clients/ocp"
+
+// stableChannel is downloaded when the cluster's version is unknown.
+const stableChannel = "stable"
+
+// checksumsFile lists the SHA-256 checksum of each file in a channel
+// of the mirror.
+const checksumsFile = "sha256sum.txt"
+
+// installOcClient downloads oc, and is replaced by tests.
+var installOcClient = &http.Client{Timeout: 5 * time.Minute}
+
+// InstallOcOptions are how 'ocf install-oc' picks and downloads oc.
+type InstallOcOptions struct {
+	// Server is the cluster whose version picks the client, if
+	// Version isn't given
+	Server string
+	// Version is the OpenShift version to download, such as "4.12",
+	// or "stable" for the latest
+	Version string
+	// Mirror defaults to DefaultOcMirror
+	Mirror string
+	// Dir defaults to exec.BinDir
+	Dir string
+	// GOOS and GOARCH default to ocf's own
+	GOOS   string
+	GOARCH string
+}
+
+// InstallOc downloads the oc client matching the cluster's version
+// into Dir, where ocf prefers it over any other, returning its path.
+func InstallOc(options InstallOcOptions) (string, error) {
+	if options.Mirror == "" {
+		options.Mirror = DefaultOcMirror
+	}
+	if !strings.HasPrefix(options.Mirror, "https://") {
+		return "", errors.New(fmt.Sprintf("Error: The mirror %s must use https", options.Mirror))
+	}
+	if options.Dir == "" {
+		options.Dir = exec.BinDir
+	}
+	if options.Dir == "" {
+		return "", errors.New("Error: Unable to find your home directory to install oc in")
+	}
+	if options.GOOS == "" {
+		options.GOOS = runtime.GOOS
+	}
+	if options.GOARCH == "" {
+		options.GOARCH = runtime.GOARCH
+	}
+	if options.Version == "" {
+		if options.Server == "" {
+			options.Server = currentServer()
+		}
+		options.Version = clusterOcVersion(options.Server)
+	}
+
+	url, err := ocDownloadURL(options)
+	if err != nil {
+		return "", err
+	}
+	log.Infof("Downloading oc %s from %s", options.Version, url)
+	archive, err := download(url)
+	if err != nil {
+		return "", err
+	}
+	channelURL, file := url[:strings.LastIndex(url, "/")+1], url[strings.LastIndex(url, "/")+1:]
+	checksums, err := download(fmt.Sprint(channelURL, checksumsFile))
+	if err != nil {
+		return "", err
+	}
+	err = verifyChecksum(archive, file, checksums)
+	if err != nil {
+		return "", err
+	}
+
+	binary := "oc"
+	if options.GOOS == "windows" {
+		binary = "oc.exe"
+	}
+	var contents []byte
+	if filepath.Ext(url) == ".zip" {
+		contents, err = unzipFile(archive, binary)
+	} else {
+		contents, err = untarFile(archive, binary)
+	}
+	if err != nil {
+		return "", err
+	}
+	return installBinary(options.Dir, binary, contents)
+}
+
+// download returns the contents of url.
+func download(url string) ([]byte, error) {
+	resp, err := installOcClient.Get(url)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error downloading %s: %v", url, err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("Error downloading %s: %s", url, resp.Status))
+	}
+	contents, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error downloading %s: %v", url, err))
+	}
+	return contents, nil
+}
+
+// verifyChecksum checks contents against the checksum of the file name
+// in checksums, a sha256sum.txt listing.
+func verifyChecksum(contents []byte, name string, checksums []byte) error {
+	for _, line := range strings.Split(string(checksums), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		sum := sha256.Sum256(contents)
+		if !strings.EqualFold(fields[0], hex.EncodeToString(sum[:])) {
+			return errors.New(fmt.Sprintf("Error: The checksum of %s doesn't match the mirror's %s", name, checksumsFile))
+		}
+		return nil
+	}
+	return errors.New(fmt.Sprintf("Error: The mirror's %s has no checksum for %s", checksumsFile, name))
+}
+
+// ocDownloadURL returns where the client for options' version and
+// platform is published.
+func ocDownloadURL(options InstallOcOptions) (string, error) {
+	var file string
+	switch options.GOOS {
+	case "linux":
+		file = "openshift-client-linux"
+	case "darwin":
+		file = "openshift-client-mac"
+	case "windows":
+		file = "openshift-client-windows"
+	default:
+		return "", errors.New(fmt.Sprintf("Error: oc is not published for %s", options.GOOS))
+	}
+	switch options.GOARCH {
+	case "amd64":
+	case "arm64", "ppc64le", "s390x":
+		if options.GOOS == "windows" {
+			return "", errors.New(fmt.Sprintf("Error: oc is not published for windows/%s", options.GOARCH))
+		}
+		file = fmt.Sprint(file, "-", options.GOARCH)
+	default:
+		return "", errors.New(fmt.Sprintf("Error: oc is not published for %s/%s", options.GOOS, options.GOARCH))
+	}
+	if options.GOOS == "windows" {
+		file += ".zip"
+	} else {
+		file += ".tar.gz"
+	}
+	channel := options.Version
+	if channel != stableChannel {
+		channel = fmt.Sprint("stable-", options.Version)
+	}
+	return fmt.Sprintf("%s/%s/%s", options.Mirror, channel, file), nil
+}
+
+// currentServer returns the API server to log in to, from
+// LoginServerEnv or else the current kubeconfig context.
+func currentServer() string {
+	if server := os.Getenv(LoginServerEnv); server != "" {
+		return server
+	}
+	path := filepath.SplitList(os.Getenv("KUBECONFIG"))
+	if len(path) == 0 || path[0] == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		path = []string{filepath.Join(home, ".kube", "config")}
+	}
+	contents, err := ioutil.ReadFile(path[0])
+	if err != nil {
+		return ""
+	}
+	return kubeconfigServer(contents)
+}
+
+// kubeconfigServer returns the server of a kubeconfig's current
+// context.
+func kubeconfigServer(contents []byte) string {
+	config := struct {
+		CurrentContext string `json:"current-context"`
+		Contexts       []struct {
+			Name    string `json:"name"`
+			Context struct {
+				Cluster string `json:"cluster"`
+			} `json:"context"`
+		} `json:"contexts"`
+		Clusters []struct {
+			Name    string `json:"name"`
+			Cluster struct {
+				Server string `json:"server"`
+			} `json:"cluster"`
+		} `json:"clusters"`
+	}{}
+	if yaml.Unmarshal(contents, &config) != nil {
+		return ""
+	}
+	for _, context := range config.Contexts {
+		if context.Name != config.CurrentContext {
+			continue
+		}
+		for _, cluster := range config.Clusters {
+			if cluster.Name == context.Context.Cluster {
+				return cluster.Cluster.Server
+			}
+		}
+	}
+	return ""
+}
+
+// kubeVersionPattern matches the Kubernetes minor version of an
+// OpenShift cluster, such as "v1.25.4+77bec7a".
+var kubeVersionPattern = regexp.MustCompile(`^v1\.(\d+)\.`)
+
+// clusterOcVersion returns the OpenShift version of the cluster at
+// server, or "stable" if it can't be found.
+func clusterOcVersion(server string) string {
+	if server == "" {
+		return stableChannel
+	}
+	// Clusters usually have self-signed certificates, and their
+	// version only picks which client to download
+	client := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+	resp, err := client.Get(fmt.Sprint(server, "/version"))
+	if err != nil {
+		log.Debugf("Unable to get the cluster's version: %v", err)
+		return stableChannel
+	}
+	defer resp.Body.Close()
+	info := struct {
+		GitVersion string `json:"gitVersion"`
+	}{}
+	if err = json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		log.Debugf("Unable to parse the cluster's version: %v", err)
+		return stableChannel
+	}
+	version, ok := openShiftVersion(info.GitVersion)
+	if !ok {
+		return stableChannel
+	}
+	return version.String()
+}
+
+// openShiftVersion returns the OpenShift 4 release built on a
+// Kubernetes version. 4.1 shipped Kubernetes 1.13 and 4.2 shipped
+// 1.14, then 4.3 skipped to 1.16 and each release since has moved up
+// one Kubernetes version.
+func openShiftVersion(kubeVersion string) (types.Version, bool) {
+	match := kubeVersionPattern.FindStringSubmatch(kubeVersion)
+	if match == nil {
+		return types.Version{}, false
+	}
+	minor, _ := strconv.Atoi(match[1])
+	switch {
+	case minor < 13:
+		return types.Version{}, false
+	case minor == 13:
+		return types.Version{Major: 4, Minor: 1}, true
+	case minor < 16:
+		return types.Version{Major: 4, Minor: 2}, true
+	}
+	return types.Version{Major: 4, Minor: minor - 13}, true
+}
+
+func untarFile(archive []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error reading the oc download: %v", err))
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.New(fmt.Sprintf("Error reading the oc download: %v", err))
+		}
+		if filepath.Base(header.Name) == name && header.Typeflag == tar.TypeReg {
+			return ioutil.ReadAll(tr)
+		}
+	}
+	return nil, errors.New(fmt.Sprintf("Error: The oc download has no %s", name))
+}
+
+func unzipFile(archive []byte, name string) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error reading the oc download: %v", err))
+	}
+	for _, f := range zr.File {
+		if filepath.Base(f.Name) != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return ioutil.ReadAll(rc)
+	}
+	return nil, errors.New(fmt.Sprintf("Error: The oc download has no %s", name))
+}
+
+// installBinary writes contents to dir without leaving a partial
+// binary behind if it fails.
+func installBinary(dir string, name string, contents []byte) (string, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+	file, err := ioutil.TempFile(dir, fmt.Sprint(".", name, "-"))
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(contents)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), 0755)
+	}
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	if err = os.Rename(file.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
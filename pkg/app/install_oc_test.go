@@ -0,0 +1,136 @@
+package app
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOcDownloadURL(t *testing.T) {
+	url, err := ocDownloadURL(InstallOcOptions{Mirror: "https://mirror", Version: "4.12", GOOS: "linux", GOARCH: "amd64"})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://mirror/stable-4.12/openshift-client-linux.tar.gz", url)
+
+	url, err = ocDownloadURL(InstallOcOptions{Mirror: "https://mirror", Version: "stable", GOOS: "darwin", GOARCH: "arm64"})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://mirror/stable/openshift-client-mac-arm64.tar.gz", url)
+
+	url, err = ocDownloadURL(InstallOcOptions{Mirror: "https://mirror", Version: "4.12", GOOS: "windows", GOARCH: "amd64"})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://mirror/stable-4.12/openshift-client-windows.zip", url)
+
+	_, err = ocDownloadURL(InstallOcOptions{Version: "4.12", GOOS: "plan9", GOARCH: "amd64"})
+	assert.NotNil(t, err)
+}
+
+func TestOpenShiftVersion(t *testing.T) {
+	version, ok := openShiftVersion("v1.25.4+77bec7a")
+	assert.True(t, ok)
+	assert.Equal(t, "4.12", version.String())
+
+	version, ok = openShiftVersion("v1.14.6+8fc50dea9")
+	assert.True(t, ok)
+	assert.Equal(t, "4.2", version.String())
+
+	_, ok = openShiftVersion("v1.11.0+d4cacc0")
+	assert.False(t, ok)
+}
+
+func TestKubeconfigServer(t *testing.T) {
+	kubeconfig := `
+current-context: dev
+contexts:
+- name: prod
+  context: {cluster: prod-cluster}
+- name: dev
+  context: {cluster: dev-cluster}
+clusters:
+- name: prod-cluster
+  cluster: {server: "https://api.prod.example.com:6443"}
+- name: dev-cluster
+  cluster: {server: "https://api.dev.example.com:6443"}
+`
+	assert.Equal(t, "https://api.dev.example.com:6443", kubeconfigServer([]byte(kubeconfig)))
+	assert.Equal(t, "", kubeconfigServer([]byte("not: [valid")))
+}
+
+func clientTarball(t *testing.T) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, contents := range map[string]string{"README.md": "readme", "oc": "oc binary"} {
+		assert.Nil(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(contents)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(contents))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, tw.Close())
+	assert.Nil(t, gz.Close())
+	return buf.Bytes()
+}
+
+func ocMirror(t *testing.T, tarball []byte, checksums string) (*httptest.Server, *string) {
+	var requested string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/version":
+			w.Write([]byte(`{"gitVersion": "v1.25.4+77bec7a"}`))
+		case "/stable-4.12/sha256sum.txt":
+			w.Write([]byte(checksums))
+		default:
+			requested = r.URL.Path
+			w.Write(tarball)
+		}
+	}))
+	original := installOcClient
+	installOcClient = server.Client()
+	t.Cleanup(func() {
+		installOcClient = original
+		server.Close()
+	})
+	return server, &requested
+}
+
+func TestInstallOc(t *testing.T) {
+	tarball := clientTarball(t)
+	sum := sha256.Sum256(tarball)
+	server, requested := ocMirror(t, tarball, fmt.Sprintf("%x  openshift-client-mac.tar.gz\n%s  openshift-client-linux.tar.gz\n",
+		sha256.Sum256(nil), hex.EncodeToString(sum[:])))
+
+	dir := t.TempDir()
+	path, err := InstallOc(InstallOcOptions{Server: server.URL, Mirror: server.URL, Dir: dir, GOOS: "linux", GOARCH: "amd64"})
+	assert.Nil(t, err)
+	assert.Equal(t, "/stable-4.12/openshift-client-linux.tar.gz", *requested)
+	assert.Equal(t, filepath.Join(dir, "oc"), path)
+	contents, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "oc binary", string(contents))
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.NotZero(t, info.Mode()&0100)
+}
+
+func TestInstallOcVerifiesChecksum(t *testing.T) {
+	server, _ := ocMirror(t, clientTarball(t), fmt.Sprintf("%x  openshift-client-linux.tar.gz\n", sha256.Sum256(nil)))
+
+	dir := t.TempDir()
+	_, err := InstallOc(InstallOcOptions{Server: server.URL, Mirror: server.URL, Dir: dir, GOOS: "linux", GOARCH: "amd64"})
+	assert.NotNil(t, err)
+	_, err = os.Stat(filepath.Join(dir, "oc"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestInstallOcRequiresHTTPS(t *testing.T) {
+	_, err := InstallOc(InstallOcOptions{Mirror: "http://mirror.example.com", Version: "4.12", Dir: t.TempDir()})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// InternalDomain is the domain of Cloud Foundry's internal routes.
+// Manifest routes under it are only reachable inside the cluster.
+const InternalDomain = "apps.internal"
+
+// internalRoute returns true if route is under InternalDomain.
+func internalRoute(route string) bool {
+	host, _, err := ParseRoute(route)
+	return err == nil && strings.HasSuffix(host, fmt.Sprint(".", InternalDomain))
+}
+
+// internal returns true if the application is only reachable through
+// its service inside the cluster, because it was pushed with
+// --internal or all of its manifest routes are internal ones.
+func (app *Application) internal() bool {
+	if app.options.Internal {
+		return true
+	}
+	if len(app.Routes) == 0 {
+		return false
+	}
+	for _, route := range app.Routes {
+		if !internalRoute(route.Route) {
+			return false
+		}
+	}
+	return true
+}
+
+// ensureInternal removes the default route or ingress earlier pushes
+// created for an application that's now internal, and shows the
+// address other applications reach it at.
+func (app *Application) ensureInternal() error {
+	objType := "route"
+	if app.kubernetes() {
+		objType = "ingress"
+	}
+	owned, err := app.oc.Owned(app.context(), app.Name)
+	if err != nil {
+		return err
+	}
+	for _, object := range owned {
+		if object != fmt.Sprint(objType, "/", app.Name) {
+			continue
+		}
+		log.Infof("Removing the %s of %s, which is now internal", objType, app.Name)
+		err = app.oc.Delete(app.context(), objType, app.Name)
+		if err != nil {
+			return err
+		}
+		app.disown(objType, app.Name)
+	}
+	project, err := app.oc.Project(app.context())
+	if err != nil {
+		return err
+	}
+	log.Infof("Your application is available inside the cluster at %s", app.internalAddress(strings.TrimSpace(project)))
+	return nil
+}
+
+// internalAddress returns the host and port of the application's
+// service, the equivalent of its APP.apps.internal route.
+func (app *Application) internalAddress(project string) string {
+	return fmt.Sprintf("%s.%s.svc:%d", app.Name, project, app.servicePort())
+}
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestInternal(t *testing.T) {
+	app := Application{Name: "foo"}
+	assert.False(t, app.internal())
+
+	app.options.Internal = true
+	assert.True(t, app.internal())
+
+	app = Application{Name: "foo", Routes: []Route{{Route: "foo.apps.internal"}}}
+	assert.True(t, app.internal())
+
+	app.Routes = append(app.Routes, Route{Route: "foo.example.com"})
+	assert.False(t, app.internal())
+}
+
+func TestEnsureInternalRemovesDefaultRoute(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.Own(context.Background(), "foo", "svc", "foo")
+	oc.Own(context.Background(), "foo", "route", "foo")
+	oc.On("Delete", "route", "foo").Return(nil)
+
+	app := Application{oc: oc, Name: "foo"}
+	assert.Nil(t, app.ensureInternal())
+	oc.AssertExpectations(t)
+	assert.Equal(t, []string{"svc/foo"}, oc.OwnedObjects["foo"])
+
+	assert.Equal(t, "foo.test-project.svc:8080", app.internalAddress("test-project"))
+}
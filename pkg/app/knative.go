@@ -0,0 +1,113 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+// ServeKnative runs applications as Knative Services, which are
+// revisioned and scale to zero when idle, instead of deployments.
+const ServeKnative string = "knative"
+
+func (app *Application) knative() bool {
+	return app.options.Serve == ServeKnative
+}
+
+// ensureKnativeService creates or updates the application's Knative
+// Service. Every push creates a new revision.
+func (app *Application) ensureKnativeService() error {
+	image, err := app.deploymentImage()
+	if err != nil {
+		return withOutput(image, err)
+	}
+	env, secretNames, err := app.envForServiceBindings()
+	if err != nil {
+		return err
+	}
+	manifest, err := app.knativeServiceManifest(app.knativeImage(string(image)), env, secretNames)
+	if err != nil {
+		return err
+	}
+	log.Infof("Applying Knative service %s", app.Name)
+	err = app.oc.Apply(app.context(), manifest)
+	if err != nil {
+		return err
+	}
+
+	waitCmd := app.oc.Exec("wait", "--for=condition=Ready", fmt.Sprint("ksvc/", app.Name))
+	waitCmd.AttachStdIO()
+	waitCmd.SetTimeout(exec.BuildTimeout)
+	log.Infof("Waiting for Knative service with command: %s", waitCmd.ArgsString())
+	err = waitCmd.Run(app.context())
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// knativeImage tags build output, which is an image stream's
+// repository, with latest.
+func (app *Application) knativeImage(image string) string {
+	image = strings.TrimSpace(image)
+	if app.IsDocker() || app.kubernetes() {
+		return image
+	}
+	return fmt.Sprint(image, ":latest")
+}
+
+// knativeServiceManifest returns a serving.knative.dev/v1 Service for
+// the application. Instances caps how far the service scales out,
+// while it always scales to zero when idle.
+func (app *Application) knativeServiceManifest(image string, env []string, secretNames []string) ([]byte, error) {
+	container := app.container(image, env, secretNames)
+	delete(container, "name")
+
+	annotations := map[string]string{
+		"autoscaling.knative.dev/min-scale": "0",
+		// Knative only creates a revision when the template
+		// changes, which a rebuilt image with the same tag doesn't
+		"ocf/pushed-at": time.Now().UTC().Format(time.RFC3339),
+	}
+	if app.Instances > 0 {
+		annotations["autoscaling.knative.dev/max-scale"] = fmt.Sprint(app.Instances)
+	}
+
+	service := map[string]interface{}{
+		"apiVersion": "serving.knative.dev/v1",
+		"kind":       "Service",
+		"metadata": map[string]interface{}{
+			"name":   app.Name,
+			"labels": map[string]string{"app": app.Name},
+		},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels":      map[string]string{"app": app.Name},
+					"annotations": annotations,
+				},
+				"spec": map[string]interface{}{
+					"containers": []interface{}{container},
+				},
+			},
+		},
+	}
+	return json.MarshalIndent(service, "", "  ")
+}
+
+func (app *Application) displayKnativeURL() error {
+	service := &types.KnativeService{}
+	err := oc.Get(app.context(), app.oc, "ksvc", app.Name, service)
+	if err != nil {
+		return err
+	} else {
+		log.Infof("Your application is available at %s", service.Status.URL)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKnativeServiceManifest(t *testing.T) {
+	app := Application{Name: "foo", Memory: "512M", Instances: 3}
+	manifest, err := app.knativeServiceManifest("172.30.1.1:5000/p/foo:latest", []string{"A=b"}, []string{"foo-db-binding"})
+	assert.Nil(t, err)
+
+	var service struct {
+		APIVersion string
+		Kind       string
+		Spec       struct {
+			Template struct {
+				Metadata struct {
+					Annotations map[string]string
+				}
+				Spec struct {
+					Containers []struct {
+						Image   string
+						Env     []map[string]string
+						EnvFrom []struct {
+							SecretRef map[string]string
+						}
+					}
+				}
+			}
+		}
+	}
+	assert.Nil(t, json.Unmarshal(manifest, &service))
+	assert.Equal(t, "serving.knative.dev/v1", service.APIVersion)
+	assert.Equal(t, "Service", service.Kind)
+	annotations := service.Spec.Template.Metadata.Annotations
+	assert.Equal(t, "0", annotations["autoscaling.knative.dev/min-scale"])
+	assert.Equal(t, "3", annotations["autoscaling.knative.dev/max-scale"])
+	assert.NotEmpty(t, annotations["ocf/pushed-at"])
+	container := service.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "172.30.1.1:5000/p/foo:latest", container.Image)
+	assert.Equal(t, "foo-db-binding", container.EnvFrom[0].SecretRef["name"])
+	assert.Contains(t, container.Env, map[string]string{"name": "A", "value": "b"})
+}
+
+func TestKnativeImage(t *testing.T) {
+	app := Application{Name: "foo"}
+	assert.Equal(t, "172.30.1.1:5000/p/foo:latest", app.knativeImage("172.30.1.1:5000/p/foo\n"))
+
+	app.Docker = &Docker{Image: "nginx:1.13"}
+	assert.Equal(t, "nginx:1.13", app.knativeImage("nginx:1.13"))
+}
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+// newOc creates the Oc of applications that don't have one set. It's
+// chosen once, by SelectPlatform, before any command runs.
+var newOc oc.Constructor = oc.NewOpenShift
+
+// SelectPlatform chooses the cluster type targeted by applications
+// that don't have an Oc set, either oc.PlatformOpenShift or
+// oc.PlatformKubernetes.
+func SelectPlatform(platform string) error {
+	constructor, err := oc.ForPlatform(platform)
+	if err != nil {
+		return err
+	}
+	newOc = constructor
+	return nil
+}
+
+// CurrentProject returns the project commands run in by default.
+func CurrentProject(ctx context.Context) (string, error) {
+	return newOc("").Project(ctx)
+}
+
+func (app *Application) kubernetes() bool {
+	return app.oc != nil && app.oc.Platform() == oc.PlatformKubernetes
+}
+
+// workloadKinds returns the types of objects that can run
+// applications on o's platform.
+func workloadKinds(o oc.Oc) []string {
+	if o.Platform() == oc.PlatformKubernetes {
+		return []string{"deployment"}
+	}
+	return []string{"dc", "deployment"}
+}
+
+// registryImage returns the image built locally for Kubernetes.
+func (app *Application) registryImage() string {
+	return fmt.Sprint(strings.TrimSuffix(app.options.Registry, "/"), "/", app.Name, ":latest")
+}
+
+// buildImage builds the application with s2i and the builder image,
+// since Kubernetes has no builds of its own, and pushes the result
+// to the registry.
+func (app *Application) buildImage(builder string) error {
+	if app.options.Registry == "" {
+		return errors.New("Error: A registry is required to build applications on Kubernetes")
+	}
+
+	env := make(map[string]string)
+	for key, value := range app.BuildEnv {
+		env[key] = value
+	}
+	if app.Buildpack != "" {
+		env[BuildpackUrl] = app.Buildpack
+	}
+	var keys []string
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	image := app.registryImage()
+	buildArgs := []string{"build", app.Path, builder, image}
+	for _, key := range keys {
+		buildArgs = append(buildArgs, "-e", fmt.Sprint(key, "=", env[key]))
+	}
+	buildCmd := app.execer.Command("s2i", buildArgs...)
+	buildCmd.AttachStdIO()
+	buildCmd.SetTimeout(exec.BuildTimeout)
+	log.Infof("Building image with command: %s", buildCmd.ArgsString())
+	err := buildCmd.Run(app.context())
+	if err != nil {
+		return err
+	}
+
+	pushCmd := app.execer.Command("docker", "push", image)
+	pushCmd.AttachStdIO()
+	pushCmd.SetTimeout(exec.BuildTimeout)
+	log.Infof("Pushing image with command: %s", pushCmd.ArgsString())
+	err = pushCmd.Run(app.context())
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (app *Application) ingressHost() string {
+	return fmt.Sprint(app.Name, ".", app.options.Domain)
+}
+
+// ensureIngressExists exposes the application's service outside of
+// the cluster, the Kubernetes equivalent of a route. Ingresses need
+// an explicit host, so nothing is created without a domain.
+func (app *Application) ensureIngressExists() error {
+	if app.options.Domain == "" {
+		log.Infof("No domain given, skipping creating an ingress for %s", app.Name)
+		return nil
+	}
+	output, err := app.oc.Exec("get", "ingress", app.Name).CombinedOutput(app.context())
+	if strings.Contains(string(output), "not found") {
+		app.created = append(app.created, "ingress")
+		newCmd := app.oc.Exec("create", "ingress", app.Name,
+			fmt.Sprint("--rule=", app.ingressHost(), "/*=", app.Name, ":8080"))
+		log.Infof("Creating ingress with command: %s", newCmd.ArgsString())
+		output, err = newCmd.CombinedOutput(app.context())
+		log.Printf("%s", output)
+		if err != nil {
+			return err
+		}
+		app.own("ingress")
+	} else if err != nil {
+		return withOutput(output, err)
+	} else {
+		log.Infof("Ingress already exists for %s, skipping creating one", app.Name)
+	}
+	return nil
+}
+
+func (app *Application) displayIngress() error {
+	if app.options.Domain == "" {
+		log.Infof("Your application is available inside the cluster as service %s. Run 'kubectl port-forward svc/%s 8080' to reach it locally", app.Name, app.Name)
+		return nil
+	}
+	ingress := &types.Ingress{}
+	err := oc.Get(app.context(), app.oc, "ingress", app.Name, ingress)
+	if err != nil {
+		return err
+	} else {
+		log.Infof("Your application is available at %s", ingress.Host())
+	}
+	return nil
+}
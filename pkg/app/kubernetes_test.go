@@ -0,0 +1,64 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestKubernetesWorkloadKind(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.PlatformName = "k8s"
+	app := Application{oc: oc, Name: "foo"}
+	assert.Equal(t, "deployment", app.workloadKind())
+	assert.Equal(t, []string{"deployment"}, workloadKinds(oc))
+}
+
+func TestBuildImageWithS2I(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.PlatformName = "k8s"
+	execer := &mocks.Execer{}
+	app := Application{oc: oc, execer: execer, Name: "foo", Path: "/src",
+		Buildpack: "https://example.com/bp.git", BuildEnv: map[string]string{"A": "b"}}
+	app.options.Registry = "quay.io/me/"
+
+	buildCmd := &mocks.ExecCmd{Args: []string{"build", "/src", "builder", "quay.io/me/foo:latest",
+		"-e", "A=b", "-e", "BUILDPACK_URL=https://example.com/bp.git"}}
+	buildCmd.On("AttachStdIO").Return()
+	buildCmd.On("Run").Return(nil)
+	pushCmd := &mocks.ExecCmd{Args: []string{"push", "quay.io/me/foo:latest"}}
+	pushCmd.On("AttachStdIO").Return()
+	pushCmd.On("Run").Return(nil)
+	execer.On("Command", "s2i", buildCmd.Args).Return(buildCmd)
+	execer.On("Command", "docker", pushCmd.Args).Return(pushCmd)
+
+	assert.Nil(t, app.buildImage("builder"))
+	execer.AssertExpectations(t)
+	buildCmd.AssertExpectations(t)
+	pushCmd.AssertExpectations(t)
+
+	image, err := app.deploymentImage()
+	assert.Nil(t, err)
+	assert.Equal(t, "quay.io/me/foo:latest", string(image))
+}
+
+func TestEnsureIngressExists(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.PlatformName = "k8s"
+	app := Application{oc: oc, Name: "foo"}
+	app.options.Domain = "apps.example.com"
+
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte("ingresses \"foo\" not found"), nil)
+	createCmd := &mocks.ExecCmd{}
+	createCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", []string{"get", "ingress", "foo"}).Return(getCmd)
+	oc.Execer.On("Oc", []string{"create", "ingress", "foo",
+		"--rule=foo.apps.example.com/*=foo:8080"}).Return(createCmd)
+
+	assert.Nil(t, app.ensureIngressExists())
+	oc.Execer.AssertExpectations(t)
+	createCmd.AssertExpectations(t)
+}
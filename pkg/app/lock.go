@@ -0,0 +1,175 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+// PushLockTTL is how long a push lock is honored, so a push that was
+// killed doesn't block the application forever. A running push keeps
+// renewing its lock.
+const PushLockTTL = 15 * time.Minute
+
+var (
+	lockPollInterval  = 5 * time.Second
+	lockRenewInterval = PushLockTTL / 3
+)
+
+func (app *Application) pushLockName() string {
+	return fmt.Sprint(app.Name, "-ocf-push-lock")
+}
+
+func pushLockOwner() string {
+	user := os.Getenv("USER")
+	if user == "" {
+		user = "unknown"
+	}
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s@%s (pid %d)", user, host, os.Getpid())
+}
+
+// acquirePushLock claims the application for this push by creating a
+// config map, which fails if another push already created it. It
+// waits up to wait for the other push to finish, or fails fast if
+// wait is 0. The lock is renewed until the returned function releases
+// it.
+func (app *Application) acquirePushLock(wait time.Duration) (func(), error) {
+	name := app.pushLockName()
+	owner := pushLockOwner()
+	deadline := time.Now().Add(wait)
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	release := func() {
+		close(stop)
+		<-stopped
+		app.releasePushLock(owner)
+	}
+	for {
+		expires := time.Now().Add(PushLockTTL).UTC().Format(time.RFC3339)
+		output, err := app.oc.Exec("create", "configmap", name,
+			fmt.Sprint("--from-literal=owner=", owner),
+			fmt.Sprint("--from-literal=expires=", expires)).CombinedOutput(app.context())
+		if err == nil {
+			go app.renewPushLock(owner, lockRenewInterval, stop, stopped)
+			return release, nil
+		}
+		if !strings.Contains(string(output), "already exists") {
+			return nil, errors.New(fmt.Sprintf("Error creating push lock %s: %s\n", name, output))
+		}
+
+		// Read the lock directly, since it changes under a cache
+		lock := &types.Data{}
+		err = oc.Get(app.context(), app.oc, "configmap", name, lock)
+		if err != nil && strings.Contains(err.Error(), "not found") {
+			// The other push just released it
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		lockExpires, err := time.Parse(time.RFC3339, lock.Data["expires"])
+		if err != nil || time.Now().After(lockExpires) {
+			log.Infof("Taking over expired push lock held by %s", lock.Data["owner"])
+			taken, err := app.takeOverPushLock(lock.Metadata.ResourceVersion, owner, expires)
+			if err != nil {
+				return nil, err
+			}
+			if taken {
+				go app.renewPushLock(owner, lockRenewInterval, stop, stopped)
+				return release, nil
+			}
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.New(fmt.Sprintf("Error: Push of %s already in progress by %s. If it was interrupted, delete configmap %s or wait until %s",
+				app.Name, lock.Data["owner"], name, lock.Data["expires"]))
+		}
+		log.Infof("Waiting for push of %s by %s to finish", app.Name, lock.Data["owner"])
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// takeOverPushLock makes an expired lock this push's, only if it's
+// still at resourceVersion. Otherwise another push released it or took
+// it over first, and it returns false.
+func (app *Application) takeOverPushLock(resourceVersion string, owner string, expires string) (bool, error) {
+	name := app.pushLockName()
+	patch, err := json.Marshal([]map[string]interface{}{
+		{"op": "test", "path": "/metadata/resourceVersion", "value": resourceVersion},
+		{"op": "replace", "path": "/data", "value": map[string]string{"owner": owner, "expires": expires}},
+	})
+	if err != nil {
+		return false, err
+	}
+	output, err := app.oc.Exec("patch", "configmap", name, "--type=json", "-p", string(patch)).CombinedOutput(app.context())
+	if err == nil {
+		return true, nil
+	}
+	if strings.Contains(string(output), "test failed") || strings.Contains(string(output), "not found") {
+		return false, nil
+	}
+	return false, errors.New(fmt.Sprintf("Error taking over push lock %s: %s\n", name, output))
+}
+
+// renewPushLock pushes back the expiry of the lock held by owner every
+// interval until stop is closed, then closes stopped, so a push that
+// runs longer than PushLockTTL, like one with a slow build, isn't taken
+// over.
+func (app *Application) renewPushLock(owner string, interval time.Duration, stop <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	name := app.pushLockName()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+		expires := time.Now().Add(PushLockTTL).UTC().Format(time.RFC3339)
+		patch, err := json.Marshal([]map[string]interface{}{
+			{"op": "test", "path": "/data/owner", "value": owner},
+			{"op": "replace", "path": "/data/expires", "value": expires},
+		})
+		if err != nil {
+			return
+		}
+		output, err := app.oc.Exec("patch", "configmap", name, "--type=json", "-p", string(patch)).CombinedOutput(context.Background())
+		if err == nil {
+			continue
+		}
+		log.Warnf("Unable to renew push lock %s: %s", name, output)
+		if strings.Contains(string(output), "test failed") || strings.Contains(string(output), "not found") {
+			// Another push has it now
+			return
+		}
+	}
+}
+
+// releasePushLock deletes the lock if owner still holds it. It runs
+// even after the push was cancelled, so it doesn't use the push's
+// context.
+func (app *Application) releasePushLock(owner string) {
+	ctx := context.Background()
+	name := app.pushLockName()
+	lock := &types.Data{}
+	err := oc.Get(ctx, app.oc, "configmap", name, lock)
+	if err != nil && strings.Contains(err.Error(), "not found") {
+		return
+	}
+	if err == nil && lock.Data["owner"] != owner {
+		log.Warnf("Not releasing push lock %s, which %s took over", name, lock.Data["owner"])
+		return
+	}
+	if err == nil {
+		err = app.oc.Delete(ctx, "configmap", name)
+	}
+	if err != nil {
+		log.Warnf("Unable to release push lock %s, delete configmap %s before pushing again: %v", name, name, err)
+	}
+}
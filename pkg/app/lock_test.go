@@ -0,0 +1,165 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestAcquirePushLock(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	createCmd := &mocks.ExecCmd{}
+	createCmd.On("CombinedOutput").Return([]byte("configmap/foo-ocf-push-lock created"), nil)
+	oc.Execer.On("Oc", mock.MatchedBy(func(args []string) bool {
+		return len(args) > 2 && args[0] == "create" && args[2] == "foo-ocf-push-lock"
+	})).Return(createCmd)
+	expectLockOwner(oc, pushLockOwner())
+	oc.On("Delete", "configmap", "foo-ocf-push-lock").Return(nil)
+
+	// Releasing works even once the push was cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	app.WithContext(ctx)
+	release, err := app.acquirePushLock(0)
+	assert.Nil(t, err)
+	cancel()
+	release()
+	oc.AssertExpectations(t)
+}
+
+// expectLockOwner makes owner the holder of the push lock when it's
+// released.
+func expectLockOwner(oc *mocks.Oc, owner string) {
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(fmt.Sprintf(`{"data": {"owner": %q}}`, owner)), nil)
+	oc.Execer.On("Oc", []string{"get", "configmap", "foo-ocf-push-lock", "-o", "json"}).Return(getCmd)
+}
+
+func TestReleasePushLockKeepsTakenOverLock(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+	expectLockOwner(oc, "alice@laptop (pid 42)")
+
+	app.releasePushLock(pushLockOwner())
+	oc.AssertNotCalled(t, "Delete", "configmap", "foo-ocf-push-lock")
+}
+
+func TestPushLockIsRenewed(t *testing.T) {
+	original := lockRenewInterval
+	lockRenewInterval = time.Millisecond
+	defer func() { lockRenewInterval = original }()
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	createCmd := &mocks.ExecCmd{}
+	createCmd.On("CombinedOutput").Return([]byte("configmap/foo-ocf-push-lock created"), nil)
+	oc.Execer.On("Oc", mock.MatchedBy(func(args []string) bool {
+		return len(args) > 2 && args[0] == "create"
+	})).Return(createCmd)
+	renewed := make(chan []map[string]interface{}, 1)
+	patchCmd := &mocks.ExecCmd{}
+	patchCmd.On("CombinedOutput").Return([]byte("configmap/foo-ocf-push-lock patched"), nil)
+	oc.Execer.On("Oc", mock.MatchedBy(func(args []string) bool {
+		var patch []map[string]interface{}
+		if len(args) != 6 || args[0] != "patch" || json.Unmarshal([]byte(args[5]), &patch) != nil {
+			return false
+		}
+		select {
+		case renewed <- patch:
+		default:
+		}
+		return true
+	})).Return(patchCmd)
+	expectLockOwner(oc, pushLockOwner())
+	oc.On("Delete", "configmap", "foo-ocf-push-lock").Return(nil)
+
+	release, err := app.acquirePushLock(0)
+	assert.Nil(t, err)
+	patch := <-renewed
+	release()
+	// Only the lock this push still owns is renewed
+	assert.Equal(t, map[string]interface{}{"op": "test", "path": "/data/owner", "value": pushLockOwner()}, patch[0])
+	assert.Equal(t, "/data/expires", patch[1]["path"])
+}
+
+// expectPushLock makes the push lock already exist, with lockJSON
+// read back as its contents.
+func expectPushLock(oc *mocks.Oc, lockJSON string) {
+	existsCmd := &mocks.ExecCmd{}
+	existsCmd.On("CombinedOutput").Return([]byte("configmaps \"foo-ocf-push-lock\" already exists"), errors.New("exit status 1"))
+	oc.Execer.On("Oc", mock.MatchedBy(func(args []string) bool {
+		return len(args) > 2 && args[0] == "create" && args[2] == "foo-ocf-push-lock"
+	})).Return(existsCmd).Once()
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(lockJSON), nil)
+	oc.Execer.On("Oc", []string{"get", "configmap", "foo-ocf-push-lock", "-o", "json"}).Return(getCmd).Once()
+}
+
+func TestAcquirePushLockHeldByOther(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	expectPushLock(oc, fmt.Sprintf(`{"data": {"owner": "alice@laptop (pid 42)", "expires": %q}}`,
+		time.Now().Add(time.Minute).UTC().Format(time.RFC3339)))
+
+	_, err := app.acquirePushLock(0)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "already in progress by alice@laptop (pid 42)")
+	oc.AssertExpectations(t)
+}
+
+func TestAcquirePushLockTakesOverExpiredLock(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	expectPushLock(oc, fmt.Sprintf(`{"metadata": {"resourceVersion": "7"}, "data": {"owner": "alice@laptop (pid 42)", "expires": %q}}`,
+		time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)))
+	var patch []map[string]interface{}
+	patchCmd := &mocks.ExecCmd{}
+	patchCmd.On("CombinedOutput").Return([]byte("configmap/foo-ocf-push-lock patched"), nil)
+	oc.Execer.On("Oc", mock.MatchedBy(func(args []string) bool {
+		if len(args) != 6 || args[0] != "patch" || args[4] != "-p" {
+			return false
+		}
+		return json.Unmarshal([]byte(args[5]), &patch) == nil
+	})).Return(patchCmd)
+
+	_, err := app.acquirePushLock(0)
+	assert.Nil(t, err)
+	oc.AssertExpectations(t)
+	// The lock is only taken over if no one else changed it first
+	assert.Equal(t, map[string]interface{}{"op": "test", "path": "/metadata/resourceVersion", "value": "7"}, patch[0])
+	assert.Equal(t, "replace", patch[1]["op"])
+	assert.Equal(t, pushLockOwner(), patch[1]["value"].(map[string]interface{})["owner"])
+	oc.AssertNotCalled(t, "Delete", "configmap", "foo-ocf-push-lock")
+}
+
+func TestAcquirePushLockLosesTakeOverRace(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	expectPushLock(oc, fmt.Sprintf(`{"metadata": {"resourceVersion": "7"}, "data": {"owner": "alice@laptop (pid 42)", "expires": %q}}`,
+		time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)))
+	patchCmd := &mocks.ExecCmd{}
+	patchCmd.On("CombinedOutput").Return([]byte("The request is invalid: testing value /metadata/resourceVersion failed: test failed"), errors.New("exit status 1"))
+	oc.Execer.On("Oc", mock.MatchedBy(func(args []string) bool {
+		return len(args) > 0 && args[0] == "patch"
+	})).Return(patchCmd)
+	// Bob took it over first
+	expectPushLock(oc, fmt.Sprintf(`{"metadata": {"resourceVersion": "8"}, "data": {"owner": "bob@desktop (pid 7)", "expires": %q}}`,
+		time.Now().Add(PushLockTTL).UTC().Format(time.RFC3339)))
+
+	_, err := app.acquirePushLock(0)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "already in progress by bob@desktop (pid 7)")
+	oc.AssertExpectations(t)
+}
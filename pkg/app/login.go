@@ -0,0 +1,144 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// NonInteractive makes anything that would prompt the user, like
+// 'oc login', fail instead so unattended jobs don't hang.
+var NonInteractive bool
+
+const (
+	// LoginTokenEnv holds a token to log in with for the rest of the
+	// run when not logged in, or "-" to read the token from stdin
+	LoginTokenEnv string = "OCF_TOKEN"
+	// LoginServerEnv is the API server logged in to with
+	// LoginTokenEnv, defaulting to the current one
+	LoginServerEnv string = "OCF_SERVER"
+)
+
+// loginStdin is where the token is read from when LoginTokenEnv is "-"
+var loginStdin io.Reader = os.Stdin
+
+// loginConfig is the temporary kubeconfig holding the token logged in
+// with, if any
+var loginConfig string
+
+func (app *Application) login() error {
+	token := os.Getenv(LoginTokenEnv)
+	if token == "-" {
+		line, err := bufio.NewReader(loginStdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		token = strings.TrimSpace(line)
+		if token == "" {
+			return errors.New(fmt.Sprintf("Error: No token given on stdin with %s=-\n", LoginTokenEnv))
+		}
+	}
+
+	if token != "" {
+		return app.loginWithToken(token)
+	}
+
+	if NonInteractive {
+		return errors.New(fmt.Sprintf("Error: Not logged in and running non-interactively. Run 'oc login' first or set %s\n", LoginTokenEnv))
+	}
+	loginCmd := app.oc.Exec("login")
+	loginCmd.AttachStdIO()
+	// Logging in waits on the user
+	loginCmd.SetTimeout(0)
+	return loginCmd.Run(app.context())
+}
+
+// loginWithToken logs in with token for the rest of the run. Rather
+// than 'oc login --token', which would put the token on a command
+// line, it's written to a temporary kubeconfig that later commands
+// use through KUBECONFIG. The current cluster's settings, like its
+// certificate authority and namespace, are kept when it's the one
+// logged in to.
+func (app *Application) loginWithToken(token string) error {
+	server := os.Getenv(LoginServerEnv)
+	var current struct {
+		Clusters []struct {
+			Cluster map[string]interface{} `json:"cluster"`
+		} `json:"clusters"`
+		Contexts []struct {
+			Context map[string]interface{} `json:"context"`
+		} `json:"contexts"`
+	}
+	cluster := map[string]interface{}{"server": server}
+	kubeContext := map[string]interface{}{"cluster": "ocf", "user": "ocf"}
+	output, err := app.oc.Exec("config", "view", "--minify", "--flatten", "--raw", "-o", "json").CombinedOutput(app.context())
+	if err == nil && json.Unmarshal(output, &current) == nil && len(current.Clusters) == 1 {
+		if currentServer, _ := current.Clusters[0].Cluster["server"].(string); server == "" || server == currentServer {
+			cluster = current.Clusters[0].Cluster
+			server = currentServer
+			if len(current.Contexts) == 1 && current.Contexts[0].Context["namespace"] != nil {
+				kubeContext["namespace"] = current.Contexts[0].Context["namespace"]
+			}
+		}
+	}
+	if server == "" {
+		return errors.New(fmt.Sprintf("Error: Set %s to the API server to log in to with the token from %s\n", LoginServerEnv, LoginTokenEnv))
+	}
+
+	kubeconfig, err := json.Marshal(map[string]interface{}{
+		"apiVersion":      "v1",
+		"kind":            "Config",
+		"clusters":        []interface{}{map[string]interface{}{"name": "ocf", "cluster": cluster}},
+		"users":           []interface{}{map[string]interface{}{"name": "ocf", "user": map[string]string{"token": token}}},
+		"contexts":        []interface{}{map[string]interface{}{"name": "ocf", "context": kubeContext}},
+		"current-context": "ocf",
+	})
+	if err != nil {
+		return err
+	}
+	// TempFile creates the file readable only by us
+	file, err := ioutil.TempFile("", "ocf-kubeconfig")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(kubeconfig)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+
+	log.Infof("Logging in to %s with the token from %s", server, LoginTokenEnv)
+	previous, hadPrevious := os.LookupEnv("KUBECONFIG")
+	os.Setenv("KUBECONFIG", file.Name())
+	output, err = app.oc.Exec("whoami").CombinedOutput(app.context())
+	if err != nil {
+		os.Remove(file.Name())
+		if hadPrevious {
+			os.Setenv("KUBECONFIG", previous)
+		} else {
+			os.Unsetenv("KUBECONFIG")
+		}
+		return errors.New(fmt.Sprintf("Error logging in with the token from %s: %s\n", LoginTokenEnv, output))
+	}
+	loginConfig = file.Name()
+	return nil
+}
+
+// RemoveLoginConfig removes the temporary kubeconfig a token was
+// logged in with, which should be done before exiting.
+func RemoveLoginConfig() {
+	if loginConfig != "" {
+		os.Remove(loginConfig)
+		loginConfig = ""
+	}
+}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestLoginNonInteractiveFails(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc}
+	os.Unsetenv(LoginTokenEnv)
+	NonInteractive = true
+	defer func() { NonInteractive = false }()
+
+	err := app.login()
+	assert.NotNil(t, err)
+	oc.Execer.AssertNotCalled(t, "Oc", []string{"login"})
+}
+
+func TestLoginWithTokenFromStdin(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc}
+	os.Setenv(LoginTokenEnv, "-")
+	defer os.Unsetenv(LoginTokenEnv)
+	os.Setenv(LoginServerEnv, "https://api.example.com:6443")
+	defer os.Unsetenv(LoginServerEnv)
+	loginStdin = strings.NewReader("sha256~secret\n")
+	defer func() { loginStdin = os.Stdin }()
+	NonInteractive = true
+	defer func() { NonInteractive = false }()
+
+	configCmd := &mocks.ExecCmd{}
+	configCmd.On("CombinedOutput").Return([]byte(`{
+		"clusters": [{"name": "api", "cluster": {"server": "https://api.example.com:6443", "certificate-authority-data": "Y2E="}}],
+		"contexts": [{"name": "p", "context": {"cluster": "api", "namespace": "p", "user": "old"}}]
+	}`), nil)
+	oc.Execer.On("Oc", []string{"config", "view", "--minify", "--flatten", "--raw", "-o", "json"}).Return(configCmd)
+	var kubeconfig string
+	whoamiCmd := &mocks.ExecCmd{}
+	whoamiCmd.On("CombinedOutput").Return([]byte("ci"), nil).Run(func(mock.Arguments) {
+		kubeconfig = os.Getenv("KUBECONFIG")
+	})
+	oc.Execer.On("Oc", []string{"whoami"}).Return(whoamiCmd)
+	previous, hadPrevious := os.LookupEnv("KUBECONFIG")
+	defer func() {
+		if hadPrevious {
+			os.Setenv("KUBECONFIG", previous)
+		} else {
+			os.Unsetenv("KUBECONFIG")
+		}
+	}()
+
+	err := app.login()
+	assert.Nil(t, err)
+	oc.Execer.AssertExpectations(t)
+	for _, call := range oc.Execer.Calls {
+		assert.NotContains(t, strings.Join(call.Arguments.Get(0).([]string), " "), "secret")
+	}
+
+	info, err := os.Stat(kubeconfig)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	var config struct {
+		Clusters []struct {
+			Cluster map[string]string
+		}
+		Users []struct {
+			User map[string]string
+		}
+		Contexts []struct {
+			Context map[string]string
+		}
+	}
+	data, _ := ioutil.ReadFile(kubeconfig)
+	assert.Nil(t, json.Unmarshal(data, &config))
+	assert.Equal(t, "Y2E=", config.Clusters[0].Cluster["certificate-authority-data"])
+	assert.Equal(t, "sha256~secret", config.Users[0].User["token"])
+	assert.Equal(t, "p", config.Contexts[0].Context["namespace"])
+
+	RemoveLoginConfig()
+	_, err = os.Stat(kubeconfig)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLoginWithTokenToAnotherServer(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc}
+	os.Setenv(LoginTokenEnv, "sha256~secret")
+	defer os.Unsetenv(LoginTokenEnv)
+	os.Setenv(LoginServerEnv, "https://api.other.example.com:6443")
+	defer os.Unsetenv(LoginServerEnv)
+	previous, hadPrevious := os.LookupEnv("KUBECONFIG")
+
+	configCmd := &mocks.ExecCmd{}
+	configCmd.On("CombinedOutput").Return([]byte(`{
+		"clusters": [{"name": "api", "cluster": {"server": "https://api.example.com:6443", "certificate-authority-data": "Y2E="}}]
+	}`), nil)
+	oc.Execer.On("Oc", []string{"config", "view", "--minify", "--flatten", "--raw", "-o", "json"}).Return(configCmd)
+	var data []byte
+	whoamiCmd := &mocks.ExecCmd{}
+	whoamiCmd.On("CombinedOutput").Return([]byte("error: Unauthorized"), errors.New("exit status 1")).Run(func(mock.Arguments) {
+		data, _ = ioutil.ReadFile(os.Getenv("KUBECONFIG"))
+	})
+	oc.Execer.On("Oc", []string{"whoami"}).Return(whoamiCmd)
+
+	err := app.login()
+	assert.NotNil(t, err)
+	assert.Contains(t, string(data), `"cluster":{"server":"https://api.other.example.com:6443"}`)
+	// A failed login leaves KUBECONFIG as it was
+	current, hasCurrent := os.LookupEnv("KUBECONFIG")
+	assert.Equal(t, hadPrevious, hasCurrent)
+	assert.Equal(t, previous, current)
+}
+
+func TestCommandsReturnLoginErrors(t *testing.T) {
+	oc := new(mocks.Oc)
+	app := Application{oc: oc, Name: "foo"}
+	os.Unsetenv(LoginTokenEnv)
+	NonInteractive = true
+	defer func() { NonInteractive = false }()
+
+	assert.NotNil(t, app.Scale(2))
+	_, err := app.Diff(PushOptions{})
+	assert.NotNil(t, err)
+	oc.AssertNotCalled(t, "Exists")
+}
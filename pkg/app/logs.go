@@ -0,0 +1,167 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+const (
+	// LogSourceBuild is the logs of the application's latest build,
+	// shown as Cloud Foundry's STG logs
+	LogSourceBuild string = "build"
+	// LogSourceDeploy is the logs of the pod that rolled out its
+	// latest deployment config revision, shown as CELL logs
+	LogSourceDeploy string = "deploy"
+	// LogSourceApp is the logs of its running instances, shown as
+	// APP/PROC/WEB/<index> logs
+	LogSourceApp string = "app"
+)
+
+// LogSources are the sources of an application's logs, in the order a
+// push produces them.
+var LogSources = []string{LogSourceBuild, LogSourceDeploy, LogSourceApp}
+
+// LogLine is one line of an application's logs.
+type LogLine struct {
+	Time time.Time
+	// Type is the Cloud Foundry log type of the line's source, like
+	// "STG" or "APP/PROC/WEB/0"
+	Type string
+	Text string
+}
+
+func (line LogLine) String() string {
+	return fmt.Sprintf("%s [%s] OUT %s", line.Time.Local().Format("2006-01-02T15:04:05.00-0700"), line.Type, line.Text)
+}
+
+// Logs prints the recent logs of the application from sources, some
+// of LogSources, merged into one stream in time order.
+func (app *Application) Logs(sources []string) error {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+	exists, err := app.deploymentExists()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New(fmt.Sprintf("Error: Application %s not found\n", app.Name))
+	}
+	lines, err := app.recentLogs(sources)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+func (app *Application) recentLogs(sources []string) ([]LogLine, error) {
+	var lines []LogLine
+	for _, source := range sources {
+		var sourceLines []LogLine
+		var err error
+		switch source {
+		case LogSourceBuild:
+			sourceLines, err = app.buildLogLines()
+		case LogSourceDeploy:
+			sourceLines, err = app.deployLogLines()
+		case LogSourceApp:
+			sourceLines, err = app.instanceLogLines()
+		default:
+			return nil, errors.New(fmt.Sprintf("Error: Invalid log source %s, must be one of %s\n", source, strings.Join(LogSources, ", ")))
+		}
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, sourceLines...)
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Time.Before(lines[j].Time)
+	})
+	return lines, nil
+}
+
+func (app *Application) buildLogLines() ([]LogLine, error) {
+	if app.kubernetes() || app.IsDocker() {
+		log.Debugf("%s has no builds, skipping build logs", app.Name)
+		return nil, nil
+	}
+	return app.logLines("STG", fmt.Sprint("bc/", app.Name))
+}
+
+func (app *Application) deployLogLines() ([]LogLine, error) {
+	if app.workloadKind() != "dc" {
+		log.Debugf("%s is rolled out without a deployer pod, skipping deploy logs", app.Name)
+		return nil, nil
+	}
+	workload := &types.DeploymentConfig{}
+	err := oc.Get(app.context(), app.oc, "dc", app.Name, workload)
+	if err != nil {
+		return nil, err
+	}
+	deployer := fmt.Sprintf("pod/%s-%d-deploy", app.Name, workload.Status.LatestVersion)
+	return app.logLines("CELL", deployer)
+}
+
+func (app *Application) instanceLogLines() ([]LogLine, error) {
+	pods, err := app.runningPods()
+	if err != nil {
+		return nil, err
+	}
+	var lines []LogLine
+	for i, pod := range pods {
+		podLines, err := app.logLines(fmt.Sprint("APP/PROC/WEB/", i), fmt.Sprint("pod/", pod), "-c", app.Name)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, podLines...)
+	}
+	return lines, nil
+}
+
+// logLines returns the logs of target, skipping it if it's gone, like
+// deployer pods that have been pruned.
+func (app *Application) logLines(logType string, target string, args ...string) ([]LogLine, error) {
+	args = append([]string{"logs", target, "--timestamps"}, args...)
+	output, err := app.oc.Exec(args...).CombinedOutput(app.context())
+	if err != nil && strings.Contains(string(output), "not found") {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error getting the logs of %s: %s\n", target, output))
+	}
+	return parseLogLines(logType, string(output)), nil
+}
+
+// parseLogLines splits the output of 'logs --timestamps' into lines.
+// Lines without a timestamp get the one before them so they stay in
+// place when merged.
+func parseLogLines(logType string, output string) []LogLine {
+	var lines []LogLine
+	var last time.Time
+	for _, text := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		if text == "" {
+			continue
+		}
+		split := strings.SplitN(text, " ", 2)
+		if stamp, err := time.Parse(time.RFC3339Nano, split[0]); err == nil {
+			last = stamp
+			text = ""
+			if len(split) == 2 {
+				text = split[1]
+			}
+		}
+		lines = append(lines, LogLine{Time: last, Type: logType, Text: text})
+	}
+	return lines
+}
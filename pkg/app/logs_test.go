@@ -0,0 +1,51 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestParseLogLines(t *testing.T) {
+	lines := parseLogLines("STG", "2024-05-01T10:00:00.5Z ---> Installing\n  continued\n2024-05-01T10:00:01Z done\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "---> Installing", lines[0].Text)
+	assert.Equal(t, "  continued", lines[1].Text)
+	assert.Equal(t, lines[0].Time, lines[1].Time)
+	assert.Equal(t, "STG", lines[2].Type)
+}
+
+func TestRecentLogsMergesSources(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", kind: "dc"}
+	buildCmd := &mocks.ExecCmd{}
+	buildCmd.On("CombinedOutput").Return([]byte("2024-05-01T10:00:00Z building\n2024-05-01T10:00:02Z pushed\n"), nil)
+	oc.Execer.On("Oc", []string{"logs", "bc/foo", "--timestamps"}).Return(buildCmd)
+	dcCmd := &mocks.ExecCmd{}
+	dcCmd.On("CombinedOutput").Return([]byte(`{"status":{"latestVersion":3}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "dc", "foo", "-o", "json"}).Return(dcCmd)
+	deployerCmd := &mocks.ExecCmd{}
+	deployerCmd.On("CombinedOutput").Return([]byte(`Error from server (NotFound): pods "foo-3-deploy" not found`), errors.New("exit status 1"))
+	oc.Execer.On("Oc", []string{"logs", "pod/foo-3-deploy", "--timestamps"}).Return(deployerCmd)
+	podsCmd := &mocks.ExecCmd{}
+	podsCmd.On("CombinedOutput").Return([]byte("pod/foo-3-abcde\n"), nil)
+	oc.Execer.On("Oc", []string{"get", "pods", "--selector=deploymentconfig=foo",
+		"--field-selector=status.phase=Running", "-o", "name"}).Return(podsCmd)
+	podCmd := &mocks.ExecCmd{}
+	podCmd.On("CombinedOutput").Return([]byte("2024-05-01T10:00:01Z started\n"), nil)
+	oc.Execer.On("Oc", []string{"logs", "pod/foo-3-abcde", "--timestamps", "-c", "foo"}).Return(podCmd)
+
+	lines, err := app.recentLogs(LogSources)
+	assert.Nil(t, err)
+	var texts []string
+	for _, line := range lines {
+		texts = append(texts, line.Type+" "+line.Text)
+	}
+	assert.Equal(t, []string{"STG building", "APP/PROC/WEB/0 started", "STG pushed"}, texts)
+
+	_, err = app.recentLogs([]string{"router"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,276 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+const (
+	// ServiceLabel labels the objects of a service created by
+	// CreateService with the service's name
+	ServiceLabel string = "ocf/service"
+	// ServiceOfferingLabel labels them with the offering they were
+	// created from, such as postgresql
+	ServiceOfferingLabel string = "ocf/service-offering"
+	// ServicePlanLabel labels them with the offering's plan
+	ServicePlanLabel string = "ocf/service-plan"
+)
+
+// TemplateNamespace is the project whose templates make up the
+// marketplace.
+const TemplateNamespace = "openshift"
+
+// templatePlans maps the suffixes of template names to the plans they
+// provide, so postgresql-persistent is the default plan of the
+// postgresql offering. Templates without one of these suffixes are
+// offerings with only a default plan.
+var templatePlans = map[string]string{
+	"-persistent": "default",
+	"-ephemeral":  "ephemeral",
+}
+
+// Offering is a kind of service in the marketplace.
+type Offering struct {
+	Name        string
+	Description string
+	Plans       []ServicePlan
+}
+
+// ServicePlan is one way to create an offering's service, by
+// instantiating Template.
+type ServicePlan struct {
+	Name     string
+	Template types.Template
+}
+
+// Marketplace returns the services that can be created with
+// CreateService, like 'cf marketplace'.
+func Marketplace(ctx context.Context) ([]Offering, error) {
+	app := &Application{ctx: ctx}
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return nil, err
+	}
+	app.displayProject()
+	return marketplace(ctx, app.oc)
+}
+
+// CreateService creates the service name from an offering's plan,
+// like 'cf create-service'. Its credentials are generated and it's
+// labeled so bind-service finds them.
+func CreateService(ctx context.Context, offering string, plan string, name string) error {
+	app := &Application{ctx: ctx}
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+	return createService(ctx, app.oc, offering, plan, name)
+}
+
+func marketplace(ctx context.Context, o oc.Oc) ([]Offering, error) {
+	if o.Platform() == oc.PlatformKubernetes {
+		return nil, errors.New("Error: The marketplace needs OpenShift templates, so isn't supported on Kubernetes")
+	}
+	output, err := o.Exec("get", "templates", "--namespace", TemplateNamespace, "-o", "json").CombinedOutput(ctx)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error listing templates: %s\n", output))
+	}
+	list := &types.TemplateList{}
+	err = json.Unmarshal(output, list)
+	if err != nil {
+		return nil, err
+	}
+
+	var offerings []Offering
+	byName := make(map[string]int)
+	for _, template := range list.Items {
+		if isQuickstart(template) {
+			// Quickstarts are example applications, not services
+			continue
+		}
+		name, planName := templateOffering(template.Metadata.Name)
+		i, ok := byName[name]
+		if !ok {
+			i = len(offerings)
+			byName[name] = i
+			offerings = append(offerings, Offering{Name: name, Description: templateDescription(template)})
+		}
+		offerings[i].Plans = append(offerings[i].Plans, ServicePlan{Name: planName, Template: template})
+	}
+	sort.Slice(offerings, func(i, j int) bool { return offerings[i].Name < offerings[j].Name })
+	return offerings, nil
+}
+
+func templateOffering(templateName string) (string, string) {
+	for suffix, plan := range templatePlans {
+		if strings.HasSuffix(templateName, suffix) {
+			return strings.TrimSuffix(templateName, suffix), plan
+		}
+	}
+	return templateName, "default"
+}
+
+func isQuickstart(template types.Template) bool {
+	for _, tag := range strings.Split(template.Metadata.Annotations["tags"], ",") {
+		if strings.TrimSpace(tag) == "quickstart" {
+			return true
+		}
+	}
+	return false
+}
+
+func templateDescription(template types.Template) string {
+	if name := template.Metadata.Annotations["openshift.io/display-name"]; name != "" {
+		return name
+	}
+	return template.Metadata.Name
+}
+
+// templateParameters returns the values of the template's parameters
+// that name the service or hold its credentials.
+func templateParameters(template types.Template, name string) (map[string]string, error) {
+	params := make(map[string]string)
+	for _, param := range template.Parameters {
+		switch {
+		case param.Name == "DATABASE_SERVICE_NAME" || param.Name == "SERVICE_NAME" || param.Name == "NAME":
+			params[param.Name] = name
+		case strings.HasSuffix(param.Name, "_USER"):
+			suffix, err := generatePassword()
+			if err != nil {
+				return nil, err
+			}
+			params[param.Name] = fmt.Sprint("user", suffix[:6])
+		case strings.HasSuffix(param.Name, "_PASSWORD"):
+			password, err := generatePassword()
+			if err != nil {
+				return nil, err
+			}
+			params[param.Name] = password
+		}
+	}
+	return params, nil
+}
+
+func createService(ctx context.Context, o oc.Oc, offering string, plan string, name string) error {
+	app := &Application{oc: o, ctx: ctx}
+	if _, err := app.serviceKind(name); err == nil {
+		return errors.New(fmt.Sprintf("Error: Service %s already exists\n", name))
+	}
+
+	offerings, err := marketplace(ctx, o)
+	if err != nil {
+		return err
+	}
+	var template *types.Template
+	for _, current := range offerings {
+		if current.Name != offering {
+			continue
+		}
+		// Index the plans, since the address of a range variable is
+		// the same on every iteration
+		for i := range current.Plans {
+			if current.Plans[i].Name == plan {
+				template = &current.Plans[i].Template
+			}
+		}
+		if template == nil {
+			return errors.New(fmt.Sprintf("Error: Plan %s of service offering %s not found. Run 'ocf marketplace' to list them\n", plan, offering))
+		}
+	}
+	if template == nil {
+		return errors.New(fmt.Sprintf("Error: Service offering %s not found. Run 'ocf marketplace' to list them\n", offering))
+	}
+
+	manifest, err := processTemplate(ctx, o, *template, name, map[string]string{
+		ServiceLabel:         name,
+		ServiceOfferingLabel: offering,
+		ServicePlanLabel:     plan,
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("Creating service %s from template %s", name, template.Metadata.Name)
+	return o.Apply(ctx, manifest)
+}
+
+// processTemplate returns the objects the template creates for the
+// service name, labeled with labels.
+func processTemplate(ctx context.Context, o oc.Oc, template types.Template, name string, labels map[string]string) ([]byte, error) {
+	templateName := template.Metadata.Name
+	output, err := o.Exec("get", "template", templateName, "--namespace", TemplateNamespace, "-o", "json").CombinedOutput(ctx)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error getting template %s: %s\n", templateName, output))
+	}
+	file, err := ioutil.TempFile("", "ocf-template")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(output)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := templateParameters(template, name)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"process", "-f", file.Name(), "--local", "-o", "json",
+		fmt.Sprint("--labels=", strings.Join(sortedPairs(labels), ","))}
+	for _, param := range sortedPairs(params) {
+		args = append(args, "-p", param)
+	}
+	// Don't echo the command since it contains the credentials
+	output, err = o.Exec(args...).CombinedOutput(ctx)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error processing template %s: %s\n", templateName, output))
+	}
+	return output, nil
+}
+
+// sortedPairs returns the entries of values as KEY=VALUE, sorted.
+func sortedPairs(values map[string]string) []string {
+	var pairs []string
+	for key, value := range values {
+		pairs = append(pairs, fmt.Sprint(key, "=", value))
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// brokeredCredentials fills in the credentials of a service created
+// by CreateService, which its deployment config references from the
+// service's secret instead of setting directly.
+func (app *Application) brokeredCredentials(service string, envPrefix string, env map[string]string) {
+	secret := &types.Data{}
+	if oc.Get(app.context(), app.oc, "secret", service, secret) != nil || secret.Metadata.Labels[ServiceLabel] != service {
+		return
+	}
+	names := map[string]string{
+		"database-user":     "_USER",
+		"database-password": "_PASSWORD",
+		"database-name":     "_DATABASE",
+	}
+	for key, suffix := range names {
+		if value, ok := secret.Data[key]; ok {
+			decoded, err := base64.StdEncoding.DecodeString(value)
+			if err == nil {
+				env[fmt.Sprint(envPrefix, suffix)] = string(decoded)
+			}
+		}
+	}
+	env[fmt.Sprint(envPrefix, "_LABEL")] = secret.Metadata.Labels[ServiceOfferingLabel]
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+const (
+	// DefaultNetworkPolicyPort is the port network policies allow
+	// by default, the one applications listen on
+	DefaultNetworkPolicyPort = "8080"
+	// networkPolicySourceLabel and networkPolicyDestinationLabel name
+	// the applications a network policy allows traffic between
+	networkPolicySourceLabel      = "ocf/source-app"
+	networkPolicyDestinationLabel = "ocf/destination-app"
+	// routerNamespaceLabel labels the namespaces of OpenShift's
+	// routers, so a destination's routes keep working
+	routerNamespaceLabel = "policy-group.network.openshift.io/ingress"
+)
+
+// AddNetworkPolicy allows the application's instances to reach those
+// of destination on port, a port or range like "8080-8090", with
+// protocol tcp or udp, like 'cf add-network-policy'.
+func (app *Application) AddNetworkPolicy(destination string, protocol string, port string) error {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+
+	destinationApp := &Application{Name: destination, oc: app.oc}
+	for _, each := range []*Application{app, destinationApp} {
+		exists, err := each.deploymentExists()
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return errors.New(fmt.Sprintf("Error: Application %s not found\n", each.Name))
+		}
+	}
+	manifest, err := app.networkPolicy(destinationApp, protocol, port)
+	if err != nil {
+		return err
+	}
+	log.Infof("Allowing traffic from %s to %s on %s port %s", app.Name, destination, protocol, port)
+	err = app.oc.Apply(app.context(), manifest)
+	if err != nil {
+		return err
+	}
+	app.ownObject("networkpolicy", app.networkPolicyName(destination))
+	return nil
+}
+
+// networkPolicyName names the network policy allowing the
+// application's instances to reach destination.
+func (app *Application) networkPolicyName(destination string) string {
+	return fmt.Sprint(app.Name, "-to-", destination)
+}
+
+// networkPolicy returns the network policy allowing ingress to the
+// destination's pods from the application's. Once a pod is selected by
+// any ingress policy, only what those policies allow can reach it, so
+// OpenShift's routers are allowed too.
+func (app *Application) networkPolicy(destination *Application, protocol string, port string) ([]byte, error) {
+	protocol = strings.ToUpper(protocol)
+	if protocol != "TCP" && protocol != "UDP" {
+		return nil, errors.New(fmt.Sprintf("Error: Invalid protocol %s, must be tcp or udp", strings.ToLower(protocol)))
+	}
+	policyPort, ok := policyPort(protocol, port)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("Error: Invalid port %s, must be a port or range of ports like 8080-8090", port))
+	}
+	ingress := []interface{}{map[string]interface{}{
+		"from":  []interface{}{map[string]interface{}{"podSelector": map[string]interface{}{"matchLabels": app.podLabels()}}},
+		"ports": []interface{}{policyPort},
+	}}
+	if !app.kubernetes() {
+		ingress = append(ingress, map[string]interface{}{
+			"from": []interface{}{map[string]interface{}{
+				"namespaceSelector": map[string]interface{}{"matchLabels": map[string]string{routerNamespaceLabel: ""}},
+			}},
+		})
+	}
+	return json.Marshal(map[string]interface{}{
+		"apiVersion": "networking.k8s.io/v1",
+		"kind":       "NetworkPolicy",
+		"metadata": map[string]interface{}{
+			"name": app.networkPolicyName(destination.Name),
+			"labels": map[string]string{
+				networkPolicySourceLabel:      app.Name,
+				networkPolicyDestinationLabel: destination.Name,
+			},
+		},
+		"spec": map[string]interface{}{
+			"podSelector": map[string]interface{}{"matchLabels": destination.podLabels()},
+			"policyTypes": []string{"Ingress"},
+			"ingress":     ingress,
+		},
+	})
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestAddNetworkPolicy(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.On("Exists", "dc", "frontend").Return(true, nil)
+	oc.On("Exists", "dc", "backend").Return(true, nil)
+	var applied map[string]interface{}
+	oc.On("Apply", mock.Anything).Run(func(args mock.Arguments) {
+		json.Unmarshal(args.Get(0).([]byte), &applied)
+	}).Return(nil)
+
+	app := Application{oc: oc, Name: "frontend"}
+	assert.Nil(t, app.AddNetworkPolicy("backend", "tcp", "8080"))
+	assert.Equal(t, "frontend-to-backend", applied["metadata"].(map[string]interface{})["name"])
+	spec := applied["spec"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"matchLabels": map[string]interface{}{"deploymentconfig": "backend"}}, spec["podSelector"])
+	ingress := spec["ingress"].([]interface{})
+	assert.Equal(t, 2, len(ingress))
+	assert.Equal(t, map[string]interface{}{
+		"from":  []interface{}{map[string]interface{}{"podSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"deploymentconfig": "frontend"}}}},
+		"ports": []interface{}{map[string]interface{}{"protocol": "TCP", "port": float64(8080)}},
+	}, ingress[0])
+	assert.Equal(t, []string{"networkpolicy/frontend-to-backend"}, oc.OwnedObjects["frontend"])
+}
+
+func TestNetworkPolicyOnKubernetes(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.PlatformName = "k8s"
+	app := Application{oc: oc, Name: "frontend"}
+
+	manifest, err := app.networkPolicy(&Application{oc: oc, Name: "backend"}, "udp", "9000-9010")
+	assert.Nil(t, err)
+	var policy map[string]interface{}
+	assert.Nil(t, json.Unmarshal(manifest, &policy))
+	ingress := policy["spec"].(map[string]interface{})["ingress"].([]interface{})
+	assert.Equal(t, 1, len(ingress))
+	assert.Equal(t, []interface{}{map[string]interface{}{"protocol": "UDP", "port": float64(9000), "endPort": float64(9010)}},
+		ingress[0].(map[string]interface{})["ports"])
+
+	_, err = app.networkPolicy(&Application{oc: oc, Name: "backend"}, "icmp", "8080")
+	assert.NotNil(t, err)
+	_, err = app.networkPolicy(&Application{oc: oc, Name: "backend"}, "tcp", "http")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+)
+
+// own records that the application owns the objects of kinds named
+// after it, which the push just created.
+func (app *Application) own(kinds ...string) {
+	for _, kind := range kinds {
+		app.ownObject(kind, app.Name)
+	}
+}
+
+// ownObject records that the application owns an object. Failing to
+// record it only loses track of the object, so it's not fatal.
+func (app *Application) ownObject(objType string, name string) {
+	err := app.oc.Own(app.context(), app.Name, objType, name)
+	if err != nil {
+		log.Warnf("Couldn't record that %s owns %s %s: %v", app.Name, objType, name, err)
+	}
+}
+
+// adopt records that the application owns its workload when it was
+// pushed before ocf recorded what it created, so it's still listed as
+// an application.
+func (app *Application) adopt() {
+	owned, err := app.oc.Owned(app.context(), app.Name)
+	if err != nil || len(owned) > 0 {
+		return
+	}
+	app.own(app.workloadKind())
+}
+
+func (app *Application) disown(objType string, name string) {
+	err := app.oc.Disown(app.context(), app.Name, objType, name)
+	if err != nil {
+		log.Warnf("Couldn't record that %s no longer owns %s %s: %v", app.Name, objType, name, err)
+	}
+}
+
+// Delete deletes every object recorded as belonging to the
+// application, leaving same-named objects it didn't create alone.
+func (app *Application) Delete() error {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+	objects, err := app.oc.Owned(app.context(), app.Name)
+	if err != nil {
+		return err
+	}
+	if len(objects) == 0 {
+		return errors.New(fmt.Sprintf("Error: No objects are recorded as belonging to %s. Applications pushed before ocf recorded what it created have to be deleted with 'oc delete'\n", app.Name))
+	}
+	for i := len(objects) - 1; i >= 0; i-- {
+		split := strings.SplitN(objects[i], "/", 2)
+		if len(split) != 2 {
+			continue
+		}
+		log.Infof("Deleting %s %s", split[0], split[1])
+		err = app.oc.Delete(app.context(), split[0], split[1])
+		if err != nil && !strings.Contains(err.Error(), "not found") {
+			return err
+		}
+		app.disown(split[0], split[1])
+	}
+	return nil
+}
+
+// ownedWorkloads returns the workload of each application with an
+// ownership record, by application name.
+func ownedWorkloads(ctx context.Context, o oc.Oc) (map[string]string, error) {
+	apps, err := o.OwnedApps(ctx)
+	if err != nil {
+		return nil, err
+	}
+	workloads := make(map[string]string)
+	for _, name := range apps {
+		objects, err := o.Owned(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, kind := range workloadKinds(o) {
+			for _, object := range objects {
+				if object == fmt.Sprint(kind, "/", name) {
+					workloads[name] = kind
+				}
+			}
+		}
+	}
+	return workloads, nil
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestDeleteOwnedObjects(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.OwnedObjects = map[string][]string{"foo": {"bc/foo", "is/foo", "dc/foo", "deployment/foo-canary"}}
+	oc.On("Delete", "deployment", "foo-canary").Return(nil).Once()
+	oc.On("Delete", "dc", "foo").Return(nil).Once()
+	oc.On("Delete", "is", "foo").Return(nil).Once()
+	oc.On("Delete", "bc", "foo").Return(nil).Once()
+
+	app := Application{oc: oc, Name: "foo"}
+	assert.Nil(t, app.Delete())
+	oc.AssertExpectations(t)
+	assert.Empty(t, oc.OwnedObjects["foo"])
+}
+
+func TestDeleteWithoutOwnedObjects(t *testing.T) {
+	app := Application{oc: mocks.NewMockOc(), Name: "foo"}
+	assert.NotNil(t, app.Delete())
+}
+
+func TestListOwnedApplications(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.OwnedObjects = map[string][]string{"foo": {"dc/foo", "svc/foo"}}
+	// bar was created by someone else
+	oc.On("List", "dc", "").Return([]string{"foo", "bar"}, nil)
+	oc.On("List", "deployment", "").Return([]string{"foo"}, nil)
+	oc.On("Exists", "route", "foo").Return(false, nil)
+	instancesCmd := &mocks.ExecCmd{}
+	instancesCmd.On("CombinedOutput").Return([]byte(`{"spec": {"replicas": 1}, "status": {"readyReplicas": 1}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "dc", "foo", "-o", "json"}).Return(instancesCmd)
+
+	summaries, err := listApplications(context.Background(), oc)
+	assert.Nil(t, err)
+	assert.Equal(t, []AppSummary{{Name: "foo", Kind: "dc", Instances: "1/1"}}, summaries)
+}
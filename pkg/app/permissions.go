@@ -0,0 +1,100 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// requiredPermissions returns the resources the push creates, with a
+// subresource after a slash.
+func (app *Application) requiredPermissions() []string {
+	// Push locks and revisions are config maps
+	resources := []string{"configmaps"}
+	if app.options.GitOpsOnly {
+		return resources
+	}
+	if len(app.Services) > 0 || (app.IsDocker() && app.Docker.Username != "") {
+		resources = append(resources, "secrets")
+	}
+	if !app.IsDocker() && !app.kubernetes() {
+		resources = append(resources, "buildconfigs", "buildconfigs/instantiatebinary", "imagestreams")
+	}
+	if app.knative() {
+		return append(resources, "services.serving.knative.dev")
+	}
+	if app.workloadKind() == "dc" {
+		resources = append(resources, "deploymentconfigs.apps.openshift.io")
+	} else {
+		resources = append(resources, "deployments.apps")
+	}
+	resources = append(resources, "services")
+	if app.tcp() {
+		return resources
+	}
+	if app.kubernetes() {
+		return append(resources, "ingresses.networking.k8s.io")
+	}
+	return append(resources, "routes.route.openshift.io")
+}
+
+// checkPermissions asks the cluster whether the user can create
+// everything the push needs, reporting every missing permission at
+// once instead of failing part way through the push.
+func (app *Application) checkPermissions() error {
+	var mutex sync.Mutex
+	var missing []string
+	var tasks []func() error
+	for _, resource := range app.requiredPermissions() {
+		resource := resource
+		tasks = append(tasks, func() error {
+			allowed, err := app.canCreate(resource)
+			if err != nil {
+				return err
+			}
+			if !allowed {
+				mutex.Lock()
+				missing = append(missing, resource)
+				mutex.Unlock()
+			}
+			return nil
+		})
+	}
+	err := exec.Parallel(exec.DefaultParallelism, tasks...)
+	if err != nil {
+		// Older clusters can't always answer, so the push finds out
+		// the hard way
+		log.Debugf("Skipping the permission check: %v", err)
+		return nil
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	project, _ := app.oc.Project(app.context())
+	return errors.New(fmt.Sprintf("Error: You can't push %s to project %s without permission to create:\n  %s\nAsk a project admin for the edit role\n",
+		app.Name, project, strings.Join(missing, "\n  ")))
+}
+
+func (app *Application) canCreate(resource string) (bool, error) {
+	args := []string{"auth", "can-i", "create"}
+	parts := strings.SplitN(resource, "/", 2)
+	args = append(args, parts[0])
+	if len(parts) == 2 {
+		args = append(args, fmt.Sprint("--subresource=", parts[1]))
+	}
+	output, err := app.oc.Exec(args...).CombinedOutput(app.context())
+	answer := strings.TrimSpace(string(output))
+	switch {
+	case err == nil && answer == "yes":
+		return true, nil
+	case strings.HasPrefix(answer, "no"):
+		return false, nil
+	}
+	return false, errors.New(fmt.Sprintf("Error checking permission to create %s: %s\n", resource, output))
+}
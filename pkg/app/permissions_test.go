@@ -0,0 +1,39 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestRequiredPermissions(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", Services: []string{"db"}}
+	assert.Equal(t, []string{"configmaps", "secrets", "buildconfigs", "buildconfigs/instantiatebinary", "imagestreams",
+		"deploymentconfigs.apps.openshift.io", "services", "routes.route.openshift.io"}, app.requiredPermissions())
+
+	oc.PlatformName = "k8s"
+	app = Application{oc: oc, Name: "foo", Docker: &Docker{Image: "nginx"}}
+	assert.Equal(t, []string{"configmaps", "deployments.apps", "services", "ingresses.networking.k8s.io"}, app.requiredPermissions())
+}
+
+func TestCheckPermissionsReportsEveryMissingPermission(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", options: PushOptions{RouteType: RouteTypeTCP}, Docker: &Docker{Image: "nginx"}}
+
+	yesCmd := &mocks.ExecCmd{}
+	yesCmd.On("CombinedOutput").Return([]byte("yes\n"), nil)
+	noCmd := &mocks.ExecCmd{}
+	noCmd.On("CombinedOutput").Return([]byte("no\n"), errors.New("exit status 1"))
+	oc.Execer.On("Oc", []string{"auth", "can-i", "create", "configmaps"}).Return(yesCmd)
+	oc.Execer.On("Oc", mock.Anything).Return(noCmd)
+
+	err := app.checkPermissions()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "\n  deploymentconfigs.apps.openshift.io\n  services\n")
+	assert.NotContains(t, err.Error(), "configmaps")
+}
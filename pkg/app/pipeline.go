@@ -0,0 +1,373 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// Step is one stage of a push, such as building the application or
+// creating its route.
+type Step interface {
+	Name() string
+	Run(ctx context.Context, state *PushState) error
+}
+
+// PushState is shared by the steps of a push.
+type PushState struct {
+	App *Application
+	// Image is the builder image, or the base image of a droplet
+	Image string
+	// batched is set once a new application has been created in one
+	// apply, which also creates its deployment, service, and route
+	batched bool
+	// release releases the push lock once it's acquired
+	release func()
+	// failedStep names the step the push failed at
+	failedStep string
+}
+
+// StepError is returned by Push when one of its steps fails.
+type StepError struct {
+	Step string
+	Err  error
+}
+
+func (err *StepError) Error() string {
+	return err.Err.Error()
+}
+
+// finish releases the push lock, after deleting what the push created
+// if it was cancelled.
+func (state *PushState) finish() {
+	if state.release == nil {
+		return
+	}
+	state.App.cleanupIfCancelled()
+	state.release()
+	state.release = nil
+}
+
+type step struct {
+	name string
+	run  func(ctx context.Context, state *PushState) error
+}
+
+func (s *step) Name() string {
+	return s.name
+}
+
+func (s *step) Run(ctx context.Context, state *PushState) error {
+	return s.run(ctx, state)
+}
+
+// NewStep returns a Step that calls run.
+func NewStep(name string, run func(ctx context.Context, state *PushState) error) Step {
+	return &step{name: name, run: run}
+}
+
+// appStep returns a Step that calls one of the application's methods.
+func appStep(name string, run func(app *Application) error) Step {
+	return NewStep(name, func(ctx context.Context, state *PushState) error {
+		return run(state.App)
+	})
+}
+
+// unlessBatched returns a Step that calls run unless a batched apply
+// already did its work.
+func unlessBatched(name string, run func(app *Application) error) Step {
+	return NewStep(name, func(ctx context.Context, state *PushState) error {
+		if state.batched {
+			log.Debugf("Step %s was done by the batched apply", name)
+			return nil
+		}
+		return run(state.App)
+	})
+}
+
+// Pipeline runs steps in order, stopping at the first that fails.
+type Pipeline struct {
+	Steps []Step
+	// Skip names steps not to run
+	Skip []string
+	// DryRun lists the steps that would run without running them
+	DryRun bool
+	// Events, if set, gets an event as each step starts and ends
+	Events *EventStream
+}
+
+// Run runs the pipeline's steps, returning the error of the first
+// that fails or ctx's error if it's cancelled between steps.
+func (pipeline *Pipeline) Run(ctx context.Context, state *PushState) error {
+	skip := make(map[string]bool)
+	for _, name := range pipeline.Skip {
+		skip[name] = true
+	}
+	for _, step := range pipeline.Steps {
+		if skip[step.Name()] {
+			log.Infof("Skipping step %s", step.Name())
+			pipeline.skipped(state, step)
+			continue
+		}
+		if pipeline.DryRun {
+			log.Infof("Would run step %s", step.Name())
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		log.Debugf("Running step %s", step.Name())
+		err := pipeline.runStep(ctx, state, step)
+		if err != nil {
+			state.failedStep = step.Name()
+			return err
+		}
+	}
+	return nil
+}
+
+func (pipeline *Pipeline) runStep(ctx context.Context, state *PushState, step Step) error {
+	if pipeline.Events == nil {
+		return step.Run(ctx, state)
+	}
+	started := time.Now()
+	pipeline.Events.startPhase(state.App.Name, step.Name())
+	err := step.Run(ctx, state)
+	status := "ok"
+	if err != nil {
+		status = "failed"
+	}
+	pipeline.Events.endPhase(status, started, err)
+	return err
+}
+
+func (pipeline *Pipeline) skipped(state *PushState, step Step) {
+	if pipeline.Events == nil {
+		return
+	}
+	pipeline.Events.startPhase(state.App.Name, step.Name())
+	pipeline.Events.endPhase("skipped", time.Time{}, nil)
+}
+
+// How a push builds, runs, and exposes the application, which decides
+// its steps.
+const (
+	buildDocker  = "docker"
+	buildDroplet = "droplet"
+	buildLocal   = "local"
+	buildTag     = "tag"
+	buildGit     = "git"
+	buildBinary  = "binary"
+
+	deployGitOps  = "gitops"
+	deployKnative = "knative"
+	deployCanary  = "canary"
+	deployDefault = "deployment"
+
+	exposeTCP      = "tcp"
+	exposeInternal = "internal"
+	exposeRoutes   = "routes"
+	exposeIngress  = "ingress"
+	exposeRoute    = "route"
+)
+
+// buildFlow returns how the push builds the application's image.
+func (app *Application) buildFlow() string {
+	switch {
+	case app.IsDocker():
+		return buildDocker
+	case app.options.Droplet != "":
+		return buildDroplet
+	case app.kubernetes():
+		return buildLocal
+	case app.options.Tag != "":
+		return buildTag
+	case app.options.Git != "":
+		return buildGit
+	}
+	return buildBinary
+}
+
+// deployFlow returns what runs the built application.
+func (app *Application) deployFlow() string {
+	switch {
+	case app.options.GitOpsDir != "":
+		return deployGitOps
+	case app.knative():
+		return deployKnative
+	case app.canary():
+		return deployCanary
+	}
+	return deployDefault
+}
+
+// exposeFlow returns how the deploy steps expose the application.
+func (app *Application) exposeFlow() string {
+	switch {
+	case app.tcp():
+		return exposeTCP
+	case app.internal():
+		return exposeInternal
+	case len(app.Routes) > 0:
+		return exposeRoutes
+	case app.kubernetes():
+		return exposeIngress
+	}
+	return exposeRoute
+}
+
+// pushSteps returns the steps that push the application, which depend
+// on how it's built and exposed.
+func (app *Application) pushSteps() []Step {
+	steps := []Step{
+		appStep("login", (*Application).checkLoggedIn),
+		// TODO: help user select the correct project instead of
+		// just assuming they've already done that
+		appStep("project", (*Application).displayProject),
+		appStep("permissions", (*Application).checkPermissions),
+		appStep("domains", (*Application).checkDomains),
+		appStep("default-memory", (*Application).defaultMemory),
+		appStep("quota", (*Application).checkQuota),
+	}
+	if len(app.Sidecars) > 0 {
+		steps = append(steps, appStep("check-sidecars", (*Application).checkSidecars))
+	}
+	steps = append(steps,
+		appStep("diff", (*Application).displayChanges),
+		NewStep("lock", func(ctx context.Context, state *PushState) error {
+			release, err := state.App.acquirePushLock(state.App.options.LockWait)
+			state.release = release
+			return err
+		}),
+		appStep("pre-push-hook", func(app *Application) error {
+			return app.runHook(HookPrePush)
+		}),
+	)
+	if app.canary() {
+		steps = append(steps, appStep("pin-stable", (*Application).pinStable))
+	}
+
+	switch app.buildFlow() {
+	case buildDocker:
+		steps = append(steps, appStep("pull-secret", (*Application).ensureDockerPullSecret))
+	case buildDroplet:
+		steps = append(steps, NewStep("build", func(ctx context.Context, state *PushState) error {
+			return state.App.buildDroplet(state.Image)
+		}))
+	case buildLocal:
+		steps = append(steps, NewStep("build", func(ctx context.Context, state *PushState) error {
+			return state.App.buildImage(state.Image)
+		}))
+	case buildTag:
+		steps = append(steps, appStep("tag", func(app *Application) error {
+			return app.deployTag(app.options.Tag)
+		}))
+	case buildGit:
+		steps = append(steps, NewStep("build", func(ctx context.Context, state *PushState) error {
+			app := state.App
+			err := app.ensureGitBuildExists(state.Image)
+			if err != nil {
+				return err
+			}
+			err = app.runBuild()
+			if err != nil {
+				return err
+			}
+			return app.tagBuild()
+		}))
+	default:
+		steps = append(steps, NewStep("build", func(ctx context.Context, state *PushState) error {
+			app := state.App
+			if app.batchable() {
+				state.batched = true
+				err := app.applyNewApplication(state.Image)
+				if err != nil {
+					return err
+				}
+			} else {
+				err := app.ensureBuildExists(state.Image)
+				if err != nil {
+					return err
+				}
+			}
+			err := app.startBuild()
+			if err != nil {
+				return err
+			}
+			return app.tagBuild()
+		}))
+	}
+
+	switch app.deployFlow() {
+	case deployGitOps:
+		steps = append(steps, appStep("gitops", (*Application).syncGitOps))
+	case deployKnative:
+		steps = append(steps,
+			appStep("knative-service", (*Application).ensureKnativeService),
+			appStep("knative-url", (*Application).displayKnativeURL))
+	case deployCanary:
+		steps = append(steps, app.canarySteps()...)
+	default:
+		steps = append(steps, app.deploySteps()...)
+	}
+
+	if app.options.SmokeTest != "" {
+		steps = append(steps, NewStep("smoke-test", func(ctx context.Context, state *PushState) error {
+			return state.App.smokeTest(ctx)
+		}))
+	}
+
+	return append(steps, appStep("post-push-hook", func(app *Application) error {
+		return app.runHook(HookPostPush)
+	}))
+}
+
+// deploySteps returns the steps that run and expose the built
+// application.
+func (app *Application) deploySteps() []Step {
+	// The batched apply creates the deployment config but leaves the
+	// rest of its settings to the steps after it
+	steps := []Step{
+		unlessBatched("deployment", (*Application).ensureDeploymentExists),
+		appStep("configure", (*Application).configureDeployment),
+		appStep("guid", (*Application).ensureGUID),
+	}
+	if !app.options.NoBuildMetadata {
+		steps = append(steps, appStep("build-metadata", (*Application).ensureBuildMetadata))
+	}
+	steps = append(steps,
+		appStep("redeploy", (*Application).redeployUpdated),
+		appStep("revision", func(app *Application) error {
+			app.recordRevision()
+			return nil
+		}))
+	if len(app.otherProcesses()) > 0 {
+		steps = append(steps, appStep("processes", (*Application).ensureProcesses))
+	}
+	if app.options.Prune {
+		steps = append(steps, unlessBatched("prune", (*Application).reconcile))
+	}
+	steps = append(steps, unlessBatched("service", (*Application).ensureServiceExists))
+	switch app.exposeFlow() {
+	case exposeTCP:
+		return append(steps, appStep("tcp-endpoint", (*Application).displayTCPEndpoint))
+	case exposeInternal:
+		return append(steps,
+			appStep("prune-routes", (*Application).pruneRoutes),
+			appStep("internal", (*Application).ensureInternal))
+	case exposeRoutes:
+		return append(steps,
+			appStep("prune-routes", (*Application).pruneRoutes),
+			appStep("routes", (*Application).ensureRoutes))
+	case exposeIngress:
+		return append(steps,
+			appStep("prune-routes", (*Application).pruneRoutes),
+			appStep("ingress", (*Application).ensureIngressExists),
+			appStep("show-ingress", (*Application).displayIngress))
+	}
+	return append(steps,
+		unlessBatched("prune-routes", (*Application).pruneRoutes),
+		unlessBatched("route", (*Application).ensureRouteExists),
+		appStep("show-route", (*Application).displayRoute))
+}
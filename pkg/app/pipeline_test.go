@@ -0,0 +1,141 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func recordingSteps(ran *[]string, names ...string) []Step {
+	var steps []Step
+	for _, name := range names {
+		name := name
+		steps = append(steps, NewStep(name, func(ctx context.Context, state *PushState) error {
+			*ran = append(*ran, name)
+			return nil
+		}))
+	}
+	return steps
+}
+
+func TestPipelineRun(t *testing.T) {
+	var ran []string
+	pipeline := &Pipeline{Steps: recordingSteps(&ran, "one", "two", "three"), Skip: []string{"two"}}
+	assert.Nil(t, pipeline.Run(context.Background(), &PushState{}))
+	assert.Equal(t, []string{"one", "three"}, ran)
+
+	ran = nil
+	pipeline.DryRun = true
+	assert.Nil(t, pipeline.Run(context.Background(), &PushState{}))
+	assert.Empty(t, ran)
+}
+
+func TestPipelineStopsAtFailedStep(t *testing.T) {
+	var ran []string
+	failed := errors.New("failed")
+	steps := recordingSteps(&ran, "one")
+	steps = append(steps, NewStep("fail", func(ctx context.Context, state *PushState) error {
+		return failed
+	}))
+	steps = append(steps, recordingSteps(&ran, "after")...)
+
+	pipeline := &Pipeline{Steps: steps}
+	assert.Equal(t, failed, pipeline.Run(context.Background(), &PushState{}))
+	assert.Equal(t, []string{"one"}, ran)
+}
+
+func TestPipelineStopsWhenCancelled(t *testing.T) {
+	var ran []string
+	ctx, cancel := context.WithCancel(context.Background())
+	steps := []Step{NewStep("cancel", func(ctx context.Context, state *PushState) error {
+		cancel()
+		return nil
+	})}
+	steps = append(steps, recordingSteps(&ran, "after")...)
+
+	pipeline := &Pipeline{Steps: steps}
+	assert.Equal(t, context.Canceled, pipeline.Run(ctx, &PushState{}))
+	assert.Empty(t, ran)
+}
+
+func TestUnlessBatched(t *testing.T) {
+	ran := false
+	step := unlessBatched("route", func(app *Application) error {
+		ran = true
+		return nil
+	})
+	assert.Nil(t, step.Run(context.Background(), &PushState{batched: true}))
+	assert.False(t, ran)
+	assert.Nil(t, step.Run(context.Background(), &PushState{}))
+	assert.True(t, ran)
+}
+
+func stepNames(steps []Step) []string {
+	var names []string
+	for _, step := range steps {
+		names = append(names, step.Name())
+	}
+	return names
+}
+
+func concat(parts ...[]string) []string {
+	var all []string
+	for _, part := range parts {
+		all = append(all, part...)
+	}
+	return all
+}
+
+func TestPushSteps(t *testing.T) {
+	common := []string{"login", "project", "permissions", "domains", "default-memory", "quota", "diff", "lock", "pre-push-hook"}
+	deploy := []string{"deployment", "configure", "guid", "build-metadata", "redeploy", "revision"}
+
+	app := Application{oc: mocks.NewMockOc(), Name: "foo"}
+	assert.Equal(t, concat(common, []string{"build"}, deploy, []string{"service",
+		"prune-routes", "route", "show-route", "post-push-hook"}), stepNames(app.pushSteps()))
+
+	app = Application{oc: mocks.NewMockOc(), Name: "foo", Docker: &Docker{Image: "nginx"}}
+	app.options.RouteType = RouteTypeTCP
+	assert.Equal(t, concat(common, []string{"pull-secret"}, deploy, []string{"service",
+		"tcp-endpoint", "post-push-hook"}), stepNames(app.pushSteps()))
+
+	oc := mocks.NewMockOc()
+	oc.PlatformName = "k8s"
+	app = Application{oc: oc, Name: "foo"}
+	app.options.Prune = true
+	assert.Equal(t, concat(common, []string{"build"}, deploy, []string{"prune", "service",
+		"prune-routes", "ingress", "show-ingress", "post-push-hook"}), stepNames(app.pushSteps()))
+
+	app = Application{oc: mocks.NewMockOc(), Name: "foo"}
+	app.options.GitOpsDir = "deploy"
+	assert.Equal(t, concat(common, []string{"build", "gitops", "post-push-hook"}),
+		stepNames(app.pushSteps()))
+
+	app = Application{oc: mocks.NewMockOc(), Name: "foo"}
+	app.options.Tag = "20200102-150405"
+	assert.Equal(t, concat(common, []string{"tag"}, deploy, []string{"service",
+		"prune-routes", "route", "show-route", "post-push-hook"}), stepNames(app.pushSteps()))
+
+	app = Application{oc: mocks.NewMockOc(), Name: "foo"}
+	app.options.Git = "https://github.com/org/repo#main"
+	assert.Equal(t, concat(common, []string{"build"}, deploy, []string{"service",
+		"prune-routes", "route", "show-route", "post-push-hook"}), stepNames(app.pushSteps()))
+
+	app = Application{oc: mocks.NewMockOc(), Name: "foo", Routes: []Route{{Route: "foo.apps.internal"}}}
+	assert.Equal(t, concat(common, []string{"build"}, deploy, []string{"service",
+		"prune-routes", "internal", "post-push-hook"}), stepNames(app.pushSteps()))
+
+	app = Application{oc: mocks.NewMockOc(), Name: "foo"}
+	app.options.NoBuildMetadata = true
+	assert.Equal(t, concat(common, []string{"build", "deployment", "configure", "guid", "redeploy", "revision",
+		"service", "prune-routes", "route", "show-route", "post-push-hook"}), stepNames(app.pushSteps()))
+
+	app = Application{oc: mocks.NewMockOc(), Name: "foo"}
+	app.options.Strategy = StrategyCanary
+	assert.Equal(t, concat(common, []string{"pin-stable", "build", "canary-deployment", "canary-service",
+		"canary-routes", "post-push-hook"}), stepNames(app.pushSteps()))
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// Toleration lets an application's instances run on nodes with a
+// matching taint, as in a pod spec.
+type Toleration struct {
+	Key               string `json:"key,omitempty"`
+	Operator          string `json:"operator,omitempty"`
+	Value             string `json:"value,omitempty"`
+	Effect            string `json:"effect,omitempty"`
+	TolerationSeconds *int64 `json:"tolerationSeconds,omitempty"`
+}
+
+// Placement is the nodes the applications of a stack run on, such as
+// Windows nodes for the windows stack.
+type Placement struct {
+	NodeSelector map[string]string `json:"node-selector,omitempty"`
+	Tolerations  []Toleration      `json:"tolerations,omitempty"`
+}
+
+// Placement returns the placement mapped to app's stack, or nil.
+func (builders *Builders) Placement(app *Application) *Placement {
+	if builders == nil || app.Stack == "" {
+		return nil
+	}
+	placement, ok := builders.Placements[app.Stack]
+	if !ok {
+		return nil
+	}
+	return &placement
+}
+
+// place gives an application without a node selector or tolerations
+// of its own those its stack is mapped to.
+func (app *Application) place(placement *Placement) {
+	if placement == nil {
+		return
+	}
+	if len(app.NodeSelector) == 0 {
+		app.NodeSelector = placement.NodeSelector
+	}
+	if len(app.Tolerations) == 0 {
+		app.Tolerations = placement.Tolerations
+	}
+}
+
+// addPlacementTo adds the application's node selector and tolerations
+// to podSpec.
+func (app *Application) addPlacementTo(podSpec map[string]interface{}) {
+	if len(app.NodeSelector) > 0 {
+		podSpec["nodeSelector"] = app.NodeSelector
+	}
+	if len(app.Tolerations) > 0 {
+		podSpec["tolerations"] = app.Tolerations
+	}
+}
+
+// ensurePlacement sets the node selector and tolerations of the
+// application's existing deployment config or deployment, which only
+// rolls it out again if they changed.
+func (app *Application) ensurePlacement() error {
+	podSpec := make(map[string]interface{})
+	app.addPlacementTo(podSpec)
+	if len(podSpec) == 0 {
+		return nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{"spec": podSpec},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("Placing %s on nodes matching %v", app.Name, app.NodeSelector)
+	output, err := app.oc.Exec("patch", app.workloadKind(), app.Name, "-p", string(patch)).CombinedOutput(app.context())
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error setting the node selector and tolerations of %s: %s\n", app.Name, output))
+	}
+	return nil
+}
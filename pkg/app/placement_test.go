@@ -0,0 +1,65 @@
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestBuildersPlacement(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocf-builders")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config := filepath.Join(dir, "builders.yml")
+	ioutil.WriteFile(config, []byte(`placements:
+  windows2019:
+    node-selector:
+      kubernetes.io/os: windows
+    tolerations:
+    - key: os
+      value: windows
+      effect: NoSchedule
+`), 0644)
+	builders, err := LoadBuilders(config)
+	assert.Nil(t, err)
+
+	app := Application{Name: "foo", Stack: "windows2019"}
+	app.place(builders.Placement(&app))
+	assert.Equal(t, map[string]string{"kubernetes.io/os": "windows"}, app.NodeSelector)
+	assert.Equal(t, []Toleration{{Key: "os", Value: "windows", Effect: "NoSchedule"}}, app.Tolerations)
+
+	// The application's own node selector wins
+	app = Application{Name: "foo", Stack: "windows2019", NodeSelector: map[string]string{"gpu": "true"}}
+	app.place(builders.Placement(&app))
+	assert.Equal(t, map[string]string{"gpu": "true"}, app.NodeSelector)
+	assert.Equal(t, 1, len(app.Tolerations))
+
+	assert.Nil(t, builders.Placement(&Application{Stack: "cflinuxfs4"}))
+	var noBuilders *Builders
+	assert.Nil(t, noBuilders.Placement(&app))
+}
+
+func TestEnsurePlacement(t *testing.T) {
+	oc := mocks.NewMockOc()
+	patchCmd := &mocks.ExecCmd{}
+	patchCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", []string{"patch", "dc", "foo", "-p",
+		`{"spec":{"template":{"spec":{"nodeSelector":{"gpu":"true"},"tolerations":[{"key":"nvidia.com/gpu","operator":"Exists","effect":"NoSchedule"}]}}}}`}).Return(patchCmd)
+
+	app := Application{oc: oc, Name: "foo", kind: "dc",
+		NodeSelector: map[string]string{"gpu": "true"},
+		Tolerations:  []Toleration{{Key: "nvidia.com/gpu", Operator: "Exists", Effect: "NoSchedule"}},
+	}
+	assert.Nil(t, app.ensurePlacement())
+	oc.Execer.AssertExpectations(t)
+
+	// Without a placement nothing is patched
+	app = Application{oc: mocks.NewMockOc(), Name: "foo", kind: "dc"}
+	assert.Nil(t, app.ensurePlacement())
+}
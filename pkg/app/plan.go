@@ -0,0 +1,125 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// Plan is what pushing a manifest would change, written by 'ocf plan'
+// so 'ocf apply' can push exactly that after it's been reviewed.
+type Plan struct {
+	Created string       `json:"created"`
+	Options PushOptions  `json:"options"`
+	Apps    []PlannedApp `json:"apps"`
+}
+
+// PlannedApp is one application of a plan, the project it's pushed
+// to, and what pushing it would change.
+type PlannedApp struct {
+	Application Application `json:"application"`
+	Project     string      `json:"project"`
+	Changes     []Change    `json:"changes"`
+}
+
+// NewPlan compares each of apps with what's running in the cluster.
+func NewPlan(ctx context.Context, apps []Application, options PushOptions) (*Plan, error) {
+	plan := &Plan{
+		Created: time.Now().UTC().Format(time.RFC3339),
+		Options: options,
+	}
+	for _, app := range apps {
+		app.ctx = ctx
+		changes, err := app.Diff(options)
+		if err != nil {
+			return nil, err
+		}
+		project, err := app.oc.Project(ctx)
+		if err != nil {
+			return nil, err
+		}
+		plan.Apps = append(plan.Apps, PlannedApp{
+			Application: app,
+			Project:     strings.TrimSpace(project),
+			Changes:     changes,
+		})
+	}
+	return plan, nil
+}
+
+// Write saves the plan to file as JSON.
+func (plan *Plan) Write(file string) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, append(data, '\n'), 0600)
+}
+
+// ReadPlan reads a plan written by Write.
+func ReadPlan(file string) (*Plan, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{}
+	err = json.Unmarshal(data, plan)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error parsing plan %s: %v\n", file, err))
+	}
+	return plan, nil
+}
+
+// Apply pushes the plan's applications, first checking that they'd
+// still change exactly what the plan says, so nothing that wasn't
+// reviewed is applied.
+func (plan *Plan) Apply(ctx context.Context) error {
+	for i := range plan.Apps {
+		err := plan.Apps[i].check(ctx, plan.Options)
+		if err != nil {
+			return err
+		}
+	}
+	for i := range plan.Apps {
+		app := plan.Apps[i].Application.WithContext(ctx)
+		err := app.Push(plan.Options)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (planned *PlannedApp) check(ctx context.Context, options PushOptions) error {
+	app := planned.Application.WithContext(ctx)
+	app.setupDefaults()
+	project, err := app.oc.Project(ctx)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(project) != planned.Project {
+		return errors.New(fmt.Sprintf("Error: The plan pushes %s to project %s, but the current project is %s\n", app.Name, planned.Project, strings.TrimSpace(project)))
+	}
+	changes, err := app.Diff(options)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 && len(planned.Changes) == 0 {
+		return nil
+	}
+	if !reflect.DeepEqual(changes, planned.Changes) {
+		log.Infof("Changes to %s now:", app.Name)
+		for _, change := range changes {
+			log.Printf("  %s", change)
+		}
+		return errors.New(fmt.Sprintf("Error: %s has changed since the plan was made, run 'ocf plan' again\n", app.Name))
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestPlanRoundTrip(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.On("Exists", "dc", "foo").Return(false, nil)
+	oc.On("Exists", "deployment", "foo").Return(false, nil)
+
+	options := PushOptions{Image: "builder", Builders: &Builders{Stacks: map[string]string{"cflinuxfs4": "stack-builder"}}}
+	plan, err := NewPlan(context.Background(), []Application{{Name: "foo", Memory: "512M", oc: oc}}, options)
+	assert.Nil(t, err)
+	assert.Equal(t, "test-project", plan.Apps[0].Project)
+	assert.Equal(t, []Change{{Field: "application", Desired: "foo"}}, plan.Apps[0].Changes)
+
+	file := filepath.Join(t.TempDir(), "plan.json")
+	assert.Nil(t, plan.Write(file))
+	read, err := ReadPlan(file)
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", read.Apps[0].Application.Name)
+	assert.Equal(t, "512M", read.Apps[0].Application.Memory)
+	assert.Equal(t, options.Builders, read.Options.Builders)
+	assert.Equal(t, plan.Apps[0].Changes, read.Apps[0].Changes)
+}
+
+func TestPlanCheckRefusesChanges(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.On("Exists", "dc", "foo").Return(false, nil)
+	oc.On("Exists", "deployment", "foo").Return(false, nil)
+	planned := &PlannedApp{Application: Application{Name: "foo", oc: oc}, Project: "test-project"}
+
+	// The plan saw foo running, but it's gone now
+	assert.NotNil(t, planned.check(context.Background(), PushOptions{}))
+
+	planned.Changes = []Change{{Field: "application", Desired: "foo"}}
+	assert.Nil(t, planned.check(context.Background(), PushOptions{}))
+
+	planned.Project = "other-project"
+	assert.NotNil(t, planned.check(context.Background(), PushOptions{}))
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// podSelector returns the label selector matching the application's
+// pods. OpenShift labels the pods of every deployment config with its
+// name, while deployments use the app label ocf gives them.
+func (app *Application) podSelector() string {
+	if app.workloadKind() == "dc" {
+		return fmt.Sprint("deploymentconfig=", app.Name)
+	}
+	return fmt.Sprint("app=", app.Name)
+}
+
+// podLabels returns the labels podSelector matches.
+func (app *Application) podLabels() map[string]string {
+	if app.workloadKind() == "dc" {
+		return map[string]string{"deploymentconfig": app.Name}
+	}
+	return map[string]string{"app": app.Name}
+}
+
+// runningPods returns the names of the application's running pods.
+func (app *Application) runningPods() ([]string, error) {
+	output, err := app.oc.Exec("get", "pods", fmt.Sprint("--selector=", app.podSelector()),
+		"--field-selector=status.phase=Running", "-o", "name").CombinedOutput(app.context())
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error listing pods of %s: %s\n", app.Name, output))
+	}
+	var pods []string
+	for _, line := range strings.Fields(string(output)) {
+		pods = append(pods, strings.TrimPrefix(line, "pod/"))
+	}
+	return pods, nil
+}
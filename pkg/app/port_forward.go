@@ -0,0 +1,106 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+// PortForward forwards local ports to one of the application's running
+// pods until interrupted. ports are given as 'oc port-forward' takes
+// them, like "8080" or "9000:8080", defaulting to the port
+// applications listen on.
+func (app *Application) PortForward(ports []string) error {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+
+	args, err := app.portForwardArgs(ports)
+	if err != nil {
+		return err
+	}
+	return runPortForward(app.context(), app.oc, args)
+}
+
+// PortForwardService forwards local ports to service, such as a bound
+// database, until interrupted. ports default to the service's own.
+func PortForwardService(ctx context.Context, service string, ports []string) error {
+	app := &Application{ctx: ctx}
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+
+	args, err := servicePortForwardArgs(ctx, app.oc, service, ports)
+	if err != nil {
+		return err
+	}
+	return runPortForward(ctx, app.oc, args)
+}
+
+func (app *Application) portForwardArgs(ports []string) ([]string, error) {
+	appExists, err := app.deploymentExists()
+	if err != nil {
+		return nil, err
+	}
+	if !appExists {
+		return nil, errors.New(fmt.Sprintf("Error: Application %s not found\n", app.Name))
+	}
+	pods, err := app.runningPods()
+	if err != nil {
+		return nil, err
+	}
+	if len(pods) == 0 {
+		return nil, errors.New(fmt.Sprintf("Error: No running pods found for %s\n", app.Name))
+	}
+	if len(ports) == 0 {
+		ports = []string{strconv.Itoa(defaultPort)}
+	}
+	return append([]string{"port-forward", fmt.Sprint("pod/", pods[0])}, ports...), nil
+}
+
+func servicePortForwardArgs(ctx context.Context, o oc.Oc, service string, ports []string) ([]string, error) {
+	exists, err := o.Exists(ctx, "svc", service)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New(fmt.Sprintf("Error: Service %s not found or doesn't run in the cluster\n", service))
+	}
+	if len(ports) == 0 {
+		svc := &types.Service{}
+		err = oc.Get(ctx, o, "svc", service, svc)
+		if err != nil {
+			return nil, err
+		}
+		for _, port := range svc.Spec.Ports {
+			ports = append(ports, strconv.Itoa(port.Port))
+		}
+		if len(ports) == 0 {
+			return nil, errors.New(fmt.Sprintf("Error: Service %s has no ports\n", service))
+		}
+	}
+	return append([]string{"port-forward", fmt.Sprint("svc/", service)}, ports...), nil
+}
+
+func runPortForward(ctx context.Context, o oc.Oc, args []string) error {
+	forwardCmd := o.Exec(args...)
+	forwardCmd.AttachStdIO()
+	// Forwarding runs until the user stops it
+	forwardCmd.SetTimeout(0)
+	log.Infof("Forwarding ports with command: %s, press Ctrl-C to stop", forwardCmd.ArgsString())
+	err := forwardCmd.Run(ctx)
+	if ctx.Err() != nil {
+		// Interrupted by the user
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestPortForwardArgs(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	oc.On("Exists", "dc", "foo").Return(true, nil)
+	podsCmd := &mocks.ExecCmd{}
+	podsCmd.On("CombinedOutput").Return([]byte("pod/foo-2-abcde\n"), nil)
+	oc.Execer.On("Oc", []string{"get", "pods", "--selector=deploymentconfig=foo",
+		"--field-selector=status.phase=Running", "-o", "name"}).Return(podsCmd)
+
+	args, err := app.portForwardArgs(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"port-forward", "pod/foo-2-abcde", "8080"}, args)
+
+	args, err = app.portForwardArgs([]string{"9000:8080"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"port-forward", "pod/foo-2-abcde", "9000:8080"}, args)
+}
+
+func TestServicePortForwardArgsDefaultsToServicePorts(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.On("Exists", "svc", "rails-postgres").Return(true, nil)
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(`{"spec":{"ports":[{"port":5432}]}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "svc", "rails-postgres", "-o", "json"}).Return(getCmd)
+
+	args, err := servicePortForwardArgs(context.Background(), oc, "rails-postgres", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"port-forward", "svc/rails-postgres", "5432"}, args)
+}
+
+func TestServicePortForwardArgsMissingService(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.On("Exists", "svc", "cloudant").Return(false, nil)
+
+	_, err := servicePortForwardArgs(context.Background(), oc, "cloudant", []string{"5984"})
+	assert.NotNil(t, err)
+}
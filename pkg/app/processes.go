@@ -0,0 +1,105 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// WebProcess is the process type that serves requests. It's run by
+// the application's own deployment with the application's settings,
+// and is the only process with a route.
+const WebProcess = "web"
+
+// processLabel labels the deployments of other process types with
+// their type.
+const processLabel = "ocf/process"
+
+// Process is one of an application's process types, such as a
+// worker. Each type other than web gets its own deployment running
+// the application's image.
+type Process struct {
+	Type      string `json:"type"`
+	Command   string `json:"command,omitempty"`
+	Instances int    `json:"instances,omitempty"`
+	Memory    string `json:"memory,omitempty"`
+}
+
+// processName returns the name of the deployment running a process
+// type.
+func (app *Application) processName(processType string) string {
+	return fmt.Sprint(app.Name, "-", processType)
+}
+
+// otherProcesses returns the application's processes other than web.
+func (app *Application) otherProcesses() []Process {
+	var processes []Process
+	for _, process := range app.Processes {
+		if process.Type != WebProcess {
+			processes = append(processes, process)
+		}
+	}
+	return processes
+}
+
+// processApp returns a copy of the application with the settings of
+// one of its processes.
+func (app *Application) processApp(process Process) *Application {
+	processApp := *app
+	processApp.Processes = nil
+	processApp.processType = process.Type
+	if process.Command != "" {
+		processApp.Command = process.Command
+	}
+	if process.Instances > 0 {
+		processApp.Instances = process.Instances
+	}
+	if process.Memory != "" {
+		processApp.Memory = process.Memory
+	}
+	return &processApp
+}
+
+// ensureProcesses creates or updates a deployment for each process
+// other than web. They aren't exposed by the application's service,
+// which only selects the web process's pods.
+func (app *Application) ensureProcesses() error {
+	processes := app.otherProcesses()
+	if len(processes) == 0 {
+		return nil
+	}
+	image, err := app.deploymentImage()
+	if err != nil {
+		return withOutput(image, err)
+	}
+	env, secretNames, err := app.envForServiceBindings()
+	if err != nil {
+		return err
+	}
+
+	for _, process := range processes {
+		name := app.processName(process.Type)
+		exists, err := app.oc.Exists(app.context(), "deployment", name)
+		if err != nil {
+			return err
+		}
+		labels := map[string]string{"app": name, processLabel: process.Type}
+		manifest, err := app.processApp(process).workloadManifest(name, labels, string(image), env, secretNames)
+		if err != nil {
+			return err
+		}
+		log.Infof("Applying deployment %s for the %s process", name, process.Type)
+		err = app.oc.Apply(app.context(), manifest)
+		if err != nil {
+			return err
+		}
+		if exists {
+			// The image's tag rarely changes between pushes
+			err = app.oc.Redeploy(app.context(), "deployment", name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
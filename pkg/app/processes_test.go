@@ -0,0 +1,50 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+func TestEnsureProcesses(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", Command: "./web", Memory: "1G", Instances: 3,
+		Docker: &Docker{Image: "quay.io/me/foo"},
+		Processes: []Process{
+			{Type: WebProcess, Command: "ignored"},
+			{Type: "worker", Command: "./worker", Memory: "256M"},
+		}}
+
+	oc.On("Exists", "deployment", "foo-worker").Return(true, nil)
+	var applied types.DeploymentConfig
+	oc.On("Apply", mock.Anything).Run(func(args mock.Arguments) {
+		json.Unmarshal(args.Get(0).([]byte), &applied)
+	}).Return(nil)
+	restartCmd := &mocks.ExecCmd{}
+	restartCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", []string{"rollout", "restart", "deployment/foo-worker"}).Return(restartCmd)
+
+	assert.Nil(t, app.ensureProcesses())
+	oc.AssertNumberOfCalls(t, "Apply", 1)
+	restartCmd.AssertExpectations(t)
+
+	assert.Equal(t, "foo-worker", applied.Metadata.Name)
+	assert.Equal(t, map[string]string{"app": "foo-worker", processLabel: "worker"}, applied.Metadata.Labels)
+	assert.Equal(t, 3, applied.Spec.Replicas)
+	container := applied.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "quay.io/me/foo", container.Image)
+	assert.Equal(t, []string{"/bin/sh", "-c", signalForwarder, "sh", "./worker"}, container.Command)
+	assert.Equal(t, "256M", container.Resources.Limits["memory"])
+}
+
+func TestEnsureProcessesWithOnlyWeb(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", Processes: []Process{{Type: WebProcess}}}
+	assert.Nil(t, app.ensureProcesses())
+	oc.AssertNotCalled(t, "Apply", mock.Anything)
+}
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+// derivedEnv are the deployment environment variables ocf sets from
+// application settings, so they go stale when the setting is removed.
+var derivedEnv = []string{"MEMORY_LIMIT", "CF_COMMAND"}
+
+// reconcile updates or removes what earlier pushes left in the cluster
+// that no longer matches the application, since a push otherwise only
+// adds and changes things. It only runs for pushes with --prune.
+func (app *Application) reconcile() error {
+	err := app.pruneEnv()
+	if err != nil {
+		return err
+	}
+	if !app.IsDocker() && !app.kubernetes() {
+		err = app.pruneBuildEnv()
+		if err != nil {
+			return err
+		}
+	}
+	err = app.reconcileService()
+	if err != nil {
+		return err
+	}
+	return app.reconcileDefaultRoute()
+}
+
+// pruneEnv removes the derived environment variables whose settings
+// were removed, like CF_COMMAND once there's no custom command.
+func (app *Application) pruneEnv() error {
+	liveEnv, err := app.oc.Env(app.context(), app.workloadKind(), app.Name)
+	if err != nil {
+		return err
+	}
+	desired := make(map[string]bool)
+	for _, envVar := range app.deploymentEnv(nil) {
+		desired[strings.SplitN(envVar, "=", 2)[0]] = true
+	}
+	removed := make(map[string]string)
+	for _, name := range derivedEnv {
+		if _, ok := liveEnv[name]; ok && !desired[name] {
+			log.Infof("Removing environment variable %s from %s", name, app.Name)
+			removed[name] = "-"
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	return app.oc.SetEnv(app.context(), app.workloadKind(), app.Name, removed)
+}
+
+// pruneBuildEnv removes build environment variables that aren't in the
+// application's build-env, including ones set with 'set-env --build'.
+// Variables of services bound to the build with 'bind-service
+// --staging' are kept, since those services are bound at runtime too.
+func (app *Application) pruneBuildEnv() error {
+	exists, err := app.oc.Exists(app.context(), "bc", app.Name)
+	if err != nil || !exists {
+		return err
+	}
+	liveEnv, err := app.oc.Env(app.context(), "bc", app.Name)
+	if err != nil {
+		return err
+	}
+	appEnv, err := app.oc.Env(app.context(), app.workloadKind(), app.Name)
+	if err != nil {
+		return err
+	}
+	var boundPrefixes []string
+	for _, envPrefix := range strings.Fields(appEnv[BoundServices]) {
+		boundPrefixes = append(boundPrefixes, fmt.Sprint(envPrefix, "_"))
+	}
+	isBound := func(name string) bool {
+		for _, prefix := range boundPrefixes {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	removed := make(map[string]string)
+	for name := range liveEnv {
+		if _, ok := app.BuildEnv[name]; !ok && name != BuildpackUrl && !isBound(name) {
+			log.Infof("Removing build environment variable %s from %s", name, app.Name)
+			removed[name] = "-"
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	return app.oc.SetEnv(app.context(), "bc", app.Name, removed)
+}
+
+// reconcileService deletes the application's service if its port or
+// type no longer match the route type, so it's created again.
+func (app *Application) reconcileService() error {
+	exists, err := app.oc.Exists(app.context(), "svc", app.Name)
+	if err != nil || !exists {
+		return err
+	}
+	service := &types.Service{}
+	err = oc.Get(app.context(), app.oc, "svc", app.Name, service)
+	if err != nil {
+		return err
+	}
+	loadBalancer := service.Spec.Type == "LoadBalancer"
+	portChanged := len(service.Spec.Ports) > 0 && service.Spec.Ports[0].Port != app.servicePort()
+	if !portChanged && loadBalancer == app.tcp() {
+		return nil
+	}
+	log.Infof("Recreating service %s, since its port or type changed", app.Name)
+	return app.oc.Delete(app.context(), "svc", app.Name)
+}
+
+// reconcileDefaultRoute deletes the application's default route, or
+// ingress, when it's been replaced by manifest routes or a TCP route,
+// or when the ingress's host no longer matches --domain.
+func (app *Application) reconcileDefaultRoute() error {
+	objType := "route"
+	if app.kubernetes() {
+		objType = "ingress"
+	}
+	exists, err := app.oc.Exists(app.context(), objType, app.Name)
+	if err != nil || !exists {
+		return err
+	}
+	if app.tcp() || len(app.Routes) > 0 {
+		log.Infof("Removing default %s of %s, which has been replaced", objType, app.Name)
+		return app.oc.Delete(app.context(), objType, app.Name)
+	}
+	if !app.kubernetes() || app.options.Domain == "" {
+		return nil
+	}
+	ingress := &types.Ingress{}
+	err = oc.Get(app.context(), app.oc, objType, app.Name, ingress)
+	if err != nil {
+		return err
+	}
+	if ingress.Host() == app.ingressHost() {
+		return nil
+	}
+	log.Infof("Recreating ingress %s, since its host changed from %s to %s", app.Name, ingress.Host(), app.ingressHost())
+	return app.oc.Delete(app.context(), objType, app.Name)
+}
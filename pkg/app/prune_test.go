@@ -0,0 +1,118 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestPruneEnv(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", kind: "dc", Memory: "512M"}
+
+	oc.On("Env", "dc", "foo").Return(map[string]string{
+		"MEMORY_LIMIT": "512M",
+		"CF_COMMAND":   "bundle exec rails s",
+		"DATABASE_URL": "postgres://db",
+	}, nil)
+	oc.On("SetEnv", "dc", "foo", map[string]string{"CF_COMMAND": "-"}).Return(nil)
+
+	err := app.pruneEnv()
+	assert.Nil(t, err)
+	oc.AssertExpectations(t)
+}
+
+func TestPruneBuildEnv(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", BuildEnv: map[string]string{"BUNDLE_WITHOUT": "test"}}
+
+	oc.On("Exists", "bc", "foo").Return(true, nil)
+	oc.On("Env", "bc", "foo").Return(map[string]string{
+		"BUNDLE_WITHOUT": "test",
+		"BUILDPACK_URL":  "https://github.com/cloudfoundry/ruby-buildpack",
+		"NPM_MIRROR":     "https://registry.example.com",
+	}, nil)
+	oc.On("Env", "dc", "foo").Return(map[string]string{}, nil)
+	oc.On("SetEnv", "bc", "foo", map[string]string{"NPM_MIRROR": "-"}).Return(nil)
+
+	err := app.pruneBuildEnv()
+	assert.Nil(t, err)
+	oc.AssertExpectations(t)
+}
+
+func TestPruneBuildEnvKeepsStagingBindings(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", kind: "dc"}
+
+	oc.On("Exists", "bc", "foo").Return(true, nil)
+	oc.On("Env", "bc", "foo").Return(map[string]string{
+		"MY_API_LABEL":      UserProvidedLabel,
+		"MY_API_PARAMETERS": `{"region":"eu"}`,
+		"MY_APIX_LABEL":     UserProvidedLabel,
+	}, nil)
+	oc.On("Env", "dc", "foo").Return(map[string]string{BoundServices: "MY_DB MY_API"}, nil)
+	oc.On("SetEnv", "bc", "foo", map[string]string{"MY_APIX_LABEL": "-"}).Return(nil)
+
+	err := app.pruneBuildEnv()
+	assert.Nil(t, err)
+	oc.AssertExpectations(t)
+}
+
+func TestReconcileServiceRecreatesChangedPort(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", options: PushOptions{RouteType: RouteTypeTCP, RoutePort: 5000}}
+
+	oc.On("Exists", "svc", "foo").Return(true, nil)
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(`{"spec":{"type":"LoadBalancer","ports":[{"port":8080}]}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "svc", "foo", "-o", "json"}).Return(getCmd)
+	oc.On("Delete", "svc", "foo").Return(nil)
+
+	err := app.reconcileService()
+	assert.Nil(t, err)
+	oc.AssertExpectations(t)
+}
+
+func TestReconcileServiceKeepsMatchingService(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	oc.On("Exists", "svc", "foo").Return(true, nil)
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(`{"spec":{"type":"ClusterIP","ports":[{"port":8080}]}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "svc", "foo", "-o", "json"}).Return(getCmd)
+
+	err := app.reconcileService()
+	assert.Nil(t, err)
+	oc.AssertNotCalled(t, "Delete", "svc", "foo")
+}
+
+func TestReconcileDefaultRouteReplacedByManifestRoutes(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", Routes: []Route{{Route: "example.com/api"}}}
+
+	oc.On("Exists", "route", "foo").Return(true, nil)
+	oc.On("Delete", "route", "foo").Return(nil)
+
+	err := app.reconcileDefaultRoute()
+	assert.Nil(t, err)
+	oc.AssertExpectations(t)
+}
+
+func TestReconcileDefaultRouteRecreatesIngressWithNewHost(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.PlatformName = "k8s"
+	app := Application{oc: oc, Name: "foo", options: PushOptions{Domain: "apps.example.com"}}
+
+	oc.On("Exists", "ingress", "foo").Return(true, nil)
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(`{"spec":{"rules":[{"host":"foo.old.example.com"}]}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "ingress", "foo", "-o", "json"}).Return(getCmd)
+	oc.On("Delete", "ingress", "foo").Return(nil)
+
+	err := app.reconcileDefaultRoute()
+	assert.Nil(t, err)
+	oc.AssertExpectations(t)
+}
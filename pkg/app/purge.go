@@ -0,0 +1,126 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+)
+
+// PurgedBinding describes a service binding removed by
+// PurgeServiceBindings.
+type PurgedBinding struct {
+	Application string
+	Service     string
+}
+
+// PurgeServiceBindings removes bindings from every application in the
+// current project. If service is given, all of its bindings are
+// removed. Otherwise only bindings whose backing deployment config,
+// secret, or config map no longer exists are removed.
+func PurgeServiceBindings(ctx context.Context, service string) ([]PurgedBinding, error) {
+	app := &Application{ctx: ctx}
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return nil, err
+	}
+	app.displayProject()
+	return purgeServiceBindings(ctx, app.oc, service)
+}
+
+func purgeServiceBindings(ctx context.Context, o oc.Oc, service string) ([]PurgedBinding, error) {
+	var purged []PurgedBinding
+	var apps []*Application
+	for _, kind := range workloadKinds(o) {
+		appNames, err := o.List(ctx, kind, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, appName := range appNames {
+			apps = append(apps, &Application{Name: appName, oc: o, ctx: ctx, kind: kind})
+		}
+	}
+
+	for _, app := range apps {
+		appName := app.Name
+		appEnv, err := o.Env(ctx, app.workloadKind(), appName)
+		if err != nil {
+			return purged, err
+		}
+
+		for _, envPrefix := range strings.Fields(appEnv[BoundServices]) {
+			boundService := boundServiceName(envPrefix, appEnv)
+			if service != "" {
+				if envPrefix != envPrefixFromService(service) {
+					continue
+				}
+				boundService = service
+			} else {
+				// Only a service that's definitely gone is purged,
+				// not one that couldn't be looked up
+				kind, err := app.findServiceKind(boundService)
+				if err != nil {
+					return purged, err
+				}
+				if kind != "" {
+					continue
+				}
+			}
+
+			err = app.removeBinding(envPrefix)
+			if err != nil {
+				return purged, err
+			}
+			log.Infof("Removed binding of %s from %s", boundService, appName)
+			purged = append(purged, PurgedBinding{Application: appName, Service: boundService})
+		}
+	}
+	return purged, nil
+}
+
+// removeBinding removes every trace of the binding with the given env
+// prefix from the application without needing the service itself.
+func (app *Application) removeBinding(envPrefix string) error {
+	appEnv, err := app.oc.Env(app.context(), app.workloadKind(), app.Name)
+	if err != nil {
+		return err
+	}
+	envNames, err := app.oc.EnvNames(app.context(), app.workloadKind(), app.Name)
+	if err != nil {
+		return err
+	}
+
+	var remaining []string
+	for _, bound := range strings.Fields(appEnv[BoundServices]) {
+		if bound != envPrefix {
+			remaining = append(remaining, bound)
+		}
+	}
+	newEnv := map[string]string{
+		BoundServices: strings.Join(remaining, " "),
+	}
+	for _, name := range envNames {
+		if strings.HasPrefix(name, fmt.Sprint(envPrefix, "_")) {
+			newEnv[name] = "-"
+		}
+	}
+	err = app.oc.SetEnv(app.context(), app.workloadKind(), app.Name, newEnv)
+	if err != nil {
+		return err
+	}
+
+	secretName := app.bindingSecretName(envPrefix)
+	secretExists, err := app.oc.Exists(app.context(), "secret", secretName)
+	if err != nil {
+		return err
+	}
+	if secretExists {
+		err = app.oc.Delete(app.context(), "secret", secretName)
+		if err != nil {
+			return err
+		}
+	}
+	return app.ensureVcapServices()
+}
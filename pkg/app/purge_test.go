@@ -0,0 +1,106 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestPurgeOrphanedServiceBindings(t *testing.T) {
+	oc := mocks.NewMockOc()
+
+	appEnv := map[string]string{
+		BoundServices:   "LIVE_DB GONE_DB",
+		"GONE_DB_LABEL": "mysql",
+		"LIVE_DB_LABEL": "mysql",
+	}
+	oc.On("List", "dc", "").Return([]string{"foo"}, nil)
+	oc.On("List", "deployment", "").Return([]string{}, nil)
+	oc.On("Env", "dc", "foo").Return(appEnv, nil).Twice()
+	oc.On("Env", "dc", "foo").Return(map[string]string{BoundServices: "LIVE_DB", "LIVE_DB_LABEL": "mysql"}, nil)
+	oc.On("Exists", "dc", "live-db").Return(true, nil)
+	oc.On("Exists", "dc", "gone-db").Return(false, nil)
+	oc.On("Exists", "secret", "gone-db").Return(false, nil)
+	oc.On("Exists", "configmap", "gone-db").Return(false, nil)
+	oc.On("EnvNames", "dc", "foo").Return([]string{
+		BoundServices, "GONE_DB_LABEL", "GONE_DB_PASSWORD", "LIVE_DB_LABEL",
+	}, nil)
+	oc.On("SetEnv", "dc", "foo", map[string]string{
+		BoundServices:      "LIVE_DB",
+		"GONE_DB_LABEL":    "-",
+		"GONE_DB_PASSWORD": "-",
+	}).Return(nil)
+	oc.On("Exists", "secret", "foo-gone-db-binding").Return(true, nil)
+	oc.On("Delete", "secret", "foo-gone-db-binding").Return(nil)
+	oc.On("Exists", "secret", "foo-live-db-binding").Return(false, nil)
+	oc.On("Exists", "secret", "foo-vcap-services").Return(true, nil)
+	oc.On("Delete", "secret", "foo-vcap-services").Return(nil)
+	oc.On("CreateSecret", "foo-vcap-services", map[string]string{
+		VcapServices: `{"mysql":[{"name":"live-db","label":"mysql","credentials":{}}]}`,
+	}).Return(nil)
+	oc.On("SetEnvFrom", "dc", "foo", "secret/foo-vcap-services", "", map[string]string(nil)).Return(nil)
+
+	purged, err := purgeServiceBindings(context.Background(), oc, "")
+	assert.Nil(t, err)
+	assert.Equal(t, []PurgedBinding{{Application: "foo", Service: "gone-db"}}, purged)
+	oc.AssertExpectations(t)
+}
+
+func TestPurgeNamedServiceBindings(t *testing.T) {
+	oc := mocks.NewMockOc()
+
+	oc.On("List", "dc", "").Return([]string{"foo"}, nil)
+	oc.On("List", "deployment", "").Return([]string{"bar"}, nil)
+	oc.On("Env", "dc", "foo").Return(map[string]string{BoundServices: "MY_DB"}, nil).Twice()
+	oc.On("Env", "dc", "foo").Return(map[string]string{BoundServices: ""}, nil)
+	oc.On("Exists", "secret", "foo-vcap-services").Return(false, nil)
+	oc.On("Env", "deployment", "bar").Return(map[string]string{BoundServices: "OTHER_DB"}, nil)
+	oc.On("EnvNames", "dc", "foo").Return([]string{BoundServices, "MY_DB_USER"}, nil)
+	oc.On("SetEnv", "dc", "foo", map[string]string{
+		BoundServices: "",
+		"MY_DB_USER":  "-",
+	}).Return(nil)
+	oc.On("Exists", "secret", "foo-my-db-binding").Return(false, nil)
+
+	purged, err := purgeServiceBindings(context.Background(), oc, "my-db")
+	assert.Nil(t, err)
+	assert.Equal(t, []PurgedBinding{{Application: "foo", Service: "my-db"}}, purged)
+	oc.AssertExpectations(t)
+}
+
+func TestPurgeUsesRecordedServiceName(t *testing.T) {
+	oc := mocks.NewMockOc()
+
+	oc.On("List", "dc", "").Return([]string{"foo"}, nil)
+	oc.On("List", "deployment", "").Return([]string{}, nil)
+	oc.On("Env", "dc", "foo").Return(map[string]string{
+		BoundServices:        "MY_DB",
+		"MY_DB_SERVICE_NAME": "my_db",
+	}, nil)
+	oc.On("Exists", "dc", "my_db").Return(true, nil)
+
+	purged, err := purgeServiceBindings(context.Background(), oc, "")
+	assert.Nil(t, err)
+	assert.Empty(t, purged)
+	oc.AssertNotCalled(t, "Exists", "dc", "my-db")
+	oc.AssertNotCalled(t, "SetEnv", "dc", "foo", mock.Anything)
+}
+
+func TestPurgeKeepsBindingsWhenLookupFails(t *testing.T) {
+	oc := mocks.NewMockOc()
+
+	oc.On("List", "dc", "").Return([]string{"foo"}, nil)
+	oc.On("List", "deployment", "").Return([]string{}, nil)
+	oc.On("Env", "dc", "foo").Return(map[string]string{BoundServices: "MY_DB"}, nil)
+	oc.On("Exists", "dc", "my-db").Return(false, errors.New("Unauthorized"))
+
+	purged, err := purgeServiceBindings(context.Background(), oc, "")
+	assert.NotNil(t, err)
+	assert.Empty(t, purged)
+	oc.AssertNotCalled(t, "SetEnv", "dc", "foo", mock.Anything)
+}
@@ -0,0 +1,230 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+// quotaNeeds is what a push adds to the project's resource usage.
+type quotaNeeds struct {
+	limit         int64
+	request       int64
+	pods          int64
+	limitsMemory  int64
+	requestMemory int64
+}
+
+// checkQuota compares the memory and instances the push asks for with
+// the project's limit ranges and resource quotas, so a push that can't
+// be scheduled fails before anything is built.
+func (app *Application) checkQuota() error {
+	if app.options.GitOpsOnly || app.knative() {
+		return nil
+	}
+	problems, err := app.quotaProblems()
+	if err != nil {
+		// Not everyone can read their project's quotas
+		log.Debugf("Skipping the quota check: %v", err)
+		return nil
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	if app.options.IgnoreQuota {
+		for _, problem := range problems {
+			log.Warnf("%s", problem)
+		}
+		return nil
+	}
+	return errors.New(fmt.Sprintf("Error: %s won't fit in the project's quota:\n  %s\nLower its memory or instances, or push anyway with --ignore-quota\n",
+		app.Name, strings.Join(problems, "\n  ")))
+}
+
+func (app *Application) quotaProblems() ([]string, error) {
+	limitRanges := &types.LimitRangeList{}
+	err := oc.GetSelected(app.context(), app.oc, "limitrange", "", limitRanges)
+	if err != nil {
+		return nil, err
+	}
+	quotas := &types.ResourceQuotaList{}
+	err = oc.GetSelected(app.context(), app.oc, "resourcequota", "", quotas)
+	if err != nil {
+		return nil, err
+	}
+	needs, err := app.quotaNeeds(limitRanges)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, limitRange := range limitRanges.Items {
+		for _, limit := range limitRange.Spec.Limits {
+			if limit.Type != "Container" && limit.Type != "Pod" {
+				continue
+			}
+			if max := parseMemory(limit.Max["memory"]); max > 0 && needs.limit > max {
+				problems = append(problems, fmt.Sprintf("limit range %s: memory of %s is above the %s maximum per %s",
+					limitRange.Metadata.Name, formatBytes(needs.limit), formatBytes(max), strings.ToLower(limit.Type)))
+			}
+			if min := parseMemory(limit.Min["memory"]); min > 0 && needs.limit > 0 && needs.limit < min {
+				problems = append(problems, fmt.Sprintf("limit range %s: memory of %s is below the %s minimum per %s",
+					limitRange.Metadata.Name, formatBytes(needs.limit), formatBytes(min), strings.ToLower(limit.Type)))
+			}
+		}
+	}
+
+	for _, quota := range quotas.Items {
+		checks := []struct {
+			resource string
+			needed   int64
+			perPod   int64
+		}{
+			{"limits.memory", needs.limitsMemory, needs.limit},
+			{"requests.memory", needs.requestMemory, needs.request},
+			{"memory", needs.requestMemory, needs.request},
+			{"pods", needs.pods, 1},
+		}
+		for _, check := range checks {
+			hardQuantity, ok := quota.Status.Hard[check.resource]
+			if !ok {
+				continue
+			}
+			if check.perPod == 0 {
+				problems = append(problems, fmt.Sprintf("quota %s: limits %s, so a memory limit is required", quota.Metadata.Name, check.resource))
+				continue
+			}
+			if check.needed <= 0 {
+				continue
+			}
+			format := formatBytes
+			if check.resource == "pods" {
+				format = func(count int64) string { return fmt.Sprint(count) }
+			}
+			hard := parseMemory(hardQuantity)
+			used := parseMemory(quota.Status.Used[check.resource])
+			if used+check.needed > hard {
+				problems = append(problems, fmt.Sprintf("quota %s: %s needs %s more, but %s of %s is used, %s short",
+					quota.Metadata.Name, check.resource, format(check.needed), format(used), format(hard), format(used+check.needed-hard)))
+			}
+		}
+	}
+	return problems, nil
+}
+
+// quotaNeeds works out the memory per instance, including limit range
+// defaults, and how much the push adds beyond what the application
+// already uses.
+func (app *Application) quotaNeeds(limitRanges *types.LimitRangeList) (quotaNeeds, error) {
+	needs := quotaNeeds{limit: parseMemory(app.Memory)}
+	instances := int64(app.Instances)
+
+	var currentInstances, currentLimit, currentRequest int64
+	exists, err := app.deploymentExists()
+	if err != nil {
+		return needs, err
+	}
+	if exists {
+		workload, err := app.liveWorkload()
+		if err != nil {
+			return needs, err
+		}
+		resources := workload.Spec.Template.Spec.Containers[0].Resources
+		currentInstances = int64(workload.Spec.Replicas)
+		currentLimit = parseMemory(resources.Limits["memory"])
+		currentRequest = parseMemory(resources.Requests["memory"])
+		if currentRequest == 0 {
+			currentRequest = currentLimit
+		}
+	}
+	if instances < 1 {
+		instances = currentInstances
+	}
+	if instances < 1 {
+		instances = 1
+	}
+	if needs.limit == 0 {
+		needs.limit = currentLimit
+	}
+
+	var defaultRequest int64
+	for _, limitRange := range limitRanges.Items {
+		for _, limit := range limitRange.Spec.Limits {
+			if limit.Type != "Container" {
+				continue
+			}
+			if needs.limit == 0 {
+				needs.limit = parseMemory(limit.Default["memory"])
+			}
+			if defaultRequest == 0 {
+				defaultRequest = parseMemory(limit.DefaultRequest["memory"])
+			}
+		}
+	}
+	// Without a request of their own, containers request their limit
+	needs.request = needs.limit
+	if defaultRequest > 0 && defaultRequest < needs.limit {
+		needs.request = defaultRequest
+	}
+
+	needs.pods = instances - currentInstances
+	needs.limitsMemory = instances*needs.limit - currentInstances*currentLimit
+	needs.requestMemory = instances*needs.request - currentInstances*currentRequest
+	return needs, nil
+}
+
+// defaultMemory gives an application without a memory limit the
+// default of the project's limit range, so its deployment records the
+// limit it runs with. Applications already running with a limit keep
+// it.
+func (app *Application) defaultMemory() error {
+	if app.Memory != "" {
+		return nil
+	}
+	exists, err := app.deploymentExists()
+	if err != nil {
+		return err
+	}
+	if exists {
+		workload, err := app.liveWorkload()
+		if err != nil {
+			return err
+		}
+		if workload.Spec.Template.Spec.Containers[0].Resources.Limits["memory"] != "" {
+			return nil
+		}
+	}
+
+	limitRanges := &types.LimitRangeList{}
+	err = oc.GetSelected(app.context(), app.oc, "limitrange", "", limitRanges)
+	if err != nil {
+		// Not everyone can read their project's limit ranges
+		log.Debugf("Skipping the default memory: %v", err)
+		return nil
+	}
+	memory := limitRangeDefaultMemory(limitRanges)
+	if memory == "" {
+		log.Warnf("The project has no limit range with a default memory limit, so %s will run without one. Give it one with --memory or in its manifest", app.Name)
+		return nil
+	}
+	log.Infof("Using the project's default memory limit of %s for %s", memory, app.Name)
+	app.Memory = memory
+	return nil
+}
+
+// limitRangeDefaultMemory returns the default memory limit of
+// containers in the project, or an empty string if there isn't one.
+func limitRangeDefaultMemory(limitRanges *types.LimitRangeList) string {
+	for _, limitRange := range limitRanges.Items {
+		for _, limit := range limitRange.Spec.Limits {
+			if limit.Type == "Container" && limit.Default["memory"] != "" {
+				return limit.Default["memory"]
+			}
+		}
+	}
+	return ""
+}
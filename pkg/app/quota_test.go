@@ -0,0 +1,100 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func mockQuota(oc *mocks.Oc, limitRanges string, quotas string) {
+	limitRangeCmd := &mocks.ExecCmd{}
+	limitRangeCmd.On("CombinedOutput").Return([]byte(limitRanges), nil)
+	oc.Execer.On("Oc", []string{"get", "limitrange", "-o", "json"}).Return(limitRangeCmd)
+	quotaCmd := &mocks.ExecCmd{}
+	quotaCmd.On("CombinedOutput").Return([]byte(quotas), nil)
+	oc.Execer.On("Oc", []string{"get", "resourcequota", "-o", "json"}).Return(quotaCmd)
+}
+
+func TestQuotaProblemsNewApp(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", Memory: "1Gi", Instances: 3}
+	oc.On("Exists", "dc", "foo").Return(false, nil)
+	oc.On("Exists", "deployment", "foo").Return(false, nil)
+	mockQuota(oc,
+		`{"items":[{"metadata":{"name":"limits"},"spec":{"limits":[{"type":"Container","max":{"memory":"2Gi"}}]}}]}`,
+		`{"items":[{"metadata":{"name":"compute"},"status":{"hard":{"limits.memory":"4Gi","pods":"10"},"used":{"limits.memory":"2Gi","pods":"2"}}}]}`)
+
+	problems, err := app.quotaProblems()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"quota compute: limits.memory needs 3.0 GB more, but 2.0 GB of 4.0 GB is used, 1.0 GB short"}, problems)
+}
+
+func TestQuotaProblemsExistingApp(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", Memory: "3Gi"}
+	oc.On("Exists", "dc", "foo").Return(true, nil)
+	dcCmd := &mocks.ExecCmd{}
+	dcCmd.On("CombinedOutput").Return([]byte(`{"spec":{"replicas":1,"template":{"spec":{"containers":[{"resources":{"limits":{"memory":"1Gi"}}}]}}}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "dc", "foo", "-o", "json"}).Return(dcCmd)
+	mockQuota(oc,
+		`{"items":[{"metadata":{"name":"limits"},"spec":{"limits":[{"type":"Container","max":{"memory":"2Gi"}}]}}]}`,
+		`{"items":[{"metadata":{"name":"compute"},"status":{"hard":{"limits.memory":"4Gi","pods":"2"},"used":{"limits.memory":"2Gi","pods":"2"}}}]}`)
+
+	// Only the growth from 1Gi to 3Gi counts against the quota, and
+	// no new pods are needed
+	problems, err := app.quotaProblems()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"limit range limits: memory of 3.0 GB is above the 2.0 GB maximum per container"}, problems)
+}
+
+func TestQuotaProblemsLimitRequired(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+	oc.On("Exists", "dc", "foo").Return(false, nil)
+	oc.On("Exists", "deployment", "foo").Return(false, nil)
+	mockQuota(oc, `{"items":[]}`,
+		`{"items":[{"metadata":{"name":"compute"},"status":{"hard":{"limits.memory":"4Gi"},"used":{}}}]}`)
+
+	problems, err := app.quotaProblems()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"quota compute: limits limits.memory, so a memory limit is required"}, problems)
+}
+
+func TestDefaultMemory(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+	oc.On("Exists", "dc", "foo").Return(false, nil)
+	oc.On("Exists", "deployment", "foo").Return(false, nil)
+	mockQuota(oc,
+		`{"items":[{"metadata":{"name":"limits"},"spec":{"limits":[{"type":"Pod","max":{"memory":"2Gi"}},{"type":"Container","default":{"memory":"512Mi"}}]}}]}`,
+		`{"items":[]}`)
+
+	assert.Nil(t, app.defaultMemory())
+	assert.Equal(t, "512Mi", app.Memory)
+}
+
+func TestDefaultMemoryKeepsLiveLimit(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+	oc.On("Exists", "dc", "foo").Return(true, nil)
+	dcCmd := &mocks.ExecCmd{}
+	dcCmd.On("CombinedOutput").Return([]byte(`{"spec":{"template":{"spec":{"containers":[{"resources":{"limits":{"memory":"1Gi"}}}]}}}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "dc", "foo", "-o", "json"}).Return(dcCmd)
+
+	assert.Nil(t, app.defaultMemory())
+	assert.Equal(t, "", app.Memory)
+	oc.Execer.AssertNotCalled(t, "Oc", []string{"get", "limitrange", "-o", "json"})
+}
+
+func TestDefaultMemoryWithoutLimitRange(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+	oc.On("Exists", "dc", "foo").Return(false, nil)
+	oc.On("Exists", "deployment", "foo").Return(false, nil)
+	mockQuota(oc, `{"items":[]}`, `{"items":[]}`)
+
+	assert.Nil(t, app.defaultMemory())
+	assert.Equal(t, "", app.Memory)
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+// Restart redeploys the application so it picks up changes to its
+// environment, waiting for the new deployment to finish rolling out.
+func (app *Application) Restart() error {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+
+	appExists, err := app.deploymentExists()
+	if err != nil {
+		return err
+	}
+	if !appExists {
+		return errors.New(fmt.Sprintf("Error: Application %s not found\n", app.Name))
+	}
+
+	log.Infof("Restarting application %s", app.Name)
+	err = app.redeploy()
+	if err != nil {
+		return err
+	}
+
+	return app.waitForRollout()
+}
+
+// redeploy starts a new rollout of the application's current
+// configuration.
+func (app *Application) redeploy() error {
+	return app.oc.Redeploy(app.context(), app.workloadKind(), app.Name)
+}
+
+func (app *Application) waitForRollout() error {
+	statusCmd := app.oc.Exec("rollout", "status", fmt.Sprint(app.workloadKind(), "/", app.Name))
+	statusCmd.AttachStdIO()
+	statusCmd.SetTimeout(exec.BuildTimeout)
+	log.Infof("Waiting for rollout with command: %s", statusCmd.ArgsString())
+	return statusCmd.Run(app.context())
+}
+
+// RestartInstance deletes the pod running one instance of the
+// application so its controller replaces it, leaving the other
+// instances running. Instances are numbered from 0 in the order 'ocf
+// app' lists them.
+func (app *Application) RestartInstance(index int) error {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+
+	appExists, err := app.deploymentExists()
+	if err != nil {
+		return err
+	}
+	if !appExists {
+		return errors.New(fmt.Sprintf("Error: Application %s not found\n", app.Name))
+	}
+
+	pod, err := app.instancePod(index)
+	if err != nil {
+		return err
+	}
+	log.Infof("Restarting instance %d of %s, pod %s", index, app.Name, pod)
+	return app.oc.Delete(app.context(), "pod", pod)
+}
+
+// instancePods returns the names of the application's pods sorted by
+// name, which is the order 'oc get' lists them in.
+func (app *Application) instancePods() ([]string, error) {
+	list := &types.PodList{}
+	err := oc.GetSelected(app.context(), app.oc, "pods", app.podSelector(), list)
+	if err != nil {
+		return nil, err
+	}
+	var pods []string
+	for _, pod := range list.Items {
+		pods = append(pods, pod.Metadata.Name)
+	}
+	sort.Strings(pods)
+	return pods, nil
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/mocks"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+func TestRestart(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	deployCmd := &mocks.ExecCmd{Args: []string{"rollout", "latest", "dc/foo"}}
+	deployCmd.On("CombinedOutput").Return([]byte(""), nil)
+	statusCmd := &mocks.ExecCmd{Args: []string{"rollout", "status", "dc/foo"}}
+	statusCmd.On("AttachStdIO").Return()
+	statusCmd.On("Run").Return(nil)
+
+	oc.On("Exists", "dc", "foo").Return(true, nil)
+	oc.Execer.On("Oc", deployCmd.Args).Return(deployCmd)
+	oc.Execer.On("Oc", statusCmd.Args).Return(statusCmd)
+
+	err := app.Restart()
+	assert.Nil(t, err)
+	oc.Execer.AssertExpectations(t)
+	deployCmd.AssertExpectations(t)
+	statusCmd.AssertExpectations(t)
+	// Rollouts get as long as builds
+	assert.Equal(t, exec.BuildTimeout, statusCmd.Timeout)
+}
+
+func TestRedeployWithOldOc(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.OcCapabilities = &types.Capabilities{}
+	app := Application{oc: oc, Name: "foo"}
+
+	deployCmd := &mocks.ExecCmd{}
+	deployCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", []string{"deploy", "foo", "--latest"}).Return(deployCmd)
+
+	err := app.redeploy()
+	assert.Nil(t, err)
+	oc.Execer.AssertExpectations(t)
+}
+
+func TestRestartInstance(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	podsCmd := &mocks.ExecCmd{}
+	podsCmd.On("CombinedOutput").Return([]byte(`{"items":[{"metadata":{"name":"foo-1-zzzzz"}},{"metadata":{"name":"foo-1-aaaaa"}}]}`), nil)
+	oc.On("Exists", "dc", "foo").Return(true, nil)
+	oc.Execer.On("Oc", []string{"get", "pods", "--selector=deploymentconfig=foo", "-o", "json"}).Return(podsCmd)
+	oc.On("Delete", "pod", "foo-1-zzzzz").Return(nil)
+
+	assert.Nil(t, app.RestartInstance(1))
+	oc.AssertExpectations(t)
+
+	err := app.RestartInstance(2)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Instance 2 of foo not found, it has 2 instances")
+}
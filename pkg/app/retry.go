@@ -0,0 +1,35 @@
+package app
+
+import (
+	"strings"
+)
+
+// transientMessages appear in the errors of failures that are worth
+// retrying, like the API server or registry briefly being unavailable.
+var transientMessages = []string{
+	"connection refused",
+	"connection reset by peer",
+	"i/o timeout",
+	"TLS handshake timeout",
+	"unexpected EOF",
+	"the server is currently unable to handle the request",
+	"Service Unavailable",
+	"Gateway Timeout",
+	"etcdserver: request timed out",
+	"the object has been modified",
+}
+
+// IsTransient returns true if err looks like a temporary problem with
+// the cluster rather than with the application, so the push may
+// succeed if it's tried again.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, message := range transientMessages {
+		if strings.Contains(err.Error(), message) {
+			return true
+		}
+	}
+	return false
+}
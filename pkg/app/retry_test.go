@@ -0,0 +1,15 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, IsTransient(errors.New("Error getting bc foo: dial tcp 10.0.0.1:6443: connect: connection refused\n")))
+	assert.True(t, IsTransient(&StepError{Step: "route", Err: errors.New("Error from server (ServiceUnavailable): the server is currently unable to handle the request")}))
+	assert.False(t, IsTransient(errors.New("Error: Application foo not found")))
+	assert.False(t, IsTransient(nil))
+}
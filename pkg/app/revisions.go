@@ -0,0 +1,213 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// MaxRevisions is how many pushes are kept in an application's
+// revision history, matching Cloud Foundry's default.
+const MaxRevisions = 100
+
+// revisionPollAttempts is how many times the workload is read waiting
+// for a push's rollout to get a new revision number.
+const revisionPollAttempts = 5
+
+// Revision records a push of an application. Its number is the
+// rollout revision of the deployment config or deployment, which is
+// what 'ocf rollback --to' takes.
+type Revision struct {
+	Number int    `json:"number"`
+	Time   string `json:"time"`
+	User   string `json:"user"`
+	GitSHA string `json:"gitSha,omitempty"`
+	Tag    string `json:"tag,omitempty"`
+	Image  string `json:"image"`
+	// Deployed is true for the revision currently rolled out
+	Deployed bool `json:"-"`
+}
+
+func (app *Application) revisionsName() string {
+	return fmt.Sprint(app.Name, "-ocf-revisions")
+}
+
+// Revisions returns the application's revision history, oldest first.
+func (app *Application) Revisions() ([]Revision, error) {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return nil, err
+	}
+	app.displayProject()
+
+	appExists, err := app.deploymentExists()
+	if err != nil {
+		return nil, err
+	}
+	if !appExists {
+		return nil, errors.New(fmt.Sprintf("Error: Application %s not found\n", app.Name))
+	}
+
+	revisions, err := app.revisions()
+	if err != nil {
+		return nil, err
+	}
+	current, _, err := app.rolloutRevision()
+	if err != nil {
+		return nil, err
+	}
+	for i := range revisions {
+		revisions[i].Deployed = revisions[i].Number == current
+	}
+	return revisions, nil
+}
+
+func (app *Application) revisions() ([]Revision, error) {
+	data, err := app.oc.Data(app.context(), "configmap", app.revisionsName())
+	if err != nil && strings.Contains(err.Error(), "not found") {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var revisions []Revision
+	err = json.Unmarshal([]byte(data["revisions"]), &revisions)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error parsing revisions in configmap %s: %v\n", app.revisionsName(), err))
+	}
+	return revisions, nil
+}
+
+// rolloutRevision returns the revision number of the application's
+// latest rollout and the image it runs.
+func (app *Application) rolloutRevision() (int, string, error) {
+	workload, err := app.liveWorkload()
+	if err != nil {
+		return 0, "", err
+	}
+	image := workload.Spec.Template.Spec.Containers[0].Image
+	if app.workloadKind() == "dc" {
+		return workload.Status.LatestVersion, image, nil
+	}
+	revision, _ := strconv.Atoi(workload.Metadata.Annotations["deployment.kubernetes.io/revision"])
+	return revision, image, nil
+}
+
+// recordRevision adds the push that just rolled out to the
+// application's revision history. The push has already happened, so
+// failing to record it is only a warning.
+func (app *Application) recordRevision() {
+	err := app.addRevision()
+	if err != nil {
+		log.Warnf("Couldn't record the revision of %s: %v", app.Name, err)
+	}
+}
+
+func (app *Application) addRevision() error {
+	revisions, err := app.revisions()
+	if err != nil {
+		return err
+	}
+	last := 0
+	if len(revisions) > 0 {
+		last = revisions[len(revisions)-1].Number
+	}
+
+	// The rollout's revision is set by a controller, so it can lag
+	// behind the push
+	var number int
+	var image string
+	for attempt := 0; attempt < revisionPollAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(imagePollInterval)
+		}
+		number, image, err = app.rolloutRevision()
+		if err != nil {
+			return err
+		}
+		if number > last {
+			break
+		}
+	}
+	if number <= last {
+		return errors.New(fmt.Sprintf("Error: No new rollout of %s found after revision %d\n", app.Name, last))
+	}
+
+	revisions = append(revisions, Revision{
+		Number: number,
+		Time:   time.Now().UTC().Format(time.RFC3339),
+		User:   app.pusher(),
+		GitSHA: app.gitSHA(),
+		Tag:    app.tag,
+		Image:  image,
+	})
+	if len(revisions) > MaxRevisions {
+		revisions = revisions[len(revisions)-MaxRevisions:]
+	}
+	history, err := json.Marshal(revisions)
+	if err != nil {
+		return err
+	}
+	configMap, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":   app.revisionsName(),
+			"labels": map[string]string{"app": app.Name},
+		},
+		"data": map[string]string{"revisions": string(history)},
+	})
+	if err != nil {
+		return err
+	}
+	return app.oc.Apply(app.context(), configMap)
+}
+
+// pusher returns who is pushing, as the cluster knows them when it
+// can say.
+func (app *Application) pusher() string {
+	if !app.kubernetes() {
+		output, err := app.oc.Exec("whoami").CombinedOutput(app.context())
+		if err == nil {
+			return strings.TrimSpace(string(output))
+		}
+	}
+	user := os.Getenv("USER")
+	if user == "" {
+		user = "unknown"
+	}
+	return user
+}
+
+// gitSHA returns the commit the application's source is at, or an
+// empty string if it isn't in a Git repository, the push deploys an
+// earlier build's tag, or the cluster builds from a Git URL.
+func (app *Application) gitSHA() string {
+	if app.options.Droplet != "" || app.IsDocker() || app.options.Tag != "" || app.options.Git != "" {
+		return ""
+	}
+	return app.git("rev-parse", "HEAD")
+}
+
+// git runs git in the application's directory, returning its output
+// or an empty string if it fails.
+func (app *Application) git(args ...string) string {
+	dir := app.Path
+	if fi, err := os.Stat(dir); err == nil && !fi.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	if dir == "" {
+		dir = "."
+	}
+	output, err := app.execer.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(app.context())
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
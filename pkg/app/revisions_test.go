@@ -0,0 +1,57 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestAddRevision(t *testing.T) {
+	oc := mocks.NewMockOc()
+	execer := &mocks.Execer{}
+	app := Application{oc: oc, execer: execer, Name: "foo", Path: "/src/foo", kind: "dc"}
+
+	oc.On("Data", "configmap", "foo-ocf-revisions").Return(map[string]string{
+		"revisions": `[{"number":1,"time":"2020-01-01T00:00:00Z","user":"alice","image":"foo@sha256:aaa"}]`,
+	}, nil)
+	dcCmd := &mocks.ExecCmd{}
+	dcCmd.On("CombinedOutput").Return([]byte(`{"spec":{"template":{"spec":{"containers":[{"image":"foo@sha256:bbb"}]}}},"status":{"latestVersion":2}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "dc", "foo", "-o", "json"}).Return(dcCmd)
+	whoamiCmd := &mocks.ExecCmd{}
+	whoamiCmd.On("CombinedOutput").Return([]byte("bob\n"), nil)
+	oc.Execer.On("Oc", []string{"whoami"}).Return(whoamiCmd)
+	gitCmd := &mocks.ExecCmd{}
+	gitCmd.On("CombinedOutput").Return([]byte("0123456789abcdef\n"), nil)
+	execer.On("Command", "git", []string{"-C", "/src/foo", "rev-parse", "HEAD"}).Return(gitCmd)
+
+	var applied map[string]interface{}
+	oc.On("Apply", mock.Anything).Run(func(args mock.Arguments) {
+		json.Unmarshal(args.Get(0).([]byte), &applied)
+	}).Return(nil)
+
+	assert.Nil(t, app.addRevision())
+	var revisions []Revision
+	data := applied["data"].(map[string]interface{})
+	assert.Nil(t, json.Unmarshal([]byte(data["revisions"].(string)), &revisions))
+	assert.Equal(t, 2, len(revisions))
+	assert.Equal(t, 2, revisions[1].Number)
+	assert.Equal(t, "bob", revisions[1].User)
+	assert.Equal(t, "0123456789abcdef", revisions[1].GitSHA)
+	assert.Equal(t, "foo@sha256:bbb", revisions[1].Image)
+}
+
+func TestRevisionsWithoutHistory(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+	oc.On("Data", "configmap", "foo-ocf-revisions").Return(map[string]string{},
+		errors.New("configmaps \"foo-ocf-revisions\" not found"))
+
+	revisions, err := app.revisions()
+	assert.Nil(t, err)
+	assert.Nil(t, revisions)
+}
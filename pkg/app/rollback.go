@@ -0,0 +1,75 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// RolloutHistory returns the application's previous revisions as
+// listed by 'oc rollout history'.
+func (app *Application) RolloutHistory() (string, error) {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return "", err
+	}
+	app.displayProject()
+
+	appExists, err := app.deploymentExists()
+	if err != nil {
+		return "", err
+	}
+	if !appExists {
+		return "", errors.New(fmt.Sprintf("Error: Application %s not found\n", app.Name))
+	}
+
+	output, err := app.oc.Exec("rollout", "history", fmt.Sprint(app.workloadKind(), "/", app.Name)).CombinedOutput(app.context())
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("Error getting history of %s: %s\n", app.Name, output))
+	}
+	return string(output), nil
+}
+
+// Rollback rolls the application back to revision, or to its last
+// successful revision if revision is 0, and waits for the rollout.
+func (app *Application) Rollback(revision int) error {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+
+	appExists, err := app.deploymentExists()
+	if err != nil {
+		return err
+	}
+	if !appExists {
+		return errors.New(fmt.Sprintf("Error: Application %s not found\n", app.Name))
+	}
+
+	rollbackCmd := app.oc.Exec(app.rollbackArgs(revision)...)
+	log.Infof("Rolling back with command: %s", rollbackCmd.ArgsString())
+	output, err := rollbackCmd.CombinedOutput(app.context())
+	log.Printf("%s", output)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error rolling back %s: %s\n", app.Name, output))
+	}
+
+	return app.waitForRollout()
+}
+
+func (app *Application) rollbackArgs(revision int) []string {
+	if app.workloadKind() == "deployment" {
+		args := []string{"rollout", "undo", fmt.Sprint("deployment/", app.Name)}
+		if revision > 0 {
+			args = append(args, fmt.Sprint("--to-revision=", revision))
+		}
+		return args
+	}
+	args := []string{"rollback", app.Name}
+	if revision > 0 {
+		args = append(args, fmt.Sprint("--to-version=", revision))
+	}
+	return args
+}
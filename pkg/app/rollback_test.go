@@ -0,0 +1,37 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestRollbackToLastSuccessful(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	rollbackCmd := &mocks.ExecCmd{Args: []string{"rollback", "foo"}}
+	rollbackCmd.On("CombinedOutput").Return([]byte(""), nil)
+	statusCmd := &mocks.ExecCmd{Args: []string{"rollout", "status", "dc/foo"}}
+	statusCmd.On("AttachStdIO").Return()
+	statusCmd.On("Run").Return(nil)
+
+	oc.On("Exists", "dc", "foo").Return(true, nil)
+	oc.Execer.On("Oc", rollbackCmd.Args).Return(rollbackCmd)
+	oc.Execer.On("Oc", statusCmd.Args).Return(statusCmd)
+
+	err := app.Rollback(0)
+	assert.Nil(t, err)
+	oc.Execer.AssertExpectations(t)
+	statusCmd.AssertExpectations(t)
+}
+
+func TestRollbackArgsForDeployment(t *testing.T) {
+	app := Application{Name: "foo", kind: "deployment"}
+	assert.Equal(t, []string{"rollout", "undo", "deployment/foo", "--to-revision=3"}, app.rollbackArgs(3))
+
+	app.kind = "dc"
+	assert.Equal(t, []string{"rollback", "foo", "--to-version=3"}, app.rollbackArgs(3))
+}
@@ -0,0 +1,199 @@
+package app
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+// Route is one of the addresses an application is reached at, given
+// in the manifest like Cloud Foundry's as a host with an optional
+// path, such as "example.com/api".
+type Route struct {
+	Route string `json:"route" yaml:"route"`
+}
+
+// routeAnnotation records the manifest route an OpenShift route or
+// Kubernetes ingress was created for.
+const routeAnnotation = "ocf/route"
+
+// ParseRoute splits a route into its host and path, which is empty if
+// the route has none.
+func ParseRoute(route string) (string, string, error) {
+	if strings.Contains(route, "://") {
+		return "", "", errors.New(fmt.Sprintf("Error: Route %s must not include a scheme\n", route))
+	}
+	host, path := route, ""
+	if i := strings.Index(route, "/"); i >= 0 {
+		host, path = route[:i], strings.TrimRight(route[i:], "/")
+	}
+	if host == "" {
+		return "", "", errors.New(fmt.Sprintf("Error: Route %s has no host\n", route))
+	}
+	return strings.ToLower(host), path, nil
+}
+
+// routeName returns the name of the object created for route, unique
+// per application and route but stable across pushes.
+func routeName(appName string, route string) string {
+	sum := sha1.Sum([]byte(route))
+	suffix := hex.EncodeToString(sum[:])[:8]
+	// Leave room for the suffix within the 63 character limit
+	if len(appName) > 54 {
+		appName = appName[:54]
+	}
+	return fmt.Sprint(appName, "-", suffix)
+}
+
+// routeObjectFor returns the OpenShift route, or Kubernetes ingress,
+// that sends requests for route to the application.
+func (app *Application) routeObjectFor(route string) (map[string]interface{}, error) {
+	host, path, err := ParseRoute(route)
+	if err != nil {
+		return nil, err
+	}
+	metadata := map[string]interface{}{
+		"name":        routeName(app.Name, route),
+		"labels":      map[string]string{"app": app.Name},
+		"annotations": map[string]string{routeAnnotation: route},
+	}
+	if app.kubernetes() {
+		if path == "" {
+			path = "/"
+		}
+		return map[string]interface{}{
+			"apiVersion": "networking.k8s.io/v1",
+			"kind":       "Ingress",
+			"metadata":   metadata,
+			"spec": map[string]interface{}{
+				"rules": []interface{}{map[string]interface{}{
+					"host": host,
+					"http": map[string]interface{}{
+						"paths": []interface{}{map[string]interface{}{
+							"path":     path,
+							"pathType": "Prefix",
+							"backend": map[string]interface{}{
+								"service": map[string]interface{}{
+									"name": app.Name,
+									"port": map[string]int{"number": defaultPort},
+								},
+							},
+						}},
+					},
+				}},
+			},
+		}, nil
+	}
+	spec := map[string]interface{}{
+		"host": host,
+		"to":   map[string]string{"kind": "Service", "name": app.Name},
+	}
+	if path != "" {
+		spec["path"] = path
+	}
+	return map[string]interface{}{
+		"apiVersion": "route.openshift.io/v1",
+		"kind":       "Route",
+		"metadata":   metadata,
+		"spec":       spec,
+	}, nil
+}
+
+// ensureRoutes creates or updates a route for each of the application's
+// manifest routes in place of its default route. Internal routes are
+// skipped, since the application's service already serves them.
+func (app *Application) ensureRoutes() error {
+	for _, route := range app.Routes {
+		if internalRoute(route.Route) {
+			continue
+		}
+		err := app.mapRoute(route.Route)
+		if err != nil {
+			return err
+		}
+	}
+	for _, route := range app.Routes {
+		if internalRoute(route.Route) {
+			continue
+		}
+		log.Infof("Your application is available at %s", route.Route)
+	}
+	return nil
+}
+
+// MapRoute sends requests for host and path, if it isn't empty, to
+// the application, like 'cf map-route'.
+func (app *Application) MapRoute(host string, path string) error {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+
+	appExists, err := app.deploymentExists()
+	if err != nil {
+		return err
+	}
+	if !appExists {
+		return errors.New(fmt.Sprintf("Error: Application %s not found\n", app.Name))
+	}
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = fmt.Sprint("/", path)
+	}
+	err = checkHost(host, clusterDomains(app.context(), app.oc))
+	if err != nil {
+		return err
+	}
+	return app.mapRoute(fmt.Sprint(host, path))
+}
+
+func (app *Application) mapRoute(route string) error {
+	object, err := app.routeObjectFor(route)
+	if err != nil {
+		return err
+	}
+	manifest, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	log.Infof("Mapping route %s to %s", route, app.Name)
+	return app.oc.Apply(app.context(), manifest)
+}
+
+// pruneRoutes deletes the routes created for manifest routes that
+// have since been removed from the manifest. Routes created any other
+// way, like the default route, are left alone.
+func (app *Application) pruneRoutes() error {
+	objType := "route"
+	if app.kubernetes() {
+		objType = "ingress"
+	}
+	list := &types.ObjectList{}
+	err := oc.GetSelected(app.context(), app.oc, objType, fmt.Sprint("app=", app.Name), list)
+	if err != nil {
+		return err
+	}
+	wanted := make(map[string]bool)
+	for _, route := range app.Routes {
+		wanted[routeName(app.Name, route.Route)] = true
+	}
+	for _, item := range list.Items {
+		route, ok := item.Metadata.Annotations[routeAnnotation]
+		if !ok || wanted[item.Metadata.Name] {
+			continue
+		}
+		log.Infof("Removing route %s, which is no longer in the manifest", route)
+		err = app.oc.Delete(app.context(), objType, item.Metadata.Name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestParseRoute(t *testing.T) {
+	host, path, err := ParseRoute("Example.com/api/")
+	assert.Nil(t, err)
+	assert.Equal(t, "example.com", host)
+	assert.Equal(t, "/api", path)
+
+	host, path, err = ParseRoute("foo.example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, "foo.example.com", host)
+	assert.Equal(t, "", path)
+
+	_, _, err = ParseRoute("https://example.com")
+	assert.NotNil(t, err)
+	_, _, err = ParseRoute("/api")
+	assert.NotNil(t, err)
+}
+
+func TestRouteObjectWithPath(t *testing.T) {
+	app := Application{oc: mocks.NewMockOc(), Name: "foo"}
+
+	object, err := app.routeObjectFor("example.com/api")
+	assert.Nil(t, err)
+	manifest, err := json.Marshal(object)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"apiVersion":"route.openshift.io/v1","kind":"Route","metadata":{"annotations":{"ocf/route":"example.com/api"},"labels":{"app":"foo"},"name":"`+routeName("foo", "example.com/api")+`"},"spec":{"host":"example.com","path":"/api","to":{"kind":"Service","name":"foo"}}}`, string(manifest))
+}
+
+func TestRouteObjectOnKubernetes(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.PlatformName = "k8s"
+	app := Application{oc: oc, Name: "foo"}
+
+	object, err := app.routeObjectFor("example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, "Ingress", object["kind"])
+	manifest, err := json.Marshal(object["spec"])
+	assert.Nil(t, err)
+	assert.Contains(t, string(manifest), `"host":"example.com"`)
+	assert.Contains(t, string(manifest), `"path":"/"`)
+}
+
+func TestRouteNamesAreStableAndShort(t *testing.T) {
+	assert.Equal(t, routeName("foo", "example.com/api"), routeName("foo", "example.com/api"))
+	assert.NotEqual(t, routeName("foo", "example.com/api"), routeName("foo", "example.com"))
+	long := "a-very-long-application-name-that-goes-on-and-on-and-on-forever"
+	assert.True(t, len(routeName(long, "example.com")) <= 63)
+}
+
+func TestPruneRoutes(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", Routes: []Route{{Route: "example.com/api"}}}
+
+	kept := routeName("foo", "example.com/api")
+	removed := routeName("foo", "example.com/old")
+	listCmd := &mocks.ExecCmd{}
+	listCmd.On("CombinedOutput").Return([]byte(`{"items":[
+		{"metadata":{"name":"foo"}},
+		{"metadata":{"name":"`+kept+`","annotations":{"ocf/route":"example.com/api"}}},
+		{"metadata":{"name":"`+removed+`","annotations":{"ocf/route":"example.com/old"}}}
+	]}`), nil)
+	oc.Execer.On("Oc", []string{"get", "route", "--selector=app=foo", "-o", "json"}).Return(listCmd)
+	oc.On("Delete", "route", removed).Return(nil)
+
+	err := app.pruneRoutes()
+	assert.Nil(t, err)
+	oc.AssertExpectations(t)
+	oc.AssertNumberOfCalls(t, "Delete", 1)
+}
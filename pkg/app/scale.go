@@ -0,0 +1,64 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// CPURegexp matches CPU quantities, in cores or millicores.
+var CPURegexp = regexp.MustCompile("^(\\d+(\\.\\d+)?|\\d+m)$")
+
+// Scale changes how many instances of the application run.
+func (app *Application) Scale(instances int) error {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+	if instances < 0 {
+		return errors.New(fmt.Sprintf("Error: Invalid number of instances %d\n", instances))
+	}
+	exists, err := app.deploymentExists()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New(fmt.Sprintf("Error: Application %s not found\n", app.Name))
+	}
+	if err := app.removeInstanceIndex(); err != nil {
+		return err
+	}
+	log.Infof("Scaling %s to %d instances", app.Name, instances)
+	output, err := app.oc.Exec("scale", fmt.Sprint(app.workloadKind(), "/", app.Name), fmt.Sprint("--replicas=", instances)).CombinedOutput(app.context())
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error scaling %s: %s\n", app.Name, output))
+	}
+	return nil
+}
+
+// ScaleCPU changes the CPU limit and request of the application's
+// instances, which rolls them out again.
+func (app *Application) ScaleCPU(cpu string) error {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+	exists, err := app.deploymentExists()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New(fmt.Sprintf("Error: Application %s not found\n", app.Name))
+	}
+	log.Infof("Scaling %s to %s CPU", app.Name, cpu)
+	output, err := app.oc.Exec("set", "resources", fmt.Sprint(app.workloadKind(), "/", app.Name),
+		fmt.Sprint("--containers=", app.Name), fmt.Sprint("--limits=cpu=", cpu), fmt.Sprint("--requests=cpu=", cpu)).CombinedOutput(app.context())
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error scaling the CPU of %s: %s\n", app.Name, output))
+	}
+	return nil
+}
@@ -0,0 +1,334 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+const (
+	// securityGroupLabel names the security group a network policy
+	// defines or binds
+	securityGroupLabel = "ocf/security-group"
+	// securityGroupAppLabel names the application a binding's
+	// network policy applies to
+	securityGroupAppLabel = "ocf/app"
+	// securityGroupRulesAnnotation holds a security group's rules as
+	// they were given, so they can be bound and listed
+	securityGroupRulesAnnotation = "ocf/rules"
+)
+
+// SecurityGroupRule is one rule of a Cloud Foundry application
+// security group, allowing egress to destination.
+type SecurityGroupRule struct {
+	// Protocol is tcp, udp, or all
+	Protocol string `json:"protocol"`
+	// Destination is an IP address or CIDR block
+	Destination string `json:"destination"`
+	// Ports is a comma separated list of ports or ranges, like
+	// "80,443" or "8000-8100"
+	Ports       string `json:"ports,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// SecurityGroup is a security group and the applications bound to it.
+type SecurityGroup struct {
+	Name  string
+	Rules []SecurityGroupRule
+	Apps  []string
+}
+
+// LoadSecurityGroupRules reads a security group's rules from a JSON
+// file in the format 'cf create-security-group' takes.
+func LoadSecurityGroupRules(path string) ([]SecurityGroupRule, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []SecurityGroupRule
+	err = json.Unmarshal(contents, &rules)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error parsing security group rules in %s: %v", path, err))
+	}
+	return rules, nil
+}
+
+// CreateSecurityGroup creates a security group allowing egress by
+// rules, like 'cf create-security-group'. It's a network policy that
+// selects no pods until applications are bound to it.
+func CreateSecurityGroup(ctx context.Context, name string, rules []SecurityGroupRule) error {
+	app := &Application{ctx: ctx}
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+	return createSecurityGroup(ctx, app.oc, name, rules)
+}
+
+// SecurityGroups returns every security group in the project.
+func SecurityGroups(ctx context.Context) ([]SecurityGroup, error) {
+	app := &Application{ctx: ctx}
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return nil, err
+	}
+	app.displayProject()
+	return securityGroups(ctx, app.oc)
+}
+
+func createSecurityGroup(ctx context.Context, o oc.Oc, name string, rules []SecurityGroupRule) error {
+	exists, err := o.Exists(ctx, "networkpolicy", name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.New(fmt.Sprintf("Error: Security group %s already exists\n", name))
+	}
+	manifest, err := securityGroupPolicy(name, name, rules, map[string]string{securityGroupLabel: name}, "")
+	if err != nil {
+		return err
+	}
+	return o.Apply(ctx, manifest)
+}
+
+// securityGroupPolicy returns a network policy named name that allows
+// the pods matching podLabels egress by the rules of group, binding
+// them to app if it's not empty. The group's own policy selects pods
+// by its label, which none have.
+func securityGroupPolicy(name string, group string, rules []SecurityGroupRule, podLabels map[string]string, app string) ([]byte, error) {
+	var egress []interface{}
+	for _, rule := range rules {
+		ruleEgress, err := rule.egress()
+		if err != nil {
+			return nil, err
+		}
+		egress = append(egress, ruleEgress)
+	}
+	rulesJSON, err := json.Marshal(rules)
+	if err != nil {
+		return nil, err
+	}
+	labels := map[string]string{securityGroupLabel: group}
+	if app != "" {
+		labels[securityGroupAppLabel] = app
+	}
+	return json.Marshal(map[string]interface{}{
+		"apiVersion": "networking.k8s.io/v1",
+		"kind":       "NetworkPolicy",
+		"metadata": map[string]interface{}{
+			"name":        name,
+			"labels":      labels,
+			"annotations": map[string]string{securityGroupRulesAnnotation: string(rulesJSON)},
+		},
+		"spec": map[string]interface{}{
+			"podSelector": map[string]interface{}{"matchLabels": podLabels},
+			"policyTypes": []string{"Egress"},
+			"egress":      egress,
+		},
+	})
+}
+
+// egress returns the network policy egress rule allowing the same
+// traffic as rule.
+func (rule SecurityGroupRule) egress() (map[string]interface{}, error) {
+	cidr, err := ruleCIDR(rule.Destination)
+	if err != nil {
+		return nil, err
+	}
+	egress := map[string]interface{}{
+		"to": []interface{}{map[string]interface{}{"ipBlock": map[string]string{"cidr": cidr}}},
+	}
+	protocol := strings.ToUpper(rule.Protocol)
+	switch protocol {
+	case "ALL":
+		if rule.Ports != "" {
+			return nil, errors.New(fmt.Sprintf("Error: Security group rule for %s can't have ports with protocol all", rule.Destination))
+		}
+		return egress, nil
+	case "TCP", "UDP":
+	case "ICMP":
+		return nil, errors.New(fmt.Sprintf("Error: Security group rule for %s uses icmp, which network policies can't allow", rule.Destination))
+	default:
+		return nil, errors.New(fmt.Sprintf("Error: Security group rule for %s has invalid protocol %s, must be tcp, udp, or all", rule.Destination, rule.Protocol))
+	}
+	var ports []interface{}
+	for _, portRange := range strings.Split(rule.Ports, ",") {
+		portRange = strings.TrimSpace(portRange)
+		if portRange == "" {
+			continue
+		}
+		port, ok := policyPort(protocol, portRange)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("Error: Security group rule for %s has invalid ports %s", rule.Destination, rule.Ports))
+		}
+		ports = append(ports, port)
+	}
+	if len(ports) == 0 {
+		ports = append(ports, map[string]interface{}{"protocol": protocol})
+	}
+	egress["ports"] = ports
+	return egress, nil
+}
+
+// policyPort returns the network policy port for a port, or a range
+// of ports like "8000-8100", and false if it isn't valid.
+func policyPort(protocol string, portRange string) (map[string]interface{}, bool) {
+	port := map[string]interface{}{"protocol": protocol}
+	bounds := strings.SplitN(portRange, "-", 2)
+	for i, bound := range bounds {
+		number, err := strconv.Atoi(strings.TrimSpace(bound))
+		if err != nil || number < 1 || number > 65535 {
+			return nil, false
+		}
+		if i == 0 {
+			port["port"] = number
+		} else {
+			port["endPort"] = number
+		}
+	}
+	return port, true
+}
+
+// ruleCIDR returns destination as a CIDR block, since network policies
+// don't take single addresses or address ranges.
+func ruleCIDR(destination string) (string, error) {
+	if _, _, err := net.ParseCIDR(destination); err == nil {
+		return destination, nil
+	}
+	ip := net.ParseIP(destination)
+	if ip == nil {
+		return "", errors.New(fmt.Sprintf("Error: Security group destination %s must be an IP address or CIDR block", destination))
+	}
+	if ip.To4() != nil {
+		return fmt.Sprint(destination, "/32"), nil
+	}
+	return fmt.Sprint(destination, "/128"), nil
+}
+
+func securityGroups(ctx context.Context, o oc.Oc) ([]SecurityGroup, error) {
+	policies := &types.NetworkPolicyList{}
+	err := oc.GetSelected(ctx, o, "networkpolicy", securityGroupLabel, policies)
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[string]*SecurityGroup)
+	group := func(name string) *SecurityGroup {
+		if groups[name] == nil {
+			groups[name] = &SecurityGroup{Name: name}
+		}
+		return groups[name]
+	}
+	for _, policy := range policies.Items {
+		name := policy.Metadata.Labels[securityGroupLabel]
+		if app, ok := policy.Metadata.Labels[securityGroupAppLabel]; ok {
+			group(name).Apps = append(group(name).Apps, app)
+			continue
+		}
+		rules, err := policyRules(policy)
+		if err != nil {
+			return nil, err
+		}
+		group(name).Rules = rules
+	}
+	var names []string
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var result []SecurityGroup
+	for _, name := range names {
+		sort.Strings(groups[name].Apps)
+		result = append(result, *groups[name])
+	}
+	return result, nil
+}
+
+func policyRules(policy types.NetworkPolicy) ([]SecurityGroupRule, error) {
+	var rules []SecurityGroupRule
+	err := json.Unmarshal([]byte(policy.Metadata.Annotations[securityGroupRulesAnnotation]), &rules)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error parsing the rules of security group %s: %v", policy.Metadata.Name, err))
+	}
+	return rules, nil
+}
+
+// securityGroupBinding names the network policy binding a security
+// group to the application.
+func (app *Application) securityGroupBinding(group string) string {
+	return fmt.Sprint(group, "-", app.Name)
+}
+
+// BindSecurityGroup allows the application's instances the egress the
+// security group does, like 'cf bind-security-group'. Once bound to
+// any security group, instances can only reach what their groups
+// allow.
+func (app *Application) BindSecurityGroup(group string) error {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+
+	appExists, err := app.deploymentExists()
+	if err != nil {
+		return err
+	}
+	if !appExists {
+		return errors.New(fmt.Sprintf("Error: Application %s not found\n", app.Name))
+	}
+	policy := &types.NetworkPolicy{}
+	err = oc.Get(app.context(), app.oc, "networkpolicy", group, policy)
+	if err != nil || policy.Metadata.Labels[securityGroupLabel] != group {
+		return errors.New(fmt.Sprintf("Error: Security group %s not found\n", group))
+	}
+	rules, err := policyRules(*policy)
+	if err != nil {
+		return err
+	}
+	name := app.securityGroupBinding(group)
+	manifest, err := securityGroupPolicy(name, group, rules, app.podLabels(), app.Name)
+	if err != nil {
+		return err
+	}
+	err = app.oc.Apply(app.context(), manifest)
+	if err != nil {
+		return err
+	}
+	app.ownObject("networkpolicy", name)
+	return nil
+}
+
+// UnbindSecurityGroup removes the egress the security group allows
+// the application's instances.
+func (app *Application) UnbindSecurityGroup(group string) error {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+
+	name := app.securityGroupBinding(group)
+	exists, err := app.oc.Exists(app.context(), "networkpolicy", name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New(fmt.Sprintf("Error: Security group %s isn't bound to %s\n", group, app.Name))
+	}
+	err = app.oc.Delete(app.context(), "networkpolicy", name)
+	if err != nil {
+		return err
+	}
+	app.disown("networkpolicy", name)
+	return nil
+}
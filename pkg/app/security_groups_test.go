@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestSecurityGroupRuleEgress(t *testing.T) {
+	egress, err := SecurityGroupRule{Protocol: "tcp", Destination: "10.0.11.0/24", Ports: "80, 8000-8100"}.egress()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"to": []interface{}{map[string]interface{}{"ipBlock": map[string]string{"cidr": "10.0.11.0/24"}}},
+		"ports": []interface{}{
+			map[string]interface{}{"protocol": "TCP", "port": 80},
+			map[string]interface{}{"protocol": "TCP", "port": 8000, "endPort": 8100},
+		},
+	}, egress)
+
+	egress, err = SecurityGroupRule{Protocol: "all", Destination: "10.0.0.1"}.egress()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"to": []interface{}{map[string]interface{}{"ipBlock": map[string]string{"cidr": "10.0.0.1/32"}}},
+	}, egress)
+
+	for _, rule := range []SecurityGroupRule{
+		{Protocol: "icmp", Destination: "0.0.0.0/0"},
+		{Protocol: "sctp", Destination: "0.0.0.0/0"},
+		{Protocol: "tcp", Destination: "10.0.0.1-10.0.0.9", Ports: "443"},
+		{Protocol: "tcp", Destination: "0.0.0.0/0", Ports: "http"},
+		{Protocol: "all", Destination: "0.0.0.0/0", Ports: "443"},
+	} {
+		_, err = rule.egress()
+		assert.NotNil(t, err, rule.Protocol, rule.Destination)
+	}
+}
+
+func TestLoadSecurityGroupRules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocf-security-groups")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "rules.json")
+	ioutil.WriteFile(path, []byte(`[{"protocol":"tcp","destination":"10.0.11.0/24","ports":"5432"}]`), 0644)
+
+	rules, err := LoadSecurityGroupRules(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []SecurityGroupRule{{Protocol: "tcp", Destination: "10.0.11.0/24", Ports: "5432"}}, rules)
+}
+
+func TestBindSecurityGroup(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.On("Exists", "dc", "foo").Return(true, nil)
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(`{"metadata":{"name":"databases","labels":{"ocf/security-group":"databases"},
+		"annotations":{"ocf/rules":"[{\"protocol\":\"tcp\",\"destination\":\"10.0.11.0/24\",\"ports\":\"5432\"}]"}}}`), nil)
+	oc.Execer.On("Oc", []string{"get", "networkpolicy", "databases", "-o", "json"}).Return(getCmd)
+	var applied map[string]interface{}
+	oc.On("Apply", mock.Anything).Run(func(args mock.Arguments) {
+		json.Unmarshal(args.Get(0).([]byte), &applied)
+	}).Return(nil)
+
+	app := Application{oc: oc, Name: "foo"}
+	assert.Nil(t, app.BindSecurityGroup("databases"))
+	metadata := applied["metadata"].(map[string]interface{})
+	assert.Equal(t, "databases-foo", metadata["name"])
+	assert.Equal(t, map[string]interface{}{"ocf/security-group": "databases", "ocf/app": "foo"}, metadata["labels"])
+	spec := applied["spec"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"matchLabels": map[string]interface{}{"deploymentconfig": "foo"}}, spec["podSelector"])
+	assert.Equal(t, 1, len(spec["egress"].([]interface{})))
+	assert.Equal(t, []string{"networkpolicy/databases-foo"}, oc.OwnedObjects["foo"])
+}
+
+func TestSecurityGroups(t *testing.T) {
+	oc := mocks.NewMockOc()
+	listCmd := &mocks.ExecCmd{}
+	listCmd.On("CombinedOutput").Return([]byte(`{"items":[
+		{"metadata":{"name":"databases","labels":{"ocf/security-group":"databases"},"annotations":{"ocf/rules":"[{\"protocol\":\"tcp\",\"destination\":\"10.0.11.0/24\",\"ports\":\"5432\"}]"}}},
+		{"metadata":{"name":"databases-foo","labels":{"ocf/security-group":"databases","ocf/app":"foo"}}},
+		{"metadata":{"name":"dns","labels":{"ocf/security-group":"dns"},"annotations":{"ocf/rules":"[]"}}}
+	]}`), nil)
+	oc.Execer.On("Oc", []string{"get", "networkpolicy", "--selector=ocf/security-group", "-o", "json"}).Return(listCmd)
+
+	groups, err := securityGroups(context.Background(), oc)
+	assert.Nil(t, err)
+	assert.Equal(t, []SecurityGroup{
+		{Name: "databases", Rules: []SecurityGroupRule{{Protocol: "tcp", Destination: "10.0.11.0/24", Ports: "5432"}}, Apps: []string{"foo"}},
+		{Name: "dns", Rules: []SecurityGroupRule{}},
+	}, groups)
+}
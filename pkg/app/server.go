@@ -0,0 +1,441 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/oc"
+)
+
+const (
+	// maxRequestBody limits the size of API requests.
+	maxRequestBody = 1 << 20
+	// maxPushBody limits the size of push requests, which upload the
+	// application's source.
+	maxPushBody = 1 << 30
+	// maxPushMemory is how much of a push request is kept in memory
+	// before it's spooled to disk.
+	maxPushMemory = 32 << 20
+)
+
+// appNameRegexp matches the DNS-1123 labels application names become
+// in object names.
+var appNameRegexp = regexp.MustCompile("^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$")
+
+// Server serves pushing, binding, scaling, and deleting applications
+// as an HTTP and JSON API, so tools written against the Cloud Foundry
+// API can be pointed at ocf.
+type Server struct {
+	// Token is the bearer token every request must give
+	Token string
+	// Options are used for every push
+	Options PushOptions
+	// Operations share global state, like where commands write, so
+	// only one runs at a time
+	mutex sync.Mutex
+	// oc, if set, is used by every operation instead of the default
+	oc oc.Oc
+}
+
+// PushRequest is the application a push request describes, in manifest
+// form. Hooks run commands and path reads files on the machine pushing,
+// so neither can be given to the server. The source is uploaded with
+// the request instead.
+type PushRequest struct {
+	Buildpack    string            `json:"buildpack"`
+	Stack        string            `json:"stack"`
+	Command      string            `json:"command"`
+	DiskQuota    string            `json:"disk_quota"`
+	Instances    int               `json:"instances"`
+	Memory       string            `json:"memory"`
+	CPU          string            `json:"cpu,omitempty"`
+	Timeout      int               `json:"timeout,omitempty"`
+	GracePeriod  int               `json:"termination-grace-period,omitempty"`
+	Services     []string          `json:"services"`
+	Docker       *Docker           `json:"docker,omitempty"`
+	BuildEnv     map[string]string `json:"build-env,omitempty"`
+	Routes       []Route           `json:"routes,omitempty"`
+	Processes    []Process         `json:"processes,omitempty"`
+	Sidecars     []Sidecar         `json:"sidecars,omitempty"`
+	Project      string            `json:"project,omitempty"`
+	NodeSelector map[string]string `json:"node-selector,omitempty"`
+	Tolerations  []Toleration      `json:"tolerations,omitempty"`
+}
+
+// check returns an error if the request has a value that isn't safe
+// to pass to oc, like a service name that would be taken as a flag.
+func (request *PushRequest) check() error {
+	for _, service := range request.Services {
+		if !appNameRegexp.MatchString(service) {
+			return errors.New(fmt.Sprintf("Error: Invalid service name %s", service))
+		}
+	}
+	if request.CPU != "" && !CPURegexp.MatchString(request.CPU) {
+		return errors.New(fmt.Sprintf("Error: Invalid CPU %s", request.CPU))
+	}
+	return nil
+}
+
+// application returns the application named name the request
+// describes.
+func (request *PushRequest) application(name string) *Application {
+	return &Application{
+		Name:         name,
+		Buildpack:    request.Buildpack,
+		Stack:        request.Stack,
+		Command:      request.Command,
+		DiskQuota:    request.DiskQuota,
+		Instances:    request.Instances,
+		Memory:       request.Memory,
+		CPU:          request.CPU,
+		Timeout:      request.Timeout,
+		GracePeriod:  request.GracePeriod,
+		Services:     request.Services,
+		Docker:       request.Docker,
+		BuildEnv:     request.BuildEnv,
+		Routes:       request.Routes,
+		Processes:    request.Processes,
+		Sidecars:     request.Sidecars,
+		Project:      request.Project,
+		NodeSelector: request.NodeSelector,
+		Tolerations:  request.Tolerations,
+	}
+}
+
+// BindRequest is the body of a request to bind a service.
+type BindRequest struct {
+	Service    string          `json:"service"`
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+	// Staging binds the service to the application's builds too
+	Staging bool `json:"staging,omitempty"`
+}
+
+// ScaleRequest is the body of a request to scale an application.
+type ScaleRequest struct {
+	Instances int `json:"instances"`
+	// CPU, if set, also changes the CPU limit of each instance
+	CPU string `json:"cpu,omitempty"`
+}
+
+// Handler returns the API's routes:
+//
+//	GET    /v1/apps                  list applications
+//	POST   /v1/apps/NAME/push        push, streaming events. The body is
+//	                                 a PushRequest, or a multipart form
+//	                                 with it in "app" and a zip of the
+//	                                 source in "source"
+//	POST   /v1/apps/NAME/bindings    bind a service
+//	PUT    /v1/apps/NAME/instances   scale
+//	DELETE /v1/apps/NAME             delete
+func (server *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/apps", server.handleApps)
+	mux.HandleFunc("/v1/apps/", server.handleApp)
+	return server.authenticate(mux)
+}
+
+func (server *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if server.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(server.Token)) != 1 {
+			writeJSONError(w, http.StatusUnauthorized, errors.New("Error: Missing or invalid bearer token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (server *Server) handleApps(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, errors.New(fmt.Sprintf("Error: %s not allowed", r.Method)))
+		return
+	}
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	app := server.app(r.Context(), "")
+	if !server.checkSession(w, app) {
+		return
+	}
+	summaries, err := listApplications(r.Context(), app.oc)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+func (server *Server) handleApp(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/apps/"), "/")
+	name, action := parts[0], ""
+	if len(parts) == 2 {
+		action = parts[1]
+	}
+	if name == "" || len(parts) > 2 {
+		writeJSONError(w, http.StatusNotFound, errors.New(fmt.Sprintf("Error: %s not found", r.URL.Path)))
+		return
+	}
+	if !appNameRegexp.MatchString(name) {
+		writeJSONError(w, http.StatusBadRequest, errors.New(fmt.Sprintf("Error: Invalid application name %s", name)))
+		return
+	}
+	route := fmt.Sprint(r.Method, " ", action)
+	switch route {
+	case "POST push":
+		server.push(w, r, name)
+	case "POST bindings":
+		request := &BindRequest{}
+		if !readJSON(w, r, request) {
+			return
+		}
+		if !appNameRegexp.MatchString(request.Service) {
+			writeJSONError(w, http.StatusBadRequest, errors.New(fmt.Sprintf("Error: Invalid service name %s", request.Service)))
+			return
+		}
+		server.run(w, r, func() error {
+			app := server.app(r.Context(), name)
+			err := app.BindService(request.Service, string(request.Parameters))
+			if err != nil || !request.Staging {
+				return err
+			}
+			return app.BindServiceToBuild(request.Service, string(request.Parameters))
+		})
+	case "PUT instances":
+		request := &ScaleRequest{}
+		if !readJSON(w, r, request) {
+			return
+		}
+		if request.CPU != "" && !CPURegexp.MatchString(request.CPU) {
+			writeJSONError(w, http.StatusBadRequest, errors.New(fmt.Sprintf("Error: Invalid CPU %s", request.CPU)))
+			return
+		}
+		server.run(w, r, func() error {
+			err := server.app(r.Context(), name).Scale(request.Instances)
+			if err != nil || request.CPU == "" {
+				return err
+			}
+			return server.app(r.Context(), name).ScaleCPU(request.CPU)
+		})
+	case "DELETE ":
+		server.run(w, r, func() error {
+			return server.app(r.Context(), name).Delete()
+		})
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, errors.New(fmt.Sprintf("Error: %s %s not allowed", r.Method, r.URL.Path)))
+	}
+}
+
+// app returns an application named name using the server's oc, whose
+// commands are cancelled with ctx.
+func (server *Server) app(ctx context.Context, name string) *Application {
+	app := &Application{Name: name, oc: server.oc, ctx: ctx}
+	app.setupDefaults()
+	return app
+}
+
+// checkSession checks that the cluster can be reached and ocf is still
+// logged in to it, responding with an error if not, so an expired
+// session fails the request instead of the operation part way through.
+func (server *Server) checkSession(w http.ResponseWriter, app *Application) bool {
+	_, err := app.oc.Capabilities(app.context())
+	if err != nil {
+		writeJSONError(w, http.StatusServiceUnavailable, err)
+		return false
+	}
+	err = app.checkLoggedIn()
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, err)
+		return false
+	}
+	return true
+}
+
+// run runs an operation for request r, responding with its error if
+// it fails.
+func (server *Server) run(w http.ResponseWriter, r *http.Request, operation func() error) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	if !server.checkSession(w, server.app(r.Context(), "")) {
+		return
+	}
+	err := operation()
+	if err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// push pushes the application in the request, streaming the events of
+// the push as server-sent events until an EventDone event.
+func (server *Server) push(w http.ResponseWriter, r *http.Request, name string) {
+	app, cleanup, err := readPushRequest(w, r, name)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer cleanup()
+	app.oc = server.oc
+	app.ctx = r.Context()
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSONError(w, http.StatusInternalServerError, errors.New("Error: Streaming isn't supported"))
+		return
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	if !server.checkSession(w, server.app(r.Context(), name)) {
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	events := NewEventStream(&sseWriter{w: w, flusher: flusher})
+	exec.Stdout, exec.Stderr = events, events
+	defer func() { exec.Stdout, exec.Stderr = defaultStdout, defaultStderr }()
+
+	options := server.Options
+	options.Events = events
+	err = app.Push(options)
+	events.Done(app.Name, err)
+}
+
+// readPushRequest reads the application a push request describes,
+// either a PushRequest body or a multipart form with one in its "app"
+// field and a zip archive of the source in its "source" file. The
+// returned function removes the extracted source.
+func readPushRequest(w http.ResponseWriter, r *http.Request, name string) (*Application, func(), error) {
+	cleanup := func() {}
+	request := &PushRequest{}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		err := decodeJSON(http.MaxBytesReader(w, r.Body, maxRequestBody), request)
+		if err == nil {
+			err = request.check()
+		}
+		if err != nil {
+			return nil, cleanup, err
+		}
+		app := request.application(name)
+		if !app.IsDocker() {
+			return nil, cleanup, errors.New("Error: A zip archive of the source must be uploaded in the \"source\" field of a multipart form")
+		}
+		return app, cleanup, nil
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxPushBody)
+	err := r.ParseMultipartForm(maxPushMemory)
+	if err != nil {
+		return nil, cleanup, errors.New(fmt.Sprintf("Error: Invalid request body: %v", err))
+	}
+	cleanup = func() { r.MultipartForm.RemoveAll() }
+	err = decodeJSON(strings.NewReader(r.FormValue("app")), request)
+	if err == nil {
+		err = request.check()
+	}
+	if err != nil {
+		return nil, cleanup, err
+	}
+	app := request.application(name)
+	source, _, err := r.FormFile("source")
+	if err == http.ErrMissingFile && app.IsDocker() {
+		return app, cleanup, nil
+	} else if err != nil {
+		return nil, cleanup, errors.New(fmt.Sprintf("Error: Invalid source archive: %v", err))
+	}
+	defer source.Close()
+
+	dir, err := ioutil.TempDir("", "ocf-serve")
+	if err != nil {
+		return nil, cleanup, err
+	}
+	removeForm := cleanup
+	cleanup = func() {
+		removeForm()
+		os.RemoveAll(dir)
+	}
+	err = extractUpload(source, dir)
+	if err != nil {
+		return nil, cleanup, err
+	}
+	app.Path = dir
+	return app, cleanup, nil
+}
+
+var defaultStdout, defaultStderr = exec.Stdout, exec.Stderr
+
+// sseWriter sends each line written to it as a server-sent event.
+type sseWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func (sse *sseWriter) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		_, err := fmt.Fprintf(sse.w, "data: %s\n\n", line)
+		if err != nil {
+			return 0, err
+		}
+	}
+	sse.flusher.Flush()
+	return len(p), nil
+}
+
+// extractUpload extracts an uploaded zip archive into dir.
+func extractUpload(source multipart.File, dir string) error {
+	size, err := source.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	reader, err := zip.NewReader(source, size)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error: Invalid source archive: %v", err))
+	}
+	return extractZipFiles(reader.File, dir)
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, body interface{}) bool {
+	err := decodeJSON(http.MaxBytesReader(w, r.Body, maxRequestBody), body)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return false
+	}
+	return true
+}
+
+// decodeJSON decodes a request body, refusing fields body doesn't have
+// so they aren't silently ignored.
+func decodeJSON(r io.Reader, body interface{}) error {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(body)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error: Invalid request body: %v", err))
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": strings.TrimSpace(err.Error())})
+}
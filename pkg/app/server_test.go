@@ -0,0 +1,145 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func serve(server *Server, method string, path string, token string, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestServerRequiresToken(t *testing.T) {
+	server := &Server{Token: "secret"}
+	assert.Equal(t, http.StatusUnauthorized, serve(server, "GET", "/v1/apps", "", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(server, "GET", "/v1/apps", "wrong", "").Code)
+
+	// A server without a token refuses everything
+	assert.Equal(t, http.StatusUnauthorized, serve(&Server{}, "GET", "/v1/apps", "", "").Code)
+}
+
+func TestServerRoutes(t *testing.T) {
+	server := &Server{Token: "secret"}
+	response := serve(server, "POST", "/v1/apps", "secret", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, response.Code)
+	assert.Contains(t, response.Body.String(), `"error":"Error: POST not allowed"`)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(server, "GET", "/v1/apps/foo/push", "secret", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve(server, "DELETE", "/v1/apps/foo/bar/baz", "secret", "").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(server, "PUT", "/v1/apps/foo/instances", "secret", "{").Code)
+}
+
+func TestServerRejectsInvalidNames(t *testing.T) {
+	server := &Server{Token: "secret"}
+	response := serve(server, "DELETE", "/v1/apps/Foo;rm", "secret", "")
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Contains(t, response.Body.String(), "Invalid application name")
+}
+
+func TestServerRejectsHooksAndPaths(t *testing.T) {
+	server := &Server{Token: "secret", oc: mocks.NewMockOc()}
+	for _, body := range []string{
+		`{"docker": {"image": "nginx"}, "hooks": {"pre-push": "rm -rf /"}}`,
+		`{"docker": {"image": "nginx"}, "path": "/etc"}`,
+		`{"memory": "512M"}`,
+	} {
+		assert.Equal(t, http.StatusBadRequest, serve(server, "POST", "/v1/apps/foo/push", "secret", body).Code, body)
+	}
+}
+
+func TestServerRejectsFlagLikeServices(t *testing.T) {
+	// Nothing reaches oc, so no calls are expected
+	oc := mocks.NewMockOc()
+	server := &Server{Token: "secret", oc: oc}
+	response := serve(server, "POST", "/v1/apps/foo/bindings", "secret", `{"service": "--server=https://attacker"}`)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Contains(t, response.Body.String(), "Invalid service name --server=https://attacker")
+
+	response = serve(server, "POST", "/v1/apps/foo/push", "secret",
+		`{"docker": {"image": "nginx"}, "services": ["db", "--kubeconfig=/tmp/evil"]}`)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Contains(t, response.Body.String(), "Invalid service name --kubeconfig=/tmp/evil")
+	oc.AssertExpectations(t)
+	oc.Execer.AssertExpectations(t)
+}
+
+func TestServerRejectsInvalidCPU(t *testing.T) {
+	oc := mocks.NewMockOc()
+	server := &Server{Token: "secret", oc: oc}
+	response := serve(server, "PUT", "/v1/apps/foo/instances", "secret", `{"instances": 2, "cpu": "1 --server=https://attacker"}`)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Contains(t, response.Body.String(), "Invalid CPU")
+
+	response = serve(server, "POST", "/v1/apps/foo/push", "secret", `{"docker": {"image": "nginx"}, "cpu": "--limits=x"}`)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Contains(t, response.Body.String(), "Invalid CPU --limits=x")
+	oc.AssertExpectations(t)
+	oc.Execer.AssertExpectations(t)
+}
+
+func TestServerChecksSession(t *testing.T) {
+	os.Unsetenv(LoginTokenEnv)
+	NonInteractive = true
+	defer func() { NonInteractive = false }()
+
+	server := &Server{Token: "secret", oc: new(mocks.Oc)}
+	assert.Equal(t, http.StatusUnauthorized, serve(server, "GET", "/v1/apps", "secret", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(server, "DELETE", "/v1/apps/foo", "secret", "").Code)
+}
+
+func TestReadPushRequestExtractsSource(t *testing.T) {
+	var archive bytes.Buffer
+	zipWriter := zip.NewWriter(&archive)
+	file, _ := zipWriter.Create("app.rb")
+	file.Write([]byte("puts 'hi'"))
+	zipWriter.Close()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("app", `{"memory": "512M"}`)
+	source, _ := form.CreateFormFile("source", "source.zip")
+	source.Write(archive.Bytes())
+	form.Close()
+
+	request := httptest.NewRequest("POST", "/v1/apps/foo/push", &body)
+	request.Header.Set("Content-Type", form.FormDataContentType())
+	app, cleanup, err := readPushRequest(httptest.NewRecorder(), request, "foo")
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", app.Name)
+	assert.Equal(t, "512M", app.Memory)
+	contents, err := ioutil.ReadFile(filepath.Join(app.Path, "app.rb"))
+	assert.Nil(t, err)
+	assert.Equal(t, "puts 'hi'", string(contents))
+
+	cleanup()
+	_, err = os.Stat(app.Path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSSEWriter(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	var buf bytes.Buffer
+	stream := NewEventStream(&sseWriter{w: &buf, flusher: recorder})
+	stream.Done("foo", nil)
+	assert.True(t, strings.HasPrefix(buf.String(), `data: {"time":`))
+	assert.Contains(t, buf.String(), `"type":"done","app":"foo","status":"ok"}`+"\n\n")
+	assert.True(t, recorder.Flushed)
+}
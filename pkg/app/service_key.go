@@ -0,0 +1,189 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+)
+
+const (
+	serviceKeyServiceLabel string = "ocf-service"
+	serviceKeyNameLabel    string = "ocf-service-key"
+)
+
+// serviceKeyNameRegexp matches the key names that can be part of a
+// secret's name and, with dashes replaced, a database user's.
+var serviceKeyNameRegexp = regexp.MustCompile("^[a-z][a-z0-9-]*$")
+
+// sqlIdentifierRegexp matches the database user and database names
+// that are put in SQL without quoting.
+var sqlIdentifierRegexp = regexp.MustCompile("^[a-z][a-z0-9_]*$")
+
+// CreateServiceKey creates credentials for service that can be used
+// outside of any application, storing them in a secret. A new
+// database user is created for PostgreSQL and MySQL (when its root
+// password is known); other services get a copy of their existing
+// credentials.
+func CreateServiceKey(ctx context.Context, service string, keyName string) (string, error) {
+	app := &Application{ctx: ctx}
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return "", err
+	}
+	app.displayProject()
+	return createServiceKey(ctx, app.oc, service, keyName)
+}
+
+// ServiceKeys returns the names of all keys created for service.
+func ServiceKeys(ctx context.Context, service string) ([]string, error) {
+	app := &Application{ctx: ctx}
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return nil, err
+	}
+	app.displayProject()
+	return serviceKeys(ctx, app.oc, service)
+}
+
+func serviceKeySecretName(service string, keyName string) string {
+	return fmt.Sprint(service, "-key-", keyName)
+}
+
+func createServiceKey(ctx context.Context, o oc.Oc, service string, keyName string) (string, error) {
+	if !serviceKeyNameRegexp.MatchString(keyName) {
+		return "", errors.New(fmt.Sprintf("Error: Invalid service key name %s, must start with a letter and have only lowercase letters, digits, and dashes\n", keyName))
+	}
+	secretName := serviceKeySecretName(service, keyName)
+	exists, err := o.Exists(ctx, "secret", secretName)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return "", errors.New(fmt.Sprintf("Error: Service key %s already exists for service %s\n", keyName, service))
+	}
+
+	app := &Application{oc: o, ctx: ctx}
+	kind, err := app.serviceKind(service)
+	if err != nil {
+		return "", err
+	}
+
+	var credentials map[string]string
+	if kind == "dc" {
+		credentials, err = createDatabaseServiceKey(app, service, keyName)
+	} else {
+		credentials, err = o.Data(ctx, kind, service)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	err = o.CreateSecret(ctx, secretName, credentials)
+	if err != nil {
+		return "", err
+	}
+	output, err := o.Exec("label", "secret", secretName,
+		fmt.Sprint(serviceKeyServiceLabel, "=", service),
+		fmt.Sprint(serviceKeyNameLabel, "=", keyName)).CombinedOutput(ctx)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("Error labeling secret %s: %s\n", secretName, output))
+	}
+	return secretName, nil
+}
+
+func createDatabaseServiceKey(app *Application, service string, keyName string) (map[string]string, error) {
+	const envPrefix = "KEY"
+	env, err := app.envForServiceBinding(service, envPrefix)
+	if err != nil {
+		return nil, err
+	}
+	credentials := map[string]string{
+		"username": env["KEY_USER"],
+		"password": env["KEY_PASSWORD"],
+		"database": env["KEY_DATABASE"],
+		"label":    env["KEY_LABEL"],
+	}
+
+	serviceEnv, err := app.oc.Env(app.context(), "dc", service)
+	if err != nil {
+		return nil, err
+	}
+	var createUser []string
+	var sql string
+	username := strings.Replace(fmt.Sprint(keyName, "_key"), "-", "_", -1)
+	database := credentials["database"]
+	if !sqlIdentifierRegexp.MatchString(username) || !sqlIdentifierRegexp.MatchString(database) {
+		return nil, errors.New(fmt.Sprintf("Error: Unable to create a database user %s for database %s\n", username, database))
+	}
+	password, err := generatePassword()
+	if err != nil {
+		return nil, err
+	}
+	// The SQL is sent over stdin so the new password isn't on any
+	// command line
+	switch credentials["label"] {
+	case "postgresql":
+		sql = fmt.Sprintf("CREATE USER %s WITH PASSWORD '%s'; GRANT ALL PRIVILEGES ON DATABASE %s TO %s;",
+			username, password, database, username)
+		createUser = []string{"psql", "-d", database}
+	case "mysql":
+		if serviceEnv["MYSQL_ROOT_PASSWORD"] != "" {
+			sql = fmt.Sprintf("CREATE USER '%s'@'%%' IDENTIFIED BY '%s'; GRANT ALL ON %s.* TO '%s'@'%%';",
+				username, password, database, username)
+			// The root password is passed from the container's own
+			// environment for the same reason
+			createUser = []string{"sh", "-c", `MYSQL_PWD="$MYSQL_ROOT_PASSWORD" exec mysql -uroot`}
+		}
+	}
+
+	if createUser != nil {
+		execArgs := append([]string{"exec", "-i", fmt.Sprint("dc/", service), "--"}, createUser...)
+		log.Infof("Creating database user %s for service key %s", username, keyName)
+		execCmd := app.oc.Exec(execArgs...)
+		execCmd.SetStdin(strings.NewReader(sql))
+		output, err := execCmd.CombinedOutput(app.context())
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Error creating database user: %s\n", output))
+		}
+		credentials["username"] = username
+		credentials["password"] = password
+	} else {
+		log.Infof("Copying existing credentials of %s for service key %s", service, keyName)
+	}
+
+	uri, err := app.serviceURI(service, credentials["label"], credentials["username"],
+		credentials["password"], credentials["database"])
+	if err == nil && uri != "" {
+		credentials["uri"] = uri
+	}
+	return credentials, nil
+}
+
+func serviceKeys(ctx context.Context, o oc.Oc, service string) ([]string, error) {
+	secretNames, err := o.List(ctx, "secret", fmt.Sprint(serviceKeyServiceLabel, "=", service))
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	prefix := serviceKeySecretName(service, "")
+	for _, secretName := range secretNames {
+		keys = append(keys, strings.TrimPrefix(secretName, prefix))
+	}
+	return keys, nil
+}
+
+func generatePassword() (string, error) {
+	bytes := make([]byte, 16)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
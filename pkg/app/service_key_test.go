@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestCreateServiceKeyCopiesSecret(t *testing.T) {
+	oc := mocks.NewMockOc()
+	credentials := map[string]string{"token": "abc"}
+
+	oc.On("Exists", "secret", "api-key-ci").Return(false, nil)
+	oc.On("Exists", "dc", "api").Return(false, nil)
+	oc.On("Exists", "secret", "api").Return(true, nil)
+	oc.On("Data", "secret", "api").Return(credentials, nil)
+	oc.On("CreateSecret", "api-key-ci", credentials).Return(nil)
+	labelCmd := &mocks.ExecCmd{}
+	labelCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", []string{"label", "secret", "api-key-ci", "ocf-service=api", "ocf-service-key=ci"}).Return(labelCmd)
+
+	secretName, err := createServiceKey(context.Background(), oc, "api", "ci")
+	assert.Nil(t, err)
+	assert.Equal(t, "api-key-ci", secretName)
+	oc.AssertExpectations(t)
+	oc.Execer.AssertExpectations(t)
+}
+
+func TestCreateServiceKeyCreatesPostgresUser(t *testing.T) {
+	oc := mocks.NewMockOc()
+	serviceEnv := map[string]string{
+		"POSTGRESQL_USER":     "app",
+		"POSTGRESQL_PASSWORD": "pass",
+		"POSTGRESQL_DATABASE": "db",
+	}
+
+	oc.On("Exists", "secret", "pg-key-ci").Return(false, nil)
+	oc.On("Exists", "dc", "pg").Return(true, nil)
+	oc.On("Env", "dc", "pg").Return(serviceEnv, nil)
+	oc.On("ServiceAddress", "pg").Return("172.30.1.2:5432", nil)
+	execCmd := &mocks.ExecCmd{}
+	execCmd.On("CombinedOutput").Return([]byte(""), nil)
+	psqlCmd := &mocks.ExecCmd{}
+	psqlCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", []string{"exec", "-i", "dc/pg", "--", "psql", "-d", "db"}).Return(psqlCmd)
+	oc.On("CreateSecret", "pg-key-ci", mock.MatchedBy(func(data map[string]string) bool {
+		return data["username"] == "ci_key" && data["password"] != "pass" &&
+			data["database"] == "db" && data["label"] == "postgresql"
+	})).Return(nil)
+	oc.Execer.On("Oc", []string{"label", "secret", "pg-key-ci", "ocf-service=pg", "ocf-service-key=ci"}).Return(execCmd)
+
+	_, err := createServiceKey(context.Background(), oc, "pg", "ci")
+	assert.Nil(t, err)
+	oc.AssertExpectations(t)
+	oc.Execer.AssertExpectations(t)
+	sql, _ := ioutil.ReadAll(psqlCmd.Stdin)
+	assert.Contains(t, string(sql), "CREATE USER ci_key WITH PASSWORD '")
+}
+
+func TestServiceKeys(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.On("List", "secret", "ocf-service=pg").Return([]string{"pg-key-ci", "pg-key-admin"}, nil)
+	keys, err := serviceKeys(context.Background(), oc, "pg")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ci", "admin"}, keys)
+}
+
+func TestCreateServiceKeyCreatesMySQLUser(t *testing.T) {
+	oc := mocks.NewMockOc()
+	serviceEnv := map[string]string{
+		"MYSQL_USER":          "app",
+		"MYSQL_PASSWORD":      "pass",
+		"MYSQL_DATABASE":      "db",
+		"MYSQL_ROOT_PASSWORD": "r00t",
+	}
+
+	oc.On("Exists", "secret", "mysql-key-ci").Return(false, nil)
+	oc.On("Exists", "dc", "mysql").Return(true, nil)
+	oc.On("Env", "dc", "mysql").Return(serviceEnv, nil)
+	oc.On("ServiceAddress", "mysql").Return("172.30.1.2:3306", nil)
+	execCmd := &mocks.ExecCmd{}
+	execCmd.On("CombinedOutput").Return([]byte(""), nil)
+	mysqlCmd := &mocks.ExecCmd{}
+	mysqlCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", []string{"exec", "-i", "dc/mysql", "--", "sh", "-c",
+		`MYSQL_PWD="$MYSQL_ROOT_PASSWORD" exec mysql -uroot`}).Return(mysqlCmd)
+	oc.On("CreateSecret", "mysql-key-ci", mock.MatchedBy(func(data map[string]string) bool {
+		return data["username"] == "ci_key" && data["label"] == "mysql"
+	})).Return(nil)
+	oc.Execer.On("Oc", []string{"label", "secret", "mysql-key-ci", "ocf-service=mysql", "ocf-service-key=ci"}).Return(execCmd)
+
+	_, err := createServiceKey(context.Background(), oc, "mysql", "ci")
+	assert.Nil(t, err)
+	oc.AssertExpectations(t)
+	oc.Execer.AssertExpectations(t)
+	sql, _ := ioutil.ReadAll(mysqlCmd.Stdin)
+	assert.True(t, strings.HasPrefix(string(sql), "CREATE USER 'ci_key'"))
+}
+
+func TestCreateServiceKeyRejectsInvalidNames(t *testing.T) {
+	oc := mocks.NewMockOc()
+	for _, keyName := range []string{"ci; DROP TABLE users", "ci key", "CI", "1ci", "ci_key"} {
+		_, err := createServiceKey(context.Background(), oc, "pg", keyName)
+		assert.NotNil(t, err, keyName)
+	}
+	oc.AssertNotCalled(t, "Exists", mock.Anything, mock.Anything)
+}
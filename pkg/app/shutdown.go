@@ -0,0 +1,43 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// signalForwarder runs the command given as its first argument and
+// passes the SIGTERM Kubernetes sends the container's first process on
+// to every process the command starts, as Cloud Foundry does, waiting
+// for them to exit. A plain 'sh -c' leaves compound commands' processes
+// to be killed once the grace period ends.
+const signalForwarder = `trap 'trap "" TERM; kill -TERM 0; wait' TERM
+/bin/sh -c "$1" &
+wait $!`
+
+// ensureGracePeriod sets how long the instances of the application's
+// existing deployment config or deployment have to exit after
+// SIGTERM, which only rolls it out again if it changed.
+func (app *Application) ensureGracePeriod() error {
+	if app.GracePeriod <= 0 {
+		return nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]int{"terminationGracePeriodSeconds": app.GracePeriod},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("Giving %s %d seconds to shut down", app.Name, app.GracePeriod)
+	output, err := app.oc.Exec("patch", app.workloadKind(), app.Name, "-p", string(patch)).CombinedOutput(app.context())
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error setting the termination grace period of %s: %s\n", app.Name, output))
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestEnsureGracePeriod(t *testing.T) {
+	oc := mocks.NewMockOc()
+	patchCmd := &mocks.ExecCmd{}
+	patchCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", []string{"patch", "deployment", "foo", "-p",
+		`{"spec":{"template":{"spec":{"terminationGracePeriodSeconds":60}}}}`}).Return(patchCmd)
+
+	app := Application{oc: oc, Name: "foo", kind: "deployment", GracePeriod: 60}
+	assert.Nil(t, app.ensureGracePeriod())
+	oc.Execer.AssertExpectations(t)
+
+	// Without a grace period the cluster's default is kept
+	app = Application{oc: mocks.NewMockOc(), Name: "foo", kind: "deployment"}
+	assert.Nil(t, app.ensureGracePeriod())
+}
+
+func TestDeploymentManifestGracePeriod(t *testing.T) {
+	app := Application{Name: "foo", GracePeriod: 45}
+	manifest, err := app.deploymentManifest("foo", nil, nil)
+	assert.Nil(t, err)
+
+	var deployment struct {
+		Spec struct {
+			Template struct {
+				Spec struct {
+					TerminationGracePeriodSeconds int
+				}
+			}
+		}
+	}
+	assert.Nil(t, json.Unmarshal(manifest, &deployment))
+	assert.Equal(t, 45, deployment.Spec.Template.Spec.TerminationGracePeriodSeconds)
+}
@@ -0,0 +1,169 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// Sidecar is an additional process run alongside some of the
+// application's process types, in its own container of the same pod.
+// Its memory is a share of the application's, as in Cloud Foundry.
+type Sidecar struct {
+	Name         string   `json:"name"`
+	ProcessTypes []string `json:"process_types"`
+	Command      string   `json:"command"`
+	Memory       string   `json:"memory,omitempty"`
+}
+
+// process returns the application's process type, web unless it's a
+// copy made by processApp.
+func (app *Application) process() string {
+	if app.processType == "" {
+		return WebProcess
+	}
+	return app.processType
+}
+
+// processSidecars returns the sidecars run alongside the application's
+// process type.
+func (app *Application) processSidecars() []Sidecar {
+	var sidecars []Sidecar
+	for _, sidecar := range app.Sidecars {
+		for _, processType := range sidecar.ProcessTypes {
+			if processType == app.process() {
+				sidecars = append(sidecars, sidecar)
+				break
+			}
+		}
+	}
+	return sidecars
+}
+
+// sidecarMemory returns the total memory of the process type's
+// sidecars in bytes.
+func (app *Application) sidecarMemory() int64 {
+	var total int64
+	for _, sidecar := range app.processSidecars() {
+		total += parseMemory(sidecar.Memory)
+	}
+	return total
+}
+
+// containerMemory returns the memory limit of the main container, what
+// remains of the application's memory after its sidecars' share.
+func (app *Application) containerMemory() string {
+	sidecarMemory := app.sidecarMemory()
+	if app.Memory == "" || sidecarMemory == 0 {
+		return app.Memory
+	}
+	return formatMemory(parseMemory(app.Memory) - sidecarMemory)
+}
+
+// formatMemory formats bytes as a quantity, in megabytes when that's
+// exact.
+func formatMemory(bytes int64) string {
+	if bytes%1e6 == 0 {
+		return fmt.Sprint(bytes/1e6, "M")
+	}
+	return fmt.Sprint(bytes)
+}
+
+// checkSidecars returns an error if a sidecar runs alongside a process
+// type the application doesn't have, or if sidecars leave no memory
+// for the process they run alongside.
+func (app *Application) checkSidecars() error {
+	processTypes := map[string]bool{WebProcess: true}
+	for _, process := range app.Processes {
+		processTypes[process.Type] = true
+	}
+	for _, sidecar := range app.Sidecars {
+		if sidecar.Command == "" {
+			return errors.New(fmt.Sprintf("Error: Sidecar %s has no command", sidecar.Name))
+		}
+		for _, processType := range sidecar.ProcessTypes {
+			if !processTypes[processType] {
+				return errors.New(fmt.Sprintf("Error: Sidecar %s runs alongside unknown process type %s", sidecar.Name, processType))
+			}
+		}
+	}
+	for processType := range processTypes {
+		processApp := app.processApp(Process{Type: processType})
+		for _, process := range app.Processes {
+			if process.Type == processType {
+				processApp = app.processApp(process)
+			}
+		}
+		if processApp.Memory != "" && processApp.sidecarMemory() >= parseMemory(processApp.Memory) {
+			return errors.New(fmt.Sprintf("Error: The sidecars of the %s process need all of its %s of memory", processType, processApp.Memory))
+		}
+	}
+	return nil
+}
+
+// sidecarContainers returns the containers of the process type's
+// sidecars, which run the same image as the application.
+func (app *Application) sidecarContainers(image string, env []string, secretNames []string) []interface{} {
+	var containers []interface{}
+	for _, sidecar := range app.processSidecars() {
+		sidecarApp := *app
+		sidecarApp.Command = sidecar.Command
+		sidecarApp.Memory = sidecar.Memory
+		sidecarApp.Sidecars = nil
+		container := sidecarApp.container(image, env, secretNames)
+		container["name"] = sidecar.Name
+		sidecarApp.addInstanceEnvTo(container)
+		containers = append(containers, container)
+	}
+	return containers
+}
+
+// ensureSidecars adds the application's sidecars to the pod template
+// of its existing deployment config or deployment, updating them and
+// the main container's memory share if they changed.
+func (app *Application) ensureSidecars() error {
+	if len(app.processSidecars()) == 0 {
+		return nil
+	}
+	image, err := app.deploymentImage()
+	if err != nil {
+		return withOutput(image, err)
+	}
+	env, secretNames, err := app.envForServiceBindings()
+	if err != nil {
+		return err
+	}
+
+	main := map[string]interface{}{"name": app.Name}
+	if memory := app.containerMemory(); memory != "" {
+		main["resources"] = map[string]interface{}{
+			"limits": map[string]string{"memory": memory},
+		}
+		main["env"] = []interface{}{map[string]string{"name": "MEMORY_LIMIT", "value": memory}}
+	}
+	containers := append([]interface{}{main}, app.sidecarContainers(string(image), env, secretNames)...)
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{"containers": containers},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, sidecar := range app.processSidecars() {
+		names = append(names, sidecar.Name)
+	}
+	log.Infof("Adding sidecars %s to %s", strings.Join(names, ", "), app.Name)
+	output, err := app.oc.Exec("patch", app.workloadKind(), app.Name, "-p", string(patch)).CombinedOutput(app.context())
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error adding sidecars to %s: %s\n", app.Name, output))
+	}
+	return nil
+}
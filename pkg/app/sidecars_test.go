@@ -0,0 +1,81 @@
+package app
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+func TestContainerMemory(t *testing.T) {
+	app := Application{Name: "foo", Memory: "1G", Sidecars: []Sidecar{
+		{Name: "agent", ProcessTypes: []string{WebProcess}, Command: "./agent", Memory: "256M"},
+		{Name: "metrics", ProcessTypes: []string{"worker"}, Command: "./metrics", Memory: "128M"},
+	}}
+	assert.Equal(t, "744M", app.containerMemory())
+	assert.Equal(t, "872M", app.processApp(Process{Type: "worker"}).containerMemory())
+
+	app.Memory = ""
+	assert.Equal(t, "", app.containerMemory())
+}
+
+func TestCheckSidecars(t *testing.T) {
+	app := Application{Name: "foo", Memory: "512M", Sidecars: []Sidecar{
+		{Name: "agent", ProcessTypes: []string{WebProcess}, Command: "./agent", Memory: "256M"},
+	}}
+	assert.Nil(t, app.checkSidecars())
+
+	app.Sidecars[0].Memory = "512M"
+	err := app.checkSidecars()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "need all of its 512M")
+
+	app.Sidecars[0].Memory = ""
+	app.Sidecars[0].ProcessTypes = []string{"worker"}
+	err = app.checkSidecars()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unknown process type worker")
+}
+
+func TestDeploymentManifestWithSidecars(t *testing.T) {
+	app := Application{oc: mocks.NewMockOc(), Name: "foo", Memory: "1G",
+		Docker: &Docker{Image: "nginx"},
+		Sidecars: []Sidecar{
+			{Name: "agent", ProcessTypes: []string{WebProcess}, Command: "./agent", Memory: "256M"},
+		}}
+
+	manifest, err := app.deploymentManifest("nginx", nil, nil)
+	assert.Nil(t, err)
+	var deployment types.DeploymentConfig
+	assert.Nil(t, json.Unmarshal(manifest, &deployment))
+	containers := deployment.Spec.Template.Spec.Containers
+	assert.Equal(t, 2, len(containers))
+	assert.Equal(t, "744M", containers[0].Resources.Limits["memory"])
+	assert.Equal(t, "agent", containers[1].Name)
+	assert.Equal(t, "nginx", containers[1].Image)
+	assert.Equal(t, []string{"/bin/sh", "-c", signalForwarder, "sh", "./agent"}, containers[1].Command)
+	assert.Equal(t, "256M", containers[1].Resources.Limits["memory"])
+}
+
+func TestEnsureSidecars(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", Docker: &Docker{Image: "nginx"}, kind: "dc",
+		Sidecars: []Sidecar{{Name: "agent", ProcessTypes: []string{WebProcess}, Command: "./agent"}}}
+
+	patchCmd := &mocks.ExecCmd{}
+	patchCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", mock.MatchedBy(func(args []string) bool {
+		return args[0] == "patch" && args[1] == "dc" && args[2] == "foo" &&
+			strings.Contains(args[4], `"name":"agent"`) &&
+			strings.Contains(args[4], `"command":["/bin/sh","-c","trap`) &&
+			strings.Contains(args[4], `"sh","./agent"]`)
+	})).Return(patchCmd)
+
+	assert.Nil(t, app.ensureSidecars())
+	oc.Execer.AssertExpectations(t)
+}
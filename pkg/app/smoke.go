@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// DefaultSmokeTestTimeout is how long a smoke test waits for the
+// application to respond by default.
+const DefaultSmokeTestTimeout = 2 * time.Minute
+
+var smokeTestInterval = 2 * time.Second
+
+// smokeTestURL returns the URL the smoke test requests. The smoke test
+// option is either a full URL or a path on the application's first
+// route.
+func (app *Application) smokeTestURL() (string, error) {
+	smokeTest := app.options.SmokeTest
+	if strings.HasPrefix(smokeTest, "http://") || strings.HasPrefix(smokeTest, "https://") {
+		return smokeTest, nil
+	}
+	var url string
+	if len(app.Routes) > 0 {
+		url = fmt.Sprint("http://", app.Routes[0].Route)
+	} else {
+		var err error
+		url, err = app.url()
+		if err != nil {
+			return "", err
+		}
+	}
+	if url == "" {
+		return "", errors.New(fmt.Sprintf("Error: %s has no HTTP route to smoke test. Give --smoke-test a full URL instead", app.Name))
+	}
+	return fmt.Sprint(strings.TrimRight(url, "/"), "/", strings.TrimLeft(smokeTest, "/")), nil
+}
+
+// smokeTest requests the application's URL until it responds with the
+// expected status, failing the push if it doesn't before the timeout.
+func (app *Application) smokeTest(ctx context.Context) error {
+	url, err := app.smokeTestURL()
+	if err != nil {
+		return err
+	}
+	status := app.options.SmokeTestStatus
+	if status == 0 {
+		status = http.StatusOK
+	}
+	timeout := app.options.SmokeTestTimeout
+	if timeout == 0 {
+		timeout = DefaultSmokeTestTimeout
+	}
+
+	log.Infof("Waiting for %s to respond with %d", url, status)
+	client := &http.Client{Timeout: 10 * time.Second}
+	deadline := time.Now().Add(timeout)
+	for {
+		var got string
+		request, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return err
+		}
+		response, err := client.Do(request.WithContext(ctx))
+		if err != nil {
+			got = err.Error()
+		} else {
+			response.Body.Close()
+			if response.StatusCode == status {
+				log.Infof("%s is up", app.Name)
+				return nil
+			}
+			got = response.Status
+		}
+		log.Debugf("Smoke test of %s got %s", url, got)
+
+		if time.Now().After(deadline) {
+			return errors.New(fmt.Sprintf("Error: %s didn't respond with %d within %v, last got %s", url, status, timeout, got))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(smokeTestInterval):
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSmokeTestURL(t *testing.T) {
+	app := Application{Name: "foo", Routes: []Route{{Route: "foo.example.com/api"}}}
+	app.options.SmokeTest = "/health"
+	url, err := app.smokeTestURL()
+	assert.Nil(t, err)
+	assert.Equal(t, "http://foo.example.com/api/health", url)
+
+	app.options.SmokeTest = "https://foo.example.com/"
+	url, err = app.smokeTestURL()
+	assert.Nil(t, err)
+	assert.Equal(t, "https://foo.example.com/", url)
+}
+
+func TestSmokeTest(t *testing.T) {
+	defer func(interval time.Duration) { smokeTestInterval = interval }(smokeTestInterval)
+	smokeTestInterval = time.Millisecond
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	app := Application{Name: "foo"}
+	app.options.SmokeTest = server.URL
+	assert.Nil(t, app.smokeTest(context.Background()))
+	assert.Equal(t, 3, requests)
+
+	app.options.SmokeTestStatus = http.StatusCreated
+	app.options.SmokeTestTimeout = 10 * time.Millisecond
+	err := app.smokeTest(context.Background())
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "didn't respond with 201")
+	assert.Contains(t, err.Error(), "last got 200 OK")
+}
@@ -0,0 +1,84 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+const (
+	// SpreadZone spreads an application's instances across zones,
+	// and then nodes, as Cloud Foundry spreads them across
+	// availability zones
+	SpreadZone string = "zone"
+	// SpreadNode spreads an application's instances across nodes
+	SpreadNode string = "node"
+	// SpreadOff leaves where instances run to the scheduler
+	SpreadOff string = "off"
+)
+
+// spreadTopologyKeys are the node labels each spread balances
+// instances across, in order.
+var spreadTopologyKeys = map[string][]string{
+	SpreadZone: {"topology.kubernetes.io/zone", "kubernetes.io/hostname"},
+	SpreadNode: {"kubernetes.io/hostname"},
+}
+
+func (app *Application) spread() string {
+	if app.options.Spread == "" {
+		return SpreadZone
+	}
+	return app.options.Spread
+}
+
+// topologySpread returns the constraints that spread the pods with
+// labels, or nil if the application has a single instance. They're
+// preferences, so instances still run when the cluster can't spread
+// them.
+func (app *Application) topologySpread(labels map[string]string) []interface{} {
+	if app.Instances < 2 {
+		return nil
+	}
+	var constraints []interface{}
+	for _, topologyKey := range spreadTopologyKeys[app.spread()] {
+		constraints = append(constraints, map[string]interface{}{
+			"maxSkew":           1,
+			"topologyKey":       topologyKey,
+			"whenUnsatisfiable": "ScheduleAnyway",
+			"labelSelector":     map[string]interface{}{"matchLabels": labels},
+		})
+	}
+	return constraints
+}
+
+// ensureSpread sets how the instances of the application's existing
+// deployment config or deployment are spread, removing the spread
+// with SpreadOff. Applications pushed with a single instance are left
+// as they are.
+func (app *Application) ensureSpread() error {
+	var constraints []interface{}
+	if app.spread() != SpreadOff {
+		constraints = app.topologySpread(app.podLabels())
+		if constraints == nil {
+			return nil
+		}
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{"topologySpreadConstraints": constraints},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	log.Debugf("Spreading the instances of %s by %s", app.Name, app.spread())
+	output, err := app.oc.Exec("patch", app.workloadKind(), app.Name, "-p", string(patch)).CombinedOutput(app.context())
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error spreading the instances of %s: %s\n", app.Name, output))
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestTopologySpread(t *testing.T) {
+	app := Application{Name: "foo", Instances: 1}
+	assert.Nil(t, app.topologySpread(map[string]string{"app": "foo"}))
+
+	app.Instances = 3
+	constraints := app.topologySpread(map[string]string{"app": "foo"})
+	assert.Equal(t, 2, len(constraints))
+	assert.Equal(t, "topology.kubernetes.io/zone", constraints[0].(map[string]interface{})["topologyKey"])
+	assert.Equal(t, "kubernetes.io/hostname", constraints[1].(map[string]interface{})["topologyKey"])
+
+	app.options.Spread = SpreadNode
+	assert.Equal(t, 1, len(app.topologySpread(map[string]string{"app": "foo"})))
+
+	app.options.Spread = SpreadOff
+	assert.Nil(t, app.topologySpread(map[string]string{"app": "foo"}))
+}
+
+func TestEnsureSpread(t *testing.T) {
+	oc := mocks.NewMockOc()
+	patchCmd := &mocks.ExecCmd{}
+	patchCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", []string{"patch", "dc", "foo", "-p",
+		`{"spec":{"template":{"spec":{"topologySpreadConstraints":[{"labelSelector":{"matchLabels":{"deploymentconfig":"foo"}},"maxSkew":1,"topologyKey":"kubernetes.io/hostname","whenUnsatisfiable":"ScheduleAnyway"}]}}}}`}).Return(patchCmd)
+	oc.Execer.On("Oc", []string{"patch", "dc", "foo", "-p",
+		`{"spec":{"template":{"spec":{"topologySpreadConstraints":null}}}}`}).Return(patchCmd)
+
+	app := Application{oc: oc, Name: "foo", kind: "dc", Instances: 2}
+	app.options.Spread = SpreadNode
+	assert.Nil(t, app.ensureSpread())
+
+	app.options.Spread = SpreadOff
+	assert.Nil(t, app.ensureSpread())
+	oc.Execer.AssertExpectations(t)
+
+	// A single instance is left alone
+	app = Application{oc: mocks.NewMockOc(), Name: "foo", kind: "dc", Instances: 1}
+	assert.Nil(t, app.ensureSpread())
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// syncDestination is where S2I builder images put the application's
+// source, and so where synced files go.
+const syncDestination = "/opt/app-root/src"
+
+// syncLanguages are the languages whose source is run as is, so
+// copying changed files into a running pod is enough to update it.
+var syncLanguages = map[string]bool{
+	"node":   true,
+	"php":    true,
+	"python": true,
+	"ruby":   true,
+}
+
+// syncable returns true if the application's files can be copied into
+// its running pods instead of rebuilding it.
+func (app *Application) syncable() bool {
+	if app.IsDocker() || app.kubernetes() || app.knative() {
+		return false
+	}
+	return syncLanguages[DetectLanguage(app.Path)]
+}
+
+// Sync copies the application's directory into its running pods, then
+// runs restartCommand in each of them, if it's given, so the
+// application picks up the changes without a new build.
+func (app *Application) Sync(restartCommand string) error {
+	app.setupDefaults()
+	if err := app.checkLoggedIn(); err != nil {
+		return err
+	}
+	app.displayProject()
+
+	if app.kubernetes() {
+		return errors.New("Error: Syncing files needs 'oc rsync', which is only available on OpenShift")
+	}
+	appExists, err := app.deploymentExists()
+	if err != nil {
+		return err
+	}
+	if !appExists {
+		return errors.New(fmt.Sprintf("Error: Application %s not found\n", app.Name))
+	}
+	if language := DetectLanguage(app.Path); !syncLanguages[language] {
+		log.Warnf("%s doesn't look like a Ruby, Node.js, Python, or PHP application, so synced files may need a new build to take effect", app.Name)
+	}
+	return app.syncFiles(restartCommand)
+}
+
+// syncFiles copies the application's directory into each of its
+// running pods with 'oc rsync', running restartCommand afterwards
+// unless it's empty.
+func (app *Application) syncFiles(restartCommand string) error {
+	dir := app.Path
+	if dir == "" {
+		dir = "."
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return errors.New(fmt.Sprintf("Error: Only application directories can be synced, not %s\n", dir))
+	}
+	pods, err := app.runningPods()
+	if err != nil {
+		return err
+	}
+	if len(pods) == 0 {
+		return errors.New(fmt.Sprintf("Error: No running pods found for %s\n", app.Name))
+	}
+	for _, pod := range pods {
+		rsyncCmd := app.oc.Exec("rsync", fmt.Sprint(filepath.Clean(dir), "/"),
+			fmt.Sprint(pod, ":", syncDestination), "--exclude=.git", "--no-perms=true")
+		rsyncCmd.SetTimeout(exec.BuildTimeout)
+		log.Infof("Syncing files with command: %s", rsyncCmd.ArgsString())
+		output, err := rsyncCmd.CombinedOutput(app.context())
+		if err != nil {
+			return errors.New(fmt.Sprintf("Error syncing files to %s: %s\n", pod, output))
+		}
+		if restartCommand == "" {
+			continue
+		}
+		restartCmd := app.oc.Exec("exec", pod, "--", "sh", "-c", restartCommand)
+		log.Infof("Restarting with command: %s", restartCmd.ArgsString())
+		output, err = restartCmd.CombinedOutput(app.context())
+		if err != nil {
+			return errors.New(fmt.Sprintf("Error restarting %s: %s\n", pod, output))
+		}
+	}
+	return nil
+}
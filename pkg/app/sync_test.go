@@ -0,0 +1,73 @@
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestSyncFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocf-sync")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "package.json"), []byte("{}"), 0644)
+
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", Path: dir, kind: "dc"}
+	assert.True(t, app.syncable())
+
+	podsCmd := &mocks.ExecCmd{}
+	podsCmd.On("CombinedOutput").Return([]byte("pod/foo-1-abcde\npod/foo-1-fghij\n"), nil)
+	oc.Execer.On("Oc", []string{"get", "pods", "--selector=deploymentconfig=foo",
+		"--field-selector=status.phase=Running", "-o", "name"}).Return(podsCmd)
+	for _, pod := range []string{"foo-1-abcde", "foo-1-fghij"} {
+		rsyncCmd := &mocks.ExecCmd{}
+		rsyncCmd.On("CombinedOutput").Return([]byte(""), nil)
+		oc.Execer.On("Oc", []string{"rsync", dir + "/", pod + ":/opt/app-root/src",
+			"--exclude=.git", "--no-perms=true"}).Return(rsyncCmd)
+	}
+
+	err = app.syncFiles("")
+	assert.Nil(t, err)
+	oc.Execer.AssertExpectations(t)
+}
+
+func TestSyncableSkipsCompiledLanguages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocf-sync")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "pom.xml"), []byte(""), 0644)
+
+	app := Application{oc: mocks.NewMockOc(), Name: "foo", Path: dir}
+	assert.False(t, app.syncable())
+}
+
+func TestSyncFilesWithRestartCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocf-sync")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo", Path: dir, kind: "deployment"}
+
+	podsCmd := &mocks.ExecCmd{}
+	podsCmd.On("CombinedOutput").Return([]byte("pod/foo-6d4cf56db6-x2v7k\n"), nil)
+	oc.Execer.On("Oc", []string{"get", "pods", "--selector=app=foo",
+		"--field-selector=status.phase=Running", "-o", "name"}).Return(podsCmd)
+	rsyncCmd := &mocks.ExecCmd{}
+	rsyncCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", []string{"rsync", dir + "/", "foo-6d4cf56db6-x2v7k:/opt/app-root/src",
+		"--exclude=.git", "--no-perms=true"}).Return(rsyncCmd)
+	restartCmd := &mocks.ExecCmd{}
+	restartCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", []string{"exec", "foo-6d4cf56db6-x2v7k", "--", "sh", "-c", "touch tmp/restart.txt"}).Return(restartCmd)
+
+	err = app.syncFiles("touch tmp/restart.txt")
+	assert.Nil(t, err)
+	oc.Execer.AssertExpectations(t)
+}
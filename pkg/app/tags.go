@@ -0,0 +1,74 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// buildTagFormat is the timestamp that starts the tag of each build.
+const buildTagFormat = "20060102-150405"
+
+// buildTag returns the immutable tag for a build started at now: its
+// timestamp, followed by the short Git SHA of the source if there is
+// one.
+func (app *Application) buildTag(now time.Time) string {
+	tag := now.UTC().Format(buildTagFormat)
+	if sha := app.gitSHA(); len(sha) >= 7 {
+		tag = fmt.Sprint(tag, "-", sha[:7])
+	}
+	return tag
+}
+
+// tagBuild tags the image the build just produced with an immutable
+// tag, so it can be deployed again later with --tag.
+func (app *Application) tagBuild() error {
+	tag := app.buildTag(time.Now())
+	output, err := app.oc.Exec("tag", fmt.Sprint(app.Name, ":latest"), fmt.Sprint(app.Name, ":", tag)).CombinedOutput(app.context())
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error tagging the build of %s: %s\n", app.Name, output))
+	}
+	app.tag = tag
+	log.Infof("Tagged the build as %s:%s", app.Name, tag)
+	return nil
+}
+
+// deployTag points the application's latest tag at the image of a
+// previous build, instead of building it again. The image change
+// triggers on latest roll it out.
+func (app *Application) deployTag(tag string) error {
+	exists, err := app.oc.Exists(app.context(), "istag", fmt.Sprint(app.Name, ":", tag))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New(fmt.Sprintf("Error: Image stream tag %s:%s not found. Tags built so far: %s\n",
+			app.Name, tag, strings.Join(app.buildTags(), ", ")))
+	}
+	output, err := app.oc.Exec("tag", fmt.Sprint(app.Name, ":", tag), fmt.Sprint(app.Name, ":latest")).CombinedOutput(app.context())
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error deploying tag %s of %s: %s\n", tag, app.Name, output))
+	}
+	app.tag = tag
+	log.Infof("Deploying %s:%s", app.Name, tag)
+	return nil
+}
+
+// buildTags returns the tags of the application's image stream other
+// than latest, or nothing if they can't be read.
+func (app *Application) buildTags() []string {
+	output, err := app.oc.Exec("get", "is", app.Name, "-o", "jsonpath={.status.tags[*].tag}").CombinedOutput(app.context())
+	if err != nil {
+		return nil
+	}
+	var tags []string
+	for _, tag := range strings.Fields(string(output)) {
+		if tag != "latest" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestBuildTag(t *testing.T) {
+	now := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+	execer := &mocks.Execer{}
+	gitCmd := &mocks.ExecCmd{}
+	gitCmd.On("CombinedOutput").Return([]byte("0123456789abcdef\n"), nil)
+	execer.On("Command", "git", []string{"-C", "/src/foo", "rev-parse", "HEAD"}).Return(gitCmd)
+	app := Application{execer: execer, Name: "foo", Path: "/src/foo"}
+	assert.Equal(t, "20200102-150405-0123456", app.buildTag(now))
+
+	execer = &mocks.Execer{}
+	notGitCmd := &mocks.ExecCmd{}
+	notGitCmd.On("CombinedOutput").Return([]byte("fatal: not a git repository\n"), errors.New("exit status 128"))
+	execer.On("Command", "git", []string{"-C", "/src/foo", "rev-parse", "HEAD"}).Return(notGitCmd)
+	app = Application{execer: execer, Name: "foo", Path: "/src/foo"}
+	assert.Equal(t, "20200102-150405", app.buildTag(now))
+}
+
+func TestDeployTag(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.On("Exists", "istag", "foo:20200102-150405").Return(true, nil)
+	tagCmd := &mocks.ExecCmd{}
+	tagCmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc.Execer.On("Oc", []string{"tag", "foo:20200102-150405", "foo:latest"}).Return(tagCmd)
+
+	app := Application{oc: oc, Name: "foo"}
+	assert.Nil(t, app.deployTag("20200102-150405"))
+	assert.Equal(t, "20200102-150405", app.tag)
+	oc.Execer.AssertExpectations(t)
+}
+
+func TestDeployMissingTag(t *testing.T) {
+	oc := mocks.NewMockOc()
+	oc.On("Exists", "istag", "foo:missing").Return(false, nil)
+	isCmd := &mocks.ExecCmd{}
+	isCmd.On("CombinedOutput").Return([]byte("latest 20200102-150405"), nil)
+	oc.Execer.On("Oc", []string{"get", "is", "foo", "-o", "jsonpath={.status.tags[*].tag}"}).Return(isCmd)
+
+	app := Application{oc: oc, Name: "foo"}
+	err := app.deployTag("missing")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Tags built so far: 20200102-150405")
+}
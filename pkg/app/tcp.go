@@ -0,0 +1,70 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+const (
+	// RouteTypeHTTP exposes applications with an HTTP route, or an
+	// ingress on Kubernetes
+	RouteTypeHTTP string = "http"
+	// RouteTypeTCP exposes applications on a TCP port of a load
+	// balancer service, like a Cloud Foundry TCP route
+	RouteTypeTCP string = "tcp"
+)
+
+// defaultPort is the port applications listen on, passed to them as
+// PORT.
+const defaultPort = 8080
+
+func (app *Application) tcp() bool {
+	return app.options.RouteType == RouteTypeTCP
+}
+
+// servicePort returns the port the application's service listens on,
+// which is the external port of a TCP route.
+func (app *Application) servicePort() int {
+	if app.tcp() && app.options.RoutePort > 0 {
+		return app.options.RoutePort
+	}
+	return defaultPort
+}
+
+// exposeArgs returns the arguments that create the application's
+// service.
+func (app *Application) exposeArgs() []string {
+	args := []string{"expose", app.workloadKind(), app.Name, fmt.Sprint("--port=", app.servicePort())}
+	if app.tcp() {
+		args = append(args, fmt.Sprint("--target-port=", defaultPort), "--type=LoadBalancer")
+	}
+	return args
+}
+
+// displayTCPEndpoint reports the load balancer address of a TCP
+// application, or its node port while the load balancer is pending.
+func (app *Application) displayTCPEndpoint() error {
+	service := &types.Service{}
+	err := oc.Get(app.context(), app.oc, "svc", app.Name, service)
+	if err != nil {
+		return err
+	}
+	if len(service.Spec.Ports) == 0 {
+		return errors.New(fmt.Sprintf("Error: Service %s has no ports\n", app.Name))
+	}
+	port := service.Spec.Ports[0]
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		host := ingress.IP
+		if host == "" {
+			host = ingress.Hostname
+		}
+		log.Infof("Your application is available at tcp://%s:%d", host, port.Port)
+		return nil
+	}
+	log.Infof("The load balancer for %s is still pending. Until it's ready, your application is available at port %d of any cluster node", app.Name, port.NodePort)
+	return nil
+}
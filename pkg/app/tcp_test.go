@@ -0,0 +1,39 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestExposeArgs(t *testing.T) {
+	app := Application{Name: "foo"}
+	assert.Equal(t, []string{"expose", "dc", "foo", "--port=8080"}, app.exposeArgs())
+
+	app.options = PushOptions{RouteType: RouteTypeTCP, RoutePort: 5432}
+	assert.Equal(t, []string{"expose", "dc", "foo", "--port=5432", "--target-port=8080", "--type=LoadBalancer"}, app.exposeArgs())
+}
+
+func TestTCPServiceObject(t *testing.T) {
+	app := Application{Name: "foo", options: PushOptions{RouteType: RouteTypeTCP, RoutePort: 5432}}
+	spec := app.serviceObject()["spec"].(map[string]interface{})
+	assert.Equal(t, "LoadBalancer", spec["type"])
+	assert.Equal(t, []interface{}{map[string]int{"port": 5432, "targetPort": 8080}}, spec["ports"])
+}
+
+func TestDisplayTCPEndpoint(t *testing.T) {
+	oc := mocks.NewMockOc()
+	app := Application{oc: oc, Name: "foo"}
+
+	getCmd := &mocks.ExecCmd{}
+	getCmd.On("CombinedOutput").Return([]byte(`{
+  "spec": {"ports": [{"port": 5432, "nodePort": 31234}]},
+  "status": {"loadBalancer": {"ingress": [{"hostname": "lb.example.com"}]}}
+}`), nil)
+	oc.Execer.On("Oc", []string{"get", "svc", "foo", "-o", "json"}).Return(getCmd)
+
+	assert.Nil(t, app.displayTCPEndpoint())
+	oc.Execer.AssertExpectations(t)
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// VcapServices is the environment variable describing the services
+// bound to an application, in the same shape as Cloud Foundry's.
+const VcapServices string = "VCAP_SERVICES"
+
+// VcapService is one bound service in VCAP_SERVICES. Binding
+// parameters given with bind-service -c are under the "parameters"
+// key of its credentials.
+type VcapService struct {
+	Name        string                 `json:"name"`
+	Label       string                 `json:"label"`
+	Credentials map[string]interface{} `json:"credentials"`
+}
+
+// vcapServicesSecretName returns the name of the secret holding the
+// application's VCAP_SERVICES, since it contains every credential.
+func (app *Application) vcapServicesSecretName() string {
+	return fmt.Sprint(app.Name, "-vcap-services")
+}
+
+// boundServiceName returns the name of the service bound with
+// envPrefix, as recorded in the application's environment appEnv.
+func boundServiceName(envPrefix string, appEnv map[string]string) string {
+	if name := appEnv[serviceNameEnv(envPrefix)]; name != "" {
+		return name
+	}
+	// Older bindings only record the env prefix, so assume the
+	// service name followed the usual conventions
+	return strings.ToLower(strings.Replace(envPrefix, "_", "-", -1))
+}
+
+// ensureVcapServices rebuilds the application's VCAP_SERVICES from
+// its service bindings, removing it once nothing is bound.
+func (app *Application) ensureVcapServices() error {
+	appEnv, err := app.oc.Env(app.context(), app.workloadKind(), app.Name)
+	if err != nil {
+		return err
+	}
+	secretName := app.vcapServicesSecretName()
+
+	envPrefixes := strings.Fields(appEnv[BoundServices])
+	if len(envPrefixes) == 0 {
+		exists, err := app.oc.Exists(app.context(), "secret", secretName)
+		if err != nil || !exists {
+			return err
+		}
+		err = app.oc.SetEnv(app.context(), app.workloadKind(), app.Name, map[string]string{VcapServices: "-"})
+		if err != nil {
+			return err
+		}
+		return app.oc.Delete(app.context(), "secret", secretName)
+	}
+
+	services := make(map[string][]VcapService)
+	for _, envPrefix := range envPrefixes {
+		service, err := app.vcapService(envPrefix, appEnv)
+		if err != nil {
+			return err
+		}
+		services[service.Label] = append(services[service.Label], service)
+	}
+	contents, err := json.Marshal(services)
+	if err != nil {
+		return err
+	}
+	err = app.replaceSecret(secretName, map[string]string{VcapServices: string(contents)})
+	if err != nil {
+		return err
+	}
+	return app.oc.SetEnvFrom(app.context(), app.workloadKind(), app.Name, fmt.Sprint("secret/", secretName), "", nil)
+}
+
+// vcapService describes the service bound with envPrefix. The
+// credentials of a database are the binding's variables, named without
+// the prefix, and those of an existing secret or config map are its
+// data.
+func (app *Application) vcapService(envPrefix string, appEnv map[string]string) (VcapService, error) {
+	service := VcapService{
+		Name:        boundServiceName(envPrefix, appEnv),
+		Credentials: make(map[string]interface{}),
+	}
+	values := make(map[string]string)
+	for key, value := range appEnv {
+		if strings.HasPrefix(key, fmt.Sprint(envPrefix, "_")) {
+			values[strings.TrimPrefix(key, fmt.Sprint(envPrefix, "_"))] = value
+		}
+	}
+	secretName := app.bindingSecretName(envPrefix)
+	exists, err := app.oc.Exists(app.context(), "secret", secretName)
+	if err != nil {
+		return service, err
+	}
+	if exists {
+		data, err := app.oc.Data(app.context(), "secret", secretName)
+		if err != nil {
+			return service, err
+		}
+		for key, value := range data {
+			if strings.HasPrefix(key, fmt.Sprint(envPrefix, "_")) {
+				values[strings.TrimPrefix(key, fmt.Sprint(envPrefix, "_"))] = value
+			}
+		}
+	}
+	service.Label = values["LABEL"]
+
+	if service.Label == UserProvidedLabel {
+		kind, err := app.serviceKind(service.Name)
+		if err != nil {
+			return service, err
+		}
+		data, err := app.oc.Data(app.context(), kind, service.Name)
+		if err != nil {
+			return service, err
+		}
+		for key, value := range data {
+			service.Credentials[key] = value
+		}
+	} else {
+		for key, value := range values {
+			switch key {
+			case "LABEL", "PARAMETERS", "SERVICE_NAME":
+			default:
+				service.Credentials[strings.ToLower(key)] = value
+			}
+		}
+	}
+
+	if parameters := values["PARAMETERS"]; parameters != "" {
+		var decoded interface{}
+		if json.Unmarshal([]byte(parameters), &decoded) == nil {
+			service.Credentials["parameters"] = decoded
+		} else {
+			service.Credentials["parameters"] = parameters
+		}
+	}
+	return service, nil
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bbrowning/ocf/pkg/log"
+)
+
+// watchInterval is how often Watch checks the application's files for
+// changes.
+var watchInterval = time.Second
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watch pushes the application and then, until its context is cancelled,
+// pushes it again whenever its files change. Interpreted applications
+// on OpenShift have their files synced into their running pods
+// instead, which is much faster than a new build. Pushes after the
+// first that fail are logged and the watch goes on.
+func (app *Application) Watch(options PushOptions) error {
+	err := app.Push(options)
+	if err != nil {
+		return err
+	}
+
+	dir := app.Path
+	if dir == "" {
+		dir = "."
+	}
+	files, err := snapshotFiles(dir)
+	if err != nil {
+		return err
+	}
+	log.Infof("Watching %s for changes, press Ctrl-C to stop", dir)
+	for {
+		changed := 0
+		// Wait for a quiet interval so a save touching several
+		// files only triggers one push
+		for {
+			select {
+			case <-app.context().Done():
+				return nil
+			case <-time.After(watchInterval):
+			}
+			current, err := snapshotFiles(dir)
+			if err != nil {
+				log.Warnf("Unable to check %s for changes: %v", dir, err)
+				continue
+			}
+			count := changedFiles(files, current)
+			files = current
+			if count == 0 && changed > 0 {
+				break
+			}
+			changed += count
+		}
+
+		log.Infof("Detected changes to %d file(s) in %s", changed, dir)
+		if app.syncable() {
+			err = app.syncFiles("")
+			if err == nil {
+				continue
+			}
+			log.Warnf("%v", err)
+		}
+		err = app.Push(options)
+		if err != nil {
+			log.Errorf("%v", err)
+		}
+	}
+}
+
+// snapshotFiles records the modification time and size of every file
+// under dir, or of dir itself if it's a file.
+func snapshotFiles(dir string) (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() {
+			files[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+		return nil
+	})
+	return files, err
+}
+
+// changedFiles returns how many files were added, removed, or
+// modified between two snapshots.
+func changedFiles(before map[string]fileState, after map[string]fileState) int {
+	changed := 0
+	for path, state := range after {
+		if previous, ok := before[path]; !ok || previous.size != state.size || !previous.modTime.Equal(state.modTime) {
+			changed++
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed++
+		}
+	}
+	return changed
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangedFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocf-watch")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "app.rb"), []byte("puts 1"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "Gemfile"), []byte(""), 0644)
+	os.Mkdir(filepath.Join(dir, ".git"), 0755)
+
+	before, err := snapshotFiles(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(before))
+
+	ioutil.WriteFile(filepath.Join(dir, ".git", "HEAD"), []byte("ref"), 0644)
+	after, err := snapshotFiles(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, changedFiles(before, after))
+
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(dir, "app.rb"), later, later)
+	os.Remove(filepath.Join(dir, "Gemfile"))
+	ioutil.WriteFile(filepath.Join(dir, "config.ru"), []byte(""), 0644)
+	after, err = snapshotFiles(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, changedFiles(before, after))
+}
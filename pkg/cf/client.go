@@ -0,0 +1,288 @@
+// Package cf is a small client for the parts of the Cloud Foundry v3
+// API that 'ocf import' reads applications from.
+package cf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Client makes authenticated requests to a Cloud Foundry API.
+type Client struct {
+	// API is the URL of the Cloud Foundry API, such as
+	// "https://api.example.com"
+	API string
+	// Token is an OAuth access token, with or without its "bearer"
+	// prefix
+	Token string
+	HTTP  *http.Client
+}
+
+// NewClient returns a client for api authenticated with token.
+func NewClient(api string, token string) *Client {
+	if !strings.Contains(api, "://") {
+		api = fmt.Sprint("https://", api)
+	}
+	return &Client{
+		API:   strings.TrimRight(api, "/"),
+		Token: token,
+		HTTP:  http.DefaultClient,
+	}
+}
+
+// Config is the part of the cf CLI's config.json that holds the API,
+// token, and space it targets.
+type Config struct {
+	Target             string `json:"Target"`
+	AccessToken        string `json:"AccessToken"`
+	OrganizationFields struct {
+		Name string `json:"Name"`
+	} `json:"OrganizationFields"`
+	SpaceFields struct {
+		Name string `json:"Name"`
+	} `json:"SpaceFields"`
+}
+
+// ReadConfig reads the cf CLI's config from $CF_HOME/.cf/config.json,
+// where CF_HOME defaults to the home directory.
+func ReadConfig() (*Config, error) {
+	home := os.Getenv("CF_HOME")
+	if home == "" {
+		var err error
+		home, err = os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+	}
+	data, err := ioutil.ReadFile(filepath.Join(home, ".cf", "config.json"))
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	err = json.Unmarshal(data, config)
+	return config, err
+}
+
+func (client *Client) request(path string) (*http.Response, error) {
+	if !strings.Contains(path, "://") {
+		path = fmt.Sprint(client.API, path)
+	}
+	req, err := http.NewRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	token := client.Token
+	if !strings.HasPrefix(strings.ToLower(token), "bearer ") {
+		token = fmt.Sprint("bearer ", token)
+	}
+	req.Header.Set("Authorization", token)
+	resp, err := client.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, errors.New(fmt.Sprintf("Error requesting %s: %s %s\n", path, resp.Status, body))
+	}
+	return resp, nil
+}
+
+// get decodes the JSON response to a GET of path into obj.
+func (client *Client) get(path string, obj interface{}) error {
+	resp, err := client.request(path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(obj)
+}
+
+// list calls add with each page of the paginated resources at path.
+func (client *Client) list(path string, add func(page []byte) error) error {
+	for path != "" {
+		page := &struct {
+			Pagination struct {
+				Next *struct {
+					Href string `json:"href"`
+				} `json:"next"`
+			} `json:"pagination"`
+			Resources json.RawMessage `json:"resources"`
+		}{}
+		err := client.get(path, page)
+		if err != nil {
+			return err
+		}
+		err = add(page.Resources)
+		if err != nil {
+			return err
+		}
+		path = ""
+		if page.Pagination.Next != nil {
+			path = page.Pagination.Next.Href
+		}
+	}
+	return nil
+}
+
+// Download writes the body of a GET of path to file. Blobstore
+// redirects are followed.
+func (client *Client) Download(path string, file string) error {
+	resp, err := client.request(path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	out, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, resp.Body)
+	closeErr := out.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// SpaceGUID looks up the GUID of space in org.
+func (client *Client) SpaceGUID(org string, space string) (string, error) {
+	var orgs []Resource
+	err := client.list(fmt.Sprint("/v3/organizations?names=", url.QueryEscape(org)), func(page []byte) error {
+		var items []Resource
+		err := json.Unmarshal(page, &items)
+		orgs = append(orgs, items...)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(orgs) == 0 {
+		return "", errors.New(fmt.Sprintf("Error: Organization %s not found\n", org))
+	}
+
+	var spaces []Resource
+	path := fmt.Sprintf("/v3/spaces?names=%s&organization_guids=%s", url.QueryEscape(space), orgs[0].GUID)
+	err = client.list(path, func(page []byte) error {
+		var items []Resource
+		err := json.Unmarshal(page, &items)
+		spaces = append(spaces, items...)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(spaces) == 0 {
+		return "", errors.New(fmt.Sprintf("Error: Space %s not found in organization %s\n", space, org))
+	}
+	return spaces[0].GUID, nil
+}
+
+// Apps returns the applications in the space with spaceGUID.
+func (client *Client) Apps(spaceGUID string) ([]App, error) {
+	var apps []App
+	err := client.list(fmt.Sprint("/v3/apps?order_by=name&space_guids=", spaceGUID), func(page []byte) error {
+		var items []App
+		err := json.Unmarshal(page, &items)
+		apps = append(apps, items...)
+		return err
+	})
+	return apps, err
+}
+
+// Env returns the environment variables set on an application by the
+// user.
+func (client *Client) Env(appGUID string) (map[string]string, error) {
+	env := &struct {
+		Var map[string]string `json:"var"`
+	}{}
+	err := client.get(fmt.Sprintf("/v3/apps/%s/environment_variables", appGUID), env)
+	return env.Var, err
+}
+
+// Processes returns an application's processes, such as "web".
+func (client *Client) Processes(appGUID string) ([]Process, error) {
+	var processes []Process
+	err := client.list(fmt.Sprintf("/v3/apps/%s/processes", appGUID), func(page []byte) error {
+		var items []Process
+		err := json.Unmarshal(page, &items)
+		processes = append(processes, items...)
+		return err
+	})
+	return processes, err
+}
+
+// Routes returns the routes mapped to an application.
+func (client *Client) Routes(appGUID string) ([]Route, error) {
+	var routes []Route
+	err := client.list(fmt.Sprintf("/v3/apps/%s/routes", appGUID), func(page []byte) error {
+		var items []Route
+		err := json.Unmarshal(page, &items)
+		routes = append(routes, items...)
+		return err
+	})
+	return routes, err
+}
+
+// ServiceInstances returns the names of the service instances bound to
+// an application.
+func (client *Client) ServiceInstances(appGUID string) ([]string, error) {
+	var names []string
+	path := fmt.Sprintf("/v3/service_credential_bindings?type=app&include=service_instance&app_guids=%s", appGUID)
+	for path != "" {
+		page := &struct {
+			Pagination struct {
+				Next *struct {
+					Href string `json:"href"`
+				} `json:"next"`
+			} `json:"pagination"`
+			Included struct {
+				ServiceInstances []Resource `json:"service_instances"`
+			} `json:"included"`
+		}{}
+		err := client.get(path, page)
+		if err != nil {
+			return nil, err
+		}
+		for _, instance := range page.Included.ServiceInstances {
+			names = append(names, instance.Name)
+		}
+		path = ""
+		if page.Pagination.Next != nil {
+			path = page.Pagination.Next.Href
+		}
+	}
+	return names, nil
+}
+
+// LatestPackage returns the most recently uploaded package of an
+// application, or nil if it has none.
+func (client *Client) LatestPackage(appGUID string) (*Package, error) {
+	packages := &struct {
+		Resources []Package `json:"resources"`
+	}{}
+	err := client.get(fmt.Sprintf("/v3/packages?order_by=-created_at&per_page=1&app_guids=%s", appGUID), packages)
+	if err != nil || len(packages.Resources) == 0 {
+		return nil, err
+	}
+	return &packages.Resources[0], nil
+}
+
+// CurrentDroplet returns the GUID of the droplet an application runs,
+// or an empty string if it has none.
+func (client *Client) CurrentDroplet(appGUID string) (string, error) {
+	droplet := &Resource{}
+	err := client.get(fmt.Sprintf("/v3/apps/%s/droplets/current", appGUID), droplet)
+	if err != nil && strings.Contains(err.Error(), "404") {
+		return "", nil
+	}
+	return droplet.GUID, err
+}
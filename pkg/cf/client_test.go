@@ -0,0 +1,56 @@
+package cf
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppsFollowsPagination(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "bearer abc", r.Header.Get("Authorization"))
+		assert.Equal(t, "/v3/apps", r.URL.Path)
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `{"pagination":{"next":null},"resources":[{"guid":"2","name":"bar"}]}`)
+			return
+		}
+		fmt.Fprintf(w, `{"pagination":{"next":{"href":"%s/v3/apps?page=2"}},"resources":[{"guid":"1","name":"foo","lifecycle":{"type":"docker"}}]}`, server.URL)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "abc")
+	apps, err := client.Apps("space")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(apps))
+	assert.Equal(t, "foo", apps[0].Name)
+	assert.True(t, apps[0].Docker())
+	assert.Equal(t, "bar", apps[1].Name)
+}
+
+func TestRequestError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"errors":[{"title":"CF-InvalidAuthToken"}]}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "bearer expired")
+	_, err := client.SpaceGUID("org", "space")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "CF-InvalidAuthToken")
+}
+
+func TestCurrentDropletMissing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	droplet, err := NewClient(server.URL, "abc").CurrentDroplet("app")
+	assert.Nil(t, err)
+	assert.Equal(t, "", droplet)
+}
@@ -0,0 +1,55 @@
+package cf
+
+// Resource is the GUID and name common to most v3 resources.
+type Resource struct {
+	GUID string `json:"guid"`
+	Name string `json:"name"`
+}
+
+// App is a v3 app.
+type App struct {
+	GUID      string `json:"guid"`
+	Name      string `json:"name"`
+	State     string `json:"state"`
+	Lifecycle struct {
+		Type string `json:"type"`
+		Data struct {
+			Buildpacks []string `json:"buildpacks"`
+			Stack      string   `json:"stack"`
+		} `json:"data"`
+	} `json:"lifecycle"`
+}
+
+// Docker returns true if the app runs a Docker image rather than one
+// staged with buildpacks.
+func (app *App) Docker() bool {
+	return app.Lifecycle.Type == "docker"
+}
+
+// Process is a v3 process, such as an app's "web" process.
+type Process struct {
+	GUID       string `json:"guid"`
+	Type       string `json:"type"`
+	Command    string `json:"command"`
+	Instances  int    `json:"instances"`
+	MemoryInMB int    `json:"memory_in_mb"`
+}
+
+// Route is a v3 route.
+type Route struct {
+	GUID string `json:"guid"`
+	Host string `json:"host"`
+	Path string `json:"path"`
+	URL  string `json:"url"`
+}
+
+// Package is a v3 package, either the bits uploaded by 'cf push' or a
+// Docker image.
+type Package struct {
+	GUID  string `json:"guid"`
+	Type  string `json:"type"`
+	State string `json:"state"`
+	Data  struct {
+		Image string `json:"image"`
+	} `json:"data"`
+}
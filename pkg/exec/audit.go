@@ -0,0 +1,104 @@
+package exec
+
+import (
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxAuditOutput is how much of a failed command's output is kept in
+// its audit record.
+const maxAuditOutput = 4096
+
+// AuditRecord is a command run while an Audit was active.
+type AuditRecord struct {
+	Command  []string `json:"command"`
+	Started  string   `json:"started"`
+	Duration string   `json:"duration"`
+	ExitCode int      `json:"exitCode"`
+	Error    string   `json:"error,omitempty"`
+	// Output is only kept for failed commands, since successful ones
+	// can print secrets
+	Output string `json:"output,omitempty"`
+}
+
+// Audit records every command run by a DefaultCmd while it's active,
+// so what ocf did to a cluster can be reviewed afterwards.
+type Audit struct {
+	mutex   sync.Mutex
+	records []AuditRecord
+}
+
+var (
+	auditMutex  sync.Mutex
+	activeAudit *Audit
+)
+
+// StartAudit starts recording commands, returning the new audit.
+func StartAudit() *Audit {
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+	activeAudit = &Audit{}
+	return activeAudit
+}
+
+// StopAudit stops recording commands.
+func StopAudit() {
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+	activeAudit = nil
+}
+
+func currentAudit() *Audit {
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+	return activeAudit
+}
+
+// Records returns the commands recorded so far.
+func (audit *Audit) Records() []AuditRecord {
+	audit.mutex.Lock()
+	defer audit.mutex.Unlock()
+	return append([]AuditRecord{}, audit.records...)
+}
+
+func (audit *Audit) add(args []string, started time.Time, output []byte, err error) {
+	record := AuditRecord{
+		Command:  redactArgs(args),
+		Started:  started.UTC().Format(time.RFC3339),
+		Duration: time.Since(started).Round(time.Millisecond).String(),
+	}
+	if err != nil {
+		record.ExitCode = -1
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			record.ExitCode = exitErr.ExitCode()
+		}
+		record.Error = err.Error()
+		if len(output) > maxAuditOutput {
+			output = output[:maxAuditOutput]
+		}
+		record.Output = string(output)
+	}
+	audit.mutex.Lock()
+	defer audit.mutex.Unlock()
+	audit.records = append(audit.records, record)
+}
+
+var secretArgRegexp = regexp.MustCompile(`(?i)^(--[^=]*(password|token|secret)[^=]*=|--from-literal=[^=]+=)`)
+
+// redactArgs hides the values of arguments that carry credentials,
+// like --docker-password or a secret's --from-literal.
+func redactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		if prefix := secretArgRegexp.FindString(arg); prefix != "" {
+			arg = prefix + "REDACTED"
+		} else if i > 0 && strings.EqualFold(args[i-1], "--token") {
+			arg = "REDACTED"
+		}
+		redacted[i] = arg
+	}
+	return redacted
+}
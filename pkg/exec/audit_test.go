@@ -0,0 +1,35 @@
+package exec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditRecordsCommands(t *testing.T) {
+	audit := StartAudit()
+	execer := &DefaultExecer{}
+	assert.Nil(t, execer.Command("true").Run(context.Background()))
+	_, err := execer.Command("sh", "-c", "echo failed; exit 3").CombinedOutput(context.Background())
+	assert.NotNil(t, err)
+	StopAudit()
+	execer.Command("true").Run(context.Background())
+
+	records := audit.Records()
+	assert.Equal(t, 2, len(records))
+	assert.Equal(t, []string{"true"}, records[0].Command)
+	assert.Equal(t, 0, records[0].ExitCode)
+	assert.Equal(t, "", records[0].Output)
+	assert.Equal(t, 3, records[1].ExitCode)
+	assert.Equal(t, "failed\n", records[1].Output)
+}
+
+func TestRedactArgs(t *testing.T) {
+	assert.Equal(t,
+		[]string{"login", "--token=REDACTED", "--server=https://api.example.com"},
+		redactArgs([]string{"login", "--token=abc", "--server=https://api.example.com"}))
+	assert.Equal(t,
+		[]string{"create", "secret", "generic", "foo", "--from-literal=PASSWORD=REDACTED", "--docker-password=REDACTED"},
+		redactArgs([]string{"create", "secret", "generic", "foo", "--from-literal=PASSWORD=hunter2", "--docker-password=hunter2"}))
+}
@@ -1,39 +1,201 @@
 package exec
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
+const (
+	// DefaultRequestTimeout is the default RequestTimeout
+	DefaultRequestTimeout = time.Minute
+	// DefaultBuildTimeout is the default BuildTimeout
+	DefaultBuildTimeout = 30 * time.Minute
+)
+
+var (
+	// RequestTimeout is how long quick commands, like getting or
+	// applying objects, run before they're killed so a dead cluster
+	// can't hang us. Zero means no timeout.
+	RequestTimeout = DefaultRequestTimeout
+	// BuildTimeout is how long slow commands, like builds, rollouts,
+	// and image pushes, run before they're killed. Zero means no
+	// timeout.
+	BuildTimeout = DefaultBuildTimeout
+)
+
+var (
+	// Stdout and Stderr are where commands attached with AttachStdIO
+	// write, such as streamed build logs
+	Stdout io.Writer = os.Stdout
+	Stderr io.Writer = os.Stderr
+)
+
+// ExecCmd is a command to run. Running it with a context kills the
+// command once the context is done.
 type ExecCmd interface {
-	Run() error
-	CombinedOutput() ([]byte, error)
+	Run(ctx context.Context) error
+	CombinedOutput(ctx context.Context) ([]byte, error)
 	AttachStdIO()
 	ArgsString() string
+	SetEnv(env []string)
+	SetDir(dir string)
+	SetStdin(stdin io.Reader)
+	SetTimeout(timeout time.Duration)
 }
 
 type DefaultCmd struct {
 	*exec.Cmd
+	timeout time.Duration
+}
+
+// Run runs the command, recording it in the active audit.
+func (cmd *DefaultCmd) Run(ctx context.Context) error {
+	started := time.Now()
+	err := cmd.run(ctx)
+	if audit := currentAudit(); audit != nil {
+		audit.add(cmd.Args, started, nil, err)
+	}
+	return err
+}
+
+// CombinedOutput runs the command and returns its combined stdout and
+// stderr, recording it in the active audit. Callers usually report
+// the output rather than the error, so it says when the command timed
+// out.
+func (cmd *DefaultCmd) CombinedOutput(ctx context.Context) ([]byte, error) {
+	started := time.Now()
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.run(ctx)
+	if err, ok := err.(*TimeoutError); ok {
+		fmt.Fprintf(&output, "\n%v", err)
+	}
+	if audit := currentAudit(); audit != nil {
+		audit.add(cmd.Args, started, output.Bytes(), err)
+	}
+	return output.Bytes(), err
+}
+
+// TimeoutError is returned when a command is killed for running past
+// its timeout.
+type TimeoutError struct {
+	Command string
+	Timeout time.Duration
+}
+
+func (err *TimeoutError) Error() string {
+	return fmt.Sprintf("Error: '%s' was killed after running for %s", err.Command, err.Timeout)
+}
+
+// run starts the command and waits for it, killing it once ctx is
+// done or it runs past its timeout.
+func (cmd *DefaultCmd) run(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := cmd.Cmd.Start(); err != nil {
+		return err
+	}
+	var timedOut int32
+	var timer *time.Timer
+	if cmd.timeout > 0 {
+		timer = time.AfterFunc(cmd.timeout, func() {
+			atomic.StoreInt32(&timedOut, 1)
+			cmd.Process.Kill()
+		})
+	}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			cmd.Process.Kill()
+		case <-done:
+		}
+	}()
+	err := cmd.Cmd.Wait()
+	close(done)
+	if timer != nil {
+		timer.Stop()
+	}
+	if err != nil && atomic.LoadInt32(&timedOut) == 1 {
+		return &TimeoutError{Command: cmd.ArgsString(), Timeout: cmd.timeout}
+	}
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// SetTimeout replaces the command's timeout, which is RequestTimeout
+// by default. Zero means no timeout, for commands that stream until
+// stopped or wait on the user.
+func (cmd *DefaultCmd) SetTimeout(timeout time.Duration) {
+	cmd.timeout = timeout
 }
 
 func (cmd *DefaultCmd) AttachStdIO() {
 	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = Stdout
+	cmd.Stderr = Stderr
 }
 
 func (cmd *DefaultCmd) ArgsString() string {
 	return strings.Join(cmd.Args, " ")
 }
 
+// SetEnv runs the command with env in addition to our own environment.
+func (cmd *DefaultCmd) SetEnv(env []string) {
+	cmd.Env = append(os.Environ(), env...)
+}
+
+func (cmd *DefaultCmd) SetDir(dir string) {
+	cmd.Dir = dir
+}
+
+// SetStdin runs the command reading stdin, for input like credentials
+// that shouldn't be on its command line.
+func (cmd *DefaultCmd) SetStdin(stdin io.Reader) {
+	cmd.Stdin = stdin
+}
+
 type Execer interface {
 	Oc(args ...string) ExecCmd
+	Command(name string, args ...string) ExecCmd
 }
 
 type DefaultExecer struct {
+	// Binary is the command line client to run, defaulting to "oc"
+	Binary string
 }
 
 func (execer *DefaultExecer) Oc(args ...string) ExecCmd {
-	return &DefaultCmd{exec.Command("oc", args...)}
+	binary := execer.Binary
+	if binary == "" {
+		binary = "oc"
+	}
+	return execer.Command(binary, args...)
+}
+
+// Command runs any other local tool, such as s2i or docker.
+func (execer *DefaultExecer) Command(name string, args ...string) ExecCmd {
+	path := name
+	if found, ok := lookInBinDir(name); ok {
+		path = found
+	} else if _, err := exec.LookPath(name); err != nil {
+		if found, ok := lookBesideSelf(name); ok {
+			path = found
+		}
+	}
+	cmd := exec.Command(path, args...)
+	// Commands found outside the PATH are still shown by name
+	cmd.Args[0] = name
+	return &DefaultCmd{Cmd: cmd, timeout: RequestTimeout}
 }
@@ -1,9 +1,59 @@
 package exec
 
 import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
 type MockExecer struct {
 	mock.Mock
 }
+
+func TestTimeoutKillsCommand(t *testing.T) {
+	execer := &DefaultExecer{}
+	cmd := execer.Command("sleep", "5")
+	cmd.SetTimeout(50 * time.Millisecond)
+	started := time.Now()
+	err := cmd.Run(context.Background())
+	assert.Less(t, int64(time.Since(started)), int64(5*time.Second))
+	assert.IsType(t, &TimeoutError{}, err)
+	assert.Contains(t, err.Error(), "'sleep 5' was killed after running for 50ms")
+
+	cmd = execer.Command("sh", "-c", "echo started; sleep 5")
+	cmd.SetTimeout(50 * time.Millisecond)
+	output, err := cmd.CombinedOutput(context.Background())
+	assert.NotNil(t, err)
+	assert.Contains(t, string(output), "started\n")
+	assert.Contains(t, string(output), "was killed after running for 50ms")
+}
+
+func TestCancelKillsCommand(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	started := time.Now()
+	err := (&DefaultExecer{}).Command("sleep", "5").Run(ctx)
+	assert.Less(t, int64(time.Since(started)), int64(5*time.Second))
+	assert.Equal(t, context.Canceled, err)
+
+	// Cancelled commands aren't started
+	cmd := (&DefaultExecer{}).Command("sh", "-c", "echo started")
+	output, err := cmd.CombinedOutput(ctx)
+	assert.Equal(t, context.Canceled, err)
+	assert.Empty(t, output)
+}
+
+func TestTimeoutDefaultsToRequestTimeout(t *testing.T) {
+	original := RequestTimeout
+	RequestTimeout = 0
+	defer func() { RequestTimeout = original }()
+
+	cmd := (&DefaultExecer{}).Command("sh", "-c", "echo done")
+	assert.Equal(t, time.Duration(0), cmd.(*DefaultCmd).timeout)
+	output, err := cmd.CombinedOutput(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "done\n", string(output))
+}
@@ -0,0 +1,56 @@
+package exec
+
+import (
+	"strings"
+	"sync"
+)
+
+// DefaultParallelism is how many independent commands Parallel runs at
+// once by default, enough to hide cluster latency without hammering
+// the API server.
+const DefaultParallelism = 8
+
+// Errors combines the errors of tasks run by Parallel.
+type Errors []error
+
+func (errs Errors) Error() string {
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, strings.TrimSpace(err.Error()))
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Parallel runs tasks with at most limit of them running at once and
+// waits for all of them to finish. Every task runs even if others
+// fail, and their errors are returned together as Errors, in the
+// order of tasks.
+func Parallel(limit int, tasks ...func() error) error {
+	if limit < 1 {
+		limit = DefaultParallelism
+	}
+	results := make([]error, len(tasks))
+	slots := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, task func() error) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = task()
+		}(i, task)
+	}
+	wg.Wait()
+
+	var errs Errors
+	for _, err := range results {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
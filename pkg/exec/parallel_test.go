@@ -0,0 +1,41 @@
+package exec
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallelLimitsConcurrency(t *testing.T) {
+	var running, maxRunning int32
+	var tasks []func() error
+	for i := 0; i < 10; i++ {
+		tasks = append(tasks, func() error {
+			current := atomic.AddInt32(&running, 1)
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+	}
+	assert.Nil(t, Parallel(3, tasks...))
+	assert.True(t, maxRunning <= 3)
+}
+
+func TestParallelAggregatesErrors(t *testing.T) {
+	err := Parallel(2,
+		func() error { return errors.New("first failed\n") },
+		func() error { return nil },
+		func() error { return errors.New("third failed") },
+	)
+	assert.Equal(t, Errors{errors.New("first failed\n"), errors.New("third failed")}, err)
+	assert.Equal(t, "first failed\nthird failed", err.Error())
+}
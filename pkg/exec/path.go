@@ -0,0 +1,126 @@
+package exec
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// goos is the operating system commands run on, and is replaced by
+// tests.
+var goos = runtime.GOOS
+
+// BinDir is where 'ocf install-oc' puts clients, which are preferred
+// over those on the PATH.
+var BinDir = defaultBinDir()
+
+// binDirCommands are the clients 'ocf install-oc' manages. Nothing else
+// in BinDir shadows the PATH.
+var binDirCommands = map[string]bool{"oc": true}
+
+func defaultBinDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ocf", "bin")
+}
+
+// windowsExts are the extensions of files Windows can run, in the
+// order they're preferred.
+var windowsExts = []string{".exe", ".cmd", ".bat"}
+
+// Windows reports whether commands run on Windows.
+func Windows() bool {
+	return goos == "windows"
+}
+
+// LookPath finds the command name like os/exec's LookPath, which adds
+// .exe and the like on Windows. The clients in BinDir win over those
+// on the PATH. Commands not on the PATH are also looked for beside ocf
+// itself, since Windows users usually unpack oc.exe there instead of
+// changing their PATH.
+func LookPath(name string) (string, error) {
+	if path, ok := lookInBinDir(name); ok {
+		return path, nil
+	}
+	path, err := exec.LookPath(name)
+	if err == nil {
+		return path, nil
+	}
+	if path, ok := lookBesideSelf(name); ok {
+		return path, nil
+	}
+	return "", err
+}
+
+func lookInBinDir(name string) (string, bool) {
+	if BinDir == "" || !binDirCommands[name] {
+		return "", false
+	}
+	return findIn(BinDir, name)
+}
+
+// lookBesideSelf returns the path of the command name in the
+// directory ocf is in, if it's there.
+func lookBesideSelf(name string) (string, bool) {
+	if filepath.Base(name) != name {
+		return "", false
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return "", false
+	}
+	return findIn(filepath.Dir(self), name)
+}
+
+// findIn returns the path of the command name in dir, if it's there.
+func findIn(dir string, name string) (string, bool) {
+	candidates := []string{name}
+	if Windows() && filepath.Ext(name) == "" {
+		candidates = nil
+		for _, ext := range windowsExts {
+			candidates = append(candidates, name+ext)
+		}
+	}
+	for _, candidate := range candidates {
+		path := filepath.Join(dir, candidate)
+		if info, err := os.Stat(path); err == nil {
+			if _, ok := CommandName(info); ok {
+				return path, true
+			}
+		}
+	}
+	return "", false
+}
+
+// CommandName returns the name a file is run by, without any Windows
+// executable extension, and whether it can be run at all. Windows
+// has no executable bits, so its extension decides instead.
+func CommandName(info os.FileInfo) (string, bool) {
+	if info.IsDir() {
+		return "", false
+	}
+	name := info.Name()
+	if !Windows() {
+		return name, info.Mode()&0111 != 0
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, windowsExt := range windowsExts {
+		if ext == windowsExt {
+			return strings.TrimSuffix(name, filepath.Ext(name)), true
+		}
+	}
+	return "", false
+}
+
+// Shell returns the command that runs script with the platform's
+// shell, sh or cmd.exe on Windows.
+func Shell(script string) (string, []string) {
+	if Windows() {
+		return "cmd", []string{"/C", script}
+	}
+	return "sh", []string{"-c", script}
+}
@@ -0,0 +1,90 @@
+package exec
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func withGoos(t *testing.T, os string) {
+	original := goos
+	goos = os
+	t.Cleanup(func() { goos = original })
+}
+
+func writeFile(t *testing.T, dir string, name string, mode os.FileMode) os.FileInfo {
+	path := filepath.Join(dir, name)
+	assert.Nil(t, ioutil.WriteFile(path, []byte("#!/bin/sh\n"), mode))
+	assert.Nil(t, os.Chmod(path, mode))
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	return info
+}
+
+func TestCommandName(t *testing.T) {
+	dir := t.TempDir()
+	script := writeFile(t, dir, "ocf-hello", 0755)
+	plain := writeFile(t, dir, "ocf-notes", 0644)
+	exe := writeFile(t, dir, "ocf-hello.exe", 0644)
+
+	withGoos(t, "linux")
+	name, ok := CommandName(script)
+	assert.True(t, ok)
+	assert.Equal(t, "ocf-hello", name)
+	_, ok = CommandName(plain)
+	assert.False(t, ok)
+
+	withGoos(t, "windows")
+	name, ok = CommandName(exe)
+	assert.True(t, ok)
+	assert.Equal(t, "ocf-hello", name)
+	_, ok = CommandName(script)
+	assert.False(t, ok)
+}
+
+func TestFindIn(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "oc.exe", 0644)
+
+	withGoos(t, "windows")
+	path, ok := findIn(dir, "oc")
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join(dir, "oc.exe"), path)
+	_, ok = findIn(dir, "kubectl")
+	assert.False(t, ok)
+
+	withGoos(t, "linux")
+	_, ok = findIn(dir, "oc")
+	assert.False(t, ok)
+}
+
+func TestShell(t *testing.T) {
+	withGoos(t, "linux")
+	name, args := Shell("./smoke.sh")
+	assert.Equal(t, "sh", name)
+	assert.Equal(t, []string{"-c", "./smoke.sh"}, args)
+
+	withGoos(t, "windows")
+	name, args = Shell("smoke.cmd")
+	assert.Equal(t, "cmd", name)
+	assert.Equal(t, []string{"/C", "smoke.cmd"}, args)
+}
+
+func TestLookInBinDirOnlyFindsManagedClients(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "oc", 0755)
+	writeFile(t, dir, "git", 0755)
+	original := BinDir
+	BinDir = dir
+	defer func() { BinDir = original }()
+	withGoos(t, "linux")
+
+	path, ok := lookInBinDir("oc")
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join(dir, "oc"), path)
+	_, ok = lookInBinDir("git")
+	assert.False(t, ok)
+}
@@ -0,0 +1,166 @@
+// Package log writes ocf's progress messages, either as text for
+// people or as JSON lines for tools, filtered by level.
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is how severe a message is.
+type Level int
+
+const (
+	// DebugLevel messages help diagnose ocf itself
+	DebugLevel Level = iota
+	// InfoLevel messages report a command's progress
+	InfoLevel
+	// WarnLevel messages report problems ocf worked around
+	WarnLevel
+	// ErrorLevel messages report failures
+	ErrorLevel
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (level Level) String() string {
+	if level < DebugLevel || level > ErrorLevel {
+		return fmt.Sprint("level", int(level))
+	}
+	return levelNames[level]
+}
+
+// ParseLevel returns the level named name, such as "debug".
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return Level(level), nil
+		}
+	}
+	return InfoLevel, errors.New(fmt.Sprintf("Error: Invalid log level %s, must be one of %s", name, strings.Join(levelNames, ", ")))
+}
+
+const (
+	FormatText string = "text"
+	FormatJSON string = "json"
+)
+
+var (
+	mutex  sync.Mutex
+	level  = InfoLevel
+	format = FormatText
+	// Debug and info messages go to out, warnings and errors to errOut
+	out    io.Writer = os.Stdout
+	errOut io.Writer = os.Stderr
+)
+
+// SetLevel hides messages less severe than l.
+func SetLevel(l Level) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	level = l
+}
+
+// SetFormat writes messages as FormatText or FormatJSON.
+func SetFormat(f string) error {
+	if f != FormatText && f != FormatJSON {
+		return errors.New(fmt.Sprintf("Error: Invalid log format %s, must be %s or %s", f, FormatText, FormatJSON))
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	format = f
+	return nil
+}
+
+// SetOutput writes messages to w instead of stdout and stderr.
+func SetOutput(w io.Writer) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	out = w
+	errOut = w
+}
+
+// Output returns where informational messages are written, for
+// output that's drawn rather than logged, like progress bars.
+func Output() io.Writer {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return out
+}
+
+// Enabled returns true if messages at l are written.
+func Enabled(l Level) bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return l >= level
+}
+
+// JSON returns true if messages are written as JSON, so output meant
+// for people, like progress bars, should be skipped.
+func JSON() bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return format == FormatJSON
+}
+
+// Debugf writes details that are only useful when troubleshooting.
+func Debugf(format string, v ...interface{}) {
+	write(DebugLevel, "", format, v...)
+}
+
+// Infof writes a step of what ocf is doing, like "==> Creating build".
+func Infof(format string, v ...interface{}) {
+	write(InfoLevel, "==> ", format, v...)
+}
+
+// Printf writes informational text without a prefix, such as the
+// output of an oc command.
+func Printf(format string, v ...interface{}) {
+	write(InfoLevel, "", format, v...)
+}
+
+// Warnf writes something the user should know about that doesn't
+// stop ocf.
+func Warnf(format string, v ...interface{}) {
+	write(WarnLevel, "WARN: ", format, v...)
+}
+
+// Errorf writes why ocf failed.
+func Errorf(format string, v ...interface{}) {
+	write(ErrorLevel, "", format, v...)
+}
+
+type entry struct {
+	Time    string `json:"time"`
+	Level   string `json:"level"`
+	Message string `json:"msg"`
+}
+
+func write(l Level, prefix string, msgFormat string, v ...interface{}) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if l < level {
+		return
+	}
+	w := out
+	if l >= WarnLevel {
+		w = errOut
+	}
+	message := strings.TrimRight(fmt.Sprintf(msgFormat, v...), "\n")
+	if format == FormatJSON {
+		line, _ := json.Marshal(entry{
+			Time:    time.Now().UTC().Format(time.RFC3339),
+			Level:   l.String(),
+			Message: message,
+		})
+		fmt.Fprintln(w, string(line))
+		return
+	}
+	fmt.Fprint(w, prefix, message, "\n")
+}
@@ -0,0 +1,48 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func withOutput(t *testing.T, l Level, f string) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	SetOutput(buf)
+	SetLevel(l)
+	assert.Nil(t, SetFormat(f))
+	return buf
+}
+
+func TestTextOutput(t *testing.T) {
+	buf := withOutput(t, InfoLevel, FormatText)
+	Debugf("hidden %d", 1)
+	Infof("Creating build for %s\n", "foo")
+	Printf("Using project %s", "bar")
+	Warnf("Skipping %s", "baz")
+	assert.Equal(t, "==> Creating build for foo\nUsing project bar\nWARN: Skipping baz\n", buf.String())
+}
+
+func TestJSONOutput(t *testing.T) {
+	buf := withOutput(t, DebugLevel, FormatJSON)
+	defer withOutput(t, InfoLevel, FormatText)
+	Debugf("Config: %s\n", "{}")
+
+	var logged map[string]string
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &logged))
+	assert.Equal(t, "debug", logged["level"])
+	assert.Equal(t, "Config: {}", logged["msg"])
+	assert.NotEmpty(t, logged["time"])
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("WARN")
+	assert.Nil(t, err)
+	assert.Equal(t, WarnLevel, level)
+
+	_, err = ParseLevel("verbose")
+	assert.NotNil(t, err)
+	assert.NotNil(t, SetFormat("xml"))
+}
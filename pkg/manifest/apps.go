@@ -0,0 +1,184 @@
+// The logic for merging manifests with flags comes from Cloud
+// Foundry's 'cf' tool. See the NOTICE file for more information.
+
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/log"
+
+	"github.com/imdario/mergo"
+)
+
+// ReadApps validates and loads the applications of the manifest at
+// path, printing any problems found. Their paths are made absolute
+// relative to the manifest's directory.
+func ReadApps(path string, vars map[string]string) ([]app.Application, error) {
+	problems, err := Validate(path, vars)
+	if err != nil {
+		return nil, err
+	}
+	err = ReportProblems(problems)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := Load(path, vars)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("manifest: %+v", m)
+
+	apps := make([]app.Application, 0, len(m.Applications))
+	for i := range m.Applications {
+		apps = append(apps, m.Applications[i].Application())
+	}
+	err = ResolvePaths(apps, filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	return apps, nil
+}
+
+// Merge combines the applications from a manifest with the one
+// described by command line flags, following the same precedence
+// rules as 'cf push':
+//
+//   - Without a manifest, flags describe a single application.
+//   - With a single manifest application, flags override its values.
+//   - With multiple manifest applications, an app name selects one of
+//     them and flags override its values. Without an app name, flags
+//     are rejected unless applyToAll is set, in which case they
+//     override the values of every application.
+func Merge(manifestApps []app.Application, flagsApp app.Application, applyToAll bool) ([]app.Application, error) {
+	var apps []app.Application
+
+	switch len(manifestApps) {
+	case 0:
+		if flagsApp.Name == "" {
+			return nil, errors.New("Manifest file is not found in the current directory, please provide either an app name or manifest")
+		}
+		if err := addApp(&apps, flagsApp); err != nil {
+			return nil, err
+		}
+	case 1:
+		if err := mergeWithFlags(&manifestApps[0], flagsApp); err != nil {
+			return nil, err
+		}
+		if err := addApp(&apps, manifestApps[0]); err != nil {
+			return nil, err
+		}
+	default:
+		selectedAppName := flagsApp.Name
+
+		if selectedAppName != "" {
+			var foundApp bool
+			for _, currentApp := range manifestApps {
+				if currentApp.Name == selectedAppName {
+					foundApp = true
+					if err := mergeWithFlags(&currentApp, flagsApp); err != nil {
+						return nil, err
+					}
+					if err := addApp(&apps, currentApp); err != nil {
+						return nil, err
+					}
+				}
+			}
+			if !foundApp {
+				return nil, errors.New(fmt.Sprintf("Could not find app named %s in manifest", selectedAppName))
+			}
+		} else {
+			if hasFlagOverrides(flagsApp) && !applyToAll {
+				return nil, errors.New("Error: Command line flags (except -f and --no-manifest) cannot be applied when pushing multiple apps from a manifest file. Specify an app name or use --all to apply them to every app.")
+			}
+			for _, manifestApp := range manifestApps {
+				if err := mergeWithFlags(&manifestApp, flagsApp); err != nil {
+					return nil, err
+				}
+				if err := addApp(&apps, manifestApp); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return apps, nil
+}
+
+func mergeWithFlags(manifestApp *app.Application, flagsApp app.Application) error {
+	return mergo.MergeWithOverwrite(manifestApp, flagsApp)
+}
+
+// hasFlagOverrides returns true if any flag other than the app name
+// was given.
+func hasFlagOverrides(flagsApp app.Application) bool {
+	flagsApp.Name = ""
+	return !reflect.DeepEqual(flagsApp, app.Application{})
+}
+
+func addApp(apps *[]app.Application, app app.Application) error {
+	if app.Name == "" {
+		return errors.New("App name is a required field")
+	}
+
+	if app.Path == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		app.Path = cwd
+	}
+
+	*apps = append(*apps, app)
+	return nil
+}
+
+// ResolvePaths makes each application's path absolute, treating
+// relative paths as relative to the manifest's directory instead of
+// the current working directory. Applications without a path default
+// to the manifest's directory. Paths written on Windows, with
+// backslashes, work everywhere.
+func ResolvePaths(apps []app.Application, manifestDir string) error {
+	manifestDir, err := filepath.Abs(manifestDir)
+	if err != nil {
+		return err
+	}
+	for i := range apps {
+		apps[i].Path = filepath.FromSlash(strings.ReplaceAll(apps[i].Path, `\`, "/"))
+		switch {
+		case apps[i].Path == "":
+			apps[i].Path = manifestDir
+		case !filepath.IsAbs(apps[i].Path):
+			apps[i].Path = filepath.Join(manifestDir, apps[i].Path)
+		default:
+			apps[i].Path = filepath.Clean(apps[i].Path)
+		}
+	}
+	return nil
+}
+
+// ValidatePaths ensures every application's path exists before we
+// start touching the cluster, so a typo doesn't leave a half-pushed
+// set of applications behind. Docker applications have nothing to
+// upload so their path is ignored.
+func ValidatePaths(apps []app.Application) error {
+	for _, app := range apps {
+		if app.IsDocker() {
+			continue
+		}
+		if _, err := os.Stat(app.Path); err != nil {
+			if os.IsNotExist(err) {
+				return errors.New(fmt.Sprintf("Error: Path %s for app %s does not exist", app.Path, app.Name))
+			}
+			return err
+		}
+	}
+	return nil
+}
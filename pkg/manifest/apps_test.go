@@ -0,0 +1,87 @@
+package manifest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bbrowning/ocf/pkg/app"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolvePaths(t *testing.T) {
+	apps := []app.Application{
+		{Name: "default"},
+		{Name: "relative", Path: "target/foo.jar"},
+		{Name: "absolute", Path: "/tmp/../opt/foo"},
+		{Name: "windows", Path: `target\foo.jar`},
+	}
+	err := ResolvePaths(apps, "/srv/project")
+	assert.Nil(t, err)
+	assert.Equal(t, "/srv/project", apps[0].Path)
+	assert.Equal(t, filepath.Join("/srv/project", "target", "foo.jar"), apps[1].Path)
+	assert.Equal(t, "/opt/foo", apps[2].Path)
+	assert.Equal(t, filepath.Join("/srv/project", "target", "foo.jar"), apps[3].Path)
+}
+
+func TestValidatePaths(t *testing.T) {
+	withManifestDir(t, func(dir string) {
+		err := ValidatePaths([]app.Application{{Name: "foo", Path: dir}})
+		assert.Nil(t, err)
+
+		err = ValidatePaths([]app.Application{{Name: "foo", Path: filepath.Join(dir, "missing")}})
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "does not exist")
+	})
+}
+
+func TestMergeWithoutManifest(t *testing.T) {
+	_, err := Merge(nil, app.Application{}, false)
+	assert.NotNil(t, err)
+
+	apps, err := Merge(nil, app.Application{Name: "foo", Path: "/foo"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []app.Application{{Name: "foo", Path: "/foo"}}, apps)
+}
+
+func TestMergeSingleManifestApp(t *testing.T) {
+	manifestApps := []app.Application{{Name: "foo", Memory: "1G", Buildpack: "bp", Path: "/foo"}}
+	apps, err := Merge(manifestApps, app.Application{Memory: "2G"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(apps))
+	assert.Equal(t, "2G", apps[0].Memory)
+	assert.Equal(t, "bp", apps[0].Buildpack)
+}
+
+func TestMergeMultipleManifestAppsSelectedByName(t *testing.T) {
+	manifestApps := []app.Application{
+		{Name: "foo", Memory: "1G", Path: "/foo"},
+		{Name: "bar", Memory: "1G", Path: "/bar"},
+	}
+	apps, err := Merge(manifestApps, app.Application{Name: "bar", Memory: "2G"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []app.Application{{Name: "bar", Memory: "2G", Path: "/bar"}}, apps)
+
+	_, err = Merge(manifestApps, app.Application{Name: "baz"}, false)
+	assert.NotNil(t, err)
+}
+
+func TestMergeMultipleManifestAppsWithFlags(t *testing.T) {
+	manifestApps := []app.Application{
+		{Name: "foo", Memory: "1G", Path: "/foo"},
+		{Name: "bar", Memory: "1G", Path: "/bar"},
+	}
+	apps, err := Merge(manifestApps, app.Application{}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, manifestApps, apps)
+
+	_, err = Merge(manifestApps, app.Application{Memory: "2G"}, false)
+	assert.NotNil(t, err)
+
+	apps, err = Merge(manifestApps, app.Application{Memory: "2G"}, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(apps))
+	assert.Equal(t, "2G", apps[0].Memory)
+	assert.Equal(t, "2G", apps[1].Memory)
+	assert.Equal(t, "/bar", apps[1].Path)
+}
@@ -0,0 +1,241 @@
+// The inheritance and global property semantics here follow the
+// legacy behavior of Cloud Foundry's 'cf' tool. See the NOTICE file
+// for more information.
+
+// Package manifest loads Cloud Foundry application manifests and
+// merges their applications with those given by command line flags.
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ghodss/yaml"
+)
+
+const (
+	applicationsKey = "applications"
+	inheritKey      = "inherit"
+	versionKey      = "version"
+)
+
+// Find returns the path of the manifest to use given the
+// user-supplied manifest path, which may be empty, a file, or a
+// directory. Directories are searched for the first of
+// candidates that exists. The returned path may not exist.
+func Find(manifestPath string) (string, error) {
+	var path string
+	var err error
+	if manifestPath != "" {
+		path = manifestPath
+	} else {
+		path, err = os.Getwd()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return findInDir(path), nil
+	}
+	return path, nil
+}
+
+// candidates lists, in order of preference, the locations
+// checked when looking for a manifest inside a directory.
+var candidates = []string{
+	"manifest.yml",
+	"manifest.yaml",
+	filepath.Join("manifests", "manifest.yml"),
+	filepath.Join("manifests", "manifest.yaml"),
+}
+
+func findInDir(dir string) string {
+	for _, candidate := range candidates {
+		path := filepath.Join(dir, candidate)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return filepath.Join(dir, candidates[0])
+}
+
+// Load reads the manifest at path, merges in any manifests it
+// inherits from, and applies top-level properties as defaults to
+// every application. YAML anchors and merge keys are resolved by the
+// YAML parser itself. Any ((var)) placeholders are replaced with vars.
+func Load(path string, vars map[string]string) (Manifest, error) {
+	var m Manifest
+
+	raw, err := loadRaw(path, vars, make(map[string]bool))
+	if err != nil {
+		return m, err
+	}
+	version, hasVersion := raw[versionKey]
+	delete(raw, versionKey)
+	raw = applyGlobalProperties(raw)
+	if hasVersion {
+		raw[versionKey] = version
+	}
+
+	j, err := json.Marshal(raw)
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(j, &m)
+	if err != nil {
+		return m, errors.New(fmt.Sprintf("Error parsing manifest %s: %v", path, err))
+	}
+	if hasVersion && m.Version != Version {
+		return m, errors.New(fmt.Sprintf("Error: Manifest %s has unsupported version %v, only version %d is supported", path, version, Version))
+	}
+	return m, nil
+}
+
+func loadRaw(path string, vars map[string]string, seen map[string]bool) (map[string]interface{}, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if seen[absPath] {
+		return nil, errors.New(fmt.Sprintf("Error: Circular manifest inheritance detected at %s", absPath))
+	}
+	seen[absPath] = true
+
+	y, err := ioutil.ReadFile(absPath)
+	if err != nil {
+		return nil, err
+	}
+	y, err = interpolate(absPath, y, vars)
+	if err != nil {
+		return nil, err
+	}
+	raw := make(map[string]interface{})
+	err = yaml.Unmarshal(y, &raw)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error parsing manifest %s: %v", absPath, err))
+	}
+
+	inherit, ok := raw[inheritKey]
+	if !ok {
+		return raw, nil
+	}
+	delete(raw, inheritKey)
+	parentPath, ok := inherit.(string)
+	if !ok || parentPath == "" {
+		return nil, errors.New(fmt.Sprintf("Error: Invalid inherit value in manifest %s", absPath))
+	}
+	if !filepath.IsAbs(parentPath) {
+		parentPath = filepath.Join(filepath.Dir(absPath), parentPath)
+	}
+
+	parent, err := loadRaw(parentPath, vars, seen)
+	if err != nil {
+		return nil, err
+	}
+	return mergeMaps(parent, raw), nil
+}
+
+// mergeMaps merges child on top of parent. Nested maps are
+// merged recursively, applications are merged by name, and any other
+// value in child replaces the one in parent.
+func mergeMaps(parent map[string]interface{}, child map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{})
+	for key, value := range parent {
+		merged[key] = value
+	}
+	for key, childValue := range child {
+		parentValue, ok := merged[key]
+		if !ok {
+			merged[key] = childValue
+			continue
+		}
+		parentMap, parentIsMap := parentValue.(map[string]interface{})
+		childMap, childIsMap := childValue.(map[string]interface{})
+		parentList, parentIsList := parentValue.([]interface{})
+		childList, childIsList := childValue.([]interface{})
+		switch {
+		case parentIsMap && childIsMap:
+			merged[key] = mergeMaps(parentMap, childMap)
+		case key == applicationsKey && parentIsList && childIsList:
+			merged[key] = mergeApps(parentList, childList)
+		default:
+			merged[key] = childValue
+		}
+	}
+	return merged
+}
+
+func mergeApps(parentApps []interface{}, childApps []interface{}) []interface{} {
+	var merged []interface{}
+	childByName := make(map[string]map[string]interface{})
+	for _, childApp := range childApps {
+		if childMap, ok := childApp.(map[string]interface{}); ok {
+			if name, ok := childMap["name"].(string); ok {
+				childByName[name] = childMap
+			}
+		}
+	}
+
+	used := make(map[string]bool)
+	for _, parentApp := range parentApps {
+		parentMap, ok := parentApp.(map[string]interface{})
+		if !ok {
+			merged = append(merged, parentApp)
+			continue
+		}
+		name, _ := parentMap["name"].(string)
+		if childMap, ok := childByName[name]; ok {
+			merged = append(merged, mergeMaps(parentMap, childMap))
+			used[name] = true
+		} else {
+			merged = append(merged, parentMap)
+		}
+	}
+
+	for _, childApp := range childApps {
+		if childMap, ok := childApp.(map[string]interface{}); ok {
+			if name, ok := childMap["name"].(string); ok && used[name] {
+				continue
+			}
+		}
+		merged = append(merged, childApp)
+	}
+	return merged
+}
+
+// applyGlobalProperties copies top-level manifest properties into each
+// application that doesn't already set them. Other top-level keys,
+// like one only holding a YAML anchor, are left out.
+func applyGlobalProperties(raw map[string]interface{}) map[string]interface{} {
+	globals := make(map[string]interface{})
+	for key, value := range raw {
+		if _, ok := appKeys[key]; ok {
+			globals[key] = value
+		}
+	}
+	if len(globals) == 0 {
+		return raw
+	}
+
+	apps, _ := raw[applicationsKey].([]interface{})
+	if len(apps) == 0 {
+		// A manifest with only global properties describes a
+		// single, unnamed application
+		apps = []interface{}{make(map[string]interface{})}
+	}
+
+	var merged []interface{}
+	for _, currentApp := range apps {
+		if appMap, ok := currentApp.(map[string]interface{}); ok {
+			merged = append(merged, mergeMaps(globals, appMap))
+		} else {
+			merged = append(merged, currentApp)
+		}
+	}
+	return map[string]interface{}{applicationsKey: merged}
+}
@@ -0,0 +1,129 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadManifestWithInherit(t *testing.T) {
+	withManifestDir(t, func(dir string) {
+		writeManifest(t, dir, "parent.yml", `
+memory: 512M
+applications:
+- name: foo
+  buildpack: parent-bp
+  instances: 2
+- name: bar
+`)
+		writeManifest(t, filepath.Join(dir, "child"), "manifest.yml", `
+inherit: ../parent.yml
+applications:
+- name: foo
+  buildpack: child-bp
+- name: baz
+`)
+		m, err := Load(filepath.Join(dir, "child", "manifest.yml"), nil)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(m.Applications))
+		assert.Equal(t, "foo", m.Applications[0].Name)
+		assert.Equal(t, "child-bp", m.Applications[0].Buildpack)
+		assert.Equal(t, 2, m.Applications[0].Instances)
+		assert.Equal(t, "512M", m.Applications[0].Memory)
+		assert.Equal(t, "bar", m.Applications[1].Name)
+		assert.Equal(t, "baz", m.Applications[2].Name)
+		assert.Equal(t, "512M", m.Applications[2].Memory)
+	})
+}
+
+func TestLoadManifestWithInheritCycle(t *testing.T) {
+	withManifestDir(t, func(dir string) {
+		writeManifest(t, dir, "a.yml", "inherit: b.yml\n")
+		writeManifest(t, dir, "b.yml", "inherit: a.yml\n")
+		_, err := Load(filepath.Join(dir, "a.yml"), nil)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "Circular")
+	})
+}
+
+func TestLoadManifestWithAnchors(t *testing.T) {
+	withManifestDir(t, func(dir string) {
+		writeManifest(t, dir, "manifest.yml", `
+defaults: &defaults
+  memory: 1G
+  buildpack: shared-bp
+applications:
+- name: foo
+  <<: *defaults
+- name: bar
+  <<: *defaults
+  memory: 256M
+`)
+		m, err := Load(filepath.Join(dir, "manifest.yml"), nil)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(m.Applications))
+		assert.Equal(t, "1G", m.Applications[0].Memory)
+		assert.Equal(t, "shared-bp", m.Applications[0].Buildpack)
+		assert.Equal(t, "256M", m.Applications[1].Memory)
+	})
+}
+
+func TestLoadManifestIgnoresUnknownGlobals(t *testing.T) {
+	withManifestDir(t, func(dir string) {
+		writeManifest(t, dir, "manifest.yml", `
+defaults: &defaults
+  memory: 1G
+x-notes: shared settings
+applications:
+- name: foo
+  <<: *defaults
+`)
+		raw, err := loadRaw(filepath.Join(dir, "manifest.yml"), nil, make(map[string]bool))
+		assert.Nil(t, err)
+		apps := applyGlobalProperties(raw)[applicationsKey].([]interface{})
+		assert.Equal(t, map[string]interface{}{"name": "foo", "memory": "1G"}, apps[0])
+	})
+}
+
+func TestFindManifestDiscoveryOrder(t *testing.T) {
+	withManifestDir(t, func(dir string) {
+		path, err := Find(dir)
+		assert.Nil(t, err)
+		assert.Equal(t, filepath.Join(dir, "manifest.yml"), path)
+
+		expected := writeManifest(t, filepath.Join(dir, "manifests"), "manifest.yaml", "")
+		path, _ = Find(dir)
+		assert.Equal(t, expected, path)
+
+		expected = writeManifest(t, dir, "manifest.yaml", "")
+		path, _ = Find(dir)
+		assert.Equal(t, expected, path)
+
+		expected = writeManifest(t, dir, "manifest.yml", "")
+		path, _ = Find(dir)
+		assert.Equal(t, expected, path)
+	})
+}
+
+func withManifestDir(t *testing.T, handler func(string)) {
+	dir, err := ioutil.TempDir("", "ocf-manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	handler(dir)
+}
+
+func writeManifest(t *testing.T, dir string, name string, contents string) string {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,291 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/bbrowning/ocf/pkg/app"
+)
+
+// Version is the manifest schema version of Cloud Foundry's v3 API,
+// the only one it defines. Manifests without a version are legacy
+// manifests.
+const Version = 1
+
+// Manifest is a manifest's applications, after inheritance and global
+// properties have been applied.
+type Manifest struct {
+	Version      int   `json:"version,omitempty"`
+	Applications []App `json:"applications"`
+}
+
+// App is an application in a manifest, with every key of Cloud
+// Foundry's schema plus ocf's own build-env, hooks, project, cpu,
+// termination-grace-period, node-selector, and tolerations.
+type App struct {
+	Name       string      `json:"name"`
+	Path       string      `json:"path,omitempty"`
+	Buildpacks []string    `json:"buildpacks,omitempty"`
+	Stack      string      `json:"stack,omitempty"`
+	Command    string      `json:"command,omitempty"`
+	DiskQuota  string      `json:"disk_quota,omitempty"`
+	Memory     string      `json:"memory,omitempty"`
+	CPU        CPU         `json:"cpu,omitempty"`
+	Instances  int         `json:"instances,omitempty"`
+	Docker     *app.Docker `json:"docker,omitempty"`
+	Env        EnvVars     `json:"env,omitempty"`
+	Services   []Service   `json:"services,omitempty"`
+	Metadata   *Metadata   `json:"metadata,omitempty"`
+
+	Routes       []Route `json:"routes,omitempty"`
+	NoRoute      bool    `json:"no-route,omitempty"`
+	RandomRoute  bool    `json:"random-route,omitempty"`
+	DefaultRoute bool    `json:"default-route,omitempty"`
+
+	HealthCheck
+	Processes []Process `json:"processes,omitempty"`
+	Sidecars  []Sidecar `json:"sidecars,omitempty"`
+
+	BuildEnv EnvVars    `json:"build-env,omitempty"`
+	Hooks    *app.Hooks `json:"hooks,omitempty"`
+	// Project is the project the application is pushed to. Space is
+	// accepted for it too, as Cloud Foundry's closest equivalent
+	Project string `json:"project,omitempty"`
+	Space   string `json:"space,omitempty"`
+	// GracePeriod is how many seconds instances have to exit after
+	// SIGTERM
+	GracePeriod int `json:"termination-grace-period,omitempty"`
+	// NodeSelector and Tolerations pick the nodes instances run on
+	NodeSelector map[string]string `json:"node-selector,omitempty"`
+	Tolerations  []app.Toleration  `json:"tolerations,omitempty"`
+
+	// Buildpack is the legacy form of Buildpacks
+	Buildpack string `json:"buildpack,omitempty"`
+	// Host, Hosts, Domain, Domains, and NoHostname are the legacy
+	// form of Routes
+	Host       string   `json:"host,omitempty"`
+	Hosts      []string `json:"hosts,omitempty"`
+	Domain     string   `json:"domain,omitempty"`
+	Domains    []string `json:"domains,omitempty"`
+	NoHostname bool     `json:"no-hostname,omitempty"`
+}
+
+// HealthCheck is how Cloud Foundry decides an application's or
+// process's instances are healthy.
+type HealthCheck struct {
+	HealthCheckType              string `json:"health-check-type,omitempty"`
+	HealthCheckHTTPEndpoint      string `json:"health-check-http-endpoint,omitempty"`
+	HealthCheckInvocationTimeout int    `json:"health-check-invocation-timeout,omitempty"`
+	// Timeout is how many seconds instances have to become healthy
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// Route is a route in a manifest.
+type Route struct {
+	Route    string `json:"route"`
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// Service is a service instance bound to an application, given in a
+// manifest either by name or as a map.
+type Service struct {
+	Name        string                 `json:"name"`
+	BindingName string                 `json:"binding_name,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// UnmarshalJSON accepts a service's name alone as well as a map.
+func (service *Service) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*service = Service{Name: name}
+		return nil
+	}
+	type plain Service
+	return json.Unmarshal(data, (*plain)(service))
+}
+
+// Process is one of an application's process types, such as web or
+// worker.
+type Process struct {
+	Type      string `json:"type"`
+	Command   string `json:"command,omitempty"`
+	DiskQuota string `json:"disk_quota,omitempty"`
+	Memory    string `json:"memory,omitempty"`
+	Instances int    `json:"instances,omitempty"`
+	HealthCheck
+}
+
+// Sidecar is an additional process run alongside some of an
+// application's process types.
+type Sidecar struct {
+	Name         string   `json:"name"`
+	ProcessTypes []string `json:"process_types"`
+	Command      string   `json:"command"`
+	Memory       string   `json:"memory,omitempty"`
+}
+
+// Metadata is the labels and annotations of an application.
+type Metadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// CPU is a CPU quantity from a manifest, which YAML reads as a number
+// when it's a whole or fractional number of cores.
+type CPU string
+
+// UnmarshalJSON accepts numbers as well as strings.
+func (cpu *CPU) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	switch raw := raw.(type) {
+	case string:
+		*cpu = CPU(raw)
+	case float64:
+		*cpu = CPU(strconv.FormatFloat(raw, 'f', -1, 64))
+	default:
+		return errors.New("Error: cpu must be a number of cores or millicores")
+	}
+	return nil
+}
+
+// EnvVars are environment variables from a manifest. YAML numbers and
+// booleans are kept as they were written instead of being rejected.
+type EnvVars map[string]string
+
+// UnmarshalJSON converts any scalar value to a string.
+func (env *EnvVars) UnmarshalJSON(data []byte) error {
+	var raw map[string]interface{}
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	*env = make(EnvVars)
+	for name, value := range raw {
+		switch value := value.(type) {
+		case string:
+			(*env)[name] = value
+		case nil:
+			(*env)[name] = ""
+		case map[string]interface{}, []interface{}:
+			return errors.New(fmt.Sprintf("Error: Environment variable %s must be a string", name))
+		default:
+			(*env)[name] = fmt.Sprint(value)
+		}
+	}
+	return nil
+}
+
+// Application returns the application push deploys for app.
+func (a *App) Application() app.Application {
+	application := app.Application{
+		Name:        a.Name,
+		Buildpack:   a.Buildpack,
+		Stack:       a.Stack,
+		Command:     a.Command,
+		DiskQuota:   a.DiskQuota,
+		Instances:   a.Instances,
+		Memory:      a.Memory,
+		CPU:         string(a.CPU),
+		Timeout:     a.Timeout,
+		GracePeriod: a.GracePeriod,
+		Path:        a.Path,
+		Docker:      a.Docker,
+		Hooks:       a.Hooks,
+		Project:     a.Project,
+
+		NodeSelector: a.NodeSelector,
+		Tolerations:  a.Tolerations,
+	}
+	if application.Project == "" {
+		application.Project = a.Space
+	}
+	if len(a.Buildpacks) > 0 {
+		// The last buildpack is the final one, which starts the
+		// application
+		application.Buildpack = a.Buildpacks[len(a.Buildpacks)-1]
+	}
+	if len(a.BuildEnv) > 0 {
+		application.BuildEnv = map[string]string(a.BuildEnv)
+	}
+	for _, service := range a.Services {
+		application.Services = append(application.Services, service.Name)
+	}
+	for _, process := range a.Processes {
+		if process.Type != app.WebProcess {
+			application.Processes = append(application.Processes, app.Process{
+				Type:      process.Type,
+				Command:   process.Command,
+				Instances: process.Instances,
+				Memory:    process.Memory,
+			})
+			continue
+		}
+		// The web process's settings are the application's own
+		if process.Command != "" {
+			application.Command = process.Command
+		}
+		if process.Instances > 0 {
+			application.Instances = process.Instances
+		}
+		if process.Memory != "" {
+			application.Memory = process.Memory
+		}
+		if process.Timeout > 0 {
+			application.Timeout = process.Timeout
+		}
+	}
+	for _, sidecar := range a.Sidecars {
+		application.Sidecars = append(application.Sidecars, app.Sidecar{
+			Name:         sidecar.Name,
+			ProcessTypes: sidecar.ProcessTypes,
+			Command:      sidecar.Command,
+			Memory:       sidecar.Memory,
+		})
+	}
+	for _, route := range a.Routes {
+		application.Routes = append(application.Routes, app.Route{Route: route.Route})
+	}
+	if len(a.Routes) == 0 {
+		application.Routes = a.legacyRoutes()
+	}
+	return application
+}
+
+// legacyRoutes returns the routes given by the legacy host and domain
+// keys. Hosts default to the application's name. Without a domain the
+// cluster's default one is used, as if none were given.
+func (a *App) legacyRoutes() []app.Route {
+	var domains []string
+	if a.Domain != "" {
+		domains = append(domains, a.Domain)
+	}
+	domains = append(domains, a.Domains...)
+
+	var hosts []string
+	if a.Host != "" {
+		hosts = append(hosts, a.Host)
+	}
+	hosts = append(hosts, a.Hosts...)
+	if len(hosts) == 0 {
+		hosts = []string{a.Name}
+	}
+
+	var routes []app.Route
+	for _, domain := range domains {
+		if a.NoHostname {
+			routes = append(routes, app.Route{Route: domain})
+			continue
+		}
+		for _, host := range hosts {
+			routes = append(routes, app.Route{Route: fmt.Sprint(host, ".", domain)})
+		}
+	}
+	return routes
+}
@@ -0,0 +1,132 @@
+package manifest
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/bbrowning/ocf/pkg/app"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadFullSchema(t *testing.T) {
+	withManifestDir(t, func(dir string) {
+		writeManifest(t, dir, "manifest.yml", `
+version: 1
+applications:
+- name: foo
+  buildpacks:
+  - nodejs_buildpack
+  - final_buildpack
+  env:
+    PORT: 8080
+    DEBUG: true
+    NAME: foo
+  services:
+  - db
+  - name: cache
+    binding_name: redis
+    parameters:
+      size: small
+  routes:
+  - route: foo.example.com
+    protocol: http2
+  metadata:
+    labels:
+      team: payments
+  health-check-type: http
+  health-check-http-endpoint: /health
+  processes:
+  - type: web
+    instances: 2
+  - type: worker
+    command: ./worker
+  sidecars:
+  - name: proxy
+    process_types: [web]
+    command: ./proxy
+`)
+		m, err := Load(filepath.Join(dir, "manifest.yml"), nil)
+		assert.Nil(t, err)
+		assert.Equal(t, Version, m.Version)
+		assert.Equal(t, 1, len(m.Applications))
+
+		foo := m.Applications[0]
+		assert.Equal(t, EnvVars{"PORT": "8080", "DEBUG": "true", "NAME": "foo"}, foo.Env)
+		assert.Equal(t, []Service{{Name: "db"}, {Name: "cache", BindingName: "redis",
+			Parameters: map[string]interface{}{"size": "small"}}}, foo.Services)
+		assert.Equal(t, "http2", foo.Routes[0].Protocol)
+		assert.Equal(t, "payments", foo.Metadata.Labels["team"])
+		assert.Equal(t, "/health", foo.HealthCheckHTTPEndpoint)
+		assert.Equal(t, 2, len(foo.Processes))
+		assert.Equal(t, 2, foo.Processes[0].Instances)
+		assert.Equal(t, []string{"web"}, foo.Sidecars[0].ProcessTypes)
+
+		application := foo.Application()
+		assert.Equal(t, "final_buildpack", application.Buildpack)
+		assert.Equal(t, []string{"db", "cache"}, application.Services)
+		assert.Equal(t, []app.Route{{Route: "foo.example.com"}}, application.Routes)
+		assert.Equal(t, 2, application.Instances)
+		assert.Equal(t, []app.Process{{Type: "worker", Command: "./worker"}}, application.Processes)
+		assert.Equal(t, []app.Sidecar{{Name: "proxy", ProcessTypes: []string{"web"}, Command: "./proxy"}}, application.Sidecars)
+	})
+}
+
+func TestLoadUnsupportedVersion(t *testing.T) {
+	withManifestDir(t, func(dir string) {
+		writeManifest(t, dir, "manifest.yml", "version: 2\napplications:\n- name: foo\n")
+		_, err := Load(filepath.Join(dir, "manifest.yml"), nil)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "unsupported version 2")
+	})
+}
+
+func TestLegacyRoutes(t *testing.T) {
+	a := App{Name: "foo", Hosts: []string{"www", "api"}, Domain: "example.com"}
+	assert.Equal(t, []app.Route{{Route: "www.example.com"}, {Route: "api.example.com"}}, a.Application().Routes)
+
+	a = App{Name: "foo", Domains: []string{"example.com", "example.org"}}
+	assert.Equal(t, []app.Route{{Route: "foo.example.com"}, {Route: "foo.example.org"}}, a.Application().Routes)
+
+	a = App{Name: "foo", Domain: "example.com", NoHostname: true}
+	assert.Equal(t, []app.Route{{Route: "example.com"}}, a.Application().Routes)
+
+	a = App{Name: "foo", Host: "www"}
+	assert.Nil(t, a.Application().Routes)
+}
+
+func TestProject(t *testing.T) {
+	a := App{Name: "foo", Space: "backend"}
+	assert.Equal(t, "backend", a.Application().Project)
+
+	a = App{Name: "foo", Project: "frontend", Space: "backend"}
+	assert.Equal(t, "frontend", a.Application().Project)
+}
+
+func TestCPU(t *testing.T) {
+	var a App
+	assert.Nil(t, json.Unmarshal([]byte(`{"name":"foo","cpu":0.5}`), &a))
+	assert.Equal(t, "0.5", a.Application().CPU)
+
+	assert.Nil(t, json.Unmarshal([]byte(`{"name":"foo","cpu":"500m"}`), &a))
+	assert.Equal(t, "500m", a.Application().CPU)
+
+	assert.NotNil(t, json.Unmarshal([]byte(`{"name":"foo","cpu":[1]}`), &a))
+}
+
+func TestTimeout(t *testing.T) {
+	a := App{Name: "foo", HealthCheck: HealthCheck{Timeout: 60}}
+	assert.Equal(t, 60, a.Application().Timeout)
+
+	a.Processes = []Process{{Type: "web", HealthCheck: HealthCheck{Timeout: 120}}}
+	assert.Equal(t, 120, a.Application().Timeout)
+}
+
+func TestPlacement(t *testing.T) {
+	var a App
+	assert.Nil(t, json.Unmarshal([]byte(`{"name":"foo","node-selector":{"gpu":"true"},"tolerations":[{"key":"gpu","operator":"Exists","effect":"NoSchedule"}]}`), &a))
+	application := a.Application()
+	assert.Equal(t, map[string]string{"gpu": "true"}, application.NodeSelector)
+	assert.Equal(t, []app.Toleration{{Key: "gpu", Operator: "Exists", Effect: "NoSchedule"}}, application.Tolerations)
+}
@@ -0,0 +1,615 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/app"
+	"github.com/bbrowning/ocf/pkg/oc"
+
+	"gopkg.in/yaml.v3"
+)
+
+type keyType int
+
+const (
+	keyString keyType = iota
+	keyInt
+	keyByteSize
+	keyStringList
+	keyDocker
+	keyStringMap
+	keyHooks
+	keyRoutes
+	keyBool
+	keyServices
+	keyProcesses
+	keySidecars
+	keyMetadata
+	keyMap
+	keyHealthCheckType
+	keyProject
+	keyEnv
+	keyCPU
+	keyTolerations
+)
+
+// appKeys lists the application keys we understand along
+// with the type of value each one expects. Anything not listed here
+// generates a warning since it will be ignored during push.
+var appKeys = map[string]keyType{
+	"name":          keyString,
+	"buildpack":     keyString,
+	"buildpacks":    keyStringList,
+	"build-env":     keyEnv,
+	"command":       keyString,
+	"cpu":           keyCPU,
+	"default-route": keyBool,
+	"disk_quota":    keyByteSize,
+	"docker":        keyDocker,
+	"domain":        keyString,
+	"domains":       keyStringList,
+	"env":           keyEnv,
+	"hooks":         keyHooks,
+	"host":          keyString,
+	"hosts":         keyStringList,
+	"instances":     keyInt,
+	"memory":        keyByteSize,
+	"metadata":      keyMetadata,
+	"no-hostname":   keyBool,
+	"no-route":      keyBool,
+	"path":          keyString,
+	"processes":     keyProcesses,
+	"project":       keyProject,
+	"random-route":  keyBool,
+	"routes":        keyRoutes,
+	"services":      keyServices,
+	"sidecars":      keySidecars,
+	"space":         keyProject,
+	"stack":         keyString,
+
+	"termination-grace-period": keyInt,
+	"node-selector":            keyStringMap,
+	"tolerations":              keyTolerations,
+
+	"health-check-type":               keyHealthCheckType,
+	"health-check-http-endpoint":      keyString,
+	"health-check-invocation-timeout": keyInt,
+	"timeout":                         keyInt,
+}
+
+// deprecatedKeys maps legacy application keys to what replaces them.
+var deprecatedKeys = map[string]string{
+	"buildpack":   "buildpacks",
+	"domain":      "routes",
+	"domains":     "routes",
+	"host":        "routes",
+	"hosts":       "routes",
+	"no-hostname": "routes",
+}
+
+// ignoredKeys are part of Cloud Foundry's schema but not applied by
+// push yet.
+var ignoredKeys = map[string]bool{
+	"default-route":                   true,
+	"env":                             true,
+	"health-check-type":               true,
+	"health-check-http-endpoint":      true,
+	"health-check-invocation-timeout": true,
+	"metadata":                        true,
+	"no-route":                        true,
+	"random-route":                    true,
+}
+
+var healthCheckTypes = map[string]bool{"port": true, "process": true, "http": true, "none": true}
+
+var dockerKeys = map[string]keyType{
+	"image":    keyString,
+	"username": keyString,
+}
+
+var hookKeys = map[string]keyType{
+	"pre-push":  keyString,
+	"post-push": keyString,
+}
+
+var routeKeys = map[string]keyType{
+	"route":    keyString,
+	"protocol": keyString,
+}
+
+var serviceKeys = map[string]keyType{
+	"name":         keyString,
+	"binding_name": keyString,
+	"parameters":   keyMap,
+}
+
+var metadataKeys = map[string]keyType{
+	"labels":      keyStringMap,
+	"annotations": keyStringMap,
+}
+
+var processKeys = map[string]keyType{
+	"type":       keyString,
+	"command":    keyString,
+	"disk_quota": keyByteSize,
+	"instances":  keyInt,
+	"memory":     keyByteSize,
+
+	"health-check-type":               keyHealthCheckType,
+	"health-check-http-endpoint":      keyString,
+	"health-check-invocation-timeout": keyInt,
+	"timeout":                         keyInt,
+}
+
+var sidecarKeys = map[string]keyType{
+	"name":          keyString,
+	"process_types": keyStringList,
+	"command":       keyString,
+	"memory":        keyByteSize,
+}
+
+var tolerationKeys = map[string]keyType{
+	"key":               keyString,
+	"operator":          keyString,
+	"value":             keyString,
+	"effect":            keyString,
+	"tolerationSeconds": keyInt,
+}
+
+var ByteSizeRegexp = regexp.MustCompile("^\\d+[EPTGMK]?$")
+
+// CPURegexp matches CPU quantities, in cores or millicores.
+var CPURegexp = app.CPURegexp
+
+// projectRegexp matches the names projects and namespaces can have.
+var projectRegexp = regexp.MustCompile("^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$")
+
+// Problem describes a single issue found while validating a
+// manifest. Warnings don't prevent a push but errors do.
+type Problem struct {
+	File    string
+	Line    int
+	Message string
+	Warning bool
+}
+
+func (problem Problem) String() string {
+	level := "Error"
+	if problem.Warning {
+		level = "Warning"
+	}
+	return fmt.Sprintf("%s: %s:%d: %s", level, problem.File, problem.Line, problem.Message)
+}
+
+// Validate validates the manifest at path and every manifest
+// it inherits from, after replacing any ((var)) placeholders with
+// vars, returning all problems found.
+func Validate(path string, vars map[string]string) ([]Problem, error) {
+	var problems []Problem
+	seen := make(map[string]bool)
+	for path != "" {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		if seen[absPath] {
+			return nil, errors.New(fmt.Sprintf("Error: Circular manifest inheritance detected at %s", absPath))
+		}
+		seen[absPath] = true
+
+		y, err := ioutil.ReadFile(absPath)
+		if err != nil {
+			return nil, err
+		}
+		y, err = interpolate(absPath, y, vars)
+		if err != nil {
+			return nil, err
+		}
+		var fileProblems []Problem
+		fileProblems, path = validateContents(absPath, y)
+		problems = append(problems, fileProblems...)
+		if path != "" && !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(absPath), path)
+		}
+	}
+	return problems, nil
+}
+
+// validateContents validates a single manifest file's
+// contents, returning any problems found along with the path of the
+// manifest it inherits from, if any.
+func validateContents(file string, contents []byte) ([]Problem, string) {
+	v := &validator{file: file}
+
+	var doc yaml.Node
+	err := yaml.Unmarshal(contents, &doc)
+	if err != nil {
+		v.errorf(0, "%v", err)
+		return v.problems, ""
+	}
+	if len(doc.Content) == 0 {
+		return v.problems, ""
+	}
+
+	root := resolveAlias(doc.Content[0])
+	if root.Kind != yaml.MappingNode {
+		v.errorf(root.Line, "manifest must be a map of keys to values")
+		return v.problems, ""
+	}
+
+	var inherit string
+	var versioned bool
+	var legacyKeys []*yaml.Node
+	appNames := make(map[string]int)
+	v.eachPair(root, func(key *yaml.Node, value *yaml.Node) {
+		switch key.Value {
+		case versionKey:
+			if v.checkType(key, value, keyInt) {
+				versioned = true
+				if value.Value != strconv.Itoa(Version) {
+					v.errorf(value.Line, "unsupported manifest version %s, only version %d is supported", value.Value, Version)
+				}
+			}
+		case inheritKey:
+			if v.checkType(key, value, keyString) {
+				inherit = value.Value
+			}
+			legacyKeys = append(legacyKeys, key)
+		case applicationsKey:
+			if value.Kind != yaml.SequenceNode {
+				v.errorf(value.Line, "applications must be a list")
+				return
+			}
+			for _, appNode := range value.Content {
+				v.validateApp(resolveAlias(appNode), appNames)
+			}
+		default:
+			v.validateAppKey(key, value)
+			if _, ok := appKeys[key.Value]; ok {
+				legacyKeys = append(legacyKeys, key)
+			}
+		}
+	})
+
+	// Inheritance and global properties are still supported, but
+	// aren't part of the versioned schema so Cloud Foundry itself
+	// rejects them
+	if versioned {
+		for _, key := range legacyKeys {
+			if key.Value == inheritKey {
+				v.warnf(key.Line, "inherit is deprecated in version %d manifests", Version)
+			} else {
+				v.warnf(key.Line, "top-level %s is deprecated in version %d manifests, set it on each application instead", key.Value, Version)
+			}
+		}
+	}
+	return v.problems, inherit
+}
+
+type validator struct {
+	file     string
+	problems []Problem
+}
+
+func (v *validator) errorf(line int, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{
+		File:    v.file,
+		Line:    line,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) warnf(line int, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{
+		File:    v.file,
+		Line:    line,
+		Message: fmt.Sprintf(format, args...),
+		Warning: true,
+	})
+}
+
+// eachPair calls handler for every key/value pair in a mapping node,
+// expanding YAML merge keys along the way.
+func (v *validator) eachPair(node *yaml.Node, handler func(*yaml.Node, *yaml.Node)) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], resolveAlias(node.Content[i+1])
+		if key.Value == "<<" {
+			merged := []*yaml.Node{value}
+			if value.Kind == yaml.SequenceNode {
+				merged = value.Content
+			}
+			for _, m := range merged {
+				if m = resolveAlias(m); m.Kind == yaml.MappingNode {
+					v.eachPair(m, handler)
+				}
+			}
+			continue
+		}
+		handler(key, value)
+	}
+}
+
+func (v *validator) validateApp(node *yaml.Node, appNames map[string]int) {
+	if node.Kind != yaml.MappingNode {
+		v.errorf(node.Line, "each application must be a map of keys to values")
+		return
+	}
+	v.eachPair(node, func(key *yaml.Node, value *yaml.Node) {
+		v.validateAppKey(key, value)
+		if key.Value == "name" && value.Kind == yaml.ScalarNode {
+			if line, ok := appNames[value.Value]; ok {
+				v.errorf(value.Line, "duplicate application name %s (first defined on line %d)", value.Value, line)
+			} else {
+				appNames[value.Value] = value.Line
+			}
+		}
+	})
+}
+
+func (v *validator) validateAppKey(key *yaml.Node, value *yaml.Node) {
+	keyType, ok := appKeys[key.Value]
+	if !ok {
+		v.warnf(key.Line, "unknown key %s will be ignored", key.Value)
+		return
+	}
+	if !v.checkType(key, value, keyType) {
+		return
+	}
+	if replacement, ok := deprecatedKeys[key.Value]; ok {
+		v.warnf(key.Line, "%s is deprecated, use %s instead", key.Value, replacement)
+	}
+	if ignoredKeys[key.Value] {
+		v.warnf(key.Line, "%s is not applied by push yet and will be ignored", key.Value)
+	}
+}
+
+func (v *validator) checkType(key *yaml.Node, value *yaml.Node, keyType keyType) bool {
+	switch keyType {
+	case keyString:
+		if value.Kind != yaml.ScalarNode || value.Tag == "!!null" {
+			v.errorf(value.Line, "%s must be a string", key.Value)
+			return false
+		}
+		if value.Tag != "!!str" {
+			// Quote-less numbers and booleans still unmarshal
+			// fine, but are almost certainly a mistake
+			v.warnf(value.Line, "%s should be a quoted string", key.Value)
+		}
+	case keyInt:
+		if value.Kind != yaml.ScalarNode || value.Tag != "!!int" {
+			v.errorf(value.Line, "%s must be an integer", key.Value)
+			return false
+		}
+	case keyBool:
+		if value.Kind != yaml.ScalarNode || value.Tag != "!!bool" {
+			v.errorf(value.Line, "%s must be true or false", key.Value)
+			return false
+		}
+	case keyHealthCheckType:
+		if !v.checkType(key, value, keyString) {
+			return false
+		}
+		if !healthCheckTypes[value.Value] {
+			v.errorf(value.Line, "%s must be port, process, or http", key.Value)
+			return false
+		}
+		if value.Value == "none" {
+			v.warnf(value.Line, "%s none is deprecated, use process instead", key.Value)
+		}
+	case keyMap:
+		if value.Kind != yaml.MappingNode {
+			v.errorf(value.Line, "%s must be a map of keys to values", key.Value)
+			return false
+		}
+	case keyMetadata:
+		return v.checkMap(key, value, metadataKeys)
+	case keyProcesses:
+		return v.checkMapList(key, value, processKeys, "type")
+	case keySidecars:
+		return v.checkMapList(key, value, sidecarKeys, "name", "process_types", "command")
+	case keyTolerations:
+		return v.checkMapList(key, value, tolerationKeys)
+	case keyServices:
+		if value.Kind != yaml.SequenceNode {
+			v.errorf(value.Line, "%s must be a list", key.Value)
+			return false
+		}
+		valid := true
+		for _, item := range value.Content {
+			item = resolveAlias(item)
+			switch item.Kind {
+			case yaml.ScalarNode:
+			case yaml.MappingNode:
+				valid = v.checkMap(key, item, serviceKeys, "name") && valid
+			default:
+				v.errorf(item.Line, "%s must only contain names or maps with a name", key.Value)
+				valid = false
+			}
+		}
+		return valid
+	case keyByteSize:
+		size := strings.TrimSuffix(strings.ToUpper(value.Value), "B")
+		if value.Kind != yaml.ScalarNode || value.Tag != "!!str" || !ByteSizeRegexp.MatchString(size) {
+			v.errorf(value.Line, "%s must be in the format of 8690K, 256M, 256MB, 1G, 1GB, etc", key.Value)
+			return false
+		}
+	case keyCPU:
+		if value.Kind != yaml.ScalarNode || !CPURegexp.MatchString(value.Value) {
+			v.errorf(value.Line, "%s must be a number of cores or millicores, such as 0.5, 2, or 500m", key.Value)
+			return false
+		}
+	case keyProject:
+		if value.Kind != yaml.ScalarNode || !projectRegexp.MatchString(value.Value) {
+			v.errorf(value.Line, "%s must be a project name of lowercase letters, digits, and dashes", key.Value)
+			return false
+		}
+	case keyStringList:
+		if value.Kind != yaml.SequenceNode {
+			v.errorf(value.Line, "%s must be a list", key.Value)
+			return false
+		}
+		for _, item := range value.Content {
+			if item = resolveAlias(item); item.Kind != yaml.ScalarNode {
+				v.errorf(item.Line, "%s must only contain strings", key.Value)
+				return false
+			}
+		}
+	case keyStringMap, keyEnv:
+		if value.Kind != yaml.MappingNode {
+			v.errorf(value.Line, "%s must be a map of keys to values", key.Value)
+			return false
+		}
+		valid := true
+		v.eachPair(value, func(mapKey *yaml.Node, mapValue *yaml.Node) {
+			if mapValue.Kind != yaml.ScalarNode {
+				v.errorf(mapValue.Line, "%s.%s must be a string", key.Value, mapKey.Value)
+				valid = false
+			}
+			if keyType == keyEnv && oc.CheckEnvName(mapKey.Value) != nil {
+				v.errorf(mapKey.Line, "%s.%s is not a valid environment variable name", key.Value, mapKey.Value)
+				valid = false
+			}
+		})
+		return valid
+	case keyDocker:
+		if value.Kind != yaml.MappingNode {
+			v.errorf(value.Line, "%s must be a map of keys to values", key.Value)
+			return false
+		}
+		var hasImage bool
+		valid := true
+		v.eachPair(value, func(dockerKey *yaml.Node, dockerValue *yaml.Node) {
+			dockerKeyType, ok := dockerKeys[dockerKey.Value]
+			if !ok {
+				v.warnf(dockerKey.Line, "unknown key %s.%s will be ignored", key.Value, dockerKey.Value)
+				return
+			}
+			hasImage = hasImage || dockerKey.Value == "image"
+			valid = v.checkType(dockerKey, dockerValue, dockerKeyType) && valid
+		})
+		if !hasImage {
+			v.errorf(key.Line, "%s.image is required", key.Value)
+			return false
+		}
+		return valid
+	case keyHooks:
+		if value.Kind != yaml.MappingNode {
+			v.errorf(value.Line, "%s must be a map of keys to values", key.Value)
+			return false
+		}
+		valid := true
+		v.eachPair(value, func(hookKey *yaml.Node, hookValue *yaml.Node) {
+			hookKeyType, ok := hookKeys[hookKey.Value]
+			if !ok {
+				v.warnf(hookKey.Line, "unknown key %s.%s will be ignored", key.Value, hookKey.Value)
+				return
+			}
+			valid = v.checkType(hookKey, hookValue, hookKeyType) && valid
+		})
+		return valid
+	case keyRoutes:
+		if value.Kind != yaml.SequenceNode {
+			v.errorf(value.Line, "%s must be a list", key.Value)
+			return false
+		}
+		valid := true
+		for _, item := range value.Content {
+			item = resolveAlias(item)
+			if item.Kind != yaml.MappingNode {
+				v.errorf(item.Line, "%s must only contain maps with a route", key.Value)
+				valid = false
+				continue
+			}
+			var hasRoute bool
+			v.eachPair(item, func(routeKey *yaml.Node, routeValue *yaml.Node) {
+				routeKeyType, ok := routeKeys[routeKey.Value]
+				if !ok {
+					v.warnf(routeKey.Line, "unknown key %s.%s will be ignored", key.Value, routeKey.Value)
+					return
+				}
+				hasRoute = true
+				if !v.checkType(routeKey, routeValue, routeKeyType) {
+					valid = false
+				} else if _, _, err := app.ParseRoute(routeValue.Value); err != nil {
+					v.errorf(routeValue.Line, "%s", strings.TrimSpace(err.Error()))
+					valid = false
+				}
+			})
+			if !hasRoute {
+				v.errorf(item.Line, "%s.route is required", key.Value)
+				valid = false
+			}
+		}
+		return valid
+	}
+	return true
+}
+
+// checkMap checks the values of a map's keys, warning about unknown
+// ones, and that the required keys are present.
+func (v *validator) checkMap(key *yaml.Node, value *yaml.Node, keys map[string]keyType, required ...string) bool {
+	if value.Kind != yaml.MappingNode {
+		v.errorf(value.Line, "%s must be a map of keys to values", key.Value)
+		return false
+	}
+	valid := true
+	found := make(map[string]bool)
+	v.eachPair(value, func(mapKey *yaml.Node, mapValue *yaml.Node) {
+		mapKeyType, ok := keys[mapKey.Value]
+		if !ok {
+			v.warnf(mapKey.Line, "unknown key %s.%s will be ignored", key.Value, mapKey.Value)
+			return
+		}
+		found[mapKey.Value] = true
+		valid = v.checkType(mapKey, mapValue, mapKeyType) && valid
+	})
+	for _, name := range required {
+		if !found[name] {
+			v.errorf(value.Line, "%s.%s is required", key.Value, name)
+			valid = false
+		}
+	}
+	return valid
+}
+
+// checkMapList checks a list of maps with checkMap.
+func (v *validator) checkMapList(key *yaml.Node, value *yaml.Node, keys map[string]keyType, required ...string) bool {
+	if value.Kind != yaml.SequenceNode {
+		v.errorf(value.Line, "%s must be a list", key.Value)
+		return false
+	}
+	valid := true
+	for _, item := range value.Content {
+		valid = v.checkMap(key, resolveAlias(item), keys, required...) && valid
+	}
+	return valid
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// ReportProblems prints all problems and returns an error if
+// any of them are errors rather than warnings.
+func ReportProblems(problems []Problem) error {
+	var errorCount int
+	for _, problem := range problems {
+		fmt.Println(problem)
+		if !problem.Warning {
+			errorCount++
+		}
+	}
+	if errorCount > 0 {
+		return errors.New(fmt.Sprintf("Error: Manifest has %d error(s)", errorCount))
+	}
+	return nil
+}
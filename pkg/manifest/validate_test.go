@@ -0,0 +1,218 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateManifestContentsValid(t *testing.T) {
+	problems, inherit := validateContents("manifest.yml", []byte(`
+inherit: base.yml
+applications:
+- name: foo
+  memory: 512M
+  instances: 2
+  services:
+  - rails-postgres
+`))
+	assert.Equal(t, 0, len(problems))
+	assert.Equal(t, "base.yml", inherit)
+}
+
+func TestValidateManifestContentsProblems(t *testing.T) {
+	problems, _ := validateContents("manifest.yml", []byte(`applications:
+- name: foo
+  memory: lots
+  instances: two
+  bogus: true
+- name: foo
+`))
+	assert.Equal(t, 4, len(problems))
+	assert.Equal(t, Problem{File: "manifest.yml", Line: 3, Message: "memory must be in the format of 8690K, 256M, 256MB, 1G, 1GB, etc"}, problems[0])
+	assert.Equal(t, 4, problems[1].Line)
+	assert.Contains(t, problems[1].Message, "instances must be an integer")
+	assert.True(t, problems[2].Warning)
+	assert.Equal(t, 5, problems[2].Line)
+	assert.Contains(t, problems[3].Message, "duplicate application name foo (first defined on line 2)")
+	assert.Equal(t, 6, problems[3].Line)
+}
+
+func TestValidateManifestContentsFollowsMergeKeys(t *testing.T) {
+	problems, _ := validateContents("manifest.yml", []byte(`defaults: &defaults
+  memory: 1X
+applications:
+- name: foo
+  <<: *defaults
+`))
+	var errors []Problem
+	for _, problem := range problems {
+		if !problem.Warning {
+			errors = append(errors, problem)
+		}
+	}
+	assert.Equal(t, 1, len(errors))
+	assert.Contains(t, errors[0].Message, "memory must be")
+}
+
+func TestReportProblems(t *testing.T) {
+	assert.Nil(t, ReportProblems([]Problem{{Warning: true}}))
+	assert.NotNil(t, ReportProblems([]Problem{{Warning: true}, {}}))
+}
+
+func TestValidateManifestContentsDocker(t *testing.T) {
+	problems, _ := validateContents("manifest.yml", []byte(`applications:
+- name: foo
+  docker:
+    image: nginx
+    username: me
+- name: bar
+  docker:
+    username: me
+`))
+	assert.Equal(t, 1, len(problems))
+	assert.Equal(t, 7, problems[0].Line)
+	assert.Contains(t, problems[0].Message, "docker.image is required")
+}
+
+func TestValidateManifestContentsHooks(t *testing.T) {
+	problems, _ := validateContents("manifest.yml", []byte(`applications:
+- name: foo
+  hooks:
+    pre-push: ./migrate.sh
+    post-deploy: ./smoke.sh
+`))
+	assert.Equal(t, 1, len(problems))
+	assert.True(t, problems[0].Warning)
+	assert.Contains(t, problems[0].Message, "unknown key hooks.post-deploy will be ignored")
+}
+
+func TestValidateManifestContentsRoutes(t *testing.T) {
+	problems, _ := validateContents("manifest.yml", []byte(`applications:
+- name: foo
+  routes:
+  - route: example.com/api
+  - route: https://example.com
+  - host: example.com
+`))
+	assert.Equal(t, 3, len(problems))
+	assert.Contains(t, problems[0].Message, "must not include a scheme")
+	assert.True(t, problems[1].Warning)
+	assert.Contains(t, problems[2].Message, "routes.route is required")
+}
+
+func TestValidateContentsDeprecatedKeys(t *testing.T) {
+	problems, _ := validateContents("manifest.yml", []byte(`applications:
+- name: foo
+  buildpack: ruby_buildpack
+  host: www
+  health-check-type: none
+`))
+	assert.Equal(t, 4, len(problems))
+	for _, problem := range problems {
+		assert.True(t, problem.Warning)
+	}
+	assert.Contains(t, problems[0].Message, "buildpack is deprecated, use buildpacks instead")
+	assert.Contains(t, problems[1].Message, "host is deprecated, use routes instead")
+	assert.Contains(t, problems[2].Message, "health-check-type none is deprecated")
+	assert.Contains(t, problems[3].Message, "health-check-type is not applied by push yet")
+}
+
+func TestValidateContentsProject(t *testing.T) {
+	problems, _ := validateContents("manifest.yml", []byte(`applications:
+- name: frontend
+  project: web
+- name: backend
+  space: Backend_Services
+`))
+	assert.Equal(t, 1, len(problems))
+	assert.False(t, problems[0].Warning)
+	assert.Contains(t, problems[0].Message, "space must be a project name")
+}
+
+func TestValidateContentsEnvNames(t *testing.T) {
+	problems, _ := validateContents("manifest.yml", []byte(`applications:
+- name: foo
+  env:
+    SPRING_PROFILES_ACTIVE: cloud
+    1BAD: x
+  build-env:
+    NPM TOKEN: x
+`))
+	assert.Equal(t, 2, len(problems))
+	assert.Contains(t, problems[0].Message, "env.1BAD is not a valid environment variable name")
+	assert.Contains(t, problems[1].Message, "build-env.NPM TOKEN is not a valid environment variable name")
+}
+
+func TestValidateContentsVersion(t *testing.T) {
+	problems, _ := validateContents("manifest.yml", []byte(`version: 1
+memory: 1G
+applications:
+- name: foo
+`))
+	assert.Equal(t, 1, len(problems))
+	assert.True(t, problems[0].Warning)
+	assert.Contains(t, problems[0].Message, "top-level memory is deprecated in version 1 manifests")
+
+	problems, _ = validateContents("manifest.yml", []byte("version: 3\napplications:\n- name: foo\n"))
+	assert.Equal(t, 1, len(problems))
+	assert.Contains(t, problems[0].Message, "unsupported manifest version 3")
+}
+
+func TestValidateContentsProcessesAndSidecars(t *testing.T) {
+	problems, _ := validateContents("manifest.yml", []byte(`applications:
+- name: foo
+  services:
+  - name: db
+  - binding_name: cache
+  processes:
+  - type: web
+    instances: two
+  sidecars:
+  - name: proxy
+    command: ./proxy
+`))
+	var errors []string
+	for _, problem := range problems {
+		if !problem.Warning {
+			errors = append(errors, problem.Message)
+		}
+	}
+	assert.Equal(t, []string{
+		"services.name is required",
+		"instances must be an integer",
+		"sidecars.process_types is required",
+	}, errors)
+}
+
+func TestValidateContentsCPU(t *testing.T) {
+	problems, _ := validateContents("manifest.yml", []byte(`applications:
+- name: foo
+  cpu: 2
+- name: bar
+  cpu: 500m
+- name: baz
+  cpu: 1 core
+`))
+	assert.Equal(t, 1, len(problems))
+	assert.False(t, problems[0].Warning)
+	assert.Contains(t, problems[0].Message, "cpu must be a number of cores or millicores")
+}
+
+func TestValidateContentsPlacement(t *testing.T) {
+	problems, _ := validateContents("manifest.yml", []byte(`applications:
+- name: foo
+  node-selector:
+    gpu: "true"
+  tolerations:
+  - key: gpu
+    operator: Exists
+    effect: NoSchedule
+- name: bar
+  tolerations:
+  - key: gpu
+    tolerationSeconds: soon
+`))
+	assert.Equal(t, 1, len(problems))
+	assert.Contains(t, problems[0].Message, "tolerationSeconds")
+}
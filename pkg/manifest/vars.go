@@ -0,0 +1,78 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultVarsEnvPrefix is the prefix of the environment variables
+// manifest variables are read from by default.
+const DefaultVarsEnvPrefix = "CF_VAR_"
+
+// varPattern matches ((name)) placeholders in a manifest.
+var varPattern = regexp.MustCompile(`\(\(([\w.-]+)\)\)`)
+
+// VarsFromEnv returns the manifest variables set by environment
+// variables starting with prefix, or nil if prefix is empty.
+func VarsFromEnv(prefix string) map[string]string {
+	if prefix == "" {
+		return nil
+	}
+	vars := make(map[string]string)
+	for _, envVar := range os.Environ() {
+		split := strings.SplitN(envVar, "=", 2)
+		if len(split) == 2 && strings.HasPrefix(split[0], prefix) {
+			vars[strings.TrimPrefix(split[0], prefix)] = split[1]
+		}
+	}
+	return vars
+}
+
+// interpolate replaces the ((name)) placeholders in a
+// manifest's contents with vars, returning an error naming every
+// placeholder that isn't set. Contents are left alone if vars is nil.
+func interpolate(file string, contents []byte, vars map[string]string) ([]byte, error) {
+	if vars == nil {
+		return contents, nil
+	}
+	missing := make(map[string]bool)
+	interpolated := varPattern.ReplaceAllFunc(contents, func(placeholder []byte) []byte {
+		name := string(varPattern.FindSubmatch(placeholder)[1])
+		value, ok := vars[name]
+		if !ok {
+			// Environment variables are usually upper case and
+			// can't contain dashes or dots
+			value, ok = vars[strings.NewReplacer("-", "_", ".", "_").Replace(strings.ToUpper(name))]
+		}
+		if !ok {
+			missing[name] = true
+			return placeholder
+		}
+		return []byte(varValue(value))
+	})
+	if len(missing) > 0 {
+		var names []string
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, errors.New(fmt.Sprintf("Error: Manifest %s uses variables that aren't set: %s", file, strings.Join(names, ", ")))
+	}
+	return interpolated, nil
+}
+
+// varValue quotes values that YAML would otherwise
+// misinterpret, which only works for placeholders that make up a
+// whole value.
+func varValue(value string) string {
+	if strings.ContainsAny(value, ":#{}[],&*!|>'\"%@`\n") || strings.TrimSpace(value) != value {
+		quoted, _ := json.Marshal(value)
+		return string(quoted)
+	}
+	return value
+}
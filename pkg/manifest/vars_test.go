@@ -0,0 +1,31 @@
+package manifest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVarsFromEnv(t *testing.T) {
+	os.Setenv("OCF_TEST_VAR_memory", "512M")
+	defer os.Unsetenv("OCF_TEST_VAR_memory")
+	assert.Equal(t, map[string]string{"memory": "512M"}, VarsFromEnv("OCF_TEST_VAR_"))
+	assert.Nil(t, VarsFromEnv(""))
+}
+
+func TestInterpolate(t *testing.T) {
+	contents := []byte("applications:\n- name: ((app-name))\n  memory: ((memory))\n  command: ((cmd))\n")
+	vars := map[string]string{"APP_NAME": "foo", "memory": "512M", "cmd": "run: now"}
+	interpolated, err := interpolate("manifest.yml", contents, vars)
+	assert.Nil(t, err)
+	assert.Equal(t, "applications:\n- name: foo\n  memory: 512M\n  command: \"run: now\"\n", string(interpolated))
+
+	_, err = interpolate("manifest.yml", contents, map[string]string{})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "variables that aren't set: app-name, cmd, memory")
+
+	unchanged, err := interpolate("manifest.yml", contents, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, contents, unchanged)
+}
@@ -1,7 +1,10 @@
 package mocks
 
 import (
+	"context"
+	"io"
 	"strings"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -9,14 +12,21 @@ import (
 type ExecCmd struct {
 	mock.Mock
 	Args []string
+	// Timeout is the last timeout set, which isn't an expected call
+	// so tests only check it when they care
+	Timeout time.Duration
+	// Stdin is the last stdin set, kept like Timeout
+	Stdin io.Reader
 }
 
-func (cmd *ExecCmd) Run() error {
+// Run doesn't record ctx, so tests expect it as "Run" with no
+// arguments.
+func (cmd *ExecCmd) Run(ctx context.Context) error {
 	args := cmd.Called()
 	return args.Error(0)
 }
 
-func (cmd *ExecCmd) CombinedOutput() ([]byte, error) {
+func (cmd *ExecCmd) CombinedOutput(ctx context.Context) ([]byte, error) {
 	args := cmd.Called()
 	return args.Get(0).([]byte), args.Error(1)
 }
@@ -25,6 +35,22 @@ func (cmd *ExecCmd) AttachStdIO() {
 	cmd.Called()
 }
 
+func (cmd *ExecCmd) SetEnv(env []string) {
+	cmd.Called(env)
+}
+
+func (cmd *ExecCmd) SetDir(dir string) {
+	cmd.Called(dir)
+}
+
+func (cmd *ExecCmd) SetStdin(stdin io.Reader) {
+	cmd.Stdin = stdin
+}
+
+func (cmd *ExecCmd) SetTimeout(timeout time.Duration) {
+	cmd.Timeout = timeout
+}
+
 func (cmd *ExecCmd) ArgsString() string {
 	return strings.Join(cmd.Args, " ")
 }
@@ -14,3 +14,8 @@ func (execer *Execer) Oc(args ...string) exec.ExecCmd {
 	mockArgs := execer.Called(args)
 	return mockArgs.Get(0).(exec.ExecCmd)
 }
+
+func (execer *Execer) Command(name string, args ...string) exec.ExecCmd {
+	mockArgs := execer.Called(name, args)
+	return mockArgs.Get(0).(exec.ExecCmd)
+}
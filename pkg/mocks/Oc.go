@@ -1,15 +1,30 @@
 package mocks
 
 import (
+	"context"
+	"fmt"
+	"sort"
+
 	"github.com/stretchr/testify/mock"
 
 	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/oc/types"
 )
 
+// Oc records the calls made to it without their contexts, so tests
+// expect them with the same arguments as before.
 type Oc struct {
 	mock.Mock
 	Execer   Execer
 	loggedIn bool
+	// PlatformName is returned by Platform, defaulting to "openshift"
+	PlatformName string
+	// OcCapabilities is returned by Capabilities, defaulting to
+	// types.LatestCapabilities
+	OcCapabilities *types.Capabilities
+	// OwnedObjects maps applications to the objects given to Own, as
+	// type/name
+	OwnedObjects map[string][]string
 }
 
 func NewMockOc() *Oc {
@@ -18,34 +33,145 @@ func NewMockOc() *Oc {
 	}
 }
 
-func (oc *Oc) LoggedIn() bool {
+func (oc *Oc) LoggedIn(ctx context.Context) bool {
 	return oc.loggedIn
 }
 
-func (oc *Oc) Project() (string, error) {
+func (oc *Oc) Project(ctx context.Context) (string, error) {
 	return "test-project", nil
 }
 
-func (oc *Oc) Exists(objType string, name string) (bool, error) {
+func (oc *Oc) Exists(ctx context.Context, objType string, name string) (bool, error) {
 	args := oc.Called(objType, name)
 	return args.Bool(0), args.Error(1)
 }
 
-func (oc *Oc) NewBuild(image string, name string, env map[string]string) error {
+func (oc *Oc) NewBuild(ctx context.Context, image string, name string, env map[string]string) error {
 	args := oc.Called(image, name, env)
 	return args.Error(0)
 }
 
-func (oc *Oc) Env(objType string, name string) (map[string]string, error) {
+func (oc *Oc) Env(ctx context.Context, objType string, name string) (map[string]string, error) {
 	args := oc.Called(objType, name)
 	return args.Get(0).(map[string]string), args.Error(1)
 }
 
-func (oc *Oc) SetEnv(objType string, name string, env map[string]string) error {
+func (oc *Oc) SetEnv(ctx context.Context, objType string, name string, env map[string]string) error {
 	args := oc.Called(objType, name, env)
 	return args.Error(0)
 }
 
+func (oc *Oc) SetEnvFrom(ctx context.Context, objType string, name string, source string, prefix string, env map[string]string) error {
+	args := oc.Called(objType, name, source, prefix, env)
+	return args.Error(0)
+}
+
+func (oc *Oc) CreateSecret(ctx context.Context, name string, data map[string]string) error {
+	args := oc.Called(name, data)
+	return args.Error(0)
+}
+
+func (oc *Oc) DataKeys(ctx context.Context, objType string, name string) ([]string, error) {
+	args := oc.Called(objType, name)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (oc *Oc) Delete(ctx context.Context, objType string, name string) error {
+	args := oc.Called(objType, name)
+	return args.Error(0)
+}
+
+func (oc *Oc) ServiceAddress(ctx context.Context, name string) (string, error) {
+	args := oc.Called(name)
+	return args.String(0), args.Error(1)
+}
+
+func (oc *Oc) List(ctx context.Context, objType string, selector string) ([]string, error) {
+	args := oc.Called(objType, selector)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (oc *Oc) Data(ctx context.Context, objType string, name string) (map[string]string, error) {
+	args := oc.Called(objType, name)
+	return args.Get(0).(map[string]string), args.Error(1)
+}
+
+func (oc *Oc) EnvNames(ctx context.Context, objType string, name string) ([]string, error) {
+	args := oc.Called(objType, name)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (oc *Oc) Apply(ctx context.Context, manifest []byte) error {
+	args := oc.Called(manifest)
+	return args.Error(0)
+}
+
+// Redeploy runs the same commands as the real Oc through Execer, so
+// tests can check which one the capabilities pick.
+func (oc *Oc) Redeploy(ctx context.Context, objType string, name string) error {
+	capabilities, _ := oc.Capabilities(ctx)
+	args := []string{"deploy", name, "--latest"}
+	switch {
+	case objType == "deployment" && capabilities.RolloutRestart:
+		args = []string{"rollout", "restart", fmt.Sprint("deployment/", name)}
+	case objType == "deployment":
+		args = []string{"patch", "deployment", name}
+	case capabilities.RolloutLatest:
+		args = []string{"rollout", "latest", fmt.Sprint("dc/", name)}
+	}
+	_, err := oc.Exec(args...).CombinedOutput(ctx)
+	return err
+}
+
+func (oc *Oc) Own(ctx context.Context, app string, objType string, name string) error {
+	if oc.OwnedObjects == nil {
+		oc.OwnedObjects = make(map[string][]string)
+	}
+	oc.OwnedObjects[app] = append(oc.OwnedObjects[app], fmt.Sprint(objType, "/", name))
+	return nil
+}
+
+func (oc *Oc) Owned(ctx context.Context, app string) ([]string, error) {
+	return oc.OwnedObjects[app], nil
+}
+
+func (oc *Oc) Disown(ctx context.Context, app string, objType string, name string) error {
+	if oc.OwnedObjects == nil {
+		return nil
+	}
+	var remaining []string
+	for _, object := range oc.OwnedObjects[app] {
+		if object != fmt.Sprint(objType, "/", name) {
+			remaining = append(remaining, object)
+		}
+	}
+	oc.OwnedObjects[app] = remaining
+	return nil
+}
+
+func (oc *Oc) OwnedApps(ctx context.Context) ([]string, error) {
+	var apps []string
+	for app := range oc.OwnedObjects {
+		apps = append(apps, app)
+	}
+	sort.Strings(apps)
+	return apps, nil
+}
+
+func (oc *Oc) Platform() string {
+	if oc.PlatformName == "" {
+		return "openshift"
+	}
+	return oc.PlatformName
+}
+
+func (oc *Oc) Capabilities(ctx context.Context) (types.Capabilities, error) {
+	if oc.OcCapabilities == nil {
+		return types.LatestCapabilities, nil
+	}
+	return *oc.OcCapabilities, nil
+}
+
 func (oc *Oc) Exec(args ...string) exec.ExecCmd {
 	return oc.Execer.Oc(args...)
 }
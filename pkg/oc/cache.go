@@ -0,0 +1,245 @@
+package oc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+// readOnlyCommands are the Exec subcommands that can't change the
+// cluster, so they don't invalidate the cache.
+var readOnlyCommands = map[string]bool{
+	"auth":    true,
+	"config":  true,
+	"diff":    true,
+	"get":     true,
+	"logs":    true,
+	"project": true,
+	"version": true,
+	"whoami":  true,
+}
+
+// CachingOc remembers the results of lookups made through another Oc
+// so the many checks made during a single push don't each cost a
+// round trip to the cluster. Mutations made through it invalidate the
+// affected entries, and raw Exec commands that aren't known to be
+// read-only invalidate everything. It's safe for concurrent use.
+type CachingOc struct {
+	oc      Oc
+	mutex   sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	value interface{}
+}
+
+// NewCache returns a CachingOc wrapping o. It should only live as
+// long as a single push, since changes made by others aren't seen.
+func NewCache(o Oc) *CachingOc {
+	return &CachingOc{oc: o, entries: make(map[string]cacheEntry)}
+}
+
+func cacheKey(op string, objType string, name string) string {
+	return fmt.Sprint(op, "/", objType, "/", name)
+}
+
+func (c *CachingOc) lookup(key string, load func() (interface{}, error)) (interface{}, error) {
+	c.mutex.Lock()
+	entry, ok := c.entries[key]
+	c.mutex.Unlock()
+	if ok {
+		return entry.value, nil
+	}
+	value, err := load()
+	if err == nil {
+		c.mutex.Lock()
+		c.entries[key] = cacheEntry{value: value}
+		c.mutex.Unlock()
+	}
+	return value, err
+}
+
+// Invalidate forgets everything cached about one object.
+func (c *CachingOc) Invalidate(objType string, name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	suffix := fmt.Sprint("/", objType, "/", name)
+	for key := range c.entries {
+		if strings.HasSuffix(key, suffix) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// InvalidateAll forgets everything cached.
+func (c *CachingOc) InvalidateAll() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries = make(map[string]cacheEntry)
+}
+
+func (c *CachingOc) LoggedIn(ctx context.Context) bool {
+	value, _ := c.lookup("loggedIn//", func() (interface{}, error) {
+		return c.oc.LoggedIn(ctx), nil
+	})
+	return value.(bool)
+}
+
+func (c *CachingOc) Project(ctx context.Context) (string, error) {
+	value, err := c.lookup("project//", func() (interface{}, error) {
+		return c.oc.Project(ctx)
+	})
+	return value.(string), err
+}
+
+// Exists only caches objects that exist, since ocf polls for some
+// objects, like image stream tags, that other processes create.
+func (c *CachingOc) Exists(ctx context.Context, objType string, name string) (bool, error) {
+	key := cacheKey("exists", objType, name)
+	c.mutex.Lock()
+	_, ok := c.entries[key]
+	c.mutex.Unlock()
+	if ok {
+		return true, nil
+	}
+	exists, err := c.oc.Exists(ctx, objType, name)
+	if exists && err == nil {
+		c.mutex.Lock()
+		c.entries[key] = cacheEntry{value: true}
+		c.mutex.Unlock()
+	}
+	return exists, err
+}
+
+func (c *CachingOc) NewBuild(ctx context.Context, image string, name string, env map[string]string) error {
+	c.Invalidate("bc", name)
+	c.Invalidate("is", name)
+	return c.oc.NewBuild(ctx, image, name, env)
+}
+
+func (c *CachingOc) Env(ctx context.Context, objType string, name string) (map[string]string, error) {
+	value, err := c.lookup(cacheKey("env", objType, name), func() (interface{}, error) {
+		return c.oc.Env(ctx, objType, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	env, _ := value.(map[string]string)
+	// Callers may modify the returned map
+	copied := make(map[string]string)
+	for key, value := range env {
+		copied[key] = value
+	}
+	return copied, nil
+}
+
+func (c *CachingOc) SetEnv(ctx context.Context, objType string, name string, env map[string]string) error {
+	c.Invalidate(objType, name)
+	return c.oc.SetEnv(ctx, objType, name, env)
+}
+
+func (c *CachingOc) SetEnvFrom(ctx context.Context, objType string, name string, source string, prefix string, env map[string]string) error {
+	c.Invalidate(objType, name)
+	return c.oc.SetEnvFrom(ctx, objType, name, source, prefix, env)
+}
+
+func (c *CachingOc) CreateSecret(ctx context.Context, name string, data map[string]string) error {
+	c.Invalidate("secret", name)
+	return c.oc.CreateSecret(ctx, name, data)
+}
+
+func (c *CachingOc) DataKeys(ctx context.Context, objType string, name string) ([]string, error) {
+	value, err := c.lookup(cacheKey("dataKeys", objType, name), func() (interface{}, error) {
+		return c.oc.DataKeys(ctx, objType, name)
+	})
+	keys, _ := value.([]string)
+	return append([]string(nil), keys...), err
+}
+
+func (c *CachingOc) Delete(ctx context.Context, objType string, name string) error {
+	c.Invalidate(objType, name)
+	return c.oc.Delete(ctx, objType, name)
+}
+
+func (c *CachingOc) ServiceAddress(ctx context.Context, name string) (string, error) {
+	value, err := c.lookup(cacheKey("serviceAddress", "svc", name), func() (interface{}, error) {
+		return c.oc.ServiceAddress(ctx, name)
+	})
+	return value.(string), err
+}
+
+func (c *CachingOc) List(ctx context.Context, objType string, selector string) ([]string, error) {
+	// Lists change whenever anything is created, so aren't cached
+	return c.oc.List(ctx, objType, selector)
+}
+
+func (c *CachingOc) Data(ctx context.Context, objType string, name string) (map[string]string, error) {
+	value, err := c.lookup(cacheKey("data", objType, name), func() (interface{}, error) {
+		return c.oc.Data(ctx, objType, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	data, _ := value.(map[string]string)
+	copied := make(map[string]string)
+	for key, value := range data {
+		copied[key] = value
+	}
+	return copied, nil
+}
+
+func (c *CachingOc) EnvNames(ctx context.Context, objType string, name string) ([]string, error) {
+	value, err := c.lookup(cacheKey("envNames", objType, name), func() (interface{}, error) {
+		return c.oc.EnvNames(ctx, objType, name)
+	})
+	names, _ := value.([]string)
+	return append([]string(nil), names...), err
+}
+
+func (c *CachingOc) Apply(ctx context.Context, manifest []byte) error {
+	c.InvalidateAll()
+	return c.oc.Apply(ctx, manifest)
+}
+
+func (c *CachingOc) Redeploy(ctx context.Context, objType string, name string) error {
+	c.Invalidate(objType, name)
+	return c.oc.Redeploy(ctx, objType, name)
+}
+
+func (c *CachingOc) Own(ctx context.Context, app string, objType string, name string) error {
+	c.Invalidate(objType, name)
+	return c.oc.Own(ctx, app, objType, name)
+}
+
+func (c *CachingOc) Owned(ctx context.Context, app string) ([]string, error) {
+	// Ownership changes as objects are created, so isn't cached
+	return c.oc.Owned(ctx, app)
+}
+
+func (c *CachingOc) Disown(ctx context.Context, app string, objType string, name string) error {
+	return c.oc.Disown(ctx, app, objType, name)
+}
+
+func (c *CachingOc) OwnedApps(ctx context.Context) ([]string, error) {
+	return c.oc.OwnedApps(ctx)
+}
+
+func (c *CachingOc) Platform() string {
+	return c.oc.Platform()
+}
+
+func (c *CachingOc) Capabilities(ctx context.Context) (types.Capabilities, error) {
+	return c.oc.Capabilities(ctx)
+}
+
+func (c *CachingOc) Exec(args ...string) exec.ExecCmd {
+	if len(args) == 0 || !readOnlyCommands[args[0]] {
+		c.InvalidateAll()
+	}
+	return c.oc.Exec(args...)
+}
@@ -0,0 +1,58 @@
+package oc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+)
+
+func TestCacheEnvUntilSetEnv(t *testing.T) {
+	o := mocks.NewMockOc()
+	o.On("Env", "dc", "foo").Return(map[string]string{"A": "b"}, nil).Twice()
+	o.On("SetEnv", "dc", "foo", map[string]string{"A": "c"}).Return(nil)
+	c := NewCache(o)
+
+	env, err := c.Env(context.Background(), "dc", "foo")
+	assert.Nil(t, err)
+	env["A"] = "modified"
+	env, _ = c.Env(context.Background(), "dc", "foo")
+	assert.Equal(t, "b", env["A"])
+
+	assert.Nil(t, c.SetEnv(context.Background(), "dc", "foo", map[string]string{"A": "c"}))
+	c.Env(context.Background(), "dc", "foo")
+	o.AssertExpectations(t)
+}
+
+func TestCacheOnlyExistingObjects(t *testing.T) {
+	o := mocks.NewMockOc()
+	o.On("Exists", "dc", "foo").Return(true, nil).Once()
+	o.On("Exists", "istag", "foo:latest").Return(false, nil).Twice()
+	c := NewCache(o)
+
+	for i := 0; i < 2; i++ {
+		exists, _ := c.Exists(context.Background(), "dc", "foo")
+		assert.True(t, exists)
+		exists, _ = c.Exists(context.Background(), "istag", "foo:latest")
+		assert.False(t, exists)
+	}
+	o.AssertExpectations(t)
+}
+
+func TestCacheInvalidatedByExec(t *testing.T) {
+	o := mocks.NewMockOc()
+	o.On("Exists", "svc", "foo").Return(true, nil).Twice()
+	cmd := &mocks.ExecCmd{}
+	o.Execer.On("Oc", []string{"get", "svc", "foo"}).Return(cmd)
+	o.Execer.On("Oc", []string{"delete", "svc", "foo"}).Return(cmd)
+	c := NewCache(o)
+
+	c.Exists(context.Background(), "svc", "foo")
+	c.Exec("get", "svc", "foo")
+	c.Exists(context.Background(), "svc", "foo")
+	c.Exec("delete", "svc", "foo")
+	c.Exists(context.Background(), "svc", "foo")
+	o.AssertExpectations(t)
+}
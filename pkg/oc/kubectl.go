@@ -0,0 +1,82 @@
+package oc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+// KubectlOc drives a vanilla Kubernetes cluster with 'kubectl'.
+// OpenShift-only operations, like builds, are not supported.
+type KubectlOc struct {
+	*DefaultOc
+}
+
+// NewKubernetes returns an Oc that drives a vanilla Kubernetes cluster
+// with 'kubectl'.
+func NewKubernetes(project string) Oc {
+	return &KubectlOc{&DefaultOc{
+		execer:    &exec.DefaultExecer{Binary: "kubectl"},
+		namespace: project,
+	}}
+}
+
+func (k *KubectlOc) Platform() string {
+	return PlatformKubernetes
+}
+
+func (k *KubectlOc) LoggedIn(ctx context.Context) bool {
+	return k.Exec("auth", "can-i", "get", "pods").Run(ctx) == nil
+}
+
+func (k *KubectlOc) Project(ctx context.Context) (string, error) {
+	if k.namespace != "" {
+		return k.namespace, nil
+	}
+	output, err := k.Exec("config", "view", "--minify", "-o", "jsonpath={..namespace}").CombinedOutput(ctx)
+	if err == nil && len(strings.TrimSpace(string(output))) == 0 {
+		return "default", nil
+	}
+	return string(output), err
+}
+
+func (k *KubectlOc) NewBuild(ctx context.Context, image string, name string, env map[string]string) error {
+	return errors.New("Error: Build configurations are not supported on Kubernetes")
+}
+
+func (k *KubectlOc) Env(ctx context.Context, objType string, name string) (map[string]string, error) {
+	return k.env(ctx, objType, name, setEnvArgs(objType, name, "--list"))
+}
+
+func (k *KubectlOc) EnvNames(ctx context.Context, objType string, name string) ([]string, error) {
+	return k.envNames(ctx, objType, name, setEnvArgs(objType, name, "--list"))
+}
+
+func (k *KubectlOc) SetEnv(ctx context.Context, objType string, name string, env map[string]string) error {
+	return k.setEnv(ctx, setEnvArgs(objType, name), env)
+}
+
+func (k *KubectlOc) SetEnvFrom(ctx context.Context, objType string, name string, source string, prefix string, env map[string]string) error {
+	return k.setEnv(ctx, envFromArgs(setEnvArgs(objType, name), source, prefix), env)
+}
+
+func (k *KubectlOc) Redeploy(ctx context.Context, objType string, name string) error {
+	capabilities, err := k.Capabilities(ctx)
+	if err != nil {
+		return err
+	}
+	return k.redeploy(ctx, objType, name, capabilities)
+}
+
+func (k *KubectlOc) Capabilities(ctx context.Context) (types.Capabilities, error) {
+	return k.probeCapabilities(ctx, "kubectl", MinimumKubectlVersion, kubectlCapabilities)
+}
+
+// kubectlCapabilities returns what a kubectl client of the given
+// version supports: only 'set env' and no deployment configs.
+func kubectlCapabilities(version types.Version) types.Capabilities {
+	return types.Capabilities{Version: version, SetEnv: true, RolloutRestart: true}
+}
@@ -1,42 +1,106 @@
 package oc
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/bbrowning/ocf/pkg/exec"
+	"github.com/bbrowning/ocf/pkg/log"
+	"github.com/bbrowning/ocf/pkg/oc/types"
 )
 
 type Oc interface {
-	LoggedIn() bool
-	Project() (string, error)
-	Exists(string, string) (bool, error)
-	NewBuild(string, string, map[string]string) error
-	Env(string, string) (map[string]string, error)
-	SetEnv(string, string, map[string]string) error
+	LoggedIn(context.Context) bool
+	Project(context.Context) (string, error)
+	Exists(context.Context, string, string) (bool, error)
+	NewBuild(context.Context, string, string, map[string]string) error
+	Env(context.Context, string, string) (map[string]string, error)
+	SetEnv(context.Context, string, string, map[string]string) error
+	SetEnvFrom(context.Context, string, string, string, string, map[string]string) error
+	CreateSecret(context.Context, string, map[string]string) error
+	DataKeys(context.Context, string, string) ([]string, error)
+	Delete(context.Context, string, string) error
+	ServiceAddress(context.Context, string) (string, error)
+	List(context.Context, string, string) ([]string, error)
+	Data(context.Context, string, string) (map[string]string, error)
+	EnvNames(context.Context, string, string) ([]string, error)
+	Apply(context.Context, []byte) error
+	Redeploy(context.Context, string, string) error
+	Own(context.Context, string, string, string) error
+	Owned(context.Context, string) ([]string, error)
+	Disown(context.Context, string, string, string) error
+	OwnedApps(context.Context) ([]string, error)
+	Platform() string
+	Capabilities(context.Context) (types.Capabilities, error)
 	Exec(args ...string) exec.ExecCmd
 }
 
+const (
+	// PlatformOpenShift targets OpenShift clusters using 'oc'
+	PlatformOpenShift string = "openshift"
+	// PlatformKubernetes targets vanilla Kubernetes clusters using
+	// 'kubectl'
+	PlatformKubernetes string = "k8s"
+)
+
+// DefaultOc drives an OpenShift cluster with 'oc'.
 type DefaultOc struct {
-	execer exec.Execer
+	execer       exec.Execer
+	namespace    string
+	mutex        sync.Mutex
+	capabilities *types.Capabilities
 }
 
-func (oc *DefaultOc) LoggedIn() bool {
-	err := oc.Exec("whoami").Run()
-	if err != nil {
-		return false
+// Constructor creates an Oc running in project instead of the current
+// one unless project is empty.
+type Constructor func(project string) Oc
+
+// ForPlatform returns the constructor of the Oc implementation for
+// platform, either PlatformOpenShift or PlatformKubernetes.
+func ForPlatform(platform string) (Constructor, error) {
+	switch platform {
+	case PlatformOpenShift:
+		return NewOpenShift, nil
+	case PlatformKubernetes:
+		return NewKubernetes, nil
 	}
-	return true
+	return nil, errors.New(fmt.Sprintf("Error: Invalid platform %s, must be %s or %s", platform, PlatformOpenShift, PlatformKubernetes))
+}
+
+// NewOpenShift returns an Oc that drives an OpenShift cluster with
+// 'oc'.
+func NewOpenShift(project string) Oc {
+	return &DefaultOc{execer: new(exec.DefaultExecer), namespace: project}
+}
+
+func (oc *DefaultOc) Platform() string {
+	return PlatformOpenShift
 }
 
-func (oc *DefaultOc) Project() (string, error) {
-	output, err := oc.Exec("project", "-q").CombinedOutput()
+func (oc *DefaultOc) LoggedIn(ctx context.Context) bool {
+	return oc.Exec("whoami").Run(ctx) == nil
+}
+
+func (oc *DefaultOc) Project(ctx context.Context) (string, error) {
+	if oc.namespace != "" {
+		return oc.namespace, nil
+	}
+	output, err := oc.Exec("project", "-q").CombinedOutput(ctx)
 	return string(output), err
 }
 
-func (oc *DefaultOc) Exists(objType string, name string) (bool, error) {
-	output, err := oc.Exec("get", objType, name).CombinedOutput()
+func (oc *DefaultOc) Exists(ctx context.Context, objType string, name string) (bool, error) {
+	output, err := oc.Exec("get", objType, name).CombinedOutput(ctx)
 	if strings.Contains(string(output), "not found") {
 		return false, nil
 	} else if err != nil {
@@ -46,54 +110,356 @@ func (oc *DefaultOc) Exists(objType string, name string) (bool, error) {
 	}
 }
 
-func (oc *DefaultOc) NewBuild(image string, name string, env map[string]string) error {
+func (oc *DefaultOc) NewBuild(ctx context.Context, image string, name string, env map[string]string) error {
 	args := []string{"new-build", image, "--binary=true", fmt.Sprint("--name=", name)}
-	args = append(args, envToSlice(env)...)
+	envSlice, err := envToSlice(env)
+	if err != nil {
+		return err
+	}
+	args = append(args, envSlice...)
 	cmd := oc.Exec(args...)
-	fmt.Printf("==> Creating build with command: %s\n", cmd.ArgsString())
+	log.Infof("Creating build with command: %s", cmd.ArgsString())
 	// oc new-build sometimes gives a non-zero exit status for ignorable errors
-	output, _ := cmd.CombinedOutput()
-	fmt.Println(string(output))
+	output, _ := cmd.CombinedOutput(ctx)
+	log.Printf("%s", output)
 	return nil
 }
 
-func (oc *DefaultOc) Env(objType string, name string) (map[string]string, error) {
+func (oc *DefaultOc) Env(ctx context.Context, objType string, name string) (map[string]string, error) {
+	return oc.env(ctx, objType, name, oc.envArgs(ctx, objType, name, "--list"))
+}
+
+// env runs the command listing an object's environment, envArgs, and
+// returns its plain variables.
+func (oc *DefaultOc) env(ctx context.Context, objType string, name string, envArgs []string) (map[string]string, error) {
 	var env = make(map[string]string)
-	output, err := oc.Exec("env", objType, name, "--list").CombinedOutput()
+	output, err := oc.Exec(envArgs...).CombinedOutput(ctx)
 	if err != nil {
 		return nil, errors.New(fmt.Sprintf("Error: %s %s not found\n", objType, name))
 	}
 	for _, line := range strings.Split(string(output), "\n") {
-		split := strings.Split(line, "=")
-		if len(split) == 2 {
+		split := strings.SplitN(line, "=", 2)
+		if len(split) == 2 && !strings.HasPrefix(line, "#") {
 			env[split[0]] = split[1]
 		}
 	}
 	return env, nil
 }
 
-func (oc *DefaultOc) SetEnv(objType string, name string, env map[string]string) error {
-	execArgs := []string{"env", objType, name}
-	execArgs = append(execArgs, envToSlice(env)...)
+// EnvNames returns the names of all environment variables set on an
+// object, including those that reference secrets or config maps and
+// so aren't returned by Env.
+func (oc *DefaultOc) EnvNames(ctx context.Context, objType string, name string) ([]string, error) {
+	return oc.envNames(ctx, objType, name, oc.envArgs(ctx, objType, name, "--list"))
+}
+
+// envNames runs the command listing an object's environment, envArgs,
+// and returns the names of all its variables.
+func (oc *DefaultOc) envNames(ctx context.Context, objType string, name string, envArgs []string) ([]string, error) {
+	var names []string
+	output, err := oc.Exec(envArgs...).CombinedOutput(ctx)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error: %s %s not found\n", objType, name))
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		// Referenced variables are listed as comments like
+		// "# FOO from secret bar, key baz"
+		if fields := strings.Fields(line); len(fields) > 2 && fields[0] == "#" && fields[2] == "from" {
+			names = append(names, fields[1])
+		} else if split := strings.SplitN(line, "=", 2); len(split) == 2 && !strings.HasPrefix(line, "#") {
+			names = append(names, split[0])
+		}
+	}
+	return names, nil
+}
+
+func (oc *DefaultOc) SetEnv(ctx context.Context, objType string, name string, env map[string]string) error {
+	return oc.setEnv(ctx, oc.envArgs(ctx, objType, name), env)
+}
+
+// setEnv runs the command changing an object's environment, envArgs,
+// with env appended.
+func (oc *DefaultOc) setEnv(ctx context.Context, envArgs []string, env map[string]string) error {
+	envSlice, err := envToSlice(env)
+	if err != nil {
+		return err
+	}
+	execArgs := append(envArgs, envSlice...)
 	envCmd := oc.Exec(execArgs...)
-	fmt.Printf("==> Updating environment variables with command: %s\n", envCmd.ArgsString())
-	output, err := envCmd.CombinedOutput()
+	log.Infof("Updating environment variables with command: %s", envCmd.ArgsString())
+	output, err := envCmd.CombinedOutput(ctx)
 	if err != nil {
 		return errors.New(fmt.Sprintf("Error updating environment: %s\n", output))
 	}
 	return nil
 }
 
+// SetEnvFrom sets environment variables on an object that reference
+// every key of source, such as "secret/foo", along with any plain env.
+// Variables from source are named after their keys, with prefix
+// prepended if it's not empty.
+func (oc *DefaultOc) SetEnvFrom(ctx context.Context, objType string, name string, source string, prefix string, env map[string]string) error {
+	return oc.setEnv(ctx, envFromArgs(oc.envArgs(ctx, objType, name), source, prefix), env)
+}
+
+// envFromArgs adds the arguments referencing every key of source,
+// with prefix prepended to their names, to envArgs.
+func envFromArgs(envArgs []string, source string, prefix string) []string {
+	envArgs = append(envArgs, fmt.Sprint("--from=", source))
+	if prefix != "" {
+		envArgs = append(envArgs, fmt.Sprint("--prefix=", prefix))
+	}
+	return envArgs
+}
+
+// CreateSecret creates a secret holding data. The values are passed in
+// a file only the user can read rather than as arguments, which other
+// users can see in the process list.
+func (oc *DefaultOc) CreateSecret(ctx context.Context, name string, data map[string]string) error {
+	secret := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]string{"name": name},
+		"type":       "Opaque",
+		"stringData": data,
+	}
+	manifest, err := json.Marshal(secret)
+	if err != nil {
+		return err
+	}
+	log.Infof("Creating secret %s", name)
+	return withManifestFile(manifest, func(file string) error {
+		output, err := oc.Exec("create", "-f", file).CombinedOutput(ctx)
+		if err != nil {
+			return errors.New(fmt.Sprintf("Error creating secret %s: %s\n", name, output))
+		}
+		return nil
+	})
+}
+
+// DataKeys returns the keys of a secret's or config map's data.
+func (oc *DefaultOc) DataKeys(ctx context.Context, objType string, name string) ([]string, error) {
+	object := &types.Data{}
+	err := Get(ctx, oc, objType, name, object)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for key := range object.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (oc *DefaultOc) Delete(ctx context.Context, objType string, name string) error {
+	output, err := oc.Exec("delete", objType, name).CombinedOutput(ctx)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error deleting %s %s: %s\n", objType, name, output))
+	}
+	return nil
+}
+
+// ServiceAddress returns the cluster IP and first port of a service
+// in host:port form.
+func (oc *DefaultOc) ServiceAddress(ctx context.Context, name string) (string, error) {
+	service := &types.Service{}
+	err := Get(ctx, oc, "svc", name, service)
+	if err != nil {
+		return "", err
+	}
+	if len(service.Spec.Ports) == 0 {
+		return "", errors.New(fmt.Sprintf("Error: Service %s has no ports\n", name))
+	}
+	return fmt.Sprint(service.Spec.ClusterIP, ":", service.Spec.Ports[0].Port), nil
+}
+
+// List returns the names of all objects of the given type in the
+// current project, optionally filtered by a label selector.
+func (oc *DefaultOc) List(ctx context.Context, objType string, selector string) ([]string, error) {
+	var names []string
+	execArgs := []string{"get", objType, "-o", "name"}
+	if selector != "" {
+		execArgs = append(execArgs, fmt.Sprint("--selector=", selector))
+	}
+	output, err := oc.Exec(execArgs...).CombinedOutput(ctx)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error listing %s: %s\n", objType, output))
+	}
+	for _, line := range strings.Fields(string(output)) {
+		split := strings.SplitN(line, "/", 2)
+		names = append(names, split[len(split)-1])
+	}
+	return names, nil
+}
+
+// Data returns the contents of a secret or config map, decoding
+// secret values.
+func (oc *DefaultOc) Data(ctx context.Context, objType string, name string) (map[string]string, error) {
+	object := &types.Data{}
+	err := Get(ctx, oc, objType, name, object)
+	if err != nil {
+		return nil, err
+	}
+	data := make(map[string]string)
+	for key, value := range object.Data {
+		if objType == "secret" {
+			decoded, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return nil, err
+			}
+			value = string(decoded)
+		}
+		data[key] = value
+	}
+	return data, nil
+}
+
+// Apply creates or updates the objects described by a JSON or YAML
+// manifest.
+func (oc *DefaultOc) Apply(ctx context.Context, manifest []byte) error {
+	return withManifestFile(manifest, func(file string) error {
+		output, err := oc.Exec("apply", "-f", file).CombinedOutput(ctx)
+		log.Printf("%s", output)
+		if err != nil {
+			return errors.New(fmt.Sprintf("Error applying manifest: %s\n", output))
+		}
+		return nil
+	})
+}
+
+// withManifestFile writes manifest to a temporary file, readable only
+// by the user since it may hold secrets, and calls run with its name.
+func withManifestFile(manifest []byte, run func(file string) error) error {
+	file, err := ioutil.TempFile("", "ocf-manifest")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(manifest)
+	file.Close()
+	if err != nil {
+		return err
+	}
+	return run(file.Name())
+}
+
+// Redeploy starts a new rollout of a deployment config or deployment
+// with its current configuration, using whichever command the client
+// supports.
+func (oc *DefaultOc) Redeploy(ctx context.Context, objType string, name string) error {
+	capabilities, err := oc.Capabilities(ctx)
+	if err != nil {
+		return err
+	}
+	return oc.redeploy(ctx, objType, name, capabilities)
+}
+
+func (oc *DefaultOc) redeploy(ctx context.Context, objType string, name string, capabilities types.Capabilities) error {
+	var args []string
+	switch {
+	case objType == "deployment" && capabilities.RolloutRestart:
+		args = []string{"rollout", "restart", fmt.Sprint("deployment/", name)}
+	case objType == "deployment":
+		// What 'rollout restart' does, for older clients
+		patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{"kubectl.kubernetes.io/restartedAt":"%s"}}}}}`,
+			time.Now().Format(time.RFC3339))
+		args = []string{"patch", "deployment", name, "-p", patch}
+	case capabilities.RolloutLatest:
+		args = []string{"rollout", "latest", fmt.Sprint("dc/", name)}
+	default:
+		args = []string{"deploy", name, "--latest"}
+	}
+	output, err := oc.Exec(args...).CombinedOutput(ctx)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error redeploying %s %s: %s\n", objType, name, output))
+	}
+	return nil
+}
+
 func (oc *DefaultOc) Exec(args ...string) exec.ExecCmd {
 	if oc.execer == nil {
 		oc.execer = new(exec.DefaultExecer)
 	}
+	if oc.namespace != "" {
+		args = append([]string{"--namespace", oc.namespace}, args...)
+	}
 	return oc.execer.Oc(args...)
 }
 
-func envToSlice(env map[string]string) []string {
+// Get reads an object from the cluster as JSON and decodes it into
+// object, usually one of the structs from the types package.
+func Get(ctx context.Context, o Oc, objType string, name string, object interface{}) error {
+	output, err := o.Exec("get", objType, name, "-o", "json").CombinedOutput(ctx)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error getting %s %s: %s\n", objType, name, output))
+	}
+	err = json.Unmarshal(output, object)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error parsing %s %s: %v\n", objType, name, err))
+	}
+	return nil
+}
+
+// GetSelected is like Get for every object of objType matching the
+// label selector, decoding the list into object. An empty selector
+// matches every object.
+func GetSelected(ctx context.Context, o Oc, objType string, selector string, object interface{}) error {
+	args := []string{"get", objType, "-o", "json"}
+	if selector != "" {
+		args = []string{"get", objType, fmt.Sprint("--selector=", selector), "-o", "json"}
+	}
+	output, err := o.Exec(args...).CombinedOutput(ctx)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error getting %s: %s\n", objType, output))
+	}
+	err = json.Unmarshal(output, object)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error parsing %s: %v\n", objType, err))
+	}
+	return nil
+}
+
+// envArgs returns the arguments to read or change the environment of
+// an object, since newer oc only has the 'set env' form.
+func (oc *DefaultOc) envArgs(ctx context.Context, objType string, name string, args ...string) []string {
+	// Fall back to the newer form if the version check failed
+	capabilities, err := oc.Capabilities(ctx)
+	if err != nil || capabilities.SetEnv {
+		return setEnvArgs(objType, name, args...)
+	}
+	return append([]string{"env", objType, name}, args...)
+}
+
+// setEnvArgs returns the 'set env' arguments to read or change the
+// environment of an object.
+func setEnvArgs(objType string, name string, args ...string) []string {
+	return append([]string{"set", "env", fmt.Sprint(objType, "/", name)}, args...)
+}
+
+// envNameRegexp matches the environment variable names Kubernetes
+// accepts.
+var envNameRegexp = regexp.MustCompile(`^[-._a-zA-Z][-._a-zA-Z0-9]*$`)
+
+// CheckEnvName returns an error if name can't be an environment
+// variable's name.
+func CheckEnvName(name string) error {
+	if !envNameRegexp.MatchString(name) {
+		return errors.New(fmt.Sprintf("Error: Invalid environment variable name %q. Names can only contain letters, digits, '_', '-', and '.', and can't start with a digit\n", name))
+	}
+	return nil
+}
+
+// envToSlice returns env as one NAME=VALUE argument per variable,
+// sorted by name, so values can contain anything, including ',' and
+// '='. A value of "-" removes the variable instead.
+func envToSlice(env map[string]string) ([]string, error) {
 	envSlice := []string{}
 	for key, value := range env {
+		err := CheckEnvName(key)
+		if err != nil {
+			return nil, err
+		}
 		var envArg string
 		if value == "-" {
 			envArg = fmt.Sprint(key, value)
@@ -102,5 +468,6 @@ func envToSlice(env map[string]string) []string {
 		}
 		envSlice = append(envSlice, envArg)
 	}
-	return envSlice
+	sort.Strings(envSlice)
+	return envSlice, nil
 }
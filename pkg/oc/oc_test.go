@@ -1,11 +1,16 @@
 package oc
 
 import (
+	"context"
 	"errors"
+	"io/ioutil"
+	"os"
 	"strings"
 	"testing"
 
+	"github.com/bbrowning/ocf/pkg/exec"
 	"github.com/bbrowning/ocf/pkg/mocks"
+	"github.com/bbrowning/ocf/pkg/oc/types"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -16,30 +21,43 @@ type execHandler func(*DefaultOc, *mocks.ExecCmd)
 func TestLoggedInTrue(t *testing.T) {
 	withSingleExec(t, []string{"whoami"}, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
 		cmd.On("Run").Return(nil)
-		assert.True(t, oc.LoggedIn())
+		assert.True(t, oc.LoggedIn(context.Background()))
 	})
 }
 
 func TestLoggedInFalse(t *testing.T) {
 	withSingleExec(t, []string{"whoami"}, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
 		cmd.On("Run").Return(errors.New("error"))
-		assert.False(t, oc.LoggedIn())
+		assert.False(t, oc.LoggedIn(context.Background()))
 	})
 }
 
 func TestProject(t *testing.T) {
 	withSingleExec(t, []string{"project", "-q"}, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
 		cmd.On("CombinedOutput").Return([]byte("test-project"), nil)
-		project, err := oc.Project()
+		project, err := oc.Project(context.Background())
 		assert.Nil(t, err)
 		assert.Equal(t, "test-project", project)
 	})
 }
 
+func TestProjectNamespace(t *testing.T) {
+	withSingleExec(t, []string{"--namespace", "backend", "get", "dc", "foo"}, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		oc.namespace = "backend"
+		cmd.On("CombinedOutput").Return([]byte(""), nil)
+		project, err := oc.Project(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, "backend", project)
+		exists, err := oc.Exists(context.Background(), "dc", "foo")
+		assert.Nil(t, err)
+		assert.True(t, exists)
+	})
+}
+
 func TestExistsTrue(t *testing.T) {
 	withSingleExec(t, []string{"get", "dc", "foo"}, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
 		cmd.On("CombinedOutput").Return([]byte(""), nil)
-		exists, err := oc.Exists("dc", "foo")
+		exists, err := oc.Exists(context.Background(), "dc", "foo")
 		assert.Nil(t, err)
 		assert.True(t, exists)
 	})
@@ -48,7 +66,7 @@ func TestExistsTrue(t *testing.T) {
 func TestExistsFalse(t *testing.T) {
 	withSingleExec(t, []string{"get", "dc", "foo"}, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
 		cmd.On("CombinedOutput").Return([]byte("not found"), errors.New(""))
-		exists, err := oc.Exists("dc", "foo")
+		exists, err := oc.Exists(context.Background(), "dc", "foo")
 		assert.Nil(t, err)
 		assert.False(t, exists)
 	})
@@ -57,7 +75,7 @@ func TestExistsFalse(t *testing.T) {
 func TestExistsError(t *testing.T) {
 	withSingleExec(t, []string{"get", "dc", "foo"}, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
 		cmd.On("CombinedOutput").Return([]byte("error"), errors.New(""))
-		_, err := oc.Exists("dc", "foo")
+		_, err := oc.Exists(context.Background(), "dc", "foo")
 		assert.NotNil(t, err)
 	})
 }
@@ -66,7 +84,7 @@ func TestNewBuild(t *testing.T) {
 	execArgs := []string{"new-build", "my-image", "--binary=true", "--name=foo"}
 	withSingleExec(t, execArgs, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
 		cmd.On("CombinedOutput").Return([]byte(""), nil)
-		err := oc.NewBuild("my-image", "foo", make(map[string]string))
+		err := oc.NewBuild(context.Background(), "my-image", "foo", make(map[string]string))
 		assert.Nil(t, err)
 	})
 }
@@ -79,7 +97,7 @@ func TestNewBuildWithEnv(t *testing.T) {
 	}
 	withSingleExec(t, execArgs, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
 		cmd.On("CombinedOutput").Return([]byte(""), nil)
-		err := oc.NewBuild("my-image", "foo", env)
+		err := oc.NewBuild(context.Background(), "my-image", "foo", env)
 		assert.Nil(t, err)
 	})
 }
@@ -88,7 +106,7 @@ func TestEnvHappyPath(t *testing.T) {
 	execArgs := []string{"env", "dc", "foo", "--list"}
 	withSingleExec(t, execArgs, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
 		cmd.On("CombinedOutput").Return([]byte("FOO=bar\nBAZ=blah"), nil)
-		env, err := oc.Env("dc", "foo")
+		env, err := oc.Env(context.Background(), "dc", "foo")
 		assert.Nil(t, err)
 		assert.Equal(t, "bar", env["FOO"])
 		assert.Equal(t, "blah", env["BAZ"])
@@ -99,7 +117,7 @@ func TestEnvNotFound(t *testing.T) {
 	execArgs := []string{"env", "dc", "foo", "--list"}
 	withSingleExec(t, execArgs, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
 		cmd.On("CombinedOutput").Return([]byte(""), errors.New(""))
-		_, err := oc.Env("dc", "foo")
+		_, err := oc.Env(context.Background(), "dc", "foo")
 		assert.NotNil(t, err)
 	})
 }
@@ -108,7 +126,7 @@ func TestEnvNoneSet(t *testing.T) {
 	execArgs := []string{"env", "dc", "foo", "--list"}
 	withSingleExec(t, execArgs, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
 		cmd.On("CombinedOutput").Return([]byte("# deploymentconfigs foo, container foo"), nil)
-		env, err := oc.Env("dc", "foo")
+		env, err := oc.Env(context.Background(), "dc", "foo")
 		assert.Nil(t, err)
 		assert.Equal(t, 0, len(env))
 	})
@@ -126,10 +144,11 @@ func TestSetEnvHappyPath(t *testing.T) {
 	})).Return(cmd)
 	cmd.On("CombinedOutput").Return([]byte(""), nil)
 	oc := &DefaultOc{
-		execer: execer,
+		execer:       execer,
+		capabilities: &types.Capabilities{},
 	}
 
-	err := oc.SetEnv("dc", "foo", map[string]string{
+	err := oc.SetEnv(context.Background(), "dc", "foo", map[string]string{
 		"FOO":     "bar",
 		"BAZ":     "blah",
 		"DELETED": "-",
@@ -139,14 +158,242 @@ func TestSetEnvHappyPath(t *testing.T) {
 	cmd.AssertExpectations(t)
 }
 
+func TestSetEnvValuesWithSeparators(t *testing.T) {
+	execArgs := []string{"env", "dc", "foo", `A={"x":1,"y":"a=b"}`, "B=c,d"}
+	withSingleExec(t, execArgs, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		cmd.On("CombinedOutput").Return([]byte(""), nil)
+		err := oc.SetEnv(context.Background(), "dc", "foo", map[string]string{"B": "c,d", "A": `{"x":1,"y":"a=b"}`})
+		assert.Nil(t, err)
+	})
+}
+
+func TestSetEnvInvalidName(t *testing.T) {
+	oc := &DefaultOc{execer: &mocks.Execer{}, capabilities: &types.Capabilities{}}
+	for _, name := range []string{"1FOO", "FOO BAR", "FOO=BAR", ""} {
+		assert.NotNil(t, oc.SetEnv(context.Background(), "dc", "foo", map[string]string{name: "x"}), name)
+	}
+	assert.Nil(t, CheckEnvName("spring.profiles.active"))
+}
+
+func TestEnvValuesWithEquals(t *testing.T) {
+	execArgs := []string{"env", "dc", "foo", "--list"}
+	withSingleExec(t, execArgs, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		cmd.On("CombinedOutput").Return([]byte("# deploymentconfigs foo, container foo\nQUERY=a=b&c=d"), nil)
+		env, err := oc.Env(context.Background(), "dc", "foo")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"QUERY": "a=b&c=d"}, env)
+	})
+}
+
+func TestRedeploy(t *testing.T) {
+	withSingleExec(t, []string{"rollout", "latest", "dc/foo"}, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		oc.capabilities = &types.Capabilities{RolloutLatest: true}
+		cmd.On("CombinedOutput").Return([]byte(""), nil)
+		assert.Nil(t, oc.Redeploy(context.Background(), "dc", "foo"))
+	})
+	withSingleExec(t, []string{"deploy", "foo", "--latest"}, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		cmd.On("CombinedOutput").Return([]byte(""), nil)
+		assert.Nil(t, oc.Redeploy(context.Background(), "dc", "foo"))
+	})
+	withSingleExec(t, []string{"rollout", "restart", "deployment/foo"}, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		oc.capabilities = &types.Capabilities{RolloutRestart: true}
+		cmd.On("CombinedOutput").Return([]byte("error: not found"), errors.New("exit status 1"))
+		err := oc.Redeploy(context.Background(), "deployment", "foo")
+		assert.Contains(t, err.Error(), "error: not found")
+	})
+}
+
+func TestRedeployDeploymentWithOldOc(t *testing.T) {
+	execer := &mocks.Execer{}
+	cmd := &mocks.ExecCmd{}
+	cmd.On("CombinedOutput").Return([]byte(""), nil)
+	execer.On("Oc", mock.MatchedBy(func(args []string) bool {
+		return len(args) == 5 && args[0] == "patch" && args[2] == "foo" &&
+			strings.Contains(args[4], "kubectl.kubernetes.io/restartedAt")
+	})).Return(cmd)
+	oc := &DefaultOc{execer: execer, capabilities: &types.Capabilities{RolloutLatest: true}}
+	assert.Nil(t, oc.Redeploy(context.Background(), "deployment", "foo"))
+	execer.AssertExpectations(t)
+}
+
+func TestSetEnvFrom(t *testing.T) {
+	execArgs := []string{"env", "dc", "foo", "--from=secret/bar", "BAZ=blah"}
+	withSingleExec(t, execArgs, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		cmd.On("CombinedOutput").Return([]byte(""), nil)
+		err := oc.SetEnvFrom(context.Background(), "dc", "foo", "secret/bar", "", map[string]string{"BAZ": "blah"})
+		assert.Nil(t, err)
+	})
+}
+
+func TestSetEnvFromWithPrefix(t *testing.T) {
+	execArgs := []string{"env", "dc", "foo", "--from=configmap/bar", "--prefix=BAR_"}
+	withSingleExec(t, execArgs, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		cmd.On("CombinedOutput").Return([]byte(""), nil)
+		err := oc.SetEnvFrom(context.Background(), "dc", "foo", "configmap/bar", "BAR_", nil)
+		assert.Nil(t, err)
+	})
+}
+
+func TestCreateSecret(t *testing.T) {
+	execer := &mocks.Execer{}
+	cmd := &mocks.ExecCmd{}
+	var manifest []byte
+	var mode os.FileMode
+	execer.On("Oc", mock.MatchedBy(func(args []string) bool {
+		if len(args) != 3 || args[0] != "create" || args[1] != "-f" {
+			return false
+		}
+		if info, err := os.Stat(args[2]); err == nil {
+			mode = info.Mode().Perm()
+		}
+		manifest, _ = ioutil.ReadFile(args[2])
+		return true
+	})).Return(cmd)
+	cmd.On("CombinedOutput").Return([]byte(""), nil)
+	oc := &DefaultOc{execer: execer, capabilities: &types.Capabilities{}}
+
+	err := oc.CreateSecret(context.Background(), "foo", map[string]string{"B_USER": "baz", "A_PASSWORD": "bar"})
+	assert.Nil(t, err)
+	// The values aren't on the command line
+	assert.Equal(t, os.FileMode(0600), mode)
+	assert.JSONEq(t, `{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "foo"}, "type": "Opaque",
+		"stringData": {"A_PASSWORD": "bar", "B_USER": "baz"}}`, string(manifest))
+	execer.AssertExpectations(t)
+	cmd.AssertExpectations(t)
+}
+
+func TestDataKeys(t *testing.T) {
+	execArgs := []string{"get", "secret", "foo", "-o", "json"}
+	withSingleExec(t, execArgs, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		cmd.On("CombinedOutput").Return([]byte(`{"data": {"B_USER": "YmF6", "A_PASSWORD": "YmFy"}}`), nil)
+		keys, err := oc.DataKeys(context.Background(), "secret", "foo")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"A_PASSWORD", "B_USER"}, keys)
+	})
+}
+
+func TestDelete(t *testing.T) {
+	withSingleExec(t, []string{"delete", "secret", "foo"}, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		cmd.On("CombinedOutput").Return([]byte(""), nil)
+		assert.Nil(t, oc.Delete(context.Background(), "secret", "foo"))
+	})
+}
+
+func TestServiceAddress(t *testing.T) {
+	execArgs := []string{"get", "svc", "foo", "-o", "json"}
+	withSingleExec(t, execArgs, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		cmd.On("CombinedOutput").Return([]byte(`{"spec": {"clusterIP": "172.30.1.2", "ports": [{"port": 5432}]}}`), nil)
+		address, err := oc.ServiceAddress(context.Background(), "foo")
+		assert.Nil(t, err)
+		assert.Equal(t, "172.30.1.2:5432", address)
+	})
+}
+
+func TestEnvNames(t *testing.T) {
+	execArgs := []string{"env", "dc", "foo", "--list"}
+	withSingleExec(t, execArgs, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		output := "# deploymentconfigs foo, container foo\nFOO=bar\n# BAZ_KEY from secret baz, key key\n"
+		cmd.On("CombinedOutput").Return([]byte(output), nil)
+		names, err := oc.EnvNames(context.Background(), "dc", "foo")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"FOO", "BAZ_KEY"}, names)
+	})
+}
+
+func TestList(t *testing.T) {
+	withSingleExec(t, []string{"get", "dc", "-o", "name"}, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		cmd.On("CombinedOutput").Return([]byte("deploymentconfig/foo\ndeploymentconfig/bar\n"), nil)
+		names, err := oc.List(context.Background(), "dc", "")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"foo", "bar"}, names)
+	})
+}
+
+func TestListWithSelector(t *testing.T) {
+	execArgs := []string{"get", "secret", "-o", "name", "--selector=foo=bar"}
+	withSingleExec(t, execArgs, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		cmd.On("CombinedOutput").Return([]byte("secret/baz\n"), nil)
+		names, err := oc.List(context.Background(), "secret", "foo=bar")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"baz"}, names)
+	})
+}
+
+func TestDataFromSecret(t *testing.T) {
+	execArgs := []string{"get", "secret", "foo", "-o", "json"}
+	withSingleExec(t, execArgs, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		cmd.On("CombinedOutput").Return([]byte(`{"data": {"password": "c2VjcmV0", "user": "Zm9v"}}`), nil)
+		data, err := oc.Data(context.Background(), "secret", "foo")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"password": "secret", "user": "foo"}, data)
+	})
+}
+
+func TestGetReportsInvalidJSON(t *testing.T) {
+	withSingleExec(t, []string{"get", "route", "foo", "-o", "json"}, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		cmd.On("CombinedOutput").Return([]byte("not json"), nil)
+		err := Get(context.Background(), oc, "route", "foo", &types.Route{})
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "Error parsing route foo")
+	})
+}
+
+func TestKubectlProjectDefaultsNamespace(t *testing.T) {
+	execArgs := []string{"config", "view", "--minify", "-o", "jsonpath={..namespace}"}
+	withSingleExec(t, execArgs, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		cmd.On("CombinedOutput").Return([]byte(""), nil)
+		project, err := (&KubectlOc{oc}).Project(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, "default", project)
+	})
+}
+
+func TestKubectlEnv(t *testing.T) {
+	withSingleExec(t, []string{"set", "env", "deployment/foo", "--list"}, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		cmd.On("CombinedOutput").Return([]byte("FOO=bar\n"), nil)
+		env, err := (&KubectlOc{oc}).Env(context.Background(), "deployment", "foo")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"FOO": "bar"}, env)
+	})
+}
+
+func TestKubectlNewBuildUnsupported(t *testing.T) {
+	oc := NewKubernetes("")
+	err := oc.NewBuild(context.Background(), "image", "foo", nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, PlatformKubernetes, oc.Platform())
+}
+
 func withSingleExec(t *testing.T, args []string, handler execHandler) {
 	execer := &mocks.Execer{}
 	cmd := &mocks.ExecCmd{Args: args}
 	execer.On("Oc", args).Return(cmd)
+	// Pretend to be the oldest supported oc so tests don't probe its
+	// version
 	oc := &DefaultOc{
-		execer: execer,
+		execer:       execer,
+		capabilities: &types.Capabilities{},
 	}
 	handler(oc, cmd)
 	execer.AssertExpectations(t)
 	cmd.AssertExpectations(t)
 }
+
+func TestForPlatform(t *testing.T) {
+	constructor, err := ForPlatform(PlatformOpenShift)
+	assert.Nil(t, err)
+	oc := constructor("my-project")
+	assert.Equal(t, PlatformOpenShift, oc.Platform())
+	project, err := oc.Project(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "my-project", project)
+
+	constructor, err = ForPlatform(PlatformKubernetes)
+	assert.Nil(t, err)
+	oc = constructor("")
+	assert.Equal(t, PlatformKubernetes, oc.Platform())
+	assert.Equal(t, "kubectl", oc.(*KubectlOc).execer.(*exec.DefaultExecer).Binary)
+
+	_, err = ForPlatform("cloudfoundry")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,110 @@
+package oc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// OwnerLabel is set on the objects ocf creates to the name of the
+	// application that owns them
+	OwnerLabel string = "ocf/app"
+	// OwnershipLabel marks the config maps recording what each
+	// application owns
+	OwnershipLabel string = "ocf/ownership"
+)
+
+// ownershipName is the config map recording the objects app owns.
+func ownershipName(app string) string {
+	return fmt.Sprint(app, "-ocf-owned")
+}
+
+// Own labels an object as belonging to app and records it in app's
+// ownership config map.
+func (oc *DefaultOc) Own(ctx context.Context, app string, objType string, name string) error {
+	object := fmt.Sprint(objType, "/", name)
+	output, err := oc.Exec("label", objType, name, fmt.Sprint(OwnerLabel, "=", app), "--overwrite").CombinedOutput(ctx)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error labeling %s: %s\n", object, output))
+	}
+	owned, err := oc.Owned(ctx, app)
+	if err != nil {
+		return err
+	}
+	for _, ownedObject := range owned {
+		if ownedObject == object {
+			return nil
+		}
+	}
+	return oc.recordOwned(ctx, app, append(owned, object))
+}
+
+// Owned returns the objects recorded as belonging to app, as
+// type/name, in the order they were created.
+func (oc *DefaultOc) Owned(ctx context.Context, app string) ([]string, error) {
+	output, err := oc.Exec("get", "configmap", ownershipName(app), "-o", "jsonpath={.data.objects}").CombinedOutput(ctx)
+	if strings.Contains(string(output), "not found") {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error getting the objects owned by %s: %s\n", app, output))
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// Disown removes an object from app's ownership record, deleting the
+// record once it's empty.
+func (oc *DefaultOc) Disown(ctx context.Context, app string, objType string, name string) error {
+	object := fmt.Sprint(objType, "/", name)
+	owned, err := oc.Owned(ctx, app)
+	if err != nil {
+		return err
+	}
+	var remaining []string
+	for _, ownedObject := range owned {
+		if ownedObject != object {
+			remaining = append(remaining, ownedObject)
+		}
+	}
+	if len(remaining) == len(owned) {
+		return nil
+	}
+	if len(remaining) == 0 {
+		return oc.Delete(ctx, "configmap", ownershipName(app))
+	}
+	return oc.recordOwned(ctx, app, remaining)
+}
+
+// OwnedApps returns the applications with an ownership record.
+func (oc *DefaultOc) OwnedApps(ctx context.Context) ([]string, error) {
+	names, err := oc.List(ctx, "configmap", OwnershipLabel)
+	if err != nil {
+		return nil, err
+	}
+	var apps []string
+	for _, name := range names {
+		if strings.HasSuffix(name, ownershipName("")) {
+			apps = append(apps, strings.TrimSuffix(name, ownershipName("")))
+		}
+	}
+	return apps, nil
+}
+
+func (oc *DefaultOc) recordOwned(ctx context.Context, app string, objects []string) error {
+	configMap := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":   ownershipName(app),
+			"labels": map[string]string{OwnerLabel: app, OwnershipLabel: "true"},
+		},
+		"data": map[string]string{"objects": strings.Join(objects, "\n")},
+	}
+	manifest, err := json.Marshal(configMap)
+	if err != nil {
+		return err
+	}
+	return oc.Apply(ctx, manifest)
+}
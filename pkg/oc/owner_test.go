@@ -0,0 +1,64 @@
+package oc
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOwn(t *testing.T) {
+	execer := &mocks.Execer{}
+	labelCmd := &mocks.ExecCmd{}
+	labelCmd.On("CombinedOutput").Return([]byte("service/foo labeled"), nil)
+	execer.On("Oc", []string{"label", "svc", "foo", "ocf/app=foo", "--overwrite"}).Return(labelCmd)
+	ownedCmd := &mocks.ExecCmd{}
+	ownedCmd.On("CombinedOutput").Return([]byte("bc/foo\nis/foo"), nil)
+	execer.On("Oc", []string{"get", "configmap", "foo-ocf-owned", "-o", "jsonpath={.data.objects}"}).Return(ownedCmd)
+	applyCmd := &mocks.ExecCmd{}
+	applyCmd.On("CombinedOutput").Return([]byte(""), nil)
+	var applied string
+	execer.On("Oc", mock.MatchedBy(func(args []string) bool {
+		if len(args) != 3 || args[0] != "apply" {
+			return false
+		}
+		manifest, _ := ioutil.ReadFile(args[2])
+		applied = string(manifest)
+		return true
+	})).Return(applyCmd)
+	oc := &DefaultOc{execer: execer, capabilities: &types.Capabilities{}}
+
+	assert.Nil(t, oc.Own(context.Background(), "foo", "svc", "foo"))
+	assert.Contains(t, applied, `"objects":"bc/foo\nis/foo\nsvc/foo"`)
+	assert.Contains(t, applied, `"ocf/ownership":"true"`)
+	execer.AssertExpectations(t)
+}
+
+func TestOwnedWithoutRecord(t *testing.T) {
+	withSingleExec(t, []string{"get", "configmap", "foo-ocf-owned", "-o", "jsonpath={.data.objects}"}, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		cmd.On("CombinedOutput").Return([]byte(`Error from server (NotFound): configmaps "foo-ocf-owned" not found`), errors.New("exit status 1"))
+		owned, err := oc.Owned(context.Background(), "foo")
+		assert.Nil(t, err)
+		assert.Empty(t, owned)
+	})
+}
+
+func TestDisownLastObject(t *testing.T) {
+	execer := &mocks.Execer{}
+	ownedCmd := &mocks.ExecCmd{}
+	ownedCmd.On("CombinedOutput").Return([]byte("svc/foo"), nil)
+	execer.On("Oc", []string{"get", "configmap", "foo-ocf-owned", "-o", "jsonpath={.data.objects}"}).Return(ownedCmd)
+	deleteCmd := &mocks.ExecCmd{}
+	deleteCmd.On("CombinedOutput").Return([]byte(""), nil)
+	execer.On("Oc", []string{"delete", "configmap", "foo-ocf-owned"}).Return(deleteCmd)
+	oc := &DefaultOc{execer: execer, capabilities: &types.Capabilities{}}
+
+	assert.Nil(t, oc.Disown(context.Background(), "foo", "svc", "foo"))
+	execer.AssertExpectations(t)
+}
@@ -0,0 +1,380 @@
+// Package types holds the subsets of OpenShift and Kubernetes objects
+// that ocf reads back from the cluster with 'get -o json'. Fields are
+// added as they're needed; anything not listed here is ignored when
+// decoding.
+package types
+
+// ObjectMeta is the metadata common to all objects.
+type ObjectMeta struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	// CreationTimestamp is in RFC 3339 form, so sorts by time
+	CreationTimestamp string `json:"creationTimestamp"`
+	// ResourceVersion changes whenever the object does
+	ResourceVersion string `json:"resourceVersion"`
+}
+
+// ObjectList is a v1 List of any kind of object, decoding only each
+// item's metadata.
+type ObjectList struct {
+	Items []struct {
+		Metadata ObjectMeta `json:"metadata"`
+	} `json:"items"`
+}
+
+// ImageStream is an image.openshift.io/v1 ImageStream.
+type ImageStream struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     struct {
+		Tags []struct {
+			Name string           `json:"name"`
+			From *ObjectReference `json:"from"`
+		} `json:"tags"`
+	} `json:"spec"`
+	Status struct {
+		DockerImageRepository string `json:"dockerImageRepository"`
+		Tags                  []struct {
+			Tag string `json:"tag"`
+			// Items is the tag's history, newest first
+			Items []struct {
+				Image string `json:"image"`
+			} `json:"items"`
+		} `json:"tags"`
+	} `json:"status"`
+}
+
+// Route is a route.openshift.io/v1 Route.
+type Route struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     struct {
+		Host string `json:"host"`
+		Path string `json:"path"`
+		To   struct {
+			Kind string `json:"kind"`
+			Name string `json:"name"`
+		} `json:"to"`
+	} `json:"spec"`
+}
+
+// RouteList is a v1 List of Routes.
+type RouteList struct {
+	Items []Route `json:"items"`
+}
+
+// Ingress is a networking.k8s.io/v1 Ingress.
+type Ingress struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     struct {
+		Rules []struct {
+			Host string `json:"host"`
+			HTTP struct {
+				Paths []struct {
+					Path string `json:"path"`
+				} `json:"paths"`
+			} `json:"http"`
+		} `json:"rules"`
+	} `json:"spec"`
+}
+
+// Host returns the host of the ingress's first rule, or an empty
+// string if it has none.
+func (ingress *Ingress) Host() string {
+	if len(ingress.Spec.Rules) == 0 {
+		return ""
+	}
+	return ingress.Spec.Rules[0].Host
+}
+
+// IngressList is a v1 List of Ingresses.
+type IngressList struct {
+	Items []Ingress `json:"items"`
+}
+
+// DeploymentConfig is an apps.openshift.io/v1 DeploymentConfig. The
+// fields ocf reads are shared with apps/v1 Deployments, so it decodes
+// those too.
+type DeploymentConfig struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     struct {
+		Replicas int `json:"replicas"`
+		Template struct {
+			Spec struct {
+				Containers []Container `json:"containers"`
+			} `json:"spec"`
+		} `json:"template"`
+		// Triggers are only set on deployment configs
+		Triggers []struct {
+			Type              string `json:"type"`
+			ImageChangeParams *struct {
+				From ObjectReference `json:"from"`
+			} `json:"imageChangeParams"`
+		} `json:"triggers"`
+	} `json:"spec"`
+	Status struct {
+		ReadyReplicas int `json:"readyReplicas"`
+		// LatestVersion is only set on deployment configs
+		LatestVersion int `json:"latestVersion"`
+	} `json:"status"`
+}
+
+// ObjectReference refers to an image by kind, such as DockerImage or
+// ImageStreamTag, and name.
+type ObjectReference struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// Container is a container in a pod template.
+type Container struct {
+	Name      string   `json:"name"`
+	Image     string   `json:"image"`
+	Command   []string `json:"command"`
+	Env       []EnvVar `json:"env"`
+	Resources struct {
+		Limits   map[string]string `json:"limits"`
+		Requests map[string]string `json:"requests"`
+	} `json:"resources"`
+}
+
+// EnvVar is a container environment variable, set either directly or
+// from a key of a secret or config map.
+type EnvVar struct {
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+	ValueFrom *struct {
+		SecretKeyRef    *KeyRef `json:"secretKeyRef"`
+		ConfigMapKeyRef *KeyRef `json:"configMapKeyRef"`
+	} `json:"valueFrom"`
+}
+
+// KeyRef selects a key of a secret or config map.
+type KeyRef struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// Pod is a v1 Pod.
+type Pod struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     struct {
+		Containers []Container `json:"containers"`
+	} `json:"spec"`
+	Status struct {
+		Phase             string            `json:"phase"`
+		StartTime         string            `json:"startTime"`
+		ContainerStatuses []ContainerStatus `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+// ContainerStatus is the state of one of a pod's containers.
+type ContainerStatus struct {
+	Name         string         `json:"name"`
+	Ready        bool           `json:"ready"`
+	RestartCount int            `json:"restartCount"`
+	State        ContainerState `json:"state"`
+	LastState    ContainerState `json:"lastState"`
+}
+
+// ContainerState describes a container that's waiting to start or
+// that has terminated. Both are nil while it's running.
+type ContainerState struct {
+	Waiting *struct {
+		Reason  string `json:"reason"`
+		Message string `json:"message"`
+	} `json:"waiting"`
+	Terminated *struct {
+		ExitCode   int    `json:"exitCode"`
+		Reason     string `json:"reason"`
+		Message    string `json:"message"`
+		FinishedAt string `json:"finishedAt"`
+	} `json:"terminated"`
+}
+
+// PodList is a v1 List of Pods.
+type PodList struct {
+	Items []Pod `json:"items"`
+}
+
+// PodMetrics is a metrics.k8s.io/v1beta1 PodMetrics, the current
+// resource usage of a pod's containers.
+type PodMetrics struct {
+	Metadata   ObjectMeta `json:"metadata"`
+	Containers []struct {
+		Name  string            `json:"name"`
+		Usage map[string]string `json:"usage"`
+	} `json:"containers"`
+}
+
+// PodMetricsList is a metrics.k8s.io/v1beta1 PodMetricsList.
+type PodMetricsList struct {
+	Items []PodMetrics `json:"items"`
+}
+
+// IngressControllerList is a list of operator.openshift.io/v1
+// IngressControllers, the routers of an OpenShift 4 cluster.
+type IngressControllerList struct {
+	Items []struct {
+		Metadata ObjectMeta `json:"metadata"`
+		Status   struct {
+			Domain string `json:"domain"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// IngressConfig is the config.openshift.io/v1 Ingress holding an
+// OpenShift 4 cluster's default domain for routes.
+type IngressConfig struct {
+	Spec struct {
+		Domain     string `json:"domain"`
+		AppsDomain string `json:"appsDomain"`
+	} `json:"spec"`
+}
+
+// BuildConfig is a build.openshift.io/v1 BuildConfig.
+type BuildConfig struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     struct {
+		Strategy struct {
+			Type string `json:"type"`
+		} `json:"strategy"`
+		Source struct {
+			Type string `json:"type"`
+		} `json:"source"`
+		Triggers []struct {
+			Type      string          `json:"type"`
+			GitHub    *WebHookTrigger `json:"github"`
+			GitLab    *WebHookTrigger `json:"gitlab"`
+			Bitbucket *WebHookTrigger `json:"bitbucket"`
+			Generic   *WebHookTrigger `json:"generic"`
+		} `json:"triggers"`
+	} `json:"spec"`
+}
+
+// WebHookTrigger is a build config's webhook trigger. Older clusters
+// keep the secret in the trigger, newer ones in a referenced secret.
+type WebHookTrigger struct {
+	Secret          string `json:"secret"`
+	SecretReference *struct {
+		Name string `json:"name"`
+	} `json:"secretReference"`
+}
+
+// ResourceQuotaList is a v1 List of ResourceQuotas.
+type ResourceQuotaList struct {
+	Items []struct {
+		Metadata ObjectMeta `json:"metadata"`
+		Status   struct {
+			Hard map[string]string `json:"hard"`
+			Used map[string]string `json:"used"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// LimitRangeList is a v1 List of LimitRanges.
+type LimitRangeList struct {
+	Items []struct {
+		Metadata ObjectMeta `json:"metadata"`
+		Spec     struct {
+			Limits []struct {
+				Type           string            `json:"type"`
+				Max            map[string]string `json:"max"`
+				Min            map[string]string `json:"min"`
+				Default        map[string]string `json:"default"`
+				DefaultRequest map[string]string `json:"defaultRequest"`
+			} `json:"limits"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// Build is a build.openshift.io/v1 Build.
+type Build struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Status   struct {
+		Phase string `json:"phase"`
+	} `json:"status"`
+}
+
+// BuildList is a v1 List of Builds.
+type BuildList struct {
+	Items []Build `json:"items"`
+}
+
+// ReplicationControllerList is a v1 List of ReplicationControllers,
+// which also decodes a list of apps/v1 ReplicaSets.
+type ReplicationControllerList struct {
+	Items []struct {
+		Metadata ObjectMeta `json:"metadata"`
+		Spec     struct {
+			Replicas int `json:"replicas"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// ServiceAccount is a v1 ServiceAccount.
+type ServiceAccount struct {
+	Metadata         ObjectMeta `json:"metadata"`
+	ImagePullSecrets []struct {
+		Name string `json:"name"`
+	} `json:"imagePullSecrets"`
+}
+
+// Service is a v1 Service.
+type Service struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     struct {
+		Type      string `json:"type"`
+		ClusterIP string `json:"clusterIP"`
+		Ports     []struct {
+			Port     int `json:"port"`
+			NodePort int `json:"nodePort"`
+		} `json:"ports"`
+	} `json:"spec"`
+	Status struct {
+		LoadBalancer struct {
+			Ingress []struct {
+				IP       string `json:"ip"`
+				Hostname string `json:"hostname"`
+			} `json:"ingress"`
+		} `json:"loadBalancer"`
+	} `json:"status"`
+}
+
+// Data holds the contents of a v1 Secret or ConfigMap. Secret values
+// are still base64 encoded.
+type Data struct {
+	Metadata ObjectMeta        `json:"metadata"`
+	Data     map[string]string `json:"data"`
+}
+
+// KnativeService is a serving.knative.dev/v1 Service.
+type KnativeService struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Status   struct {
+		URL string `json:"url"`
+	} `json:"status"`
+}
+
+// Template is a template.openshift.io/v1 Template.
+type Template struct {
+	Metadata   ObjectMeta `json:"metadata"`
+	Parameters []struct {
+		Name string `json:"name"`
+	} `json:"parameters"`
+}
+
+// TemplateList is a v1 List of Templates.
+type TemplateList struct {
+	Items []Template `json:"items"`
+}
+
+// NetworkPolicy is a networking.k8s.io/v1 NetworkPolicy.
+type NetworkPolicy struct {
+	Metadata ObjectMeta `json:"metadata"`
+}
+
+// NetworkPolicyList is a v1 List of NetworkPolicies.
+type NetworkPolicyList struct {
+	Items []NetworkPolicy `json:"items"`
+}
@@ -0,0 +1,39 @@
+package types
+
+import "fmt"
+
+// Version is the major and minor version of an oc or kubectl client.
+type Version struct {
+	Major int
+	Minor int
+}
+
+// AtLeast returns true if the version is major.minor or newer.
+func (v Version) AtLeast(major int, minor int) bool {
+	return v.Major > major || (v.Major == major && v.Minor >= minor)
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// Capabilities describes which sub-commands and flags the installed
+// client supports, where they differ between releases.
+type Capabilities struct {
+	// Version is the detected client version, or the zero Version if
+	// it couldn't be determined
+	Version Version
+	// SetEnv is true if environment variables are changed with
+	// 'set env' rather than the older 'oc env'
+	SetEnv bool
+	// RolloutLatest is true if deployment configs are redeployed with
+	// 'oc rollout latest' rather than the older 'oc deploy --latest'
+	RolloutLatest bool
+	// RolloutRestart is true if deployments are redeployed with
+	// 'rollout restart' rather than by patching their pod template
+	RolloutRestart bool
+}
+
+// LatestCapabilities are assumed when the client version can't be
+// determined, such as for development builds.
+var LatestCapabilities = Capabilities{SetEnv: true, RolloutLatest: true, RolloutRestart: true}
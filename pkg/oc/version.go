@@ -0,0 +1,88 @@
+package oc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+var (
+	// MinimumOcVersion is the oldest oc that has the 'rollout'
+	// commands ocf relies on
+	MinimumOcVersion = types.Version{Major: 3, Minor: 3}
+	// MinimumKubectlVersion is the oldest kubectl that has
+	// 'rollout restart'
+	MinimumKubectlVersion = types.Version{Major: 1, Minor: 15}
+)
+
+// versionPattern matches both "Client Version: 4.12.0" from newer
+// clients and "oc v3.11.0+0cbc58b" or GitVersion:"v1.14.0" from older
+// ones.
+var versionPattern = regexp.MustCompile(`(?:Client Version: |^oc |GitVersion:")v?(\d+)\.(\d+)`)
+
+// ParseVersion finds the client version in the output of 'oc version
+// --client' or 'kubectl version --client'.
+func ParseVersion(output string) (types.Version, bool) {
+	for _, line := range strings.Split(output, "\n") {
+		match := versionPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		major, _ := strconv.Atoi(match[1])
+		minor, _ := strconv.Atoi(match[2])
+		return types.Version{Major: major, Minor: minor}, true
+	}
+	return types.Version{}, false
+}
+
+// ocCapabilities returns what an oc client of the given version
+// supports.
+func ocCapabilities(version types.Version) types.Capabilities {
+	return types.Capabilities{
+		Version: version,
+		// 'oc env' was removed in 4.0
+		SetEnv:        version.AtLeast(4, 0),
+		RolloutLatest: version.AtLeast(3, 5),
+		// 'rollout restart' came with Kubernetes 1.15
+		RolloutRestart: version.AtLeast(4, 3),
+	}
+}
+
+// Capabilities probes the client's version the first time it's called
+// and returns what it supports, or an error if it's too old for ocf.
+func (oc *DefaultOc) Capabilities(ctx context.Context) (types.Capabilities, error) {
+	return oc.probeCapabilities(ctx, "oc", MinimumOcVersion, ocCapabilities)
+}
+
+// probeCapabilities is Capabilities for a client named binary that
+// needs at least version minimum, with capabilitiesFor returning what
+// each version supports.
+func (oc *DefaultOc) probeCapabilities(ctx context.Context, binary string, minimum types.Version,
+	capabilitiesFor func(types.Version) types.Capabilities) (types.Capabilities, error) {
+	oc.mutex.Lock()
+	defer oc.mutex.Unlock()
+	if oc.capabilities != nil {
+		return *oc.capabilities, nil
+	}
+
+	output, err := oc.Exec("version", "--client").CombinedOutput(ctx)
+	if err != nil {
+		return types.Capabilities{}, errors.New(fmt.Sprintf("Error getting %s version: %s\n", binary, output))
+	}
+	version, ok := ParseVersion(string(output))
+	if !ok {
+		oc.capabilities = &types.LatestCapabilities
+		return *oc.capabilities, nil
+	}
+	if !version.AtLeast(minimum.Major, minimum.Minor) {
+		return types.Capabilities{}, errors.New(fmt.Sprintf("Error: %s %s is not supported, ocf needs %s %s or newer", binary, version, binary, minimum))
+	}
+	capabilities := capabilitiesFor(version)
+	oc.capabilities = &capabilities
+	return capabilities, nil
+}
@@ -0,0 +1,72 @@
+package oc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bbrowning/ocf/pkg/mocks"
+	"github.com/bbrowning/ocf/pkg/oc/types"
+)
+
+func TestParseVersion(t *testing.T) {
+	outputs := map[string]types.Version{
+		"Client Version: 4.12.0\nKustomize Version: v4.5.7\n":                       {Major: 4, Minor: 12},
+		"oc v3.11.0+0cbc58b\nkubernetes v1.11.0+d4cacc0\nfeatures: Basic-Auth\n":    {Major: 3, Minor: 11},
+		"Client Version: v1.27.3\nKustomize Version: v5.0.1\n":                      {Major: 1, Minor: 27},
+		`Client Version: version.Info{Major:"1", Minor:"14", GitVersion:"v1.14.0"}`: {Major: 1, Minor: 14},
+	}
+	for output, expected := range outputs {
+		version, ok := ParseVersion(output)
+		assert.True(t, ok, output)
+		assert.Equal(t, expected, version, output)
+	}
+
+	_, ok := ParseVersion("Client Version: unknown")
+	assert.False(t, ok)
+}
+
+func TestCapabilities(t *testing.T) {
+	withSingleExec(t, []string{"version", "--client"}, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		oc.capabilities = nil
+		cmd.On("CombinedOutput").Return([]byte("oc v3.7.1+ab0f056\n"), nil).Once()
+		capabilities, err := oc.Capabilities(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, types.Capabilities{Version: types.Version{Major: 3, Minor: 7}, RolloutLatest: true}, capabilities)
+
+		// Only probed once
+		_, err = oc.Capabilities(context.Background())
+		assert.Nil(t, err)
+	})
+}
+
+func TestCapabilitiesUnsupportedVersion(t *testing.T) {
+	withSingleExec(t, []string{"version", "--client"}, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		oc.capabilities = nil
+		cmd.On("CombinedOutput").Return([]byte("Client Version: v1.14.2\n"), nil)
+		_, err := (&KubectlOc{oc}).Capabilities(context.Background())
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "kubectl 1.14 is not supported, ocf needs kubectl 1.15 or newer")
+	})
+}
+
+func TestCapabilitiesUnknownVersion(t *testing.T) {
+	withSingleExec(t, []string{"version", "--client"}, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		oc.capabilities = nil
+		cmd.On("CombinedOutput").Return([]byte("Client Version: dev\n"), nil)
+		capabilities, err := oc.Capabilities(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, types.LatestCapabilities, capabilities)
+	})
+}
+
+func TestEnvWithSetEnv(t *testing.T) {
+	withSingleExec(t, []string{"set", "env", "dc/foo", "--list"}, func(oc *DefaultOc, cmd *mocks.ExecCmd) {
+		oc.capabilities = &types.Capabilities{SetEnv: true}
+		cmd.On("CombinedOutput").Return([]byte("FOO=bar\n"), nil)
+		env, err := oc.Env(context.Background(), "dc", "foo")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"FOO": "bar"}, env)
+	})
+}